@@ -0,0 +1,157 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysisinternal
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// An ImportAdder qualifies types relative to a single file. It records the
+// packages that the file does not yet import, so that the caller can add the
+// missing imports along with any code that refers to them.
+type ImportAdder struct {
+	file *ast.File
+	pkg  *types.Package
+
+	names   map[string]string // import path -> local name
+	used    map[string]bool   // local names in use in the file
+	missing map[string]string // import path -> local name, for new imports
+}
+
+// NewImportAdder returns an ImportAdder for code inserted into f, which
+// belongs to pkg.
+func NewImportAdder(f *ast.File, pkg *types.Package) *ImportAdder {
+	a := &ImportAdder{
+		file:    f,
+		pkg:     pkg,
+		names:   make(map[string]string),
+		used:    make(map[string]bool),
+		missing: make(map[string]string),
+	}
+	for _, imp := range f.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		var name string
+		if imp.Name != nil {
+			name = imp.Name.Name
+		} else {
+			name = path[strings.LastIndex(path, "/")+1:]
+			if pkg != nil {
+				for _, p := range pkg.Imports() {
+					if p.Path() == path {
+						name = p.Name()
+						break
+					}
+				}
+			}
+		}
+		if name == "_" {
+			continue
+		}
+		a.names[path] = name
+		a.used[name] = true
+	}
+	return a
+}
+
+// Qualifier is a types.Qualifier that uses the file's local names for
+// imported packages, recording a new import for any package that the file
+// does not import yet.
+func (a *ImportAdder) Qualifier(p *types.Package) string {
+	if p == nil || p == a.pkg || (a.pkg != nil && p.Path() == a.pkg.Path()) {
+		return ""
+	}
+	if name, ok := a.names[p.Path()]; ok {
+		if name == "." {
+			return ""
+		}
+		return name
+	}
+	name := p.Name()
+	for i := 1; a.used[name] || (a.pkg != nil && a.pkg.Scope().Lookup(name) != nil); i++ {
+		name = fmt.Sprintf("%s%d", p.Name(), i)
+	}
+	a.names[p.Path()] = name
+	a.used[name] = true
+	a.missing[p.Path()] = name
+	return name
+}
+
+// Edits returns the text edits that add the missing imports recorded by the
+// Qualifier to the file. It returns nil if no imports are missing.
+func (a *ImportAdder) Edits() []analysis.TextEdit {
+	if len(a.missing) == 0 {
+		return nil
+	}
+	var paths []string
+	for path := range a.missing {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	spec := func(path string) string {
+		if name := a.missing[path]; name != path[strings.LastIndex(path, "/")+1:] {
+			return name + " " + strconv.Quote(path)
+		}
+		return strconv.Quote(path)
+	}
+
+	// Prefer adding to the last parenthesized import declaration, keeping
+	// its specs sorted as gofmt would.
+	var last *ast.GenDecl
+	for _, decl := range a.file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			last = gen
+		}
+	}
+	if last == nil || !last.Lparen.IsValid() {
+		var specs []string
+		for _, path := range paths {
+			specs = append(specs, spec(path))
+		}
+		var pos token.Pos
+		var text string
+		switch {
+		case last != nil:
+			pos = last.End()
+			text = "\nimport " + strings.Join(specs, "\nimport ")
+		case len(specs) == 1:
+			pos = a.file.Name.End()
+			text = "\n\nimport " + specs[0]
+		default:
+			pos = a.file.Name.End()
+			text = "\n\nimport (\n\t" + strings.Join(specs, "\n\t") + "\n)"
+		}
+		return []analysis.TextEdit{{Pos: pos, End: pos, NewText: []byte(text)}}
+	}
+
+	var edits []analysis.TextEdit
+	for _, path := range paths {
+		pos, text := last.Rparen, "\t"+spec(path)+"\n"
+		for _, s := range last.Specs {
+			imp := s.(*ast.ImportSpec)
+			if existing, err := strconv.Unquote(imp.Path.Value); err == nil && existing > path {
+				pos, text = imp.Pos(), spec(path)+"\n\t"
+				break
+			}
+		}
+		if n := len(edits); n > 0 && edits[n-1].Pos == pos {
+			edits[n-1].NewText = append(edits[n-1].NewText, text...)
+			continue
+		}
+		edits = append(edits, analysis.TextEdit{Pos: pos, End: pos, NewText: []byte(text)})
+	}
+	return edits
+}
@@ -0,0 +1,60 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package deprecated defines an Analyzer that reports uses of deprecated
+// identifiers, and the function that extracts the deprecation notice from
+// a doc comment.
+package deprecated
+
+import (
+	"go/ast"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+const Doc = `report uses of deprecated identifiers
+
+This analyzer reports uses of functions, types, variables, constants,
+fields and methods from other packages whose doc comment contains a
+paragraph beginning with "Deprecated:". For example, given
+	// Dial connects to the address.
+	//
+	// Deprecated: use DialContext instead.
+	func Dial(addr string) (Conn, error)
+a call to Dial is reported with the message "Dial is deprecated: use
+DialContext instead.", and editors may strike the identifier through.`
+
+// Analyzer only describes the analysis. Reporting a use requires the doc
+// comment of the object, which is usually declared in a dependency, so
+// gopls computes the diagnostics itself using the syntax of the package's
+// dependencies.
+var Analyzer = &analysis.Analyzer{
+	Name:             "deprecated",
+	Doc:              Doc,
+	Run:              run,
+	RunDespiteErrors: true,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	return nil, nil
+}
+
+// Message returns the deprecation notice in the doc comment, that is, the
+// text of its paragraph beginning with "Deprecated:", with the prefix
+// removed and the lines joined. It reports whether there is such a
+// paragraph.
+func Message(doc *ast.CommentGroup) (string, bool) {
+	if doc == nil {
+		return "", false
+	}
+	const prefix = "Deprecated:"
+	for _, para := range strings.Split(doc.Text(), "\n\n") {
+		if !strings.HasPrefix(para, prefix) {
+			continue
+		}
+		return strings.Join(strings.Fields(para[len(prefix):]), " "), true
+	}
+	return "", false
+}
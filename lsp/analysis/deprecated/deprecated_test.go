@@ -0,0 +1,44 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package deprecated_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/kevinswiber/languageserver-go/lsp/analysis/deprecated"
+)
+
+func TestMessage(t *testing.T) {
+	tests := []struct {
+		doc  string
+		msg  string
+		want bool
+	}{
+		{"// F does things.\n//\n// Deprecated: use G instead.\n", "use G instead.", true},
+		{"// F does things.\n//\n// Deprecated: use G,\n// which is faster.\n//\n// More text.\n", "use G, which is faster.", true},
+		{"// Deprecated: do not use.\n", "do not use.", true},
+		{"/*\nF does things.\n\nDeprecated: use G.\n*/\n", "use G.", true},
+		{"// Deprecated:\n", "", true},
+		{"// F does things. Deprecated: not a paragraph.\n", "", false},
+		{"// F does things.\n//  Deprecated: preformatted.\n", "", false},
+		{"// F is not deprecated.\n", "", false},
+	}
+	for _, test := range tests {
+		f, err := parser.ParseFile(token.NewFileSet(), "a.go", "package a\n\n"+test.doc+"func F() {}\n", parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg, ok := deprecated.Message(f.Decls[0].(*ast.FuncDecl).Doc)
+		if msg != test.msg || ok != test.want {
+			t.Errorf("Message(%q) = %q, %v, want %q, %v", test.doc, msg, ok, test.msg, test.want)
+		}
+	}
+	if msg, ok := deprecated.Message(nil); msg != "" || ok {
+		t.Errorf("Message(nil) = %q, %v, want \"\", false", msg, ok)
+	}
+}
@@ -0,0 +1,590 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package enumstring defines an Analyzer that reports String methods
+// generated for enum-like types that are out of date with the type's
+// constants, and the functions that generate those methods.
+package enumstring
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"github.com/kevinswiber/languageserver-go/analysisinternal"
+	"github.com/kevinswiber/languageserver-go/lsp/analysis/fillswitch"
+)
+
+const Doc = `note out-of-date generated String methods
+
+This analyzer reports String methods generated by gopls for an integer type
+with constants, marked by a //gopls:enumstring directive, that no longer
+match the type's constants. For example, after adding Yellow to
+	type Color int
+	const (
+		Red Color = iota
+		Green
+		Blue
+		Yellow
+	)
+the String method generated for Color does not return "Yellow" for Yellow.
+The suggested fix generates the method again.`
+
+var Analyzer = &analysis.Analyzer{
+	Name:             "enumstring",
+	Doc:              Doc,
+	Run:              run,
+	RunDespiteErrors: true,
+}
+
+// Directive marks a generated String method. It may be followed by the
+// -trimprefix flag, as accepted by the stringer command.
+const Directive = "//gopls:enumstring"
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	if pass.TypesInfo == nil {
+		return nil, nil
+	}
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok {
+				continue
+			}
+			trimPrefix, ok := ParseDirective(fn.Doc)
+			if !ok {
+				continue
+			}
+			named := receiverType(pass.TypesInfo, fn)
+			if named == nil {
+				continue
+			}
+			fix, stale, err := SuggestedFix(pass.Fset, file, pass.Pkg, named, trimPrefix)
+			if err != nil || !stale {
+				continue
+			}
+			pass.Report(analysis.Diagnostic{
+				Pos:            fn.Name.Pos(),
+				End:            fn.Name.End(),
+				Message:        fmt.Sprintf("String method of %s is out of date with its constants", named.Obj().Name()),
+				SuggestedFixes: []analysis.SuggestedFix{*fix},
+			})
+		}
+	}
+	return nil, nil
+}
+
+// ParseDirective reports whether the comment group contains the Directive,
+// and returns the prefix given by its -trimprefix flag.
+func ParseDirective(doc *ast.CommentGroup) (trimPrefix string, ok bool) {
+	if doc == nil {
+		return "", false
+	}
+	for _, c := range doc.List {
+		if c.Text != Directive && !strings.HasPrefix(c.Text, Directive+" ") {
+			continue
+		}
+		for _, arg := range strings.Fields(strings.TrimPrefix(c.Text, Directive)) {
+			if strings.HasPrefix(arg, "-trimprefix=") {
+				trimPrefix = strings.TrimPrefix(arg, "-trimprefix=")
+			}
+		}
+		return trimPrefix, true
+	}
+	return "", false
+}
+
+// receiverType returns the named type of fn's receiver if fn is a String
+// method of a named integer type.
+func receiverType(info *types.Info, fn *ast.FuncDecl) *types.Named {
+	if fn.Name.Name != "String" || fn.Recv == nil || len(fn.Recv.List) != 1 {
+		return nil
+	}
+	tv, ok := info.Types[fn.Recv.List[0].Type]
+	if !ok {
+		return nil
+	}
+	named, ok := tv.Type.(*types.Named)
+	if !ok || !IsInteger(named) {
+		return nil
+	}
+	return named
+}
+
+// IsInteger reports whether named has an integer underlying type.
+func IsInteger(named *types.Named) bool {
+	basic, ok := named.Underlying().(*types.Basic)
+	return ok && basic.Info()&types.IsInteger != 0
+}
+
+// SuggestedFix returns a fix that generates the String method of the named
+// integer type into file, along with the constants and variables that the
+// method uses. If the file already has a String method marked by the
+// Directive, the fix replaces it; otherwise the method is added after the
+// last declaration of the type's constants in the file, or after the type's
+// declaration. SuggestedFix also reports whether the fix changes the file,
+// which is not the case if the existing method is up to date.
+func SuggestedFix(fset *token.FileSet, file *ast.File, pkg *types.Package, named *types.Named, trimPrefix string) (*analysis.SuggestedFix, bool, error) {
+	consts := fillswitch.EnumConstants(named, pkg)
+	if len(consts) == 0 {
+		return nil, false, fmt.Errorf("%s has no constants", named.Obj().Name())
+	}
+	adder := analysisinternal.NewImportAdder(file, pkg)
+	generated, err := Generate(named, consts, trimPrefix, adder.Qualifier)
+	if err != nil {
+		return nil, false, err
+	}
+
+	name := named.Obj().Name()
+	var edits []analysis.TextEdit
+	if method, helpers := generatedDecls(file, name); method != nil {
+		upToDate, err := sameDecls(fset, append(helpers, method), generated)
+		if err != nil {
+			return nil, false, err
+		}
+		if upToDate && len(adder.Edits()) == 0 {
+			return nil, false, nil
+		}
+		// Delete the declarations that the method uses, which are generated
+		// again before the method, and replace the method.
+		for _, d := range helpers {
+			edits = append(edits, analysis.TextEdit{Pos: declStart(d), End: nextDeclStart(file, d)})
+		}
+		edits = append(edits, adder.Edits()...)
+		edits = append(edits, analysis.TextEdit{Pos: declStart(method), End: method.End(), NewText: bytes.TrimSpace(generated)})
+	} else {
+		pos := insertPos(file, named)
+		if !pos.IsValid() {
+			return nil, false, fmt.Errorf("no declaration of %s or its constants in file", name)
+		}
+		edits = append(edits, adder.Edits()...)
+		edits = append(edits, analysis.TextEdit{Pos: pos, End: pos, NewText: append([]byte("\n\n"), bytes.TrimSpace(generated)...)})
+	}
+	return &analysis.SuggestedFix{
+		Message:   fmt.Sprintf("Generate String method for %s", name),
+		TextEdits: edits,
+	}, true, nil
+}
+
+// sameDecls reports whether the declarations, ignoring their doc comments,
+// are those of the generated source.
+func sameDecls(fset *token.FileSet, decls []ast.Decl, generated []byte) (bool, error) {
+	genFset := token.NewFileSet()
+	f, err := parser.ParseFile(genFset, "", append([]byte("package p\n\n"), generated...), 0)
+	if err != nil {
+		return false, err
+	}
+	if len(f.Decls) != len(decls) {
+		return false, nil
+	}
+	for i, d := range decls {
+		if formatDecl(fset, d) != formatDecl(genFset, f.Decls[i]) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// formatDecl returns the formatted declaration, without its doc comment.
+func formatDecl(fset *token.FileSet, decl ast.Decl) string {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		copy := *d
+		copy.Doc = nil
+		decl = &copy
+	case *ast.GenDecl:
+		copy := *d
+		copy.Doc = nil
+		decl = &copy
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, decl); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+// generatedDecls returns the String method of the type name marked by the
+// Directive in file, and the package-level declarations of the _name_*
+// constants and variables that generated String methods use.
+func generatedDecls(file *ast.File, name string) (*ast.FuncDecl, []ast.Decl) {
+	var method *ast.FuncDecl
+	var helpers []ast.Decl
+	prefix := "_" + name + "_"
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if _, ok := ParseDirective(decl.Doc); !ok || decl.Name.Name != "String" || decl.Recv == nil || len(decl.Recv.List) != 1 {
+				continue
+			}
+			if recvName(decl.Recv.List[0].Type) == name {
+				method = decl
+			}
+		case *ast.GenDecl:
+			if decl.Tok != token.CONST && decl.Tok != token.VAR {
+				continue
+			}
+			generated := len(decl.Specs) > 0
+			for _, spec := range decl.Specs {
+				vs := spec.(*ast.ValueSpec)
+				for _, id := range vs.Names {
+					if !isGeneratedName(id.Name, prefix) {
+						generated = false
+					}
+				}
+			}
+			if generated {
+				helpers = append(helpers, decl)
+			}
+		}
+	}
+	return method, helpers
+}
+
+// isGeneratedName reports whether name is one of the names _T_name,
+// _T_index, or _T_map, possibly followed by _N, used by generated code for
+// the type T, given the prefix _T_.
+func isGeneratedName(name, prefix string) bool {
+	if !strings.HasPrefix(name, prefix) {
+		return false
+	}
+	rest := strings.TrimPrefix(name, prefix)
+	for _, base := range []string{"name", "index", "map"} {
+		if rest == base {
+			return true
+		}
+		if n := strings.TrimPrefix(rest, base+"_"); n != rest && n != "" && strings.Trim(n, "0123456789") == "" {
+			return true
+		}
+	}
+	return false
+}
+
+func recvName(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return expr.Name
+	case *ast.StarExpr:
+		return recvName(expr.X)
+	case *ast.ParenExpr:
+		return recvName(expr.X)
+	}
+	return ""
+}
+
+func declStart(decl ast.Decl) token.Pos {
+	switch decl := decl.(type) {
+	case *ast.FuncDecl:
+		if decl.Doc != nil {
+			return decl.Doc.Pos()
+		}
+	case *ast.GenDecl:
+		if decl.Doc != nil {
+			return decl.Doc.Pos()
+		}
+	}
+	return decl.Pos()
+}
+
+// nextDeclStart returns the start of the declaration that follows decl in
+// file, or the end of decl if it is the last one, so that deleting the
+// declaration also deletes the blank lines that follow it.
+func nextDeclStart(file *ast.File, decl ast.Decl) token.Pos {
+	for i, d := range file.Decls {
+		if d == decl && i+1 < len(file.Decls) {
+			return declStart(file.Decls[i+1])
+		}
+	}
+	return decl.End()
+}
+
+// insertPos returns the position after the last declaration in file of a
+// constant of the named type, or after the type's declaration.
+func insertPos(file *ast.File, named *types.Named) token.Pos {
+	var pos token.Pos
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			switch spec := spec.(type) {
+			case *ast.TypeSpec:
+				if spec.Name.Pos() == named.Obj().Pos() && !pos.IsValid() {
+					pos = gen.End()
+				}
+			case *ast.ValueSpec:
+				if gen.Tok == token.CONST && declaresConstOf(gen, named) {
+					pos = gen.End()
+				}
+			}
+		}
+	}
+	return pos
+}
+
+// declaresConstOf reports whether the const declaration declares a
+// constant of the named type, either explicitly or by repeating the
+// previous specification's type, as in a sequence of iota constants.
+func declaresConstOf(gen *ast.GenDecl, named *types.Named) bool {
+	var typ ast.Expr
+	for _, spec := range gen.Specs {
+		vs := spec.(*ast.ValueSpec)
+		if vs.Type != nil || len(vs.Values) > 0 {
+			typ = vs.Type
+		}
+		if id, ok := typ.(*ast.Ident); ok && id.Name == named.Obj().Name() {
+			return true
+		}
+	}
+	return false
+}
+
+// A value is a constant of the type for which a String method is generated.
+type value struct {
+	name   string
+	bits   uint64 // the value as unsigned bits; for signed types, int64(bits) is the value
+	signed bool
+}
+
+func (v value) String() string {
+	if v.signed {
+		return fmt.Sprint(int64(v.bits))
+	}
+	return fmt.Sprint(v.bits)
+}
+
+func (v value) less(w value) bool {
+	if v.signed {
+		return int64(v.bits) < int64(w.bits)
+	}
+	return v.bits < w.bits
+}
+
+// Generate returns the formatted source of a String method for the named
+// integer type with the given constants, preceded by the declarations that
+// it uses, in the form produced by the stringer command: the names are
+// concatenated into a string indexed by a table for each run of consecutive
+// values, and looked up in a map if there are more than 10 such runs. The
+// prefix trimPrefix is removed from each constant's name.
+func Generate(named *types.Named, consts []*types.Const, trimPrefix string, qf types.Qualifier) ([]byte, error) {
+	if !IsInteger(named) {
+		return nil, fmt.Errorf("%s is not an integer type", named.Obj().Name())
+	}
+	signed := named.Underlying().(*types.Basic).Info()&types.IsUnsigned == 0
+	var values []value
+	for _, c := range consts {
+		if c.Name() == "_" {
+			continue
+		}
+		v := value{name: strings.TrimPrefix(c.Name(), trimPrefix), signed: signed}
+		if v.name == "" {
+			v.name = c.Name()
+		}
+		val := constant.ToInt(c.Val())
+		if signed {
+			i, ok := constant.Int64Val(val)
+			if !ok {
+				return nil, fmt.Errorf("value of %s does not fit in 64 bits", c.Name())
+			}
+			v.bits = uint64(i)
+		} else {
+			u, ok := constant.Uint64Val(val)
+			if !ok {
+				return nil, fmt.Errorf("value of %s does not fit in 64 bits", c.Name())
+			}
+			v.bits = u
+		}
+		values = append(values, v)
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("%s has no constants", named.Obj().Name())
+	}
+
+	g := &generator{
+		typeName: named.Obj().Name(),
+		strconv:  qf(types.NewPackage("strconv", "strconv")),
+	}
+	runs := splitIntoRuns(values)
+	switch {
+	case len(runs) == 1:
+		g.buildOneRun(runs, trimPrefix)
+	case len(runs) <= 10:
+		g.buildMultipleRuns(runs, trimPrefix)
+	default:
+		g.buildMap(runs, trimPrefix)
+	}
+	return format.Source(g.buf.Bytes())
+}
+
+type generator struct {
+	buf      bytes.Buffer
+	typeName string
+	strconv  string // the name by which the strconv package is referred to
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// printMethodHeader prints the doc comment, including the Directive, and
+// the signature of the String method.
+func (g *generator) printMethodHeader(trimPrefix string) {
+	g.printf("// String returns the name of the %s constant i.\n", g.typeName)
+	g.printf("//\n")
+	if trimPrefix != "" {
+		g.printf("%s -trimprefix=%s\n", Directive, trimPrefix)
+	} else {
+		g.printf("%s\n", Directive)
+	}
+	g.printf("func (i %s) String() string {\n", g.typeName)
+}
+
+// formatInt returns an expression that formats the integer expr in decimal.
+func (g *generator) formatInt(expr string) string {
+	return fmt.Sprintf(`"%s(" + %s.FormatInt(int64(%s), 10) + ")"`, g.typeName, g.strconv, expr)
+}
+
+// splitIntoRuns breaks the values into runs of consecutive values, after
+// sorting them and removing duplicate values.
+func splitIntoRuns(values []value) [][]value {
+	sort.SliceStable(values, func(i, j int) bool { return values[i].less(values[j]) })
+	// Remove duplicates, keeping the first name declared for a value.
+	j := 1
+	for i := 1; i < len(values); i++ {
+		if values[i].bits != values[i-1].bits {
+			values[j] = values[i]
+			j++
+		}
+	}
+	values = values[:j]
+
+	var runs [][]value
+	for len(values) > 0 {
+		i := 1
+		for i < len(values) && values[i].bits == values[i-1].bits+1 {
+			i++
+		}
+		runs = append(runs, values[:i])
+		values = values[i:]
+	}
+	return runs
+}
+
+// indexType returns the smallest unsigned integer type that can hold all
+// of the offsets into a string of the given length.
+func indexType(n int) string {
+	switch {
+	case n < 1<<8:
+		return "uint8"
+	case n < 1<<16:
+		return "uint16"
+	default:
+		return "uint32"
+	}
+}
+
+// nameAndIndex returns the concatenated names of the run's values and the
+// offsets of each name in the result, followed by its length.
+func nameAndIndex(run []value) (string, []string) {
+	var b strings.Builder
+	index := []string{"0"}
+	for _, v := range run {
+		b.WriteString(v.name)
+		index = append(index, fmt.Sprint(b.Len()))
+	}
+	return b.String(), index
+}
+
+func (g *generator) buildOneRun(runs [][]value, trimPrefix string) {
+	values := runs[0]
+	names, index := nameAndIndex(values)
+	g.printf("const _%s_name = %q\n\n", g.typeName, names)
+	g.printf("var _%s_index = [...]%s{%s}\n\n", g.typeName, indexType(len(names)), strings.Join(index, ", "))
+
+	lessThanZero := ""
+	if values[0].signed {
+		lessThanZero = "i < 0 || "
+	}
+	g.printMethodHeader(trimPrefix)
+	if values[0].bits == 0 {
+		g.printf("if %si >= %s(len(_%s_index)-1) {\n", lessThanZero, g.typeName, g.typeName)
+		g.printf("return %s\n", g.formatInt("i"))
+	} else {
+		g.printf("i -= %s\n", values[0])
+		g.printf("if %si >= %s(len(_%s_index)-1) {\n", lessThanZero, g.typeName, g.typeName)
+		g.printf("return %s\n", g.formatInt("i+"+values[0].String()))
+	}
+	g.printf("}\n")
+	g.printf("return _%[1]s_name[_%[1]s_index[i]:_%[1]s_index[i+1]]\n", g.typeName)
+	g.printf("}\n")
+}
+
+func (g *generator) buildMultipleRuns(runs [][]value, trimPrefix string) {
+	var names, indexes []string
+	for i, run := range runs {
+		name, index := nameAndIndex(run)
+		names = append(names, fmt.Sprintf("_%s_name_%d = %q", g.typeName, i, name))
+		if len(run) != 1 {
+			indexes = append(indexes, fmt.Sprintf("_%s_index_%d = [...]%s{%s}", g.typeName, i, indexType(len(name)), strings.Join(index, ", ")))
+		}
+	}
+	g.printf("const (\n%s\n)\n\n", strings.Join(names, "\n"))
+	if len(indexes) > 0 {
+		g.printf("var (\n%s\n)\n\n", strings.Join(indexes, "\n"))
+	}
+
+	g.printMethodHeader(trimPrefix)
+	g.printf("switch {\n")
+	for i, values := range runs {
+		if len(values) == 1 {
+			g.printf("case i == %s:\n", values[0])
+			g.printf("return _%s_name_%d\n", g.typeName, i)
+			continue
+		}
+		if values[0].bits == 0 && !values[0].signed {
+			// For an unsigned lower bound of 0, "0 <= i" would be redundant.
+			g.printf("case i <= %s:\n", values[len(values)-1])
+		} else {
+			g.printf("case %s <= i && i <= %s:\n", values[0], values[len(values)-1])
+		}
+		if values[0].bits != 0 {
+			g.printf("i -= %s\n", values[0])
+		}
+		g.printf("return _%[1]s_name_%[2]d[_%[1]s_index_%[2]d[i]:_%[1]s_index_%[2]d[i+1]]\n", g.typeName, i)
+	}
+	g.printf("default:\n")
+	g.printf("return %s\n", g.formatInt("i"))
+	g.printf("}\n")
+	g.printf("}\n")
+}
+
+func (g *generator) buildMap(runs [][]value, trimPrefix string) {
+	var all []value
+	for _, run := range runs {
+		all = append(all, run...)
+	}
+	names, index := nameAndIndex(all)
+	g.printf("const _%s_name = %q\n\n", g.typeName, names)
+	g.printf("var _%s_map = map[%s]string{\n", g.typeName, g.typeName)
+	for i, v := range all {
+		g.printf("%s: _%s_name[%s:%s],\n", v, g.typeName, index[i], index[i+1])
+	}
+	g.printf("}\n\n")
+
+	g.printMethodHeader(trimPrefix)
+	g.printf("if str, ok := _%s_map[i]; ok {\n", g.typeName)
+	g.printf("return str\n")
+	g.printf("}\n")
+	g.printf("return %s\n", g.formatInt("i"))
+	g.printf("}\n")
+}
@@ -0,0 +1,179 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package enumstring_test
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+	"github.com/kevinswiber/languageserver-go/lsp/analysis/enumstring"
+	"github.com/kevinswiber/languageserver-go/testenv"
+)
+
+func Test(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.RunWithSuggestedFixes(t, testdata, enumstring.Analyzer, "a")
+}
+
+// runString runs a program with the declarations and the String method
+// that Generate produces for their type T, and returns what String returns
+// for each of the values.
+func runString(t *testing.T, decls string, values []string) []string {
+	t.Helper()
+	// The generated method formats the values out of range with strconv.
+	src := "package main\n\nimport \"strconv\"\n\nvar _ = strconv.Itoa\n\n" + decls
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "main.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := (&types.Config{Importer: importer.Default()}).Check("main", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	named := pkg.Scope().Lookup("T").Type().(*types.Named)
+	var consts []*types.Const
+	for _, name := range pkg.Scope().Names() {
+		if c, ok := pkg.Scope().Lookup(name).(*types.Const); ok && c.Type() == named {
+			consts = append(consts, c)
+		}
+	}
+	// The first constant declared for a value names it.
+	sort.Slice(consts, func(i, j int) bool { return consts[i].Pos() < consts[j].Pos() })
+	generated, err := enumstring.Generate(named, consts, "", types.RelativeTo(pkg))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "enumstring")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	var main strings.Builder
+	main.WriteString(src + "\n" + string(generated))
+	main.WriteString("\nfunc main() {\n")
+	for _, v := range values {
+		fmt.Fprintf(&main, "\tprintln(T(%s).String())\n", v)
+	}
+	main.WriteString("}\n")
+	files := map[string]string{
+		"go.mod":  "module example.com/enum\n\ngo 1.16\n",
+		"main.go": main.String(),
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmd := exec.Command("go", "run", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=", "GOPROXY=off", "GO111MODULE=on")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("running the generated String method: %v\n%s\n%s", err, out, files["main.go"])
+	}
+	return strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+}
+
+func TestGenerate(t *testing.T) {
+	testenv.NeedsGoBuild(t)
+	for _, test := range []struct {
+		name   string
+		decls  string
+		values []string
+		want   []string
+	}{
+		{
+			name: "one run",
+			decls: `type T uint8
+
+const (
+	A T = iota + 1
+	B
+	C
+)
+`,
+			values: []string{"0", "1", "3", "4"},
+			want:   []string{"T(0)", "A", "C", "T(4)"},
+		},
+		{
+			// Runs of consecutive values are looked up in a switch, and a
+			// value declared twice is named by its first constant.
+			name: "multiple runs",
+			decls: `type T int8
+
+const (
+	A T = iota - 2
+	B
+	_
+	D
+	E
+	F T = 10
+	G T = 10
+	H T = iota + 5
+	I
+)
+`,
+			values: []string{"-3", "-2", "-1", "0", "1", "2", "9", "10", "11", "12", "13", "14"},
+			want:   []string{"T(-3)", "A", "B", "T(0)", "D", "E", "T(9)", "F", "T(11)", "H", "I", "T(14)"},
+		},
+		{
+			name: "multiple runs from zero",
+			decls: `type T uint
+
+const (
+	A T = iota
+	B
+	C T = 5
+)
+`,
+			values: []string{"0", "1", "2", "5", "6"},
+			want:   []string{"A", "B", "T(2)", "C", "T(6)"},
+		},
+		{
+			// More than 10 runs are looked up in a map.
+			name: "map",
+			decls: `type T int
+
+const (
+	A T = 2 * iota
+	B
+	C
+	D
+	E
+	F
+	G
+	H
+	I
+	J
+	K
+	L
+)
+`,
+			values: []string{"-2", "0", "1", "2", "20", "22", "24"},
+			want:   []string{"T(-2)", "A", "T(1)", "B", "K", "L", "T(24)"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := runString(t, test.decls, test.values); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("String() of %v = %q, want %q", test.values, got, test.want)
+			}
+		})
+	}
+}
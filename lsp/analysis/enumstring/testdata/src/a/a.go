@@ -0,0 +1,131 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package enumstring
+
+import "strconv"
+
+type Color int
+
+const (
+	ColorRed Color = iota
+	ColorGreen
+	ColorBlue
+)
+
+const _Color_name = "RedGreenBlue"
+
+var _Color_index = [...]uint8{0, 3, 8, 12}
+
+// String returns the name of the Color constant i.
+//
+//gopls:enumstring -trimprefix=Color
+func (i Color) String() string {
+	if i < 0 || i >= Color(len(_Color_index)-1) {
+		return "Color(" + strconv.FormatInt(int64(i), 10) + ")"
+	}
+	return _Color_name[_Color_index[i]:_Color_index[i+1]]
+}
+
+type Status uint8
+
+const (
+	Pending Status = iota + 1
+	Running
+	Done
+)
+
+const _Status_name = "PendingRunning"
+
+var _Status_index = [...]uint8{0, 7, 14}
+
+// String returns the name of the Status constant i.
+//
+//gopls:enumstring
+func (i Status) String() string { // want "String method of Status is out of date with its constants"
+	i -= 1
+	if i >= Status(len(_Status_index)-1) {
+		return "Status(" + strconv.FormatInt(int64(i+1), 10) + ")"
+	}
+	return _Status_name[_Status_index[i]:_Status_index[i+1]]
+}
+
+type Level int
+
+const (
+	LevelDebug Level = iota - 1
+	LevelInfo
+	LevelWarn
+	LevelError Level = 8
+)
+
+const _Level_name = "DebugInfoWarn"
+
+var _Level_index = [...]uint8{0, 5, 9, 13}
+
+// String returns the name of the Level constant i.
+//
+//gopls:enumstring -trimprefix=Level
+func (i Level) String() string { // want "String method of Level is out of date with its constants"
+	i -= -1
+	if i < 0 || i >= Level(len(_Level_index)-1) {
+		return "Level(" + strconv.FormatInt(int64(i+-1), 10) + ")"
+	}
+	return _Level_name[_Level_index[i]:_Level_index[i+1]]
+}
+
+type Flag uint
+
+const (
+	FlagA Flag = 1 << iota
+	FlagB
+	FlagC
+	FlagD
+	FlagE
+	FlagF
+	FlagG
+	FlagH
+	FlagI
+	FlagJ
+	FlagK
+	FlagL
+)
+
+const _Flag_name = "ABCDEFGHIJK"
+
+var _Flag_map = map[Flag]string{
+	1:    _Flag_name[0:1],
+	2:    _Flag_name[1:2],
+	4:    _Flag_name[2:3],
+	8:    _Flag_name[3:4],
+	16:   _Flag_name[4:5],
+	32:   _Flag_name[5:6],
+	64:   _Flag_name[6:7],
+	128:  _Flag_name[7:8],
+	256:  _Flag_name[8:9],
+	512:  _Flag_name[9:10],
+	1024: _Flag_name[10:11],
+}
+
+// String returns the name of the Flag constant i.
+//
+//gopls:enumstring -trimprefix=Flag
+func (i Flag) String() string { // want "String method of Flag is out of date with its constants"
+	if str, ok := _Flag_map[i]; ok {
+		return str
+	}
+	return "Flag(" + strconv.FormatInt(int64(i), 10) + ")"
+}
+
+type Kind int
+
+const (
+	KindA Kind = iota
+	KindB
+)
+
+// String is not generated.
+func (k Kind) String() string {
+	return "kind"
+}
@@ -220,7 +220,7 @@ func SuggestedFix(fset *token.FileSet, rng span.Range, content []byte, file *ast
 			// NOTE: We currently match on the name of the field key rather than the field type.
 			value := analysisinternal.FindBestMatch(obj.Field(i).Name(), idents)
 			if value == nil {
-				value = populateValue(fset, file, pkg, fieldTyp)
+				value = PopulateValue(fset, file, pkg, fieldTyp)
 			}
 			if value == nil {
 				return nil, nil
@@ -310,7 +310,7 @@ func indent(str, ind []byte) []byte {
 	return newText.Bytes()
 }
 
-// populateValue constructs an expression to fill the value of a struct field.
+// PopulateValue constructs an expression to fill the value of a struct field.
 //
 // When the type of a struct field is a basic literal or interface, we return
 // default values. For other types, such as maps, slices, and channels, we create
@@ -318,7 +318,7 @@ func indent(str, ind []byte) []byte {
 //
 // The reasoning here is that users will call fillstruct with the intention of
 // initializing the struct, in which case setting these fields to nil has no effect.
-func populateValue(fset *token.FileSet, f *ast.File, pkg *types.Package, typ types.Type) ast.Expr {
+func PopulateValue(fset *token.FileSet, f *ast.File, pkg *types.Package, typ types.Type) ast.Expr {
 	under := typ
 	if n, ok := typ.(*types.Named); ok {
 		under = n.Underlying()
@@ -449,7 +449,7 @@ func populateValue(fset *token.FileSet, f *ast.File, pkg *types.Package, typ typ
 		default:
 			return &ast.UnaryExpr{
 				Op: token.AND,
-				X:  populateValue(fset, f, pkg, u.Elem()),
+				X:  PopulateValue(fset, f, pkg, u.Elem()),
 			}
 		}
 	case *types.Interface:
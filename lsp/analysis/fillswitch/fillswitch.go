@@ -0,0 +1,243 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package fillswitch defines an Analyzer that reports switch statements
+// over enum-like types that do not handle all of the type's constants.
+package fillswitch
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/ast/inspector"
+	"github.com/kevinswiber/languageserver-go/analysisinternal"
+	"github.com/kevinswiber/languageserver-go/span"
+)
+
+const Doc = `note non-exhaustive switch statements over enum-like types
+
+This analyzer reports switch statements without a default case whose tag
+is of a named type with package-level constants (such as a sequence of iota
+constants), but which do not have a case for each of the constants.
+For example:
+	type Color int
+	const (
+		Red Color = iota
+		Green
+		Blue
+	)
+	switch c {
+	case Red:
+	case Green:
+	}
+is missing a case for Blue. The suggested fix adds the missing cases.`
+
+var Analyzer = &analysis.Analyzer{
+	Name:             "fillswitch",
+	Doc:              Doc,
+	Requires:         []*analysis.Analyzer{inspect.Analyzer},
+	Run:              run,
+	RunDespiteErrors: true,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	nodeFilter := []ast.Node{(*ast.SwitchStmt)(nil)}
+	inspect.Preorder(nodeFilter, func(n ast.Node) {
+		if pass.TypesInfo == nil {
+			return
+		}
+		stmt := n.(*ast.SwitchStmt)
+		if hasDefault(stmt.Body) {
+			return
+		}
+		named, missing := MissingEnumCases(pass.TypesInfo, stmt, pass.Pkg)
+		if len(missing) == 0 {
+			return
+		}
+		var names []string
+		for _, c := range missing {
+			names = append(names, c.Name())
+		}
+		pass.Report(analysis.Diagnostic{
+			Pos:     stmt.Pos(),
+			End:     stmt.Body.Lbrace,
+			Message: fmt.Sprintf("switch on %s is missing cases: %s", named.Obj().Name(), strings.Join(names, ", ")),
+		})
+	})
+	return nil, nil
+}
+
+func hasDefault(body *ast.BlockStmt) bool {
+	for _, stmt := range body.List {
+		if cc, ok := stmt.(*ast.CaseClause); ok && cc.List == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// EnumConstants returns the package-level constants of the named type,
+// accessible from package from, in declaration order. It returns nil if the
+// type is not an enum-like type: a named type with a basic underlying type.
+func EnumConstants(named *types.Named, from *types.Package) []*types.Const {
+	if _, ok := named.Underlying().(*types.Basic); !ok {
+		return nil
+	}
+	pkg := named.Obj().Pkg()
+	if pkg == nil {
+		return nil
+	}
+	var consts []*types.Const
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		c, ok := scope.Lookup(name).(*types.Const)
+		if !ok || !types.Identical(c.Type(), named) {
+			continue
+		}
+		if pkg != from && !c.Exported() {
+			continue
+		}
+		consts = append(consts, c)
+	}
+	// Scope names are sorted alphabetically; present the constants in the
+	// order in which they were declared.
+	for i := 1; i < len(consts); i++ {
+		for j := i; j > 0 && consts[j].Pos() < consts[j-1].Pos(); j-- {
+			consts[j], consts[j-1] = consts[j-1], consts[j]
+		}
+	}
+	return consts
+}
+
+// MissingEnumCases returns the enum-like type of the switch statement's tag
+// and those of its constants whose values are not handled by any case of
+// the switch. Constants that share a value with a handled constant are not
+// reported.
+func MissingEnumCases(info *types.Info, stmt *ast.SwitchStmt, pkg *types.Package) (*types.Named, []*types.Const) {
+	if stmt.Tag == nil {
+		return nil, nil
+	}
+	named, ok := info.TypeOf(stmt.Tag).(*types.Named)
+	if !ok {
+		return nil, nil
+	}
+	consts := EnumConstants(named, pkg)
+	if len(consts) == 0 {
+		return nil, nil
+	}
+	var handled []constant.Value
+	for _, s := range stmt.Body.List {
+		cc, ok := s.(*ast.CaseClause)
+		if !ok {
+			continue
+		}
+		for _, e := range cc.List {
+			if tv, ok := info.Types[e]; ok && tv.Value != nil {
+				handled = append(handled, tv.Value)
+			}
+		}
+	}
+	var missing []*types.Const
+	for _, c := range consts {
+		if containsValue(handled, c.Val()) {
+			continue
+		}
+		handled = append(handled, c.Val())
+		missing = append(missing, c)
+	}
+	return named, missing
+}
+
+func containsValue(values []constant.Value, v constant.Value) bool {
+	for _, h := range values {
+		if constant.Compare(h, token.EQL, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// SuggestedFix adds the missing cases to the switch statement over an
+// enum-like type that encloses rng.
+func SuggestedFix(fset *token.FileSet, rng span.Range, content []byte, file *ast.File, pkg *types.Package, info *types.Info) (*analysis.SuggestedFix, error) {
+	path, _ := astutil.PathEnclosingInterval(file, rng.Start, rng.End)
+	var stmt *ast.SwitchStmt
+	for _, n := range path {
+		if s, ok := n.(*ast.SwitchStmt); ok {
+			stmt = s
+			break
+		}
+	}
+	if stmt == nil {
+		return nil, fmt.Errorf("no switch statement found at %v", fset.Position(rng.Start))
+	}
+	named, missing := MissingEnumCases(info, stmt, pkg)
+	if len(missing) == 0 {
+		return nil, fmt.Errorf("no missing cases in switch statement")
+	}
+	adder := analysisinternal.NewImportAdder(file, pkg)
+	var cases []string
+	for _, c := range missing {
+		if q := adder.Qualifier(c.Pkg()); q != "" {
+			cases = append(cases, q+"."+c.Name())
+		} else {
+			cases = append(cases, c.Name())
+		}
+	}
+	edit, err := InsertCases(fset, content, stmt.Body, cases)
+	if err != nil {
+		return nil, err
+	}
+	return &analysis.SuggestedFix{
+		Message:   fmt.Sprintf("Add cases for %s", named.Obj().Name()),
+		TextEdits: append(adder.Edits(), edit),
+	}, nil
+}
+
+// InsertCases returns an edit that adds an empty case clause for each of
+// the given case expressions to the body of a switch statement. The clauses
+// are inserted before the default clause, if there is one, or at the end of
+// the body otherwise.
+func InsertCases(fset *token.FileSet, content []byte, body *ast.BlockStmt, cases []string) (analysis.TextEdit, error) {
+	tok := fset.File(body.Pos())
+	if tok == nil {
+		return analysis.TextEdit{}, fmt.Errorf("no file for switch statement")
+	}
+	// Case clauses are indented to the same level as the switch statement
+	// itself.
+	lineStart := tok.Offset(tok.LineStart(tok.Line(body.Lbrace)))
+	indent := content[lineStart:]
+	indent = indent[:len(indent)-len(bytes.TrimLeft(indent, " \t"))]
+
+	pos := body.Rbrace
+	for _, s := range body.List {
+		if cc, ok := s.(*ast.CaseClause); ok && cc.List == nil {
+			pos = cc.Pos()
+			break
+		}
+	}
+	var buf bytes.Buffer
+	// If the insertion point does not begin its own line, as with an empty
+	// switch body on a single line, start a new one.
+	offset := tok.Offset(pos)
+	before := content[tok.Offset(tok.LineStart(tok.Line(pos))):offset]
+	if len(bytes.TrimLeft(before, " \t")) > 0 {
+		buf.WriteByte('\n')
+		buf.Write(indent)
+	}
+	for _, c := range cases {
+		fmt.Fprintf(&buf, "case %s:\n", c)
+		buf.Write(indent)
+	}
+	return analysis.TextEdit{Pos: pos, End: pos, NewText: buf.Bytes()}, nil
+}
@@ -0,0 +1,46 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fillswitch
+
+type Color int
+
+const (
+	Red Color = iota
+	Green
+	Blue
+
+	Crimson = Red
+)
+
+func exhaustive(c Color) {
+	switch c {
+	case Red, Green, Blue:
+	}
+}
+
+func aliased(c Color) {
+	switch c {
+	case Crimson, Green, Blue:
+	}
+}
+
+func missing(c Color) {
+	switch c { // want "switch on Color is missing cases: Green, Blue"
+	case Red:
+	}
+}
+
+func withDefault(c Color) {
+	switch c {
+	case Red:
+	default:
+	}
+}
+
+func notEnum(i int) {
+	switch i {
+	case 1:
+	}
+}
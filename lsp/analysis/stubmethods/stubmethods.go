@@ -0,0 +1,490 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package stubmethods defines an Analyzer that suggests stub methods for
+// concrete types that fail to implement an interface.
+package stubmethods
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+	"github.com/kevinswiber/languageserver-go/analysisinternal"
+	"github.com/kevinswiber/languageserver-go/span"
+	"github.com/kevinswiber/languageserver-go/typesinternal"
+)
+
+const Doc = `suggested fixes for "missing method" type errors
+
+This checker provides suggested fixes for type errors in which a value
+of a concrete type is used where an interface is expected, but the
+concrete type does not implement all of the interface's methods. For
+example:
+	var _ io.Reader = (*T)(nil)
+will add
+	func (t *T) Read(p []byte) (n int, err error) {
+		panic("unimplemented")
+	}
+to the file that declares T.`
+
+var Analyzer = &analysis.Analyzer{
+	Name:             string(analysisinternal.StubMethods),
+	Doc:              Doc,
+	Requires:         []*analysis.Analyzer{},
+	Run:              run,
+	RunDespiteErrors: true,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	for _, err := range analysisinternal.GetTypeErrors(pass) {
+		if !FixesError(err) {
+			continue
+		}
+		var file *ast.File
+		for _, f := range pass.Files {
+			if f.Pos() <= err.Pos && err.Pos < f.End() {
+				file = f
+				break
+			}
+		}
+		if file == nil {
+			continue
+		}
+		path, _ := astutil.PathEnclosingInterval(file, err.Pos, err.Pos)
+		si := GetStubInfo(pass.TypesInfo, path, pass.Pkg, err.Pos)
+		if si == nil || len(si.missingMethods()) == 0 {
+			continue
+		}
+		pass.Report(analysis.Diagnostic{
+			Pos:     si.Expr.Pos(),
+			End:     si.Expr.End(),
+			Message: err.Msg,
+		})
+	}
+	return nil, nil
+}
+
+// FixesError reports whether err is a type error that this analyzer can
+// provide a suggested fix for.
+func FixesError(err types.Error) bool {
+	if code, _, _, ok := typesinternal.ReadGo116ErrorData(err); ok {
+		switch code {
+		case typesinternal.InvalidIfaceAssign:
+			return true
+		case typesinternal.IncompatibleAssign, typesinternal.InvalidConversion:
+			// Fall back to inspecting the message below.
+		default:
+			return false
+		}
+	}
+	return strings.Contains(err.Msg, "missing method")
+}
+
+// StubInfo describes a concrete type that is used where an interface is
+// expected.
+type StubInfo struct {
+	// Expr is the expression whose value must implement the interface.
+	Expr ast.Expr
+
+	// Concrete is the named type that needs the additional methods.
+	Concrete *types.Named
+
+	// Pointer reports whether the value is a pointer to Concrete, in which
+	// case the stubs may use a pointer receiver.
+	Pointer bool
+
+	// Interface is the expected interface type.
+	Interface types.Type
+}
+
+// GetStubInfo determines whether the expression at pos, found along path,
+// is a value of a named type declared in pkg that is used in a context that
+// expects an interface. It returns nil if it is not.
+func GetStubInfo(info *types.Info, path []ast.Node, pkg *types.Package, pos token.Pos) *StubInfo {
+	for i := 0; i < len(path)-1; i++ {
+		expr, ok := path[i].(ast.Expr)
+		if !ok || expr.Pos() != pos {
+			continue
+		}
+		iface := expectedType(info, path[i:])
+		if iface == nil || !types.IsInterface(iface) {
+			continue
+		}
+		si := newStubInfo(info.TypeOf(expr), iface, pkg)
+		if si == nil {
+			continue
+		}
+		si.Expr = expr
+		return si
+	}
+	return nil
+}
+
+// NewStubInfo returns the StubInfo for adding the methods of iface to the
+// named type obj, which must be declared in pkg.
+func NewStubInfo(obj *types.TypeName, iface types.Type, pointer bool, pkg *types.Package) *StubInfo {
+	typ := obj.Type()
+	if pointer {
+		typ = types.NewPointer(typ)
+	}
+	return newStubInfo(typ, iface, pkg)
+}
+
+func newStubInfo(typ, iface types.Type, pkg *types.Package) *StubInfo {
+	if typ == nil {
+		return nil
+	}
+	var pointer bool
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ, pointer = ptr.Elem(), true
+	}
+	named, ok := typ.(*types.Named)
+	if !ok || named.Obj().Pkg() != pkg {
+		return nil
+	}
+	if types.IsInterface(named) {
+		return nil
+	}
+	if _, ok := named.Underlying().(*types.Pointer); ok {
+		return nil // methods may not be declared on pointer types
+	}
+	return &StubInfo{
+		Concrete:  named,
+		Pointer:   pointer,
+		Interface: iface,
+	}
+}
+
+// expectedType returns the type expected of the expression path[0] by its
+// enclosing node path[1], or nil if it cannot be determined.
+func expectedType(info *types.Info, path []ast.Node) types.Type {
+	expr := path[0].(ast.Expr)
+	switch parent := path[1].(type) {
+	case *ast.ValueSpec:
+		if parent.Type != nil {
+			return info.TypeOf(parent.Type)
+		}
+	case *ast.AssignStmt:
+		if parent.Tok != token.ASSIGN || len(parent.Lhs) != len(parent.Rhs) {
+			return nil
+		}
+		for i, rhs := range parent.Rhs {
+			if rhs == expr {
+				return info.TypeOf(parent.Lhs[i])
+			}
+		}
+	case *ast.CallExpr:
+		if tv, ok := info.Types[parent.Fun]; ok && tv.IsType() {
+			// A conversion, such as io.Reader(t).
+			return tv.Type
+		}
+		sig, ok := info.TypeOf(parent.Fun).(*types.Signature)
+		if !ok {
+			return nil
+		}
+		for i, arg := range parent.Args {
+			if arg != expr {
+				continue
+			}
+			params := sig.Params()
+			switch {
+			case sig.Variadic() && i >= params.Len()-1:
+				if parent.Ellipsis.IsValid() {
+					return nil
+				}
+				return params.At(params.Len() - 1).Type().(*types.Slice).Elem()
+			case i < params.Len():
+				return params.At(i).Type()
+			}
+		}
+	case *ast.ReturnStmt:
+		var sig *types.Signature
+		for _, n := range path[2:] {
+			switch n := n.(type) {
+			case *ast.FuncLit:
+				sig, _ = info.TypeOf(n).(*types.Signature)
+			case *ast.FuncDecl:
+				if obj, ok := info.Defs[n.Name].(*types.Func); ok {
+					sig, _ = obj.Type().(*types.Signature)
+				}
+			default:
+				continue
+			}
+			break
+		}
+		if sig == nil || sig.Results().Len() != len(parent.Results) {
+			return nil
+		}
+		for i, res := range parent.Results {
+			if res == expr {
+				return sig.Results().At(i).Type()
+			}
+		}
+	}
+	return nil
+}
+
+// missingMethods returns the methods of the interface that the concrete
+// type does not declare, in the order of the interface's method set.
+func (si *StubInfo) missingMethods() []*types.Func {
+	iface, ok := si.Interface.Underlying().(*types.Interface)
+	if !ok {
+		return nil
+	}
+	var missing []*types.Func
+	ptr := types.NewPointer(si.Concrete)
+	for i := 0; i < iface.NumMethods(); i++ {
+		m := iface.Method(i)
+		// Any field or method of the same name would conflict with the stub,
+		// even if it has the wrong signature.
+		if obj, _, _ := types.LookupFieldOrMethod(ptr, false, m.Pkg(), m.Name()); obj != nil {
+			continue
+		}
+		missing = append(missing, m)
+	}
+	return missing
+}
+
+// receiver returns the name of the receiver to use for the stub methods,
+// reusing the name from an existing method of the type if there is one.
+func (si *StubInfo) receiver() string {
+	for i := 0; i < si.Concrete.NumMethods(); i++ {
+		sig, ok := si.Concrete.Method(i).Type().(*types.Signature)
+		if !ok || sig.Recv() == nil {
+			continue
+		}
+		if name := sig.Recv().Name(); name != "" && name != "_" {
+			return name
+		}
+	}
+	for _, r := range si.Concrete.Obj().Name() {
+		return string(unicode.ToLower(r))
+	}
+	return "r"
+}
+
+// SuggestedFix returns the stub methods for the type error reported at rng.
+func SuggestedFix(fset *token.FileSet, rng span.Range, content []byte, file *ast.File, pkg *types.Package, info *types.Info) (*analysis.SuggestedFix, error) {
+	path, _ := astutil.PathEnclosingInterval(file, rng.Start, rng.End)
+	var si *StubInfo
+	for _, n := range path {
+		if si = GetStubInfo(info, path, pkg, n.Pos()); si != nil {
+			break
+		}
+	}
+	if si == nil {
+		return nil, fmt.Errorf("no concrete type found at %v", fset.Position(rng.Start))
+	}
+	return StubMethods(fset, file, si)
+}
+
+// StubMethods returns the suggested fix that adds stub methods to
+// si.Concrete for each method of si.Interface that it does not implement.
+// The stubs are added to file immediately after the declaration of the
+// concrete type, if it is declared in file, or at the end of file otherwise.
+func StubMethods(fset *token.FileSet, file *ast.File, si *StubInfo) (*analysis.SuggestedFix, error) {
+	missing := si.missingMethods()
+	if len(missing) == 0 {
+		return nil, fmt.Errorf("%s already implements %s", si.Concrete.Obj().Name(), si.Interface)
+	}
+	pkg := si.Concrete.Obj().Pkg()
+	adder := analysisinternal.NewImportAdder(file, pkg)
+
+	recvName := si.receiver()
+	recvType := si.Concrete.Obj().Name()
+	if si.Pointer {
+		recvType = "*" + recvType
+	}
+	// The interface is only named in comments, so it must not cause an
+	// import to be added.
+	ifaceName := types.TypeString(si.Interface, func(p *types.Package) string {
+		if p == pkg {
+			return ""
+		}
+		return p.Name()
+	})
+	var buf bytes.Buffer
+	for _, m := range missing {
+		sig := m.Type().(*types.Signature)
+		fmt.Fprintf(&buf, "\n\n// %s implements %s.\n", m.Name(), ifaceName)
+		fmt.Fprintf(&buf, "func (%s %s) %s", recvName, recvType, m.Name())
+		writeSignature(&buf, sig, recvName, adder.Qualifier)
+		buf.WriteString(" {\n\tpanic(\"unimplemented\")\n}")
+	}
+
+	pos := file.End()
+	if obj := si.Concrete.Obj(); file.Pos() <= obj.Pos() && obj.Pos() < file.End() {
+		for _, decl := range file.Decls {
+			if decl.Pos() <= obj.Pos() && obj.Pos() < decl.End() {
+				pos = decl.End()
+				break
+			}
+		}
+	}
+	edits := append(adder.Edits(), analysis.TextEdit{
+		Pos:     pos,
+		End:     pos,
+		NewText: buf.Bytes(),
+	})
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].Pos < edits[j].Pos })
+	return &analysis.SuggestedFix{
+		Message:   fmt.Sprintf("Implement %s", ifaceName),
+		TextEdits: edits,
+	}, nil
+}
+
+// writeSignature writes the parameters and results of sig to buf. Unnamed
+// parameters are given names derived from their types, and parameters and
+// results that would shadow the receiver are renamed, without colliding
+// with the names of the others.
+func writeSignature(buf *bytes.Buffer, sig *types.Signature, recv string, qf types.Qualifier) {
+	used := map[string]bool{recv: true}
+	for _, tuple := range []*types.Tuple{sig.Params(), sig.Results()} {
+		for i := 0; i < tuple.Len(); i++ {
+			used[tuple.At(i).Name()] = true
+		}
+	}
+	writeTuple := func(tuple *types.Tuple, variadic, params bool) {
+		buf.WriteByte('(')
+		for i := 0; i < tuple.Len(); i++ {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			v := tuple.At(i)
+			name := v.Name()
+			switch {
+			case name == recv:
+				for used[name] {
+					name += "_"
+				}
+				used[name] = true
+			case (name == "" || name == "_") && params:
+				name = paramName(v.Type())
+				for j := 1; used[name]; j++ {
+					name = fmt.Sprintf("%s%d", paramName(v.Type()), j)
+				}
+				used[name] = true
+			}
+			if name != "" {
+				buf.WriteString(name + " ")
+			}
+			typ := v.Type()
+			if variadic && i == tuple.Len()-1 {
+				buf.WriteString("...")
+				typ = typ.(*types.Slice).Elem()
+			}
+			buf.WriteString(types.TypeString(typ, qf))
+		}
+		buf.WriteByte(')')
+	}
+	writeTuple(sig.Params(), sig.Variadic(), true)
+	switch res := sig.Results(); {
+	case res.Len() == 1 && res.At(0).Name() == "":
+		buf.WriteString(" " + types.TypeString(res.At(0).Type(), qf))
+	case res.Len() > 0:
+		buf.WriteByte(' ')
+		writeTuple(res, false, false)
+	}
+}
+
+// paramName returns a short name for an unnamed parameter of type T, such
+// as "ctx" for a context.Context or "rw" for an http.ResponseWriter.
+func paramName(T types.Type) string {
+	for {
+		switch t := T.(type) {
+		case *types.Pointer:
+			T = t.Elem()
+			continue
+		case *types.Slice:
+			T = t.Elem()
+			continue
+		case *types.Array:
+			T = t.Elem()
+			continue
+		case *types.Named:
+			obj := t.Obj()
+			switch {
+			case obj.Pkg() != nil && obj.Pkg().Path() == "context" && obj.Name() == "Context":
+				return "ctx"
+			case obj.Pkg() == nil && obj.Name() == "error":
+				return "err"
+			}
+			var abbr []rune
+			for i, r := range obj.Name() {
+				if i == 0 || unicode.IsUpper(r) {
+					abbr = append(abbr, unicode.ToLower(r))
+				}
+			}
+			if name := string(abbr); token.Lookup(name) == token.IDENT {
+				return name
+			}
+		case *types.Basic:
+			if name := t.Name()[:1]; t.Kind() != types.UnsafePointer {
+				return name
+			}
+		case *types.Signature:
+			return "fn"
+		case *types.Map:
+			return "m"
+		case *types.Chan:
+			return "ch"
+		}
+		return "v"
+	}
+}
+
+// PartialInterfaces returns the interfaces, declared in pkg or in the
+// packages it imports, that the named type obj implements some but not all
+// of the methods of. They are the candidates for generating stub methods
+// outside of the context of a type error.
+func PartialInterfaces(obj *types.TypeName, pkg *types.Package) []*types.TypeName {
+	named, ok := obj.Type().(*types.Named)
+	if !ok || types.IsInterface(named) {
+		return nil
+	}
+	ptr := types.NewPointer(named)
+	var result []*types.TypeName
+	add := func(scope *types.Scope, exportedOnly bool) {
+		for _, name := range scope.Names() {
+			tname, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || (exportedOnly && !tname.Exported()) || tname == obj {
+				continue
+			}
+			iface, ok := tname.Type().Underlying().(*types.Interface)
+			if !ok || iface.NumMethods() == 0 || types.Implements(ptr, iface) {
+				continue
+			}
+			var found bool
+			for i := 0; i < iface.NumMethods(); i++ {
+				m := iface.Method(i)
+				if obj, _, _ := types.LookupFieldOrMethod(ptr, false, m.Pkg(), m.Name()); obj != nil {
+					if _, isFunc := obj.(*types.Func); isFunc && types.Identical(obj.Type(), m.Type()) {
+						found = true
+						continue
+					}
+					// A conflicting field or method makes the interface
+					// impossible to implement by adding methods.
+					found = false
+					break
+				}
+			}
+			if found {
+				result = append(result, tname)
+			}
+		}
+	}
+	add(pkg.Scope(), false)
+	for _, imp := range pkg.Imports() {
+		add(imp.Scope(), true)
+	}
+	return result
+}
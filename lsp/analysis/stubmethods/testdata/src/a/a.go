@@ -0,0 +1,31 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stubmethods
+
+import "io"
+
+type closer struct{}
+
+func (c *closer) Close() error { return nil }
+
+var _ io.ReadCloser = (*closer)(nil) // want "missing method Read"
+
+type reader int
+
+func newReader() io.Reader {
+	return reader(0) // want "missing method Read"
+}
+
+func use(w io.Writer) {}
+
+type writer struct{}
+
+func f() {
+	use(writer{}) // want "missing method Write"
+
+	var w io.Writer
+	w = &writer{} // want "missing method Write"
+	_ = w
+}
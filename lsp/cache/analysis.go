@@ -24,12 +24,18 @@ import (
 	errors "golang.org/x/xerrors"
 )
 
-func (s *snapshot) Analyze(ctx context.Context, id string, analyzers []*source.Analyzer) ([]*source.Diagnostic, error) {
+func (s *snapshot) Analyze(ctx context.Context, id string, analyzers []*source.Analyzer, vetTools bool) ([]*source.Diagnostic, error) {
 	var roots []*actionHandle
+	var diagnosers []*source.Analyzer
 
+	config := s.analysisConfig(ctx, packageID(id))
 	for _, a := range analyzers {
 
-		if !a.IsEnabled(s.view) {
+		if !config.IsEnabled(a, s.view) {
+			continue
+		}
+		if a.Diagnose != nil {
+			diagnosers = append(diagnosers, a)
 			continue
 		}
 		ah, err := s.actionHandle(ctx, packageID(id), a.Analyzer)
@@ -52,7 +58,102 @@ func (s *snapshot) Analyze(ctx context.Context, id string, analyzers []*source.A
 		}
 		results = append(results, diagnostics...)
 	}
-	return results, nil
+	ran := make(map[string]bool)
+	for _, ah := range roots {
+		ran[ah.analyzer.Name] = true
+	}
+	for _, a := range diagnosers {
+		diagnostics, err := s.diagnose(ctx, packageID(id), a)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, diagnostics...)
+		ran[a.Analyzer.Name] = true
+	}
+
+	// Run the external analysis tools, if the caller asked for them. Their
+	// analyzers are enabled and disabled like gopls's own.
+	external := make(map[string]bool)
+	for _, path := range s.view.Options().VetTools {
+		if !vetTools {
+			break
+		}
+		tool, err := s.view.session.cache.vetTool(ctx, path)
+		if err != nil {
+			event.Error(ctx, "unable to query vet tool", err, tag.Package.Of(id))
+			continue
+		}
+		var disabled []string
+		for _, a := range tool.analyzers {
+			external[a.Analyzer.Name] = true
+			if config.IsEnabled(a, s.view) {
+				ran[a.Analyzer.Name] = true
+			} else {
+				disabled = append(disabled, a.Analyzer.Name)
+			}
+		}
+		if len(disabled) == len(tool.analyzers) {
+			continue
+		}
+		diagnostics, err := s.vetToolDiagnostics(ctx, packageID(id), tool, disabled)
+		if err != nil {
+			event.Error(ctx, "vet tool failed", err, tag.Package.Of(id))
+			continue
+		}
+		results = append(results, diagnostics...)
+	}
+
+	if len(ran) == 0 {
+		return results, nil
+	}
+	pkg, err := s.checkedPackage(ctx, packageID(id), source.ParseFull)
+	if err != nil {
+		return nil, err
+	}
+	results, err = s.applyIgnoreDirectives(pkg, ran, external, results)
+	if err != nil {
+		return nil, err
+	}
+	return applyAnalysisConfig(config, results), nil
+}
+
+type diagnoseKey string
+
+type diagnoseData struct {
+	diagnostics []*source.Diagnostic
+	err         error
+}
+
+// diagnose returns the diagnostics computed by the Diagnose function of the
+// analyzer for the package. They are memoized by the key of the package,
+// which covers the syntax of its dependencies.
+func (s *snapshot) diagnose(ctx context.Context, id packageID, a *source.Analyzer) ([]*source.Diagnostic, error) {
+	ph, err := s.buildPackageHandle(ctx, id, source.ParseFull)
+	if err != nil {
+		return nil, err
+	}
+	key := diagnoseKey(hashContents([]byte(fmt.Sprintf("diagnose %p %s", a.Analyzer, string(ph.key)))))
+	h := s.generation.Bind(key, func(ctx context.Context, arg memoize.Arg) interface{} {
+		snapshot := arg.(*snapshot)
+		pkg, err := ph.check(ctx, snapshot)
+		if err != nil {
+			return &diagnoseData{err: err}
+		}
+		diagnostics, err := a.Diagnose(ctx, snapshot, pkg)
+		for _, d := range diagnostics {
+			d.Analyzer = a
+		}
+		return &diagnoseData{diagnostics: diagnostics, err: err}
+	}, nil)
+	v, err := h.Get(ctx, s.generation, s)
+	if err != nil {
+		return nil, err
+	}
+	data, ok := v.(*diagnoseData)
+	if !ok {
+		return nil, errors.Errorf("unexpected type for %s diagnostics of %s", a.Analyzer.Name, id)
+	}
+	return data.diagnostics, data.err
 }
 
 type actionHandleKey string
@@ -405,7 +506,7 @@ func (s *snapshot) DiagnosePackage(ctx context.Context, spkg source.Package) (ma
 	var errorAnalyzerDiag []*source.Diagnostic
 	if pkg.hasTypeErrors {
 		var err error
-		errorAnalyzerDiag, err = s.Analyze(ctx, pkg.ID(), analyzers)
+		errorAnalyzerDiag, err = s.Analyze(ctx, pkg.ID(), analyzers, false)
 		if err != nil {
 			// Keep going: analysis failures should not block diagnostics.
 			event.Error(ctx, "type error analysis failed", err, tag.Package.Of(pkg.ID()))
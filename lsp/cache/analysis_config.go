@@ -0,0 +1,108 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/kevinswiber/languageserver-go/event"
+	"github.com/kevinswiber/languageserver-go/lsp/debug/tag"
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+	"github.com/kevinswiber/languageserver-go/span"
+)
+
+// analysisConfig returns the analysis configuration of the package, merged
+// from the configuration files in the view's folder and the directories
+// below it down to the package's directory, or nil if there are none.
+//
+// The configurations are cached by directory until a configuration file
+// changes. Existing files are read through the snapshot, so that a change
+// to them invalidates it; creating a file does so too, as the file is
+// watched.
+func (s *snapshot) analysisConfig(ctx context.Context, id packageID) *source.AnalysisConfig {
+	m := s.getMetadata(id)
+	if m == nil || len(m.compiledGoFiles) == 0 {
+		return nil
+	}
+	pkgDir := span.URIFromPath(filepath.Dir(m.compiledGoFiles[0].Filename()))
+	s.mu.Lock()
+	config, ok := s.analysisConfigs[pkgDir]
+	s.mu.Unlock()
+	if ok {
+		return config
+	}
+
+	folder := s.view.folder.Filename()
+	var dirs []string
+	for dir := pkgDir.Filename(); source.InDir(folder, dir); dir = filepath.Dir(dir) {
+		dirs = append(dirs, dir)
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		uri := span.URIFromPath(filepath.Join(dirs[i], source.AnalysisConfigFile))
+		// Most directories have no configuration file. Don't add them to
+		// the snapshot's files.
+		if s.FindFile(uri) == nil {
+			if _, err := os.Stat(uri.Filename()); err != nil {
+				continue
+			}
+		}
+		fh, err := s.GetFile(ctx, uri)
+		if err != nil {
+			continue
+		}
+		data, err := fh.Read()
+		if err != nil {
+			continue
+		}
+		c, err := source.ParseAnalysisConfig(dirs[i], data)
+		if err != nil {
+			event.Error(ctx, "invalid analysis configuration", err, tag.URI.Of(uri))
+			continue
+		}
+		if config == nil {
+			config = c
+		} else {
+			config = config.Merge(c)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.analysisConfigs == nil {
+		s.analysisConfigs = make(map[span.URI]*source.AnalysisConfig)
+	}
+	s.analysisConfigs[pkgDir] = config
+	return config
+}
+
+// applyAnalysisConfig removes the diagnostics of the files excluded by the
+// configuration, and sets the configured severities.
+func applyAnalysisConfig(config *source.AnalysisConfig, diagnostics []*source.Diagnostic) []*source.Diagnostic {
+	if config == nil {
+		return diagnostics
+	}
+	var results []*source.Diagnostic
+	for _, d := range diagnostics {
+		if config.Excludes(d.URI.Filename()) {
+			continue
+		}
+		if d.Analyzer != nil {
+			if severity, ok := config.Severity[d.Analyzer.Analyzer.Name]; ok && severity != d.Severity {
+				// The diagnostic is shared with the cached analysis results,
+				// so copy it.
+				clone := *d
+				clone.Severity = severity
+				d = &clone
+			}
+		}
+		results = append(results, d)
+	}
+	return results
+}
@@ -49,6 +49,15 @@ type Cache struct {
 
 	fileMu      sync.Mutex
 	fileContent map[span.URI]*fileHandle
+
+	vetToolMu sync.Mutex
+	vetTools  map[string]*vetTool
+
+	vulnDBMu sync.Mutex
+	vulnDBs  map[string]*vulnDB
+
+	modCacheMu sync.Mutex
+	modCaches  map[string]*downloadedModules
 }
 
 type fileHandle struct {
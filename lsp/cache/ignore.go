@@ -0,0 +1,126 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+)
+
+// applyIgnoreDirectives removes the diagnostics that are suppressed by the
+// //gopls:ignore directives of the package's files. It reports the names
+// in directives that are neither gopls's analyzers nor external ones, and
+// the names of analyzers that were run but whose diagnostics the directive
+// did not suppress.
+func (s *snapshot) applyIgnoreDirectives(pkg *pkg, ran, external map[string]bool, diagnostics []*source.Diagnostic) ([]*source.Diagnostic, error) {
+	var directives []*source.IgnoreDirective
+	for _, pgf := range pkg.compiledGoFiles {
+		directives = append(directives, source.IgnoreDirectives(pgf)...)
+	}
+	if len(directives) == 0 {
+		return diagnostics, nil
+	}
+
+	used := make(map[*source.IgnoreDirective]map[string]bool)
+	var results []*source.Diagnostic
+	for _, d := range diagnostics {
+		suppressed := false
+		if d.Analyzer != nil {
+			name := d.Analyzer.Analyzer.Name
+			for _, directive := range directives {
+				if !directive.Suppresses(name, d.URI, d.Range.Start.Line) {
+					continue
+				}
+				if used[directive] == nil {
+					used[directive] = make(map[string]bool)
+				}
+				used[directive][name] = true
+				suppressed = true
+			}
+		}
+		if !suppressed {
+			results = append(results, d)
+		}
+	}
+
+	options := s.view.Options()
+	known := func(name string) bool {
+		if external[name] {
+			return true
+		}
+		for _, analyzers := range []map[string]*source.Analyzer{
+			options.DefaultAnalyzers,
+			options.TypeErrorAnalyzers,
+			options.ConvenienceAnalyzers,
+			options.StaticcheckAnalyzers,
+		} {
+			if _, ok := analyzers[name]; ok {
+				return true
+			}
+		}
+		return false
+	}
+	for _, directive := range directives {
+		unknown, unused := make(map[string]bool), make(map[string]bool)
+		for _, name := range directive.Analyzers {
+			switch {
+			case !known(name):
+				unknown[name] = true
+			case ran[name] && !used[directive][name]:
+				unused[name] = true
+			}
+		}
+		if len(unknown) > 0 {
+			diag, err := ignoreDirectiveDiagnostic(s, pkg, directive, unknown, "unknown analyzer")
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, diag)
+		}
+		if len(unused) > 0 {
+			diag, err := ignoreDirectiveDiagnostic(s, pkg, directive, unused, "no diagnostics to ignore for")
+			if err != nil {
+				return nil, err
+			}
+			diag.Tags = []protocol.DiagnosticTag{protocol.Unnecessary}
+			results = append(results, diag)
+		}
+	}
+	return results, nil
+}
+
+// ignoreDirectiveDiagnostic returns a diagnostic for the given names of the
+// directive, with a fix that removes them.
+func ignoreDirectiveDiagnostic(s *snapshot, pkg *pkg, directive *source.IgnoreDirective, names map[string]bool, problem string) (*source.Diagnostic, error) {
+	pgf, err := pkg.File(directive.URI)
+	if err != nil {
+		return nil, err
+	}
+	rng, err := source.NewMappedRange(s.FileSet(), pgf.Mapper, directive.Comment.Pos(), directive.Comment.End()).Range()
+	if err != nil {
+		return nil, err
+	}
+	fix, err := directive.RemoveAnalyzersFix(s.FileSet(), pgf, names)
+	if err != nil {
+		return nil, err
+	}
+	var listed []string
+	for _, name := range directive.Analyzers {
+		if names[name] {
+			listed = append(listed, name)
+		}
+	}
+	return &source.Diagnostic{
+		URI:            directive.URI,
+		Range:          rng,
+		Severity:       protocol.SeverityWarning,
+		Source:         source.IgnoreDirectiveError,
+		Message:        fmt.Sprintf("%s %s", problem, strings.Join(listed, ", ")),
+		SuggestedFixes: []source.SuggestedFix{fix},
+	}, nil
+}
@@ -0,0 +1,137 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+
+	"golang.org/x/tools/go/analysis"
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+	"github.com/kevinswiber/languageserver-go/span"
+)
+
+func TestApplyIgnoreDirectives(t *testing.T) {
+	const src = `package a
+
+import "fmt"
+
+func f() {
+	//gopls:ignore printf
+	fmt.Printf("%d")
+	//gopls:ignore printf,nosuch,assign,unusedresult
+	fmt.Printf("%d")
+	x := 1 //gopls:ignore myvet
+	_ = x
+}
+`
+	options := source.DefaultOptions().Clone()
+	fset := token.NewFileSet()
+	s := &snapshot{view: &View{
+		options: options,
+		session: &Session{cache: &Cache{fset: fset}},
+	}}
+	uri := span.URIFromPath("/a.go")
+	file, err := parser.ParseFile(fset, uri.Filename(), src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok := fset.File(file.Pos())
+	p := &pkg{m: &metadata{id: "example.com/a"}}
+	p.compiledGoFiles = append(p.compiledGoFiles, &source.ParsedGoFile{
+		URI:  uri,
+		File: file,
+		Tok:  tok,
+		Src:  []byte(src),
+		Mapper: &protocol.ColumnMapper{
+			URI:       uri,
+			Converter: span.NewTokenConverter(fset, tok),
+			Content:   []byte(src),
+		},
+	})
+
+	printf := options.DefaultAnalyzers["printf"]
+	myvet := &source.Analyzer{Analyzer: &analysis.Analyzer{Name: "myvet"}}
+	diagnostic := func(a *source.Analyzer, line uint32, message string) *source.Diagnostic {
+		d := &source.Diagnostic{
+			URI:     uri,
+			Range:   protocol.Range{Start: protocol.Position{Line: line, Character: 1}, End: protocol.Position{Line: line, Character: 4}},
+			Message: message,
+		}
+		if a != nil {
+			d.Analyzer = a
+			d.Source = source.DiagnosticSource(a.Analyzer.Name)
+		}
+		return d
+	}
+	diagnostics := []*source.Diagnostic{
+		diagnostic(printf, 2, "not suppressed"),
+		diagnostic(printf, 6, "suppressed by the line before"),
+		diagnostic(nil, 6, "not from an analyzer"),
+		diagnostic(printf, 8, "suppressed by a list"),
+		diagnostic(myvet, 9, "suppressed on the line of the directive"),
+	}
+	ran := map[string]bool{"printf": true, "assign": true, "myvet": true}
+	external := map[string]bool{"myvet": true}
+	results, err := s.applyIgnoreDirectives(p, ran, external, diagnostics)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, d := range results {
+		got = append(got, fmt.Sprintf("%d: %s: %s", d.Range.Start.Line+1, d.Source, d.Message))
+	}
+	// The unused unusedresult is not reported because it did not run.
+	want := []string{
+		"3: printf: not suppressed",
+		"7: : not from an analyzer",
+		"8: ignore directive: unknown analyzer nosuch",
+		"8: ignore directive: no diagnostics to ignore for assign",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("applyIgnoreDirectives = %q, want %q", got, want)
+	}
+
+	unknown, unused := results[2], results[3]
+	if unknown.Severity != protocol.SeverityWarning || len(unknown.Tags) != 0 {
+		t.Errorf("unknown analyzer diagnostic has severity %v and tags %v, want a warning without tags", unknown.Severity, unknown.Tags)
+	}
+	if !reflect.DeepEqual(unused.Tags, []protocol.DiagnosticTag{protocol.Unnecessary}) {
+		t.Errorf("unused analyzer diagnostic has tags %v, want Unnecessary", unused.Tags)
+	}
+	for _, test := range []struct {
+		d    *source.Diagnostic
+		want string
+	}{
+		{unknown, "//gopls:ignore printf,assign,unusedresult"},
+		{unused, "//gopls:ignore printf,nosuch,unusedresult"},
+	} {
+		if len(test.d.SuggestedFixes) != 1 {
+			t.Errorf("%s: got %d fixes, want 1", test.d.Message, len(test.d.SuggestedFixes))
+			continue
+		}
+		edits := test.d.SuggestedFixes[0].Edits[uri]
+		if len(edits) != 1 || edits[0].NewText != test.want {
+			t.Errorf("%s: fix edits = %v, want the directive %q", test.d.Message, edits, test.want)
+		}
+	}
+
+	// Without directives, the diagnostics are returned as they are.
+	src2 := "package a\n"
+	file2, err := parser.ParseFile(fset, "/b.go", src2, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2 := &pkg{m: &metadata{id: "example.com/b"}}
+	p2.compiledGoFiles = []*source.ParsedGoFile{{URI: span.URIFromPath("/b.go"), File: file2, Tok: fset.File(file2.Pos()), Src: []byte(src2)}}
+	if results, err := s.applyIgnoreDirectives(p2, ran, external, diagnostics); err != nil || !reflect.DeepEqual(results, diagnostics) {
+		t.Errorf("applyIgnoreDirectives without directives = %v, %v, want the diagnostics unchanged", results, err)
+	}
+}
@@ -140,6 +140,7 @@ type modAction int
 const (
 	why modAction = iota
 	upgrade
+	graph
 )
 
 type modWhyHandle struct {
@@ -0,0 +1,97 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"golang.org/x/mod/module"
+)
+
+// downloadedModulesRefresh is how long the downloaded modules of a module
+// cache are reused before they are scanned again. Like the imports cache,
+// they are refreshed at most twice per minute.
+const downloadedModulesRefresh = 30 * time.Second
+
+// downloadedModules holds the paths of the modules that have been
+// downloaded to a module cache.
+type downloadedModules struct {
+	paths      []string
+	scanned    time.Time
+	refreshing bool
+}
+
+func (s *snapshot) DownloadedModules(ctx context.Context) ([]string, error) {
+	dir := s.view.GoModCache()
+	if dir == "" {
+		return nil, nil
+	}
+	return s.view.session.cache.downloadedModules(dir), nil
+}
+
+// downloadedModules returns the paths of the modules downloaded to the
+// module cache dir. The cache is scanned the first time, and the paths are
+// then refreshed in the background once they are stale, so that only the
+// first call waits for the scan.
+func (c *Cache) downloadedModules(dir string) []string {
+	c.modCacheMu.Lock()
+	defer c.modCacheMu.Unlock()
+
+	m, ok := c.modCaches[dir]
+	if !ok {
+		m = &downloadedModules{
+			paths:   scanDownloadedModules(dir),
+			scanned: time.Now(),
+		}
+		if c.modCaches == nil {
+			c.modCaches = make(map[string]*downloadedModules)
+		}
+		c.modCaches[dir] = m
+		return m.paths
+	}
+	if !m.refreshing && time.Since(m.scanned) > downloadedModulesRefresh {
+		m.refreshing = true
+		go func() {
+			paths := scanDownloadedModules(dir)
+			c.modCacheMu.Lock()
+			m.paths, m.scanned, m.refreshing = paths, time.Now(), false
+			c.modCacheMu.Unlock()
+		}()
+	}
+	return m.paths
+}
+
+// scanDownloadedModules returns the sorted paths of the modules that have
+// a download directory in the module cache dir.
+func scanDownloadedModules(dir string) []string {
+	root := filepath.Join(dir, "cache", "download")
+	var paths []string
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return nil
+		}
+		if info.Name() == "@v" {
+			rel, err := filepath.Rel(root, filepath.Dir(path))
+			if err != nil {
+				return filepath.SkipDir
+			}
+			if modPath, err := module.UnescapePath(filepath.ToSlash(rel)); err == nil {
+				paths = append(paths, modPath)
+			}
+			return filepath.SkipDir
+		}
+		// The sumdb directory holds checksums, not modules.
+		if path == filepath.Join(root, "sumdb") {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,72 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDownloadedModules(t *testing.T) {
+	dir, err := ioutil.TempDir("", "modcache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name string) {
+		path := filepath.Join(dir, "cache", "download", filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("example.com/a/@v/list")
+	write("github.com/!user/repo/@v/v1.0.0.info")
+	write("github.com/!user/repo/sub/@v/v1.0.0.info")
+	write("sumdb/sum.golang.org/lookup/example.com/a@v1.0.0")
+
+	want := []string{"example.com/a", "github.com/User/repo", "github.com/User/repo/sub"}
+	if got := scanDownloadedModules(dir); !reflect.DeepEqual(got, want) {
+		t.Errorf("scanDownloadedModules = %v, want %v", got, want)
+	}
+
+	// The paths are reused until they are stale, and then refreshed in the
+	// background.
+	c := &Cache{}
+	if got := c.downloadedModules(dir); !reflect.DeepEqual(got, want) {
+		t.Fatalf("downloadedModules = %v, want %v", got, want)
+	}
+	write("example.com/b/@v/list")
+	if got := c.downloadedModules(dir); !reflect.DeepEqual(got, want) {
+		t.Errorf("downloadedModules before refresh = %v, want %v", got, want)
+	}
+	c.modCacheMu.Lock()
+	c.modCaches[dir].scanned = time.Now().Add(-2 * downloadedModulesRefresh)
+	c.modCacheMu.Unlock()
+	if got := c.downloadedModules(dir); !reflect.DeepEqual(got, want) {
+		t.Errorf("downloadedModules of stale paths = %v, want %v", got, want)
+	}
+	for start := time.Now(); ; time.Sleep(time.Millisecond) {
+		c.modCacheMu.Lock()
+		refreshing := c.modCaches[dir].refreshing
+		c.modCacheMu.Unlock()
+		if !refreshing {
+			break
+		}
+		if time.Since(start) > 10*time.Second {
+			t.Fatal("the downloaded modules were not refreshed")
+		}
+	}
+	want = []string{"example.com/a", "example.com/b", "github.com/User/repo", "github.com/User/repo/sub"}
+	if got := c.downloadedModules(dir); !reflect.DeepEqual(got, want) {
+		t.Errorf("downloadedModules after refresh = %v, want %v", got, want)
+	}
+}
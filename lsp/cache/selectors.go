@@ -0,0 +1,100 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+	"github.com/kevinswiber/languageserver-go/memoize"
+	errors "golang.org/x/xerrors"
+)
+
+type selectorStatsKey string
+
+type selectorStatsData struct {
+	stats source.SelectorStats
+	err   error
+}
+
+// mergedSelectorStats are the selector counts of a set of packages.
+type mergedSelectorStats struct {
+	// key identifies the set of packages by their package handle keys.
+	key   string
+	stats source.SelectorStats
+}
+
+// SelectorStats counts the selector expressions of each workspace package
+// separately, so that the counts of a package are recomputed only when
+// the package or its dependencies change. The merged counts are kept in
+// the snapshot until more of its packages are type-checked; they must not
+// be modified.
+//
+// Only the packages that are already type-checked, as they are once the
+// workspace is diagnosed, are counted, so that completion, which ranks its
+// results by the counts, never waits for the workspace to be type-checked.
+// Packages that fail to type-check are skipped.
+func (s *snapshot) SelectorStats(ctx context.Context) (source.SelectorStats, error) {
+	if err := s.awaitLoaded(ctx); err != nil {
+		return nil, err
+	}
+	var phs []*packageHandle
+	for _, id := range s.workspacePackageIDs() {
+		ph, err := s.buildPackageHandle(ctx, id, s.workspaceParseMode(id))
+		if err != nil {
+			continue
+		}
+		if pkg, err := ph.cached(s.generation); pkg == nil || err != nil {
+			continue
+		}
+		phs = append(phs, ph)
+	}
+	sort.Slice(phs, func(i, j int) bool { return phs[i].key < phs[j].key })
+	keys := make([]string, len(phs))
+	for i, ph := range phs {
+		keys[i] = string(ph.key)
+	}
+	setKey := strings.Join(keys, " ")
+
+	s.mu.Lock()
+	merged := s.selectorStats
+	s.mu.Unlock()
+	if merged != nil && merged.key == setKey {
+		return merged.stats, nil
+	}
+
+	stats := make(source.SelectorStats)
+	for _, ph := range phs {
+		ph := ph
+		key := selectorStatsKey(hashContents([]byte("selectors " + string(ph.key))))
+		h := s.generation.Bind(key, func(ctx context.Context, arg memoize.Arg) interface{} {
+			snapshot := arg.(*snapshot)
+			pkg, err := ph.check(ctx, snapshot)
+			if err != nil {
+				return &selectorStatsData{err: err}
+			}
+			return &selectorStatsData{stats: source.CountSelectors(pkg)}
+		}, nil)
+		v, err := h.Get(ctx, s.generation, s)
+		if err != nil {
+			return nil, err
+		}
+		data, ok := v.(*selectorStatsData)
+		if !ok {
+			return nil, errors.Errorf("unexpected type for selector stats of %s", ph.m.id)
+		}
+		if data.err != nil {
+			continue
+		}
+		stats.Merge(data.stats)
+	}
+
+	s.mu.Lock()
+	s.selectorStats = &mergedSelectorStats{key: setKey, stats: stats}
+	s.mu.Unlock()
+	return stats, nil
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/kevinswiber/languageserver-go/event"
 	"github.com/kevinswiber/languageserver-go/gocommand"
 	"github.com/kevinswiber/languageserver-go/imports"
+	"github.com/kevinswiber/languageserver-go/lsp/debug/tag"
 	"github.com/kevinswiber/languageserver-go/lsp/source"
 	"github.com/kevinswiber/languageserver-go/span"
 	"github.com/kevinswiber/languageserver-go/xcontext"
@@ -234,6 +235,20 @@ func (s *Session) createView(ctx context.Context, name string, folder, tempWorks
 		workspace:         workspace,
 	}
 
+	// Create the views of the build matrix. Their options have no build
+	// matrix of their own.
+	for _, config := range options.BuildMatrix {
+		config := config
+		cv, _, release, err := s.createView(ctx, fmt.Sprintf("%s [%s]", name, config), folder, "", options.ForBuildConfig(config), 0)
+		release()
+		if err != nil {
+			event.Error(ctx, "creating view for build configuration", err, tag.Directory.Of(folder.Filename()))
+			continue
+		}
+		cv.buildConfig = &config
+		v.configViews = append(v.configViews, cv)
+	}
+
 	// Initialize the view without blocking.
 	initCtx, initCancel := context.WithCancel(xcontext.Detach(ctx))
 	v.initCancelFirstAttempt = initCancel
@@ -287,8 +302,23 @@ func (s *Session) viewOf(uri span.URI) (*View, error) {
 	if len(s.views) == 0 {
 		return nil, fmt.Errorf("no views in session")
 	}
-	s.viewMap[uri] = bestViewForURI(uri, s.views)
-	return s.viewMap[uri], nil
+	v := bestViewForURI(uri, s.views)
+	// Files that the view's build constraints exclude belong to the view
+	// of a configuration that includes them, if it has one.
+	if ev := v.excludedFileView(uri); ev != nil {
+		v = ev
+	}
+	s.viewMap[uri] = v
+	return v, nil
+}
+
+// forgetViewOf forgets the view associated with the URI, so that it is
+// picked again.
+func (s *Session) forgetViewOf(uri span.URI) {
+	s.viewMu.Lock()
+	defer s.viewMu.Unlock()
+
+	delete(s.viewMap, uri)
 }
 
 func (s *Session) viewsOf(uri span.URI) []*View {
@@ -423,6 +453,13 @@ func (s *Session) DidModifyFiles(ctx context.Context, changes []source.FileModif
 		if c.Action == source.InvalidateMetadata {
 			forceReloadMetadata = true
 		}
+		// Only open files are type-checked in the views of the files that
+		// build constraints exclude.
+		if c.Action == source.Close {
+			for _, view := range s.views {
+				view.forgetExcludedFile(ctx, c.URI)
+			}
+		}
 
 		// Build the list of affected views.
 		var changedViews []*View
@@ -448,8 +485,19 @@ func (s *Session) DidModifyFiles(ctx context.Context, changes []source.FileModif
 		}
 		affectedViews[c.URI] = changedViews
 
+		// The views of the build matrices and of the excluded files are not
+		// diagnosed separately, but they need the changes too.
+		appliedViews := append([]*View(nil), changedViews...)
+		for _, view := range s.views {
+			for _, cv := range view.derivedViews() {
+				if cv.relevantChange(c) {
+					appliedViews = append(appliedViews, cv)
+				}
+			}
+		}
+
 		// Apply the changes to all affected views.
-		for _, view := range changedViews {
+		for _, view := range appliedViews {
 			// Make sure that the file is added to the view.
 			_ = view.getFile(c.URI)
 			if _, ok := views[view]; !ok {
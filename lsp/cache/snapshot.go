@@ -109,6 +109,20 @@ type snapshot struct {
 	modTidyHandles map[span.URI]*modTidyHandle
 	modWhyHandles  map[span.URI]*modWhyHandle
 
+	// goFileTrees maps directories to the .go files of their trees, as
+	// listed by GoFilesInTree. A listing is carried over to later
+	// snapshots until a .go file is created or deleted in the tree.
+	goFileTrees map[span.URI][]span.URI
+
+	// analysisConfigs maps package directories to their analysis
+	// configurations, as returned by analysisConfig. They are carried
+	// over to later snapshots until a configuration file changes.
+	analysisConfigs map[span.URI]*source.AnalysisConfig
+
+	// selectorStats holds the merged selector counts of the snapshot's
+	// type-checked workspace packages, as returned by SelectorStats.
+	selectorStats *mergedSelectorStats
+
 	workspace          *workspace
 	workspaceDirHandle *memoize.Handle
 }
@@ -147,6 +161,57 @@ func (s *snapshot) ModFiles() []span.URI {
 	return uris
 }
 
+func (s *snapshot) GoFilesInTree(ctx context.Context, dir span.URI) ([]span.URI, error) {
+	s.mu.Lock()
+	uris, ok := s.goFileTrees[dir]
+	s.mu.Unlock()
+	if ok {
+		return uris, nil
+	}
+	root := dir.Filename()
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return nil
+		}
+		base := info.Name()
+		if info.IsDir() {
+			if path != root && (base == "testdata" || base == "vendor" || strings.HasPrefix(base, ".") || strings.HasPrefix(base, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(base, ".go") {
+			uris = append(uris, span.URIFromPath(path))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Include the open files that have not been saved yet.
+	seen := make(map[span.URI]bool)
+	for _, uri := range uris {
+		seen[uri] = true
+	}
+	for _, fh := range s.openFiles() {
+		uri := fh.URI()
+		if !seen[uri] && strings.HasSuffix(uri.Filename(), ".go") && source.InDir(root, uri.Filename()) {
+			uris = append(uris, uri)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.goFileTrees == nil {
+		s.goFileTrees = make(map[span.URI][]span.URI)
+	}
+	s.goFileTrees[dir] = uris
+	return uris, nil
+}
+
 func (s *snapshot) ValidBuildConfiguration() bool {
 	return validBuildConfiguration(s.view.rootURI, &s.view.workspaceInformation, s.workspace.getActiveModFiles())
 }
@@ -181,8 +246,9 @@ func (s *snapshot) workspaceMode() workspaceMode {
 	if !options.ExpandWorkspaceToModule {
 		return mode
 	}
-	// The workspace module has been disabled by the user.
-	if !options.ExperimentalWorkspaceModule {
+	// The workspace module has been disabled by the user, and there is no
+	// go.work file that requires it.
+	if !options.ExperimentalWorkspaceModule && s.workspace.moduleSource != goWorkWorkspace {
 		return mode
 	}
 	mode |= usesWorkspaceModule
@@ -677,7 +743,8 @@ func (s *snapshot) fileWatchingGlobPatterns(ctx context.Context) map[string]stru
 	// at least, watching the user's entire workspace. This will still be
 	// applied to every folder in the workspace.
 	patterns := map[string]struct{}{
-		"**/*.{go,mod,sum}": {},
+		"**/*.{go,mod,sum,work}":          {},
+		"**/" + source.AnalysisConfigFile: {},
 	}
 	dirs := s.workspace.dirs(ctx, s)
 	for _, dir := range dirs {
@@ -691,7 +758,7 @@ func (s *snapshot) fileWatchingGlobPatterns(ctx context.Context) map[string]stru
 		// TODO(rstambler): If microsoft/vscode#3025 is resolved before
 		// microsoft/vscode#101042, we will need a work-around for Windows
 		// drive letter casing.
-		patterns[fmt.Sprintf("%s/**/*.{go,mod,sum}", dirName)] = struct{}{}
+		patterns[fmt.Sprintf("%s/**/*.{go,mod,sum,work}", dirName)] = struct{}{}
 	}
 
 	// Some clients do not send notifications for changes to directories that
@@ -1263,6 +1330,24 @@ func contains(views []*View, view *View) bool {
 	return false
 }
 
+// goFilesCreatedOrDeleted reports whether the changes create or delete a
+// .go file in the tree of dir, whose .go files are listed by uris.
+func goFilesCreatedOrDeleted(dir span.URI, uris []span.URI, changes map[span.URI]*fileChange) bool {
+	listed := make(map[span.URI]bool)
+	for _, uri := range uris {
+		listed[uri] = true
+	}
+	for uri, change := range changes {
+		if !strings.HasSuffix(uri.Filename(), ".go") || !source.InDir(dir.Filename(), uri.Filename()) {
+			continue
+		}
+		if change.exists != listed[uri] {
+			return true
+		}
+	}
+	return false
+}
+
 func inVendor(uri span.URI) bool {
 	toSlash := filepath.ToSlash(uri.Filename())
 	if !strings.Contains(toSlash, "/vendor/") {
@@ -1386,6 +1471,34 @@ func (s *snapshot) clone(ctx, bgCtx context.Context, changes map[span.URI]*fileC
 		result.modWhyHandles[k] = v
 	}
 
+	// Copy the listings of .go files, unless a .go file was created or
+	// deleted in their trees.
+	for dir, uris := range s.goFileTrees {
+		if !goFilesCreatedOrDeleted(dir, uris, changes) {
+			if result.goFileTrees == nil {
+				result.goFileTrees = make(map[span.URI][]span.URI)
+			}
+			result.goFileTrees[dir] = uris
+		}
+	}
+
+	// Copy the analysis configurations, unless a configuration file
+	// changed.
+	configChanged := false
+	for uri := range changes {
+		if filepath.Base(uri.Filename()) == source.AnalysisConfigFile {
+			configChanged = true
+		}
+	}
+	if !configChanged {
+		for dir, config := range s.analysisConfigs {
+			if result.analysisConfigs == nil {
+				result.analysisConfigs = make(map[span.URI]*source.AnalysisConfig)
+			}
+			result.analysisConfigs[dir] = config
+		}
+	}
+
 	// directIDs keeps track of package IDs that have directly changed.
 	// It maps id->invalidateMetadata.
 	directIDs := map[packageID]bool{}
@@ -0,0 +1,188 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+	"github.com/kevinswiber/languageserver-go/event"
+	"github.com/kevinswiber/languageserver-go/gocommand"
+	"github.com/kevinswiber/languageserver-go/lsp/debug/tag"
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+	"github.com/kevinswiber/languageserver-go/memoize"
+	"github.com/kevinswiber/languageserver-go/span"
+)
+
+type parseSumKey source.FileIdentity
+
+type parseSumData struct {
+	parsed *source.ParsedSumFile
+
+	// err is any error encountered while reading the file.
+	err error
+}
+
+func (s *snapshot) ParseSum(ctx context.Context, fh source.FileHandle) (*source.ParsedSumFile, error) {
+	h := s.generation.Bind(parseSumKey(fh.FileIdentity()), func(ctx context.Context, _ memoize.Arg) interface{} {
+		_, done := event.Start(ctx, "cache.ParseSum", tag.URI.Of(fh.URI()))
+		defer done()
+
+		contents, err := fh.Read()
+		if err != nil {
+			return &parseSumData{err: err}
+		}
+		m := &protocol.ColumnMapper{
+			URI:       fh.URI(),
+			Converter: span.NewContentConverter(fh.URI().Filename(), contents),
+			Content:   contents,
+		}
+		file, parseErr := source.ParseSumFile(fh.URI().Filename(), contents)
+		// Malformed lines are reported as parse errors, but do not prevent
+		// the use of the rest of the file.
+		var parseErrors []*source.Diagnostic
+		if parseErr != nil {
+			mfErrList, ok := parseErr.(modfile.ErrorList)
+			if !ok {
+				return &parseSumData{err: fmt.Errorf("unexpected parse error type %v", parseErr)}
+			}
+			for _, mfErr := range mfErrList {
+				rng, err := rangeFromPositions(m, mfErr.Pos, mfErr.Pos)
+				if err != nil {
+					return &parseSumData{err: err}
+				}
+				parseErrors = append(parseErrors, &source.Diagnostic{
+					URI:      fh.URI(),
+					Range:    rng,
+					Severity: protocol.SeverityError,
+					Source:   source.ParseError,
+					Message:  mfErr.Err.Error(),
+				})
+			}
+		}
+		return &parseSumData{
+			parsed: &source.ParsedSumFile{
+				URI:         fh.URI(),
+				Mapper:      m,
+				File:        file,
+				ParseErrors: parseErrors,
+			},
+		}
+	}, nil)
+
+	v, err := h.Get(ctx, s.generation, s)
+	if err != nil {
+		return nil, err
+	}
+	data := v.(*parseSumData)
+	return data.parsed, data.err
+}
+
+type modGraphData struct {
+	graph *source.ModuleGraph
+
+	err error
+}
+
+func (s *snapshot) ModGraph(ctx context.Context, fh source.FileHandle) (*source.ModuleGraph, error) {
+	if fh.Kind() != source.Mod {
+		return nil, fmt.Errorf("%s is not a go.mod file", fh.URI())
+	}
+	key := modKey{
+		sessionID: s.view.session.id,
+		env:       hashEnv(s),
+		mod:       fh.FileIdentity(),
+		view:      s.view.rootURI.Filename(),
+		verb:      graph,
+	}
+	h := s.generation.Bind(key, func(ctx context.Context, arg memoize.Arg) interface{} {
+		ctx, done := event.Start(ctx, "cache.ModGraph", tag.URI.Of(fh.URI()))
+		defer done()
+
+		snapshot := arg.(*snapshot)
+
+		// The go command does not access the network unless it is allowed
+		// to, so the graph is only available if the go.mod files of the
+		// dependencies are in the module cache.
+		_, inv, cleanup, err := snapshot.goCommandInvocation(ctx, source.Normal, &gocommand.Invocation{
+			Verb:       "mod",
+			Args:       []string{"graph"},
+			WorkingDir: filepath.Dir(fh.URI().Filename()),
+		})
+		if err != nil {
+			return &modGraphData{err: err}
+		}
+		defer cleanup()
+		// The graph does not depend on the go.sum file, but the go command
+		// refuses to report it if the file is malformed or has a hash that
+		// disagrees with the module cache, which we diagnose instead.
+		if inv.ModFile != "" {
+			_ = os.Remove(sumFilename(span.URIFromPath(inv.ModFile)))
+		}
+		// Without the go.sum file, the go command would otherwise look up
+		// the hashes of the go.mod files of the dependencies in the checksum
+		// database, which fails when the network is not allowed.
+		inv.Env = append(inv.Env, "GOSUMDB=off")
+		stdout, err := snapshot.view.session.gocmdRunner.Run(ctx, *inv)
+		if err != nil {
+			return &modGraphData{err: err}
+		}
+		g, err := parseModGraph(stdout.String())
+		if err != nil {
+			return &modGraphData{err: err}
+		}
+		return &modGraphData{graph: g}
+	}, nil)
+
+	v, err := h.Get(ctx, s.generation, s)
+	if err != nil {
+		return nil, err
+	}
+	data := v.(*modGraphData)
+	return data.graph, data.err
+}
+
+// parseModGraph parses the output of `go mod graph`. Each line is an edge
+// from a module to one of its requirements.
+func parseModGraph(out string) (*source.ModuleGraph, error) {
+	g := &source.ModuleGraph{
+		Requirers: make(map[module.Version][]module.Version),
+		Selected:  make(map[string]string),
+	}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("unexpected line in go mod graph output: %q", line)
+		}
+		from, to := parseGraphNode(fields[0]), parseGraphNode(fields[1])
+		// Newer versions of the go command report the go and toolchain
+		// versions as requirements, which are not modules.
+		if to.Path == "go" || to.Path == "toolchain" {
+			continue
+		}
+		g.Requirers[to] = append(g.Requirers[to], from)
+		if v, ok := g.Selected[to.Path]; !ok || semver.Compare(v, to.Version) < 0 {
+			g.Selected[to.Path] = to.Version
+		}
+	}
+	return g, nil
+}
+
+func parseGraphNode(s string) module.Version {
+	if i := strings.LastIndex(s, "@"); i >= 0 {
+		return module.Version{Path: s[:i], Version: s[i+1:]}
+	}
+	return module.Version{Path: s}
+}
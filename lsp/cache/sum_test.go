@@ -0,0 +1,119 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golang.org/x/mod/module"
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+	"github.com/kevinswiber/languageserver-go/span"
+)
+
+func TestParseModGraph(t *testing.T) {
+	out := `example.com/main example.com/a@v1.1.0
+example.com/main go@1.21
+example.com/a@v1.1.0 example.com/b@v1.0.0
+example.com/main example.com/b@v1.2.0
+example.com/b@v1.2.0 toolchain@go1.21.0
+`
+	g, err := parseModGraph(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	main := module.Version{Path: "example.com/main"}
+	a := module.Version{Path: "example.com/a", Version: "v1.1.0"}
+	wantRequirers := map[module.Version][]module.Version{
+		a: {main},
+		{Path: "example.com/b", Version: "v1.0.0"}: {a},
+		{Path: "example.com/b", Version: "v1.2.0"}: {main},
+	}
+	if !reflect.DeepEqual(g.Requirers, wantRequirers) {
+		t.Errorf("parseModGraph requirers = %v, want %v", g.Requirers, wantRequirers)
+	}
+	wantSelected := map[string]string{
+		"example.com/a": "v1.1.0",
+		"example.com/b": "v1.2.0",
+	}
+	if !reflect.DeepEqual(g.Selected, wantSelected) {
+		t.Errorf("parseModGraph selected = %v, want %v", g.Selected, wantSelected)
+	}
+
+	if _, err := parseModGraph("example.com/main\n"); err == nil {
+		t.Errorf("parseModGraph succeeded on a malformed line")
+	}
+}
+
+func TestModGraph(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "modgraph")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name, content string) {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The module cache holds the go.mod files of the dependencies, and the
+	// go.sum file has a hash that disagrees with it.
+	write("modcache/cache/download/example.com/a/@v/v1.0.0.mod", "module example.com/a\n\nrequire example.com/b v1.0.0\n")
+	write("modcache/cache/download/example.com/b/@v/v1.0.0.mod", "module example.com/b\n")
+	write("modcache/cache/download/example.com/b/@v/v1.1.0.mod", "module example.com/b\n")
+	write("main/go.mod", "module example.com/main\n\ngo 1.16\n\nrequire (\n\texample.com/a v1.0.0\n\texample.com/b v1.1.0\n)\n")
+	write("main/go.sum", "example.com/a v1.0.0/go.mod h1:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=\n")
+
+	options := source.DefaultOptions().Clone()
+	// The checksum database is left at its default, and the go command may
+	// not use the network.
+	options.Env = map[string]string{
+		"GOPACKAGESDRIVER": "off",
+		"GOMODCACHE":       filepath.Join(dir, "modcache"),
+		"GOPROXY":          "off",
+		"GOSUMDB":          "sum.golang.org",
+		"GOFLAGS":          "",
+		"GONOSUMDB":        "",
+		"GOPRIVATE":        "",
+	}
+	session := New(ctx, nil).NewSession(ctx)
+	view, snapshot, release, err := session.NewView(ctx, "modgraph", span.URIFromPath(filepath.Join(dir, "main")), "", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer view.Shutdown(ctx)
+	defer release()
+	fh, err := snapshot.GetFile(ctx, span.URIFromPath(filepath.Join(dir, "main", "go.mod")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := snapshot.ModGraph(ctx, fh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	main := module.Version{Path: "example.com/main"}
+	a := module.Version{Path: "example.com/a", Version: "v1.0.0"}
+	wantRequirers := map[module.Version][]module.Version{
+		a: {main},
+		{Path: "example.com/b", Version: "v1.0.0"}: {a},
+		{Path: "example.com/b", Version: "v1.1.0"}: {main},
+	}
+	if !reflect.DeepEqual(g.Requirers, wantRequirers) {
+		t.Errorf("Requirers = %v, want %v", g.Requirers, wantRequirers)
+	}
+	wantSelected := map[string]string{"example.com/a": "v1.0.0", "example.com/b": "v1.1.0"}
+	if !reflect.DeepEqual(g.Selected, wantSelected) {
+		t.Errorf("Selected = %v, want %v", g.Selected, wantSelected)
+	}
+}
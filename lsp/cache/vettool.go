@@ -0,0 +1,446 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/tools/go/analysis"
+	"github.com/kevinswiber/languageserver-go/event"
+	"github.com/kevinswiber/languageserver-go/gocommand"
+	"github.com/kevinswiber/languageserver-go/lsp/debug/tag"
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+	"github.com/kevinswiber/languageserver-go/memoize"
+	"github.com/kevinswiber/languageserver-go/span"
+	errors "golang.org/x/xerrors"
+)
+
+// A vetTool is an external analysis tool that speaks the protocol of
+// go vet -vettool, as implemented by unitchecker.
+type vetTool struct {
+	path    string
+	modTime time.Time
+
+	// id is the output of -V=full, which identifies the build of the tool.
+	id string
+
+	// analyzers holds an Analyzer for each analyzer of the tool, as listed
+	// by -flags. The analysis.Analyzers have no Run function: they only
+	// name the tool's analyzers, so that they can be configured like
+	// gopls's own.
+	analyzers []*source.Analyzer
+}
+
+// vetTool returns the description of the tool at path, querying the tool
+// if it has not been queried since it was last modified.
+func (c *Cache) vetTool(ctx context.Context, path string) (*vetTool, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c.vetToolMu.Lock()
+	defer c.vetToolMu.Unlock()
+
+	if tool, ok := c.vetTools[path]; ok && tool.modTime.Equal(fi.ModTime()) {
+		return tool, nil
+	}
+	tool := &vetTool{path: path, modTime: fi.ModTime()}
+	version, err := runVetToolCommand(ctx, "", path, "-V=full")
+	if err != nil {
+		return nil, err
+	}
+	tool.id = strings.TrimSpace(string(version))
+
+	out, err := runVetToolCommand(ctx, "", path, "-flags")
+	if err != nil {
+		return nil, err
+	}
+	tool.analyzers, err = vetToolAnalyzers(path, out)
+	if err != nil {
+		return nil, err
+	}
+	if c.vetTools == nil {
+		c.vetTools = make(map[string]*vetTool)
+	}
+	c.vetTools[path] = tool
+	return tool, nil
+}
+
+// vetToolAnalyzers returns the analyzers of the tool at path, given the
+// output of its -flags option.
+func vetToolAnalyzers(path string, out []byte) ([]*source.Analyzer, error) {
+	var flags []struct {
+		Name  string
+		Bool  bool
+		Usage string
+	}
+	if err := json.Unmarshal(out, &flags); err != nil {
+		return nil, errors.Errorf("%s: decoding -flags output: %w", path, err)
+	}
+	var analyzers []*source.Analyzer
+	for _, f := range flags {
+		// Each analyzer has a boolean flag that enables it. The other
+		// flags are either flags of the tool or of one of the analyzers,
+		// such as -printf.funcs.
+		if !f.Bool || strings.Contains(f.Name, ".") || !strings.HasPrefix(f.Usage, "enable ") || !strings.HasSuffix(f.Usage, " analysis") {
+			continue
+		}
+		analyzers = append(analyzers, &source.Analyzer{
+			Analyzer: &analysis.Analyzer{
+				Name: f.Name,
+				Doc:  fmt.Sprintf("%s analyzer of %s", f.Name, filepath.Base(path)),
+			},
+			Enabled: true,
+		})
+	}
+	return analyzers, nil
+}
+
+func runVetToolCommand(ctx context.Context, dir, path string, args ...string) ([]byte, error) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Dir = dir
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Errorf("%s %s: %v: %s", path, strings.Join(args, " "), err, stderr)
+	}
+	return stdout.Bytes(), nil
+}
+
+type vetToolKey string
+
+type vetToolData struct {
+	diagnostics []*source.Diagnostic
+	err         error
+}
+
+// vetToolDiagnostics runs the tool, with the given analyzers disabled, on
+// the package. The results are cached by the package's key, so the tool
+// runs again only when the package or its dependencies change.
+func (s *snapshot) vetToolDiagnostics(ctx context.Context, id packageID, tool *vetTool, disabled []string) ([]*source.Diagnostic, error) {
+	ph, err := s.buildPackageHandle(ctx, id, source.ParseFull)
+	if err != nil {
+		return nil, err
+	}
+	key := vetToolKey(hashContents([]byte(fmt.Sprintf("vettool %s %s %s %v", ph.key, tool.path, tool.id, disabled))))
+	h := s.generation.Bind(key, func(ctx context.Context, arg memoize.Arg) interface{} {
+		snapshot := arg.(*snapshot)
+		pkg, err := ph.check(ctx, snapshot)
+		if err != nil {
+			return &vetToolData{err: err}
+		}
+		diagnostics, err := runVetTool(ctx, snapshot, pkg, tool, disabled)
+		return &vetToolData{diagnostics: diagnostics, err: err}
+	}, nil)
+	v, err := h.Get(ctx, s.generation, s)
+	if err != nil {
+		return nil, err
+	}
+	data, ok := v.(*vetToolData)
+	if !ok {
+		return nil, errors.Errorf("unexpected type for vet tool results of %s", id)
+	}
+	return data.diagnostics, data.err
+}
+
+// vetConfig is the configuration file that go vet passes to a vet tool. It
+// mirrors unitchecker.Config.
+type vetConfig struct {
+	ID                        string
+	Compiler                  string
+	Dir                       string
+	ImportPath                string
+	GoFiles                   []string
+	NonGoFiles                []string
+	IgnoredFiles              []string
+	ImportMap                 map[string]string
+	PackageFile               map[string]string
+	Standard                  map[string]bool
+	PackageVetx               map[string]string
+	VetxOnly                  bool
+	VetxOutput                string
+	SucceedOnTypecheckFailure bool
+}
+
+// vetDiagnostic is a diagnostic in the -json output of a vet tool.
+type vetDiagnostic struct {
+	Category       string `json:"category,omitempty"`
+	Posn           string `json:"posn"`
+	Message        string `json:"message"`
+	SuggestedFixes []struct {
+		Message string `json:"message"`
+		Edits   []struct {
+			Filename string `json:"filename"`
+			Start    int    `json:"start"`
+			End      int    `json:"end"`
+			New      string `json:"new"`
+		} `json:"edits"`
+	} `json:"suggested_fixes,omitempty"`
+	Related []struct {
+		Posn    string `json:"posn"`
+		Message string `json:"message"`
+	} `json:"related,omitempty"`
+}
+
+// runVetTool runs the tool on the package the way go vet does, using the
+// export data that go list -export produces for the dependencies. Files
+// with unsaved changes are passed to the tool as temporary copies.
+//
+// Test variants are not analyzed, and the tool sees no facts about the
+// dependencies, as it would only if it were run on them too.
+func runVetTool(ctx context.Context, snapshot *snapshot, pkg *pkg, tool *vetTool, disabled []string) ([]*source.Diagnostic, error) {
+	if pkg.m.forTest != "" || len(pkg.compiledGoFiles) == 0 {
+		return nil, nil
+	}
+	tmpdir, err := ioutil.TempDir("", "gopls-vettool-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpdir)
+
+	dir := filepath.Dir(pkg.compiledGoFiles[0].URI.Filename())
+	cfg := &vetConfig{
+		ID:                        string(pkg.m.pkgPath),
+		Compiler:                  "gc",
+		Dir:                       dir,
+		ImportPath:                string(pkg.m.pkgPath),
+		ImportMap:                 make(map[string]string),
+		PackageFile:               make(map[string]string),
+		Standard:                  make(map[string]bool),
+		VetxOutput:                filepath.Join(tmpdir, "vet.out"),
+		SucceedOnTypecheckFailure: true,
+	}
+	copies := make(map[string]span.URI)
+	for i, pgf := range pkg.compiledGoFiles {
+		fh, err := snapshot.GetVersionedFile(ctx, pgf.URI)
+		if err != nil {
+			return nil, err
+		}
+		filename := pgf.URI.Filename()
+		if !fh.Saved() {
+			content, err := fh.Read()
+			if err != nil {
+				return nil, err
+			}
+			filename = filepath.Join(tmpdir, strconv.Itoa(i)+"_"+filepath.Base(filename))
+			if err := ioutil.WriteFile(filename, content, 0644); err != nil {
+				return nil, err
+			}
+			copies[filename] = pgf.URI
+		}
+		cfg.GoFiles = append(cfg.GoFiles, filename)
+	}
+
+	stdout, err := snapshot.RunGoCommandDirect(ctx, source.Normal, &gocommand.Invocation{
+		Verb:       "list",
+		Args:       []string{"-e", "-export", "-deps", "-json", "--", string(pkg.m.pkgPath)},
+		WorkingDir: dir,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var importMap map[string]string
+	for dec := json.NewDecoder(stdout); ; {
+		var listed struct {
+			ImportPath string
+			Export     string
+			Standard   bool
+			ImportMap  map[string]string
+		}
+		if err := dec.Decode(&listed); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, errors.Errorf("decoding go list output: %w", err)
+		}
+		if listed.ImportPath == string(pkg.m.pkgPath) {
+			importMap = listed.ImportMap
+		}
+		if listed.Export != "" {
+			cfg.PackageFile[listed.ImportPath] = listed.Export
+		}
+		cfg.Standard[listed.ImportPath] = listed.Standard
+	}
+	for _, pgf := range pkg.compiledGoFiles {
+		for _, imp := range pgf.File.Imports {
+			path, err := strconv.Unquote(imp.Path.Value)
+			if err != nil {
+				continue
+			}
+			cfg.ImportMap[path] = path
+			if resolved, ok := importMap[path]; ok {
+				cfg.ImportMap[path] = resolved
+			}
+		}
+	}
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	cfgFile := filepath.Join(tmpdir, "vet.cfg")
+	if err := ioutil.WriteFile(cfgFile, data, 0644); err != nil {
+		return nil, err
+	}
+	args := []string{"-json"}
+	for _, name := range disabled {
+		args = append(args, "-"+name+"=false")
+	}
+	out, err := runVetToolCommand(ctx, dir, tool.path, append(args, cfgFile)...)
+	if err != nil {
+		return nil, err
+	}
+	return vetToolDiagnostics(ctx, snapshot.FileSet(), pkg, tool, dir, copies, out)
+}
+
+// vetToolDiagnostics decodes the -json output of the tool. Positions are
+// relative to dir, and the positions in the temporary copies of unsaved
+// files are mapped back to the files' URIs.
+func vetToolDiagnostics(ctx context.Context, fset *token.FileSet, pkg *pkg, tool *vetTool, dir string, copies map[string]span.URI, out []byte) ([]*source.Diagnostic, error) {
+	// The output maps the package ID to the results of each analyzer,
+	// which are either a list of diagnostics or an error.
+	var tree map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(out, &tree); err != nil {
+		return nil, errors.Errorf("%s: decoding -json output: %w", tool.path, err)
+	}
+	analyzers := make(map[string]*source.Analyzer)
+	for _, a := range tool.analyzers {
+		analyzers[a.Analyzer.Name] = a
+	}
+	toSpan := func(posn string) span.Span {
+		spn := span.ParseInDir(posn, dir)
+		if uri, ok := copies[spn.URI().Filename()]; ok {
+			spn = span.New(uri, spn.Start(), spn.End())
+		}
+		return spn
+	}
+	var diagnostics []*source.Diagnostic
+	for _, results := range tree {
+		var names []string
+		for name := range results {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			analyzer, ok := analyzers[name]
+			if !ok {
+				continue
+			}
+			var diags []vetDiagnostic
+			if err := json.Unmarshal(results[name], &diags); err != nil {
+				var failure struct {
+					Err string `json:"error"`
+				}
+				if err := json.Unmarshal(results[name], &failure); err == nil && failure.Err != "" {
+					event.Error(ctx, "vet tool analyzer failed", errors.New(failure.Err), tag.Package.Of(pkg.ID()))
+				}
+				continue
+			}
+			for _, d := range diags {
+				diag, err := vetToolDiagnostic(fset, pkg, toSpan, analyzer, d)
+				if err != nil {
+					// The diagnostic is not in a file of the package.
+					continue
+				}
+				diagnostics = append(diagnostics, diag)
+			}
+		}
+	}
+	return diagnostics, nil
+}
+
+// vetToolDiagnostic converts a diagnostic of the tool's analyzer to a
+// source.Diagnostic, using toSpan to map the tool's positions back to the
+// files of the package.
+func vetToolDiagnostic(fset *token.FileSet, pkg *pkg, toSpan func(string) span.Span, analyzer *source.Analyzer, d vetDiagnostic) (*source.Diagnostic, error) {
+	spn := toSpan(d.Posn)
+	rng, err := vetToolRange(pkg, spn)
+	if err != nil {
+		return nil, err
+	}
+	category := analyzer.Analyzer.Name
+	if d.Category != "" {
+		category += "." + d.Category
+	}
+	var fixes []source.SuggestedFix
+	for _, fix := range d.SuggestedFixes {
+		edits := make(map[span.URI][]protocol.TextEdit)
+		for _, e := range fix.Edits {
+			uri := toSpan(e.Filename).URI()
+			pgf, err := pkg.File(uri)
+			if err != nil {
+				return nil, err
+			}
+			if e.Start < 0 || e.End < e.Start || e.End > pgf.Tok.Size() {
+				return nil, errors.Errorf("invalid edit offsets %d-%d in %s", e.Start, e.End, uri)
+			}
+			rng, err := source.NewMappedRange(fset, pgf.Mapper, pgf.Tok.Pos(e.Start), pgf.Tok.Pos(e.End)).Range()
+			if err != nil {
+				return nil, err
+			}
+			edits[uri] = append(edits[uri], protocol.TextEdit{
+				Range:   rng,
+				NewText: e.New,
+			})
+		}
+		fixes = append(fixes, source.SuggestedFix{
+			Title: fix.Message,
+			Edits: edits,
+		})
+	}
+	var related []source.RelatedInformation
+	for _, r := range d.Related {
+		spn := toSpan(r.Posn)
+		rng, err := vetToolRange(pkg, spn)
+		if err != nil {
+			continue
+		}
+		related = append(related, source.RelatedInformation{
+			URI:     spn.URI(),
+			Range:   rng,
+			Message: r.Message,
+		})
+	}
+	diag := &source.Diagnostic{
+		URI:            spn.URI(),
+		Range:          rng,
+		Severity:       protocol.SeverityWarning,
+		Source:         source.AnalyzerErrorKind(category),
+		Message:        d.Message,
+		Related:        related,
+		SuggestedFixes: fixes,
+		Analyzer:       analyzer,
+	}
+	// As for gopls's own analyzers, if the fixes only delete code, assume
+	// that the diagnostic is reporting dead code.
+	if onlyDeletions(fixes) {
+		diag.Tags = []protocol.DiagnosticTag{protocol.Unnecessary}
+	}
+	return diag, nil
+}
+
+// vetToolRange converts a span of one of the package's files to a
+// protocol.Range.
+func vetToolRange(pkg *pkg, spn span.Span) (protocol.Range, error) {
+	pgf, err := pkg.File(spn.URI())
+	if err != nil {
+		return protocol.Range{}, err
+	}
+	return pgf.Mapper.Range(spn)
+}
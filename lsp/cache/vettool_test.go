@@ -0,0 +1,185 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+	"github.com/kevinswiber/languageserver-go/span"
+)
+
+func TestVetToolAnalyzers(t *testing.T) {
+	out := []byte(`[
+	{"Name": "V", "Bool": false, "Usage": "print version and exit"},
+	{"Name": "flags", "Bool": true, "Usage": "print analyzer flags in JSON"},
+	{"Name": "json", "Bool": true, "Usage": "emit JSON output"},
+	{"Name": "printf", "Bool": true, "Usage": "enable printf analysis"},
+	{"Name": "printf.funcs", "Bool": false, "Usage": "comma-separated list of print function names to check"},
+	{"Name": "shadow", "Bool": true, "Usage": "enable shadow analysis"},
+	{"Name": "shadow.strict", "Bool": true, "Usage": "enable strict shadow analysis"}
+]`)
+	analyzers, err := vetToolAnalyzers("/bin/mytool", out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, a := range analyzers {
+		if !a.Enabled {
+			t.Errorf("analyzer %s is disabled", a.Analyzer.Name)
+		}
+		if a.Analyzer.Run != nil {
+			t.Errorf("analyzer %s has a Run function", a.Analyzer.Name)
+		}
+		got = append(got, a.Analyzer.Name)
+	}
+	if want := []string{"printf", "shadow"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("vetToolAnalyzers = %v, want %v", got, want)
+	}
+	if want := "shadow analyzer of mytool"; analyzers[1].Analyzer.Doc != want {
+		t.Errorf("shadow Doc = %q, want %q", analyzers[1].Analyzer.Doc, want)
+	}
+
+	if _, err := vetToolAnalyzers("/bin/mytool", []byte("usage: mytool")); err == nil {
+		t.Errorf("vetToolAnalyzers succeeded on malformed output")
+	}
+}
+
+func TestVetToolDiagnostics(t *testing.T) {
+	dir := filepath.FromSlash("/home/user/pkg")
+	saved := span.URIFromPath(filepath.Join(dir, "a.go"))
+	unsaved := span.URIFromPath(filepath.Join(dir, "b.go"))
+	copyName := filepath.FromSlash("/tmp/gopls-vettool-1/1_b.go")
+
+	fset := token.NewFileSet()
+	p := &pkg{m: &metadata{id: "example.com/pkg"}}
+	for _, f := range []struct {
+		uri span.URI
+		src string
+	}{
+		{saved, "package pkg\n\nfunc A() {}\n"},
+		{unsaved, "package pkg\n\nimport \"fmt\"\n\nfunc B() { fmt.Printf(\"%d\") }\n"},
+	} {
+		src := []byte(f.src)
+		file, err := parser.ParseFile(fset, f.uri.Filename(), src, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tok := fset.File(file.Pos())
+		p.compiledGoFiles = append(p.compiledGoFiles, &source.ParsedGoFile{
+			URI:  f.uri,
+			File: file,
+			Tok:  tok,
+			Src:  src,
+			Mapper: &protocol.ColumnMapper{
+				URI:       f.uri,
+				Converter: span.NewTokenConverter(fset, tok),
+				Content:   src,
+			},
+		})
+	}
+	tool := &vetTool{path: "/bin/mytool"}
+	tool.analyzers, _ = vetToolAnalyzers(tool.path, []byte(`[
+	{"Name": "printf", "Bool": true, "Usage": "enable printf analysis"},
+	{"Name": "unusedfunc", "Bool": true, "Usage": "enable unusedfunc analysis"}
+]`))
+
+	// The positions of the saved file are relative to the package
+	// directory, and those of the unsaved file are in its copy.
+	out := []byte(`{
+	"example.com/pkg": {
+		"printf": [{
+			"posn": "` + filepath.ToSlash(copyName) + `:5:12",
+			"message": "fmt.Printf format %d reads arg #1, but call has 0 args",
+			"related": [{"posn": "a.go:3:6", "message": "see A"}, {"posn": "/elsewhere/c.go:1:1", "message": "dropped"}]
+		}],
+		"unusedfunc": [{
+			"posn": "a.go:3:6",
+			"category": "func",
+			"message": "A is unused",
+			"suggested_fixes": [{
+				"message": "Remove A",
+				"edits": [{"filename": "a.go", "start": 13, "end": 24, "new": ""}]
+			}]
+		}, {
+			"posn": "/elsewhere/c.go:1:1",
+			"message": "not in the package"
+		}],
+		"unknown": [{"posn": "a.go:1:1", "message": "from an analyzer the tool did not list"}],
+		"failing": {"error": "analysis failed"}
+	}
+}`)
+	copies := map[string]span.URI{copyName: unsaved}
+	diagnostics, err := vetToolDiagnostics(context.Background(), fset, p, tool, dir, copies, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diagnostics) != 2 {
+		t.Fatalf("vetToolDiagnostics returned %d diagnostics, want 2: %v", len(diagnostics), diagnostics)
+	}
+
+	printf := diagnostics[0]
+	if printf.URI != unsaved {
+		t.Errorf("printf diagnostic URI = %s, want %s", printf.URI, unsaved)
+	}
+	if want := (protocol.Position{Line: 4, Character: 11}); printf.Range.Start != want {
+		t.Errorf("printf diagnostic starts at %v, want %v", printf.Range.Start, want)
+	}
+	if printf.Source != "printf" || printf.Analyzer != tool.analyzers[0] {
+		t.Errorf("printf diagnostic has source %q and analyzer %v", printf.Source, printf.Analyzer)
+	}
+	if len(printf.Related) != 1 || printf.Related[0].URI != saved || printf.Related[0].Range.Start != (protocol.Position{Line: 2, Character: 5}) {
+		t.Errorf("printf related information = %v, want one item at %s:3:6", printf.Related, saved)
+	}
+
+	unused := diagnostics[1]
+	if unused.URI != saved || unused.Source != "unusedfunc.func" {
+		t.Errorf("unusedfunc diagnostic is in %s from %q", unused.URI, unused.Source)
+	}
+	if len(unused.SuggestedFixes) != 1 {
+		t.Fatalf("unusedfunc diagnostic has %d fixes, want 1", len(unused.SuggestedFixes))
+	}
+	wantEdits := map[span.URI][]protocol.TextEdit{
+		saved: {{Range: protocol.Range{
+			Start: protocol.Position{Line: 2, Character: 0},
+			End:   protocol.Position{Line: 2, Character: 11},
+		}}},
+	}
+	if got := unused.SuggestedFixes[0].Edits; !reflect.DeepEqual(got, wantEdits) {
+		t.Errorf("unusedfunc fix edits = %v, want %v", got, wantEdits)
+	}
+	if !reflect.DeepEqual(unused.Tags, []protocol.DiagnosticTag{protocol.Unnecessary}) {
+		t.Errorf("unusedfunc diagnostic tags = %v, want Unnecessary", unused.Tags)
+	}
+
+	// Edits in the copy of an unsaved file apply to the file itself.
+	out = []byte(`{"example.com/pkg": {"printf": [{
+		"posn": "` + filepath.ToSlash(copyName) + `:5:12",
+		"message": "bad format",
+		"suggested_fixes": [{"message": "Fix", "edits": [{"filename": "` + filepath.ToSlash(copyName) + `", "start": 50, "end": 52, "new": "%v"}]}]
+	}]}}`)
+	diagnostics, err = vetToolDiagnostics(context.Background(), fset, p, tool, dir, copies, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diagnostics) != 1 || len(diagnostics[0].SuggestedFixes) != 1 {
+		t.Fatalf("vetToolDiagnostics = %v, want one diagnostic with a fix", diagnostics)
+	}
+	if edits := diagnostics[0].SuggestedFixes[0].Edits; len(edits[unsaved]) != 1 || len(edits) != 1 {
+		t.Errorf("fix edits = %v, want one edit of %s", edits, unsaved)
+	} else if e := edits[unsaved][0]; e.Range.Start != (protocol.Position{Line: 4, Character: 23}) || e.NewText != "%v" {
+		t.Errorf("fix edit = %v, want %%v at 5:24", e)
+	}
+
+	if _, err := vetToolDiagnostics(context.Background(), fset, p, tool, dir, copies, []byte("panic: oops")); err == nil {
+		t.Errorf("vetToolDiagnostics succeeded on malformed output")
+	}
+}
@@ -97,6 +97,31 @@ type View struct {
 	// tempWorkspace is a temporary directory dedicated to holding the latest
 	// version of the workspace go.mod file. (TODO: also go.sum file)
 	tempWorkspace span.URI
+
+	// configViews are the views of the configurations of the BuildMatrix
+	// option. They are not part of the session's views, but receive the
+	// same file changes.
+	configViews []*View
+
+	// buildConfig is the configuration of a view in the configViews or the
+	// excludedViews of another view.
+	buildConfig *source.BuildConfig
+
+	// excludedMu protects excludedViews, excludedFiles, and excludedHashes.
+	excludedMu sync.Mutex
+
+	// excludedViews are the views of the configurations that include the
+	// files that the view's build constraints exclude, by configuration.
+	// Like the configViews, they receive the same file changes.
+	excludedViews map[string]*View
+
+	// excludedFiles maps the excluded files to their views.
+	excludedFiles map[span.URI]*View
+
+	// excludedHashes maps the files whose views were picked by
+	// ExcludedFileView, including those with no view, to the hashes of
+	// their contents at the time.
+	excludedHashes map[span.URI]string
 }
 
 type workspaceInformation struct {
@@ -132,6 +157,9 @@ const (
 
 type environmentVariables struct {
 	gocache, gopath, goroot, goprivate, gomodcache, go111module string
+
+	// goos and goarch are the target of the build.
+	goos, goarch string
 }
 
 type workspaceMode int
@@ -254,6 +282,9 @@ func minorOptionsChange(a, b *source.Options) bool {
 	if !reflect.DeepEqual(a.DirectoryFilters, b.DirectoryFilters) {
 		return false
 	}
+	if !reflect.DeepEqual(a.BuildMatrix, b.BuildMatrix) {
+		return false
+	}
 	aBuildFlags := make([]string, len(a.BuildFlags))
 	bBuildFlags := make([]string, len(b.BuildFlags))
 	copy(aBuildFlags, a.BuildFlags)
@@ -270,6 +301,11 @@ func (v *View) SetOptions(ctx context.Context, options *source.Options) (source.
 	if minorOptionsChange(v.options, options) {
 		v.options = options
 		v.optionsMu.Unlock()
+		for _, cv := range v.derivedViews() {
+			cv.optionsMu.Lock()
+			cv.options = options.ForBuildConfig(*cv.buildConfig)
+			cv.optionsMu.Unlock()
+		}
 		return v, nil
 	}
 	v.optionsMu.Unlock()
@@ -364,11 +400,12 @@ func (v *View) relevantChange(c source.FileModification) bool {
 	if v.knownFile(c.URI) {
 		return true
 	}
-	// The gopls.mod may not be "known" because we first access it through the
-	// session. As a result, treat changes to the view's gopls.mod file as
-	// always relevant, even if they are only on-disk changes.
+	// The gopls.mod and go.work files may not be "known" because we first
+	// access them through the session. As a result, treat changes to the
+	// view's gopls.mod and go.work files as always relevant, even if they
+	// are only on-disk changes.
 	// TODO(rstambler): Make sure the gopls.mod is always known to the view.
-	if c.URI == goplsModURI(v.rootURI) {
+	if c.URI == goplsModURI(v.rootURI) || c.URI == goWorkURI(v.rootURI) {
 		return true
 	}
 	// If the file is not known to the view, and the change is only on-disk,
@@ -460,6 +497,9 @@ func (v *View) shutdown(ctx context.Context) {
 	go v.snapshot.generation.Destroy()
 	v.snapshotMu.Unlock()
 	v.importsState.destroy()
+	for _, cv := range v.derivedViews() {
+		cv.shutdown(ctx)
+	}
 }
 
 func (v *View) Session() *Session {
@@ -735,9 +775,9 @@ func go111moduleForVersion(go111module string, goversion int) go111module {
 // TODO (rFindley): move this to workspace.go
 // TODO (rFindley): simplify this once workspace modules are enabled by default.
 func findWorkspaceRoot(ctx context.Context, folder span.URI, fs source.FileSource, excludePath func(string) bool, experimental bool) (span.URI, error) {
-	patterns := []string{"go.mod"}
+	patterns := []string{"go.work", "go.mod"}
 	if experimental {
-		patterns = []string{"gopls.mod", "go.mod"}
+		patterns = []string{"go.work", "gopls.mod", "go.mod"}
 	}
 	for _, basename := range patterns {
 		dir, err := findRootPattern(ctx, folder, basename, fs)
@@ -832,6 +872,8 @@ func (s *Session) getGoEnv(ctx context.Context, folder string, goversion int, go
 		"GOPRIVATE":   &envVars.goprivate,
 		"GOMODCACHE":  &envVars.gomodcache,
 		"GO111MODULE": &envVars.go111module,
+		"GOOS":        &envVars.goos,
+		"GOARCH":      &envVars.goarch,
 	}
 
 	// We can save ~200 ms by requesting only the variables we care about.
@@ -876,6 +918,10 @@ func (v *View) IsGoPrivatePath(target string) bool {
 	return globsMatchPath(v.goprivate, target)
 }
 
+func (v *View) GoModCache() string {
+	return v.gomodcache
+}
+
 func (v *View) ModuleUpgrades() map[string]string {
 	v.mu.Lock()
 	defer v.mu.Unlock()
@@ -887,6 +933,164 @@ func (v *View) ModuleUpgrades() map[string]string {
 	return upgrades
 }
 
+func (v *View) BuildMatrixViews() []source.View {
+	views := make([]source.View, len(v.configViews))
+	for i, cv := range v.configViews {
+		views[i] = cv
+	}
+	return views
+}
+
+func (v *View) BuildConfig() *source.BuildConfig {
+	return v.buildConfig
+}
+
+func (v *View) ExcludedFileView(ctx context.Context, fh source.FileHandle) (source.View, error) {
+	if v.buildConfig != nil {
+		return nil, nil
+	}
+	uri, hash := fh.URI(), fh.FileIdentity().Hash
+
+	// The view of the file only changes with its content.
+	v.excludedMu.Lock()
+	prev, picked := v.excludedFiles[uri], v.excludedHashes[uri] == hash
+	v.excludedMu.Unlock()
+	if picked {
+		if prev == nil {
+			return nil, nil
+		}
+		return prev, nil
+	}
+
+	content, err := fh.Read()
+	if err != nil {
+		return nil, err
+	}
+	config, ok := source.BuildConfigForFile(uri.Filename(), content, v.goos, v.goarch)
+
+	v.excludedMu.Lock()
+	if v.excludedViews == nil {
+		v.excludedViews = make(map[string]*View)
+		v.excludedFiles = make(map[span.URI]*View)
+		v.excludedHashes = make(map[span.URI]string)
+	}
+	var ev *View
+	var unused []*View
+	if ok {
+		key := config.String()
+		if ev = v.excludedViews[key]; ev == nil {
+			// The view is created without holding excludedMu, so that the
+			// views of other files are not held up meanwhile.
+			v.excludedMu.Unlock()
+			created, err := v.newExcludedView(ctx, config)
+			if err != nil {
+				return nil, err
+			}
+			v.excludedMu.Lock()
+			if ev = v.excludedViews[key]; ev != nil {
+				// Another file's view of the configuration was added
+				// meanwhile.
+				unused = append(unused, created)
+			} else {
+				ev = created
+				v.excludedViews[key] = ev
+			}
+		}
+	}
+	prev = v.excludedFiles[uri]
+	if ev != nil {
+		v.excludedFiles[uri] = ev
+	} else {
+		delete(v.excludedFiles, uri)
+	}
+	v.excludedHashes[uri] = hash
+	// The file's previous view may no longer be needed.
+	unused = append(unused, v.unusedExcludedViewsLocked()...)
+	v.excludedMu.Unlock()
+
+	for _, uv := range unused {
+		uv.shutdown(ctx)
+	}
+	if ev != prev {
+		// The session must pick the view again for requests about the file.
+		v.session.forgetViewOf(uri)
+	}
+	if ev == nil {
+		return nil, nil
+	}
+	return ev, nil
+}
+
+// forgetExcludedFile forgets the view of the file, which is no longer
+// open, and shuts the view down if no other excluded file belongs to it.
+func (v *View) forgetExcludedFile(ctx context.Context, uri span.URI) {
+	v.excludedMu.Lock()
+	_, ok := v.excludedFiles[uri]
+	delete(v.excludedFiles, uri)
+	delete(v.excludedHashes, uri)
+	unused := v.unusedExcludedViewsLocked()
+	v.excludedMu.Unlock()
+
+	for _, uv := range unused {
+		uv.shutdown(ctx)
+	}
+	if ok {
+		v.session.forgetViewOf(uri)
+	}
+}
+
+// unusedExcludedViewsLocked removes the excluded views that no excluded
+// file belongs to, and returns them to be shut down. v.excludedMu must be
+// held.
+func (v *View) unusedExcludedViewsLocked() []*View {
+	used := make(map[*View]bool)
+	for _, ev := range v.excludedFiles {
+		used[ev] = true
+	}
+	var unused []*View
+	for key, ev := range v.excludedViews {
+		if !used[ev] {
+			delete(v.excludedViews, key)
+			unused = append(unused, ev)
+		}
+	}
+	return unused
+}
+
+// newExcludedView creates a view of the configuration, which the caller
+// adds to the excludedViews.
+func (v *View) newExcludedView(ctx context.Context, config source.BuildConfig) (*View, error) {
+	ev, _, release, err := v.session.createView(ctx, fmt.Sprintf("%s [%s]", v.name, config), v.folder, "", v.Options().ForBuildConfig(config), 0)
+	release()
+	if err != nil {
+		return nil, err
+	}
+	ev.buildConfig = &config
+	return ev, nil
+}
+
+// excludedFileView returns the view of the excluded file, if any.
+func (v *View) excludedFileView(uri span.URI) *View {
+	v.excludedMu.Lock()
+	defer v.excludedMu.Unlock()
+
+	return v.excludedFiles[uri]
+}
+
+// derivedViews returns the views of the build matrix and of the excluded
+// files in a new slice, which the caller may modify.
+func (v *View) derivedViews() []*View {
+	v.excludedMu.Lock()
+	defer v.excludedMu.Unlock()
+
+	views := make([]*View, 0, len(v.configViews)+len(v.excludedViews))
+	views = append(views, v.configViews...)
+	for _, ev := range v.excludedViews {
+		views = append(views, ev)
+	}
+	return views
+}
+
 func (v *View) RegisterModuleUpgrades(upgrades map[string]string) {
 	v.mu.Lock()
 	defer v.mu.Unlock()
@@ -8,6 +8,8 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/kevinswiber/languageserver-go/lsp/fake"
@@ -172,3 +174,140 @@ func TestFilters(t *testing.T) {
 		}
 	}
 }
+
+func TestExcludedFileViews(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "excluded")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, content := range map[string]string{
+		"go.mod": "module example.com/m\n\ngo 1.16\n",
+		"a.go":   "package m\n",
+		"b.go":   "// +build foo\n\npackage m\n",
+		"c.go":   "// +build foo\n\npackage m\n",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	options := source.DefaultOptions().Clone()
+	options.Env = map[string]string{"GOPACKAGESDRIVER": "off"}
+	session := New(ctx, nil).NewSession(ctx)
+	sv, _, release, err := session.NewView(ctx, "excluded", span.URIFromPath(dir), "", options)
+	release()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sv.Shutdown(ctx)
+	v := sv.(*View)
+
+	b, c := span.URIFromPath(filepath.Join(dir, "b.go")), span.URIFromPath(filepath.Join(dir, "c.go"))
+	modify := func(uri span.URI, action source.FileAction, text string) {
+		t.Helper()
+		_, releases, err := session.DidModifyFiles(ctx, []source.FileModification{{
+			URI:        uri,
+			Action:     action,
+			Version:    1,
+			Text:       []byte(text),
+			LanguageID: "go",
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, release := range releases {
+			release()
+		}
+	}
+	excludedFileView := func(uri span.URI) source.View {
+		t.Helper()
+		snapshot, release := v.getSnapshot(ctx)
+		defer release()
+		fh, err := snapshot.GetFile(ctx, uri)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ev, err := v.ExcludedFileView(ctx, fh)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ev
+	}
+	configs := func() []string {
+		var keys []string
+		for key := range v.excludedViews {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		return keys
+	}
+
+	modify(b, source.Open, "// +build foo\n\npackage m\n")
+	modify(c, source.Open, "// +build foo\n\npackage m\n")
+	bv, cv := excludedFileView(b), excludedFileView(c)
+	if bv == nil || bv != cv {
+		t.Fatalf("excluded files with the same constraints have views %v and %v, want the same view", bv, cv)
+	}
+
+	// The view of a file is not picked again while its content is the same.
+	if _, err := session.viewOf(b); err != nil {
+		t.Fatal(err)
+	}
+	snapshot, release := v.getSnapshot(ctx)
+	fh, err := snapshot.GetFile(ctx, b)
+	release()
+	if err != nil {
+		t.Fatal(err)
+	}
+	counter := &readCounter{FileHandle: fh}
+	if ev, err := v.ExcludedFileView(ctx, counter); err != nil || ev != bv {
+		t.Errorf("ExcludedFileView of the unchanged file = %v, %v, want %v", ev, err, bv)
+	}
+	if counter.reads != 0 {
+		t.Errorf("ExcludedFileView read the unchanged file %d times", counter.reads)
+	}
+	session.viewMu.Lock()
+	_, ok := session.viewMap[b]
+	session.viewMu.Unlock()
+	if !ok {
+		t.Errorf("ExcludedFileView of the unchanged file made the session forget its view")
+	}
+
+	// A view is kept while an open file belongs to it.
+	modify(b, source.Close, "")
+	if got := configs(); len(got) != 1 {
+		t.Errorf("excluded views after closing b.go = %v, want 1", got)
+	}
+	modify(c, source.Close, "")
+	if got := configs(); len(got) != 0 {
+		t.Errorf("excluded views after closing every file = %v, want none", got)
+	}
+
+	// A view is released when the constraints of its file change.
+	modify(b, source.Open, "// +build foo\n\npackage m\n")
+	excludedFileView(b)
+	modify(b, source.Change, "// +build bar\n\npackage m\n")
+	excludedFileView(b)
+	if got := configs(); len(got) != 1 || !strings.Contains(got[0], "bar") {
+		t.Errorf("excluded views after changing the constraints = %v, want only the bar view", got)
+	}
+	modify(b, source.Change, "package m\n")
+	if ev := excludedFileView(b); ev != nil {
+		t.Errorf("file without constraints has excluded view %v", ev)
+	}
+	if got := configs(); len(got) != 0 {
+		t.Errorf("excluded views of files without constraints = %v, want none", got)
+	}
+}
+
+// readCounter counts the reads of a file handle.
+type readCounter struct {
+	source.FileHandle
+	reads int
+}
+
+func (r *readCounter) Read() ([]byte, error) {
+	r.reads++
+	return r.FileHandle.Read()
+}
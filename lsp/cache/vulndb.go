@@ -0,0 +1,122 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+	errors "golang.org/x/xerrors"
+)
+
+// A vulnDB holds the reports of a vulnerability database directory.
+type vulnDB struct {
+	// stamps are the modification times and sizes of the directories and
+	// the JSON files of the database. Adding, removing, or replacing a
+	// report changes the time of its directory, and rewriting it in place
+	// changes its own, so the reports are reloaded only when one of the
+	// stamps changes.
+	stamps  map[string]fileStamp
+	entries []*source.OSVEntry
+}
+
+// A fileStamp identifies a version of a file or directory.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func stampOf(fi os.FileInfo) fileStamp {
+	return fileStamp{modTime: fi.ModTime(), size: fi.Size()}
+}
+
+func (s *snapshot) VulnerabilityDatabase(ctx context.Context) ([]*source.OSVEntry, error) {
+	dir := s.view.Options().VulnerabilityDatabase
+	if dir == "" {
+		return nil, nil
+	}
+	return s.view.session.cache.vulnerabilityDatabase(dir)
+}
+
+// vulnerabilityDatabase returns the reports of the database in dir,
+// loading them if the database has changed since it was last loaded.
+func (c *Cache) vulnerabilityDatabase(dir string) ([]*source.OSVEntry, error) {
+	c.vulnDBMu.Lock()
+	defer c.vulnDBMu.Unlock()
+
+	if db, ok := c.vulnDBs[dir]; ok && !db.changed() {
+		return db.entries, nil
+	}
+	db, err := loadVulnerabilityDatabase(dir)
+	if err != nil {
+		return nil, err
+	}
+	if c.vulnDBs == nil {
+		c.vulnDBs = make(map[string]*vulnDB)
+	}
+	c.vulnDBs[dir] = db
+	return db.entries, nil
+}
+
+// changed reports whether a directory or a report of the database was
+// modified, created, or removed since it was loaded.
+func (db *vulnDB) changed() bool {
+	for path, stamp := range db.stamps {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return true
+		}
+		if s := stampOf(fi); !s.modTime.Equal(stamp.modTime) || s.size != stamp.size {
+			return true
+		}
+	}
+	return false
+}
+
+// loadVulnerabilityDatabase loads the reports of the JSON files in the
+// directory and its subdirectories. Files that are not OSV reports, such
+// as the indexes of the Go vulnerability database, are ignored.
+func loadVulnerabilityDatabase(dir string) (*vulnDB, error) {
+	db := &vulnDB{stamps: make(map[string]fileStamp)}
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			db.stamps[path] = stampOf(info)
+			return nil
+		}
+		if filepath.Ext(path) == ".json" {
+			db.stamps[path] = stampOf(info)
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Errorf("reading vulnerability database: %w", err)
+	}
+	for _, path := range files {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Errorf("reading vulnerability database: %w", err)
+		}
+		var e source.OSVEntry
+		if err := json.Unmarshal(data, &e); err != nil || e.ID == "" || len(e.Affected) == 0 {
+			continue
+		}
+		db.entries = append(db.entries, &e)
+	}
+	sort.Slice(db.entries, func(i, j int) bool {
+		return db.entries[i].ID < db.entries[j].ID
+	})
+	return db, nil
+}
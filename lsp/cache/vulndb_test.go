@@ -0,0 +1,93 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestVulnerabilityDatabase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vulndb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name, content string) {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		// Make sure that the modification time of the directory changes,
+		// whatever the resolution of the file system.
+		later := time.Now().Add(time.Duration(len(content)) * time.Second)
+		if err := os.Chtimes(filepath.Dir(path), later, later); err != nil {
+			t.Fatal(err)
+		}
+	}
+	report := func(id string) string {
+		return `{"id": "` + id + `", "affected": [{"package": {"ecosystem": "Go", "name": "example.com/a"}}]}`
+	}
+	write("index.json", `{"example.com/a": "2021-01-01T00:00:00Z"}`)
+	write("ID/GO-2021-0002.json", report("GO-2021-0002"))
+	write("ID/GO-2021-0001.json", report("GO-2021-0001"))
+	write("ID/notes.txt", "not a report")
+	write("ID/empty.json", `{"id": "GO-2021-0003"}`)
+
+	c := &Cache{}
+	ids := func() []string {
+		entries, err := c.vulnerabilityDatabase(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, e := range entries {
+			ids = append(ids, e.ID)
+		}
+		return ids
+	}
+	if got, want := ids(), []string{"GO-2021-0001", "GO-2021-0002"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("vulnerabilityDatabase = %v, want %v", got, want)
+	}
+	first := c.vulnDBs[dir]
+	ids()
+	if c.vulnDBs[dir] != first {
+		t.Errorf("vulnerabilityDatabase reloaded an unchanged database")
+	}
+
+	write("ID/GO-2021-0004.json", report("GO-2021-0004"))
+	if got, want := ids(), []string{"GO-2021-0001", "GO-2021-0002", "GO-2021-0004"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("vulnerabilityDatabase after adding a report = %v, want %v", got, want)
+	}
+
+	// Rewriting a report in place does not change the time of its
+	// directory.
+	idDir := filepath.Join(dir, "ID")
+	fi, err := os.Stat(idDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(idDir, "GO-2021-0004.json")
+	if err := ioutil.WriteFile(path, []byte(report("GO-2021-0005")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(idDir, fi.ModTime(), fi.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ids(), []string{"GO-2021-0001", "GO-2021-0002", "GO-2021-0005"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("vulnerabilityDatabase after rewriting a report = %v, want %v", got, want)
+	}
+
+	if _, err := c.vulnerabilityDatabase(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("vulnerabilityDatabase succeeded on a missing directory")
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+	"github.com/kevinswiber/languageserver-go/event"
+	"github.com/kevinswiber/languageserver-go/lsp/debug/tag"
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+	"github.com/kevinswiber/languageserver-go/memoize"
+	"github.com/kevinswiber/languageserver-go/span"
+	errors "golang.org/x/xerrors"
+)
+
+type parseWorkKey source.FileIdentity
+
+type parseWorkData struct {
+	parsed *source.ParsedWorkFile
+
+	// err is any error encountered while parsing the file.
+	err error
+}
+
+func (s *snapshot) ParseWork(ctx context.Context, fh source.FileHandle) (*source.ParsedWorkFile, error) {
+	h := s.generation.Bind(parseWorkKey(fh.FileIdentity()), func(ctx context.Context, _ memoize.Arg) interface{} {
+		_, done := event.Start(ctx, "cache.ParseWork", tag.URI.Of(fh.URI()))
+		defer done()
+
+		contents, err := fh.Read()
+		if err != nil {
+			return &parseWorkData{err: err}
+		}
+		m := &protocol.ColumnMapper{
+			URI:       fh.URI(),
+			Converter: span.NewContentConverter(fh.URI().Filename(), contents),
+			Content:   contents,
+		}
+		file, parseErr := source.ParseWorkFile(fh.URI().Filename(), contents)
+		// Attempt to convert the error to a standardized parse error.
+		var parseErrors []*source.Diagnostic
+		if parseErr != nil {
+			mfErrList, ok := parseErr.(modfile.ErrorList)
+			if !ok {
+				return &parseWorkData{err: fmt.Errorf("unexpected parse error type %v", parseErr)}
+			}
+			for _, mfErr := range mfErrList {
+				rng, err := rangeFromPositions(m, mfErr.Pos, mfErr.Pos)
+				if err != nil {
+					return &parseWorkData{err: err}
+				}
+				parseErrors = append(parseErrors, &source.Diagnostic{
+					URI:      fh.URI(),
+					Range:    rng,
+					Severity: protocol.SeverityError,
+					Source:   source.ParseError,
+					Message:  mfErr.Err.Error(),
+				})
+			}
+		}
+		return &parseWorkData{
+			parsed: &source.ParsedWorkFile{
+				URI:         fh.URI(),
+				Mapper:      m,
+				File:        file,
+				ParseErrors: parseErrors,
+			},
+			err: parseErr,
+		}
+	}, nil)
+
+	v, err := h.Get(ctx, s.generation, s)
+	if err != nil {
+		return nil, err
+	}
+	data := v.(*parseWorkData)
+	return data.parsed, data.err
+}
+
+func (s *snapshot) WorkFile() span.URI {
+	if s.workspace.moduleSource != goWorkWorkspace {
+		return ""
+	}
+	return goWorkURI(s.workspace.root)
+}
+
+// goWorkURI returns the URI for the go.work file contained in root.
+func goWorkURI(root span.URI) span.URI {
+	return span.URIFromPath(filepath.Join(root.Filename(), "go.work"))
+}
+
+// parseGoWork parses the go.work file in root, returning the go.mod files of
+// the modules that it uses and its replace directives, with absolute paths.
+func parseGoWork(root, uri span.URI, contents []byte) (map[span.URI]struct{}, []*modfile.Replace, error) {
+	workFile, err := source.ParseWorkFile(uri.Filename(), contents)
+	if err != nil {
+		return nil, nil, errors.Errorf("parsing go.work: %w", err)
+	}
+	modFiles := make(map[span.URI]struct{})
+	for _, use := range workFile.Use {
+		modFiles[span.URIFromPath(filepath.Join(workDir(root, use.Path), "go.mod"))] = struct{}{}
+	}
+	var replaces []*modfile.Replace
+	for _, r := range workFile.Replace {
+		replace := *r
+		if replace.New.Version == "" {
+			replace.New.Path = workDir(root, replace.New.Path)
+		}
+		replaces = append(replaces, &replace)
+	}
+	return modFiles, replaces, nil
+}
+
+// workDir returns the absolute path of a directory of a go.work file in
+// root.
+func workDir(root span.URI, dir string) string {
+	dirFP := filepath.FromSlash(dir)
+	if !filepath.IsAbs(dirFP) {
+		dirFP = filepath.Join(root.Filename(), dirFP)
+	}
+	return filepath.Clean(dirFP)
+}
+
+// buildGoWorkModFile builds the workspace module of the modules used by a
+// go.work file, whose replace directives override those of the modules.
+func buildGoWorkModFile(ctx context.Context, modFiles map[span.URI]struct{}, replaces []*modfile.Replace, fs source.FileSource) (*modfile.File, error) {
+	// The modules that are used but missing are reported as diagnostics of
+	// the go.work file.
+	existing := make(map[span.URI]struct{})
+	for uri := range modFiles {
+		exists, err := fileExists(ctx, uri, fs)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			existing[uri] = struct{}{}
+		}
+	}
+	file, err := buildWorkspaceModFile(ctx, existing, fs)
+	if err != nil {
+		return nil, err
+	}
+	workspaceModules := make(map[string]bool)
+	for _, req := range file.Require {
+		workspaceModules[req.Mod.Path] = true
+	}
+	for _, r := range replaces {
+		// The modules of the workspace are always used from their
+		// directories.
+		if workspaceModules[r.Old.Path] {
+			continue
+		}
+		if err := file.AddReplace(r.Old.Path, r.Old.Version, r.New.Path, r.New.Version); err != nil {
+			return nil, err
+		}
+	}
+	file.Cleanup()
+	file.SortBlocks()
+	return file, nil
+}
+
+// BuildGoWork returns the contents of a go.work file in root that uses all
+// of the modules in root, with the highest go version of the modules.
+func BuildGoWork(ctx context.Context, root span.URI, s source.Snapshot) ([]byte, error) {
+	allModules, err := findModules(ctx, root, pathExcludedByFilterFunc(s.View().Options()), 0)
+	if err != nil {
+		return nil, err
+	}
+	// go.work files are supported as of Go 1.18.
+	goVersion := "1.18"
+	var dirs []string
+	for modURI := range allModules {
+		fh, err := s.GetFile(ctx, modURI)
+		if err != nil {
+			return nil, err
+		}
+		content, err := fh.Read()
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := modfile.ParseLax(modURI.Filename(), content, nil)
+		if err != nil {
+			return nil, err
+		}
+		if parsed.Go != nil && semver.Compare("v"+goVersion, "v"+parsed.Go.Version) < 0 {
+			goVersion = parsed.Go.Version
+		}
+		rel, err := filepath.Rel(root.Filename(), dirURI(modURI).Filename())
+		if err != nil {
+			return nil, err
+		}
+		dir := filepath.ToSlash(rel)
+		if dir != "." {
+			dir = "./" + dir
+		}
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "go %s\n", goVersion)
+	if len(dirs) > 0 {
+		b.WriteString("\nuse (\n")
+		for _, dir := range dirs {
+			fmt.Fprintf(&b, "\t%s\n", modfile.AutoQuote(dir))
+		}
+		b.WriteString(")\n")
+	}
+	filename := goWorkURI(root).Filename()
+	file, err := source.ParseWorkFile(filename, []byte(b.String()))
+	if err != nil {
+		return nil, err
+	}
+	return file.Format()
+}
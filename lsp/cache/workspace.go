@@ -26,6 +26,7 @@ const (
 	legacyWorkspace = iota
 	goplsModWorkspace
 	fileSystemWorkspace
+	goWorkWorkspace
 )
 
 func (s workspaceSource) String() string {
@@ -36,12 +37,14 @@ func (s workspaceSource) String() string {
 		return "gopls.mod"
 	case fileSystemWorkspace:
 		return "file system"
+	case goWorkWorkspace:
+		return "go.work"
 	default:
 		return "!(unknown module source)"
 	}
 }
 
-// workspace tracks go.mod files in the workspace, along with the
+// workspace tracks go.mod files in the workspace, along with the go.work or
 // gopls.mod file, to provide support for multi-module workspaces.
 //
 // Specifically, it provides:
@@ -68,6 +71,14 @@ type workspace struct {
 	// the environment.
 	go111moduleOff bool
 
+	// experimental indicates whether the experimental workspace module is
+	// enabled, which determines the module source without a go.work file.
+	experimental bool
+
+	// workReplace holds the replace directives of the go.work file, with
+	// absolute directory paths.
+	workReplace []*modfile.Replace
+
 	// The workspace module is lazily re-built once after being invalidated.
 	// buildMu+built guards this reconstruction.
 	//
@@ -83,6 +94,30 @@ type workspace struct {
 }
 
 func newWorkspace(ctx context.Context, root span.URI, fs source.FileSource, excludePath func(string) bool, go111moduleOff bool, experimental bool) (*workspace, error) {
+	// Unless GO111MODULE=off, the user may have a go.work file that lists
+	// the modules of their workspace.
+	if !go111moduleOff {
+		goWorkFH, err := fs.GetFile(ctx, goWorkURI(root))
+		if err != nil {
+			return nil, err
+		}
+		contents, err := goWorkFH.Read()
+		if err == nil {
+			activeModFiles, replaces, err := parseGoWork(root, goWorkFH.URI(), contents)
+			if err != nil {
+				return nil, err
+			}
+			return &workspace{
+				root:           root,
+				excludePath:    excludePath,
+				activeModFiles: activeModFiles,
+				knownModFiles:  activeModFiles,
+				moduleSource:   goWorkWorkspace,
+				experimental:   experimental,
+				workReplace:    replaces,
+			}, nil
+		}
+	}
 	// In experimental mode, the user may have a gopls.mod file that defines
 	// their workspace.
 	if experimental {
@@ -103,6 +138,7 @@ func newWorkspace(ctx context.Context, root span.URI, fs source.FileSource, excl
 				knownModFiles:  activeModFiles,
 				mod:            file,
 				moduleSource:   goplsModWorkspace,
+				experimental:   true,
 			}, nil
 		}
 	}
@@ -142,6 +178,7 @@ func newWorkspace(ctx context.Context, root span.URI, fs source.FileSource, excl
 		activeModFiles: knownModFiles,
 		knownModFiles:  knownModFiles,
 		moduleSource:   fileSystemWorkspace,
+		experimental:   true,
 	}, nil
 }
 
@@ -185,7 +222,13 @@ func (w *workspace) build(ctx context.Context, fs source.FileSource) {
 	// If our module source is not gopls.mod, try to build the workspace module
 	// from modules. Fall back on the pre-existing mod file if parsing fails.
 	if w.moduleSource != goplsModWorkspace {
-		file, err := buildWorkspaceModFile(ctx, w.activeModFiles, fs)
+		var file *modfile.File
+		var err error
+		if w.moduleSource == goWorkWorkspace {
+			file, err = buildGoWorkModFile(ctx, w.activeModFiles, w.workReplace, fs)
+		} else {
+			file, err = buildWorkspaceModFile(ctx, w.activeModFiles, fs)
+		}
 		switch {
 		case err == nil:
 			w.mod = file
@@ -258,6 +301,8 @@ func (w *workspace) invalidate(ctx context.Context, changes map[span.URI]*fileCh
 		knownModFiles:  make(map[span.URI]struct{}),
 		activeModFiles: make(map[span.URI]struct{}),
 		go111moduleOff: w.go111moduleOff,
+		experimental:   w.experimental,
+		workReplace:    w.workReplace,
 		mod:            w.mod,
 		sum:            w.sum,
 		wsDirs:         w.wsDirs,
@@ -269,11 +314,63 @@ func (w *workspace) invalidate(ctx context.Context, changes map[span.URI]*fileCh
 		result.activeModFiles[k] = v
 	}
 
-	// First handle changes to the gopls.mod file. This must be considered before
+	// First handle changes to the go.work file, which takes precedence over
+	// the gopls.mod file. Like the gopls.mod file, it must be considered
+	// before any changes to go.mod or go.sum files.
+	if !w.go111moduleOff {
+		gwURI := goWorkURI(w.root)
+		if change, ok := changes[gwURI]; ok {
+			if change.exists {
+				// Only invalidate if the go.work file actually parses.
+				parsedModules, replaces, err := parseGoWork(w.root, gwURI, change.content)
+				if err == nil {
+					changed = true
+					reload = change.fileHandle.Saved()
+					result.mod = nil
+					result.moduleSource = goWorkWorkspace
+					result.workReplace = replaces
+					result.knownModFiles = parsedModules
+					result.activeModFiles = make(map[span.URI]struct{})
+					for k, v := range parsedModules {
+						result.activeModFiles[k] = v
+					}
+				} else {
+					event.Error(ctx, "parsing go.work", err)
+				}
+			} else if w.moduleSource == goWorkWorkspace {
+				// go.work is deleted. Search for modules again, as if there
+				// had never been one. A gopls.mod file is handled below.
+				changed = true
+				reload = true
+				result.mod = nil
+				result.workReplace = nil
+				result.moduleSource = fileSystemWorkspace
+				if !w.experimental {
+					result.moduleSource = legacyWorkspace
+				}
+				knownModFiles, err := findModules(ctx, w.root, w.excludePath, 0)
+				if err != nil {
+					result.knownModFiles = nil
+					result.activeModFiles = nil
+					event.Error(ctx, "finding file system modules", err)
+				} else {
+					result.knownModFiles = knownModFiles
+					result.activeModFiles = make(map[span.URI]struct{})
+					for k, v := range result.knownModFiles {
+						if w.experimental || source.CompareURI(modURI(w.root), k) == 0 {
+							result.activeModFiles[k] = v
+						}
+					}
+				}
+			}
+		}
+	}
+
+	// Next handle changes to the gopls.mod file. This must be considered before
 	// any changes to go.mod or go.sum files, as the gopls.mod file determines
 	// which modules we care about. In legacy workspace mode we don't consider
-	// the gopls.mod file.
-	if w.moduleSource != legacyWorkspace {
+	// the gopls.mod file, and a go.work file overrides it.
+	if w.experimental && result.moduleSource != goWorkWorkspace {
 		// If gopls.mod has changed we need to either re-read it if it exists or
 		// walk the filesystem if it has been deleted.
 		gmURI := goplsModURI(w.root)
@@ -331,6 +428,11 @@ func (w *workspace) invalidate(ctx context.Context, changes map[span.URI]*fileCh
 			}
 			changed = true
 			active := result.moduleSource != legacyWorkspace || source.CompareURI(modURI(w.root), uri) == 0
+			if result.moduleSource == goWorkWorkspace {
+				// The go.work file determines the active modules, even
+				// those that are missing.
+				_, active = result.activeModFiles[uri]
+			}
 			reload = reload || (active && change.fileHandle.Saved())
 			if change.exists {
 				result.knownModFiles[uri] = struct{}{}
@@ -339,7 +441,9 @@ func (w *workspace) invalidate(ctx context.Context, changes map[span.URI]*fileCh
 				}
 			} else {
 				delete(result.knownModFiles, uri)
-				delete(result.activeModFiles, uri)
+				if result.moduleSource != goWorkWorkspace {
+					delete(result.activeModFiles, uri)
+				}
 			}
 		}
 	}
@@ -233,6 +233,65 @@ module modb.com`,
 				dirs:    []string{".", "a", "b"},
 			},
 		},
+		{
+			desc: "go.work",
+			initial: `
+-- go.work --
+go 1.18
+
+use ./a
+-- a/go.mod --
+module moda.com
+-- b/go.mod --
+module modb.com`,
+			initialState: wsState{
+				modules: []string{"a/go.mod"},
+				source:  goWorkWorkspace,
+				dirs:    []string{".", "a"},
+			},
+			updates: map[string]wsChange{
+				"go.work": {`go 1.18
+
+use (
+	./a
+	./b
+)`, true},
+			},
+			wantChanged: true,
+			wantReload:  true,
+			finalState: wsState{
+				modules: []string{"a/go.mod", "b/go.mod"},
+				source:  goWorkWorkspace,
+				dirs:    []string{".", "a", "b"},
+			},
+		},
+		{
+			desc: "deleting go.work",
+			initial: `
+-- go.work --
+go 1.18
+
+use ./a
+-- a/go.mod --
+module moda.com
+-- b/go.mod --
+module modb.com`,
+			initialState: wsState{
+				modules: []string{"a/go.mod"},
+				source:  goWorkWorkspace,
+				dirs:    []string{".", "a"},
+			},
+			updates: map[string]wsChange{
+				"go.work": {"", true},
+			},
+			wantChanged: true,
+			wantReload:  true,
+			finalState: wsState{
+				modules: []string{"a/go.mod", "b/go.mod"},
+				source:  fileSystemWorkspace,
+				dirs:    []string{".", "a", "b"},
+			},
+		},
 		{
 			desc: "broken module parsing",
 			initial: `
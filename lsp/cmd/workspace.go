@@ -56,16 +56,18 @@ func (w *workspace) Run(ctx context.Context, args ...string) error {
 	return tool.CommandLineErrorf("unknown command %v", command)
 }
 
-// generateWorkspaceMod (re)generates the gopls.mod file for the current
-// workspace.
+// generateWorkspaceMod (re)generates the gopls.mod file, or the go.work
+// file, for the current workspace.
 type generateWorkspaceMod struct {
+	Work bool `flag:"work" help:"generate a go.work file that uses all of the workspace modules instead"`
+
 	app *Application
 }
 
 func (c *generateWorkspaceMod) Name() string  { return "generate" }
 func (c *generateWorkspaceMod) Usage() string { return "" }
 func (c *generateWorkspaceMod) ShortHelp() string {
-	return "generate a gopls.mod file for a workspace"
+	return "generate a gopls.mod or go.work file for a workspace"
 }
 
 func (c *generateWorkspaceMod) DetailedHelp(f *flag.FlagSet) {
@@ -76,14 +78,20 @@ func (c *generateWorkspaceMod) Run(ctx context.Context, args ...string) error {
 	origOptions := c.app.options
 	c.app.options = func(opts *source.Options) {
 		origOptions(opts)
-		opts.ExperimentalWorkspaceModule = true
+		if !c.Work {
+			opts.ExperimentalWorkspaceModule = true
+		}
 	}
 	conn, err := c.app.connect(ctx)
 	if err != nil {
 		return err
 	}
 	defer conn.terminate(ctx)
-	cmd, err := command.NewGenerateGoplsModCommand("", command.URIArg{})
+	newCommand := command.NewGenerateGoplsModCommand
+	if c.Work {
+		newCommand = command.NewGenerateGoWorkCommand
+	}
+	cmd, err := newCommand("", command.URIArg{})
 	if err != nil {
 		return err
 	}
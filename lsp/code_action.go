@@ -17,6 +17,7 @@ import (
 	"github.com/kevinswiber/languageserver-go/lsp/mod"
 	"github.com/kevinswiber/languageserver-go/lsp/protocol"
 	"github.com/kevinswiber/languageserver-go/lsp/source"
+	"github.com/kevinswiber/languageserver-go/lsp/sum"
 	"github.com/kevinswiber/languageserver-go/span"
 	errors "golang.org/x/xerrors"
 )
@@ -75,6 +76,18 @@ func (s *Server) codeAction(ctx context.Context, params *protocol.CodeActionPara
 			}
 			codeActions = append(codeActions, quickFixes...)
 		}
+	case source.Sum:
+		if diagnostics := params.Context.Diagnostics; len(diagnostics) > 0 {
+			diags, err := sum.DiagnosticsForSum(ctx, snapshot, fh)
+			if err != nil {
+				return nil, err
+			}
+			quickFixes, err := codeActionsMatchingDiagnostics(ctx, snapshot, diagnostics, diags)
+			if err != nil {
+				return nil, err
+			}
+			codeActions = append(codeActions, quickFixes...)
+		}
 	case source.Go:
 		// Don't suggest fixes for generated files, since they are generally
 		// not useful and some editors may apply them automatically on save.
@@ -164,6 +177,14 @@ func (s *Server) codeAction(ctx context.Context, params *protocol.CodeActionPara
 		}
 		codeActions = append(codeActions, fixActions...)
 
+		if wanted[protocol.QuickFix] {
+			ignoreActions, err := ignoreDiagnosticActions(ctx, snapshot, pkg, diagnostics, analysisDiags[uri])
+			if err != nil {
+				return nil, err
+			}
+			codeActions = append(codeActions, ignoreActions...)
+		}
+
 		for _, nonfix := range nonFixDiags {
 			// For now, only show diagnostics for matching lines. Maybe we should
 			// alter this behavior in the future, depending on the user experience.
@@ -183,6 +204,44 @@ func (s *Server) codeAction(ctx context.Context, params *protocol.CodeActionPara
 				return nil, err
 			}
 			codeActions = append(codeActions, fixes...)
+			ifaceActions, err := extractInterface(ctx, snapshot, fh, params.Range)
+			if err != nil {
+				return nil, err
+			}
+			codeActions = append(codeActions, ifaceActions...)
+		}
+
+		if wanted[protocol.RefactorRewrite] {
+			fixes, err := source.StubMethodsFixes(ctx, snapshot, fh, params.Range)
+			if err != nil {
+				return nil, err
+			}
+			switchFixes, err := source.FillSwitchFixes(ctx, snapshot, fh, params.Range)
+			if err != nil {
+				return nil, err
+			}
+			fixes = append(fixes, switchFixes...)
+			tagFixes, err := source.StructTagFixes(ctx, snapshot, fh, params.Range)
+			if err != nil {
+				return nil, err
+			}
+			fixes = append(fixes, tagFixes...)
+			enumFixes, err := source.EnumStringFixes(ctx, snapshot, fh, params.Range)
+			if err != nil {
+				return nil, err
+			}
+			fixes = append(fixes, enumFixes...)
+			actions, err := codeActionsForFixes(ctx, snapshot, protocol.RefactorRewrite, fixes)
+			if err != nil {
+				return nil, err
+			}
+			codeActions = append(codeActions, actions...)
+
+			testActions, err := addTest(ctx, snapshot, fh, params.Range)
+			if err != nil {
+				return nil, err
+			}
+			codeActions = append(codeActions, testActions...)
 		}
 
 		if wanted[protocol.GoTest] {
@@ -354,21 +413,33 @@ func codeActionsMatchingDiagnostics(ctx context.Context, snapshot source.Snapsho
 }
 
 func codeActionsForDiagnostic(ctx context.Context, snapshot source.Snapshot, sd *source.Diagnostic, pd *protocol.Diagnostic) ([]protocol.CodeAction, error) {
+	kind := protocol.QuickFix
+	if sd.Analyzer != nil && sd.Analyzer.ActionKind != "" {
+		kind = sd.Analyzer.ActionKind
+	}
+	actions, err := codeActionsForFixes(ctx, snapshot, kind, sd.SuggestedFixes)
+	if err != nil {
+		return nil, err
+	}
+	if pd != nil {
+		for i := range actions {
+			actions[i].Diagnostics = []protocol.Diagnostic{*pd}
+		}
+	}
+	return actions, nil
+}
+
+// codeActionsForFixes returns a code action of the given kind for each of
+// the suggested fixes.
+func codeActionsForFixes(ctx context.Context, snapshot source.Snapshot, kind protocol.CodeActionKind, fixes []source.SuggestedFix) ([]protocol.CodeAction, error) {
 	var actions []protocol.CodeAction
-	for _, fix := range sd.SuggestedFixes {
+	for _, fix := range fixes {
 		action := protocol.CodeAction{
 			Title:   fix.Title,
-			Kind:    protocol.QuickFix,
+			Kind:    kind,
 			Edit:    protocol.WorkspaceEdit{},
 			Command: fix.Command,
 		}
-		if pd != nil {
-			action.Diagnostics = []protocol.Diagnostic{*pd}
-		}
-		if sd.Analyzer != nil && sd.Analyzer.ActionKind != "" {
-			action.Kind = sd.Analyzer.ActionKind
-		}
-
 		for uri, edits := range fix.Edits {
 			fh, err := snapshot.GetVersionedFile(ctx, uri)
 			if err != nil {
@@ -389,10 +460,93 @@ func codeActionsForDiagnostic(ctx context.Context, snapshot source.Snapshot, sd
 	return actions, nil
 }
 
+// ignoreDiagnosticActions returns a quick fix for each of the incoming
+// analysis diagnostics that adds a //gopls:ignore directive for its
+// analyzer.
+func ignoreDiagnosticActions(ctx context.Context, snapshot source.Snapshot, pkg source.Package, pdiags []protocol.Diagnostic, sdiags []*source.Diagnostic) ([]protocol.CodeAction, error) {
+	var actions []protocol.CodeAction
+	for _, sd := range sdiags {
+		if sd.Analyzer == nil {
+			continue
+		}
+		for _, pd := range pdiags {
+			if !sameDiagnostic(pd, sd) {
+				continue
+			}
+			pgf, err := pkg.File(sd.URI)
+			if err != nil {
+				return nil, err
+			}
+			fix, err := source.IgnoreDiagnosticFix(snapshot.FileSet(), pgf, sd.Analyzer.Analyzer.Name, sd.Range.Start.Line)
+			if err != nil {
+				return nil, err
+			}
+			fixActions, err := codeActionsForFixes(ctx, snapshot, protocol.QuickFix, []source.SuggestedFix{fix})
+			if err != nil {
+				return nil, err
+			}
+			for i := range fixActions {
+				fixActions[i].Diagnostics = []protocol.Diagnostic{pd}
+			}
+			actions = append(actions, fixActions...)
+			break
+		}
+	}
+	return actions, nil
+}
+
 func sameDiagnostic(pd protocol.Diagnostic, sd *source.Diagnostic) bool {
 	return pd.Message == sd.Message && protocol.CompareRange(pd.Range, sd.Range) == 0 && pd.Source == string(sd.Source)
 }
 
+func addTest(ctx context.Context, snapshot source.Snapshot, fh source.FileHandle, rng protocol.Range) ([]protocol.CodeAction, error) {
+	decl, name, err := source.TestableFunc(ctx, snapshot, fh, rng)
+	if err != nil || decl == nil {
+		return nil, err
+	}
+	cmd, err := command.NewAddTestCommand(fmt.Sprintf("Add test for %s", name), protocol.Location{
+		URI:   protocol.URIFromSpanURI(fh.URI()),
+		Range: rng,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []protocol.CodeAction{{
+		Title:   cmd.Title,
+		Kind:    protocol.RefactorRewrite,
+		Command: &cmd,
+	}}, nil
+}
+
+// extractInterface returns a code action that extracts an interface with
+// all of the exported methods of the type declared at rng.
+func extractInterface(ctx context.Context, snapshot source.Snapshot, fh source.FileHandle, rng protocol.Range) ([]protocol.CodeAction, error) {
+	named, methods, err := source.ConcreteTypeAt(ctx, snapshot, fh, rng)
+	if err != nil || named == nil {
+		return nil, err
+	}
+	var names []string
+	for _, m := range methods {
+		names = append(names, m.Name())
+	}
+	cmd, err := command.NewExtractInterfaceCommand(fmt.Sprintf("Extract interface from %s", named.Obj().Name()), command.ExtractInterfaceArgs{
+		Location: protocol.Location{
+			URI:   protocol.URIFromSpanURI(fh.URI()),
+			Range: rng,
+		},
+		Name:    source.InterfaceName(named, methods),
+		Methods: names,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []protocol.CodeAction{{
+		Title:   cmd.Title,
+		Kind:    protocol.RefactorExtract,
+		Command: &cmd,
+	}}, nil
+}
+
 func goTest(ctx context.Context, snapshot source.Snapshot, uri span.URI, rng protocol.Range) ([]protocol.CodeAction, error) {
 	fh, err := snapshot.GetFile(ctx, uri)
 	if err != nil {
@@ -14,6 +14,7 @@ import (
 	"github.com/kevinswiber/languageserver-go/lsp/mod"
 	"github.com/kevinswiber/languageserver-go/lsp/protocol"
 	"github.com/kevinswiber/languageserver-go/lsp/source"
+	"github.com/kevinswiber/languageserver-go/lsp/work"
 )
 
 func (s *Server) codeLens(ctx context.Context, params *protocol.CodeLensParams) ([]protocol.CodeLens, error) {
@@ -26,6 +27,8 @@ func (s *Server) codeLens(ctx context.Context, params *protocol.CodeLensParams)
 	switch fh.Kind() {
 	case source.Mod:
 		lenses = mod.LensFuncs()
+	case source.Work:
+		lenses = work.LensFuncs()
 	case source.Go:
 		lenses = source.LensFuncs()
 	default:
@@ -347,6 +347,113 @@ func (c *commandHandler) RunTests(ctx context.Context, args command.RunTestsArgs
 	})
 }
 
+func (c *commandHandler) AddTest(ctx context.Context, loc protocol.Location) error {
+	return c.run(ctx, commandConfig{
+		forURI: loc.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		change, err := source.AddTest(ctx, deps.snapshot, deps.fh, loc.Range)
+		if err != nil {
+			return err
+		}
+		return c.applyFileChanges(ctx, deps.snapshot, change)
+	})
+}
+
+func (c *commandHandler) GenerateMock(ctx context.Context, args command.GenerateMockArgs) error {
+	return c.run(ctx, commandConfig{
+		forURI: args.Location.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		change, err := source.GenerateMock(ctx, deps.snapshot, deps.fh, args.Location.Range, args.Target.SpanURI(), args.RecordCalls)
+		if err != nil {
+			return err
+		}
+		return c.applyFileChanges(ctx, deps.snapshot, change)
+	})
+}
+
+func (c *commandHandler) ExtractInterface(ctx context.Context, args command.ExtractInterfaceArgs) error {
+	return c.run(ctx, commandConfig{
+		forURI: args.Location.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		changes, err := source.ExtractInterface(ctx, deps.snapshot, deps.fh, args.Location.Range, args.Name, args.Methods, args.Target.SpanURI(), args.RewriteParams)
+		if err != nil {
+			return err
+		}
+		return c.applyFileChanges(ctx, deps.snapshot, changes...)
+	})
+}
+
+func (c *commandHandler) UnusedExported(ctx context.Context, args command.UnusedExportedArgs) (command.UnusedExportedResult, error) {
+	var result command.UnusedExportedResult
+	err := c.run(ctx, commandConfig{
+		progress: "Finding unused exported identifiers",
+		forURI:   args.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		unused, err := source.UnusedExported(ctx, deps.snapshot, args.Dir.SpanURI())
+		if err != nil {
+			return err
+		}
+		result.Identifiers = []command.UnusedIdentifier{}
+		for _, id := range unused {
+			result.Identifiers = append(result.Identifiers, command.UnusedIdentifier{
+				Name: id.Name,
+				Kind: id.Kind,
+				Location: protocol.Location{
+					URI:   protocol.URIFromSpanURI(id.URI),
+					Range: id.Range,
+				},
+			})
+		}
+		return nil
+	})
+	return result, err
+}
+
+// applyFileChanges applies the changes by asking the client to edit the
+// files, or, as workspace edits cannot create files, by writing new files
+// directly.
+func (c *commandHandler) applyFileChanges(ctx context.Context, snapshot source.Snapshot, changes ...*source.FileChange) error {
+	var created []source.FileModification
+	var edits []protocol.TextDocumentEdit
+	for _, change := range changes {
+		if change.Content != nil {
+			if err := ioutil.WriteFile(change.URI.Filename(), change.Content, 0644); err != nil {
+				return errors.Errorf("writing %s: %w", change.URI.Filename(), err)
+			}
+			created = append(created, source.FileModification{
+				URI:    change.URI,
+				Action: source.Create,
+			})
+			continue
+		}
+		fh, err := snapshot.GetVersionedFile(ctx, change.URI)
+		if err != nil {
+			return err
+		}
+		edits = append(edits, documentChanges(fh, change.Edits)...)
+	}
+	if len(created) > 0 {
+		if err := c.s.didModifyFiles(ctx, created, FromDidChangeWatchedFiles); err != nil {
+			return err
+		}
+	}
+	if len(edits) == 0 {
+		return nil
+	}
+	r, err := c.s.client.ApplyEdit(ctx, &protocol.ApplyWorkspaceEditParams{
+		Edit: protocol.WorkspaceEdit{
+			DocumentChanges: edits,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if !r.Applied {
+		return errors.New(r.FailureReason)
+	}
+	return nil
+}
+
 func (c *commandHandler) runTests(ctx context.Context, snapshot source.Snapshot, work *workDone, uri protocol.DocumentURI, tests, benchmarks []string) error {
 	// TODO: fix the error reporting when this runs async.
 	pkgs, err := snapshot.PackagesForFile(ctx, uri.SpanURI(), source.TypecheckWorkspace)
@@ -659,6 +766,39 @@ func (c *commandHandler) GenerateGoplsMod(ctx context.Context, args command.URIA
 	})
 }
 
+func (c *commandHandler) GenerateGoWork(ctx context.Context, args command.URIArg) error {
+	return c.run(ctx, commandConfig{
+		requireSave: true,
+		progress:    "Generating go.work",
+	}, func(ctx context.Context, deps commandDeps) error {
+		var view source.View
+		if args.URI != "" {
+			v, err := c.s.session.ViewOf(args.URI.SpanURI())
+			if err != nil {
+				return err
+			}
+			view = v
+		} else {
+			views := c.s.session.Views()
+			if len(views) != 1 {
+				return fmt.Errorf("cannot resolve view: have %d views", len(views))
+			}
+			view = views[0]
+		}
+		snapshot, release := view.Snapshot(ctx)
+		defer release()
+		content, err := cache.BuildGoWork(ctx, view.Folder(), snapshot)
+		if err != nil {
+			return errors.Errorf("building go.work file: %w", err)
+		}
+		filename := filepath.Join(view.Folder().Filename(), "go.work")
+		if err := ioutil.WriteFile(filename, content, 0644); err != nil {
+			return errors.Errorf("writing go.work file: %w", err)
+		}
+		return nil
+	})
+}
+
 func (c *commandHandler) ListKnownPackages(ctx context.Context, args command.URIArg) (command.ListKnownPackagesResult, error) {
 	var result command.ListKnownPackagesResult
 	err := c.run(ctx, commandConfig{
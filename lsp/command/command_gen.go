@@ -21,11 +21,15 @@ import (
 const (
 	AddDependency     Command = "add_dependency"
 	AddImport         Command = "add_import"
+	AddTest           Command = "add_test"
 	ApplyFix          Command = "apply_fix"
 	CheckUpgrades     Command = "check_upgrades"
+	ExtractInterface  Command = "extract_interface"
 	GCDetails         Command = "gc_details"
 	Generate          Command = "generate"
+	GenerateGoWork    Command = "generate_go_work"
 	GenerateGoplsMod  Command = "generate_gopls_mod"
+	GenerateMock      Command = "generate_mock"
 	GoGetPackage      Command = "go_get_package"
 	ListKnownPackages Command = "list_known_packages"
 	RegenerateCgo     Command = "regenerate_cgo"
@@ -34,6 +38,7 @@ const (
 	Test              Command = "test"
 	Tidy              Command = "tidy"
 	ToggleGCDetails   Command = "toggle_gc_details"
+	UnusedExported    Command = "unused_exported"
 	UpdateGoSum       Command = "update_go_sum"
 	UpgradeDependency Command = "upgrade_dependency"
 	Vendor            Command = "vendor"
@@ -43,11 +48,15 @@ const (
 var Commands = []Command{
 	AddDependency,
 	AddImport,
+	AddTest,
 	ApplyFix,
 	CheckUpgrades,
+	ExtractInterface,
 	GCDetails,
 	Generate,
+	GenerateGoWork,
 	GenerateGoplsMod,
+	GenerateMock,
 	GoGetPackage,
 	ListKnownPackages,
 	RegenerateCgo,
@@ -56,6 +65,7 @@ var Commands = []Command{
 	Test,
 	Tidy,
 	ToggleGCDetails,
+	UnusedExported,
 	UpdateGoSum,
 	UpgradeDependency,
 	Vendor,
@@ -76,6 +86,12 @@ func Dispatch(ctx context.Context, params *protocol.ExecuteCommandParams, s Inte
 			return nil, err
 		}
 		return s.AddImport(ctx, a0)
+	case "gopls.add_test":
+		var a0 protocol.Location
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return nil, s.AddTest(ctx, a0)
 	case "gopls.apply_fix":
 		var a0 ApplyFixArgs
 		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
@@ -88,6 +104,12 @@ func Dispatch(ctx context.Context, params *protocol.ExecuteCommandParams, s Inte
 			return nil, err
 		}
 		return nil, s.CheckUpgrades(ctx, a0)
+	case "gopls.extract_interface":
+		var a0 ExtractInterfaceArgs
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return nil, s.ExtractInterface(ctx, a0)
 	case "gopls.gc_details":
 		var a0 protocol.DocumentURI
 		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
@@ -100,12 +122,24 @@ func Dispatch(ctx context.Context, params *protocol.ExecuteCommandParams, s Inte
 			return nil, err
 		}
 		return nil, s.Generate(ctx, a0)
+	case "gopls.generate_go_work":
+		var a0 URIArg
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return nil, s.GenerateGoWork(ctx, a0)
 	case "gopls.generate_gopls_mod":
 		var a0 URIArg
 		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
 			return nil, err
 		}
 		return nil, s.GenerateGoplsMod(ctx, a0)
+	case "gopls.generate_mock":
+		var a0 GenerateMockArgs
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return nil, s.GenerateMock(ctx, a0)
 	case "gopls.go_get_package":
 		var a0 GoGetPackageArgs
 		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
@@ -156,6 +190,12 @@ func Dispatch(ctx context.Context, params *protocol.ExecuteCommandParams, s Inte
 			return nil, err
 		}
 		return nil, s.ToggleGCDetails(ctx, a0)
+	case "gopls.unused_exported":
+		var a0 UnusedExportedArgs
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return s.UnusedExported(ctx, a0)
 	case "gopls.update_go_sum":
 		var a0 URIArgs
 		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
@@ -204,6 +244,18 @@ func NewAddImportCommand(title string, a0 AddImportArgs) (protocol.Command, erro
 	}, nil
 }
 
+func NewAddTestCommand(title string, a0 protocol.Location) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   "gopls.add_test",
+		Arguments: args,
+	}, nil
+}
+
 func NewApplyFixCommand(title string, a0 ApplyFixArgs) (protocol.Command, error) {
 	args, err := MarshalArgs(a0)
 	if err != nil {
@@ -228,6 +280,18 @@ func NewCheckUpgradesCommand(title string, a0 CheckUpgradesArgs) (protocol.Comma
 	}, nil
 }
 
+func NewExtractInterfaceCommand(title string, a0 ExtractInterfaceArgs) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   "gopls.extract_interface",
+		Arguments: args,
+	}, nil
+}
+
 func NewGCDetailsCommand(title string, a0 protocol.DocumentURI) (protocol.Command, error) {
 	args, err := MarshalArgs(a0)
 	if err != nil {
@@ -252,6 +316,18 @@ func NewGenerateCommand(title string, a0 GenerateArgs) (protocol.Command, error)
 	}, nil
 }
 
+func NewGenerateGoWorkCommand(title string, a0 URIArg) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   "gopls.generate_go_work",
+		Arguments: args,
+	}, nil
+}
+
 func NewGenerateGoplsModCommand(title string, a0 URIArg) (protocol.Command, error) {
 	args, err := MarshalArgs(a0)
 	if err != nil {
@@ -264,6 +340,18 @@ func NewGenerateGoplsModCommand(title string, a0 URIArg) (protocol.Command, erro
 	}, nil
 }
 
+func NewGenerateMockCommand(title string, a0 GenerateMockArgs) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   "gopls.generate_mock",
+		Arguments: args,
+	}, nil
+}
+
 func NewGoGetPackageCommand(title string, a0 GoGetPackageArgs) (protocol.Command, error) {
 	args, err := MarshalArgs(a0)
 	if err != nil {
@@ -360,6 +448,18 @@ func NewToggleGCDetailsCommand(title string, a0 URIArg) (protocol.Command, error
 	}, nil
 }
 
+func NewUnusedExportedCommand(title string, a0 UnusedExportedArgs) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   "gopls.unused_exported",
+		Arguments: args,
+	}, nil
+}
+
 func NewUpdateGoSumCommand(title string, a0 URIArgs) (protocol.Command, error) {
 	args, err := MarshalArgs(a0)
 	if err != nil {
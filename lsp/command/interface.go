@@ -48,6 +48,31 @@ type Interface interface {
 	// Runs `go test` for a specific set of test or benchmark functions.
 	RunTests(context.Context, RunTestsArgs) error
 
+	// AddTest: Add a test for a function
+	//
+	// Generates a table-driven test skeleton for the function or method at
+	// the given location, in the _test.go file next to its declaration.
+	AddTest(context.Context, protocol.Location) error
+
+	// GenerateMock: Generate a mock implementation
+	//
+	// Generates a mock implementation of the interface type at the given
+	// location, and adds it to the target file.
+	GenerateMock(context.Context, GenerateMockArgs) error
+
+	// ExtractInterface: Extract an interface from a type
+	//
+	// Declares a new interface with a subset of the exported methods of the
+	// type at the given location, and optionally changes the parameters of
+	// workspace functions that only use those methods to the interface.
+	ExtractInterface(context.Context, ExtractInterfaceArgs) error
+
+	// UnusedExported: Find unused exported identifiers
+	//
+	// Lists the exported functions, types, variables, constants, methods,
+	// and fields of workspace packages that no workspace package uses.
+	UnusedExported(context.Context, UnusedExportedArgs) (UnusedExportedResult, error)
+
 	// Generate: Run go generate
 	//
 	// Runs `go generate` for a given directory.
@@ -115,6 +140,12 @@ type Interface interface {
 	// (Re)generate the gopls.mod file for a workspace.
 	GenerateGoplsMod(context.Context, URIArg) error
 
+	// GenerateGoWork: Generate go.work
+	//
+	// (Re)generate the go.work file for a workspace, using all of the
+	// modules in the workspace folder.
+	GenerateGoWork(context.Context, URIArg) error
+
 	ListKnownPackages(context.Context, URIArg) (ListKnownPackagesResult, error)
 
 	AddImport(context.Context, AddImportArgs) (AddImportResult, error)
@@ -141,6 +172,62 @@ type GenerateArgs struct {
 	Recursive bool
 }
 
+type GenerateMockArgs struct {
+	// The location of the interface type to mock.
+	Location protocol.Location
+
+	// The file to add the mock to. It is created if it does not exist.
+	Target protocol.DocumentURI
+
+	// Whether the mock records the arguments of each call.
+	RecordCalls bool
+}
+
+type ExtractInterfaceArgs struct {
+	// The location of the concrete type.
+	Location protocol.Location
+
+	// The name of the interface. If empty, a name is derived from the type.
+	Name string
+
+	// The names of the methods of the interface. If empty, all of the
+	// type's exported methods are included.
+	Methods []string
+
+	// The file to add the interface to. It is created if it does not
+	// exist. If empty, the interface is added after the type.
+	Target protocol.DocumentURI
+
+	// Whether to change the types of parameters that are only used to call
+	// the interface's methods to the interface.
+	RewriteParams bool
+}
+
+type UnusedExportedArgs struct {
+	// Any file of the workspace folder to search.
+	URI protocol.DocumentURI
+
+	// The directory of the packages to search, including those in its
+	// subdirectories. If empty, all workspace packages are searched.
+	Dir protocol.DocumentURI
+}
+
+type UnusedExportedResult struct {
+	Identifiers []UnusedIdentifier
+}
+
+type UnusedIdentifier struct {
+	// The name of the identifier, qualified by its package and, for
+	// methods and fields, its type, as in "pkg.Type.Method".
+	Name string
+
+	// One of "func", "type", "var", "const", "method", and "field".
+	Kind string
+
+	// The location of the identifier's declaration.
+	Location protocol.Location
+}
+
 // TODO(rFindley): document the rest of these once the docgen is fleshed out.
 
 type ApplyFixArgs struct {
@@ -12,6 +12,7 @@ import (
 
 	"github.com/kevinswiber/languageserver-go/event"
 	"github.com/kevinswiber/languageserver-go/lsp/debug/tag"
+	"github.com/kevinswiber/languageserver-go/lsp/mod"
 	"github.com/kevinswiber/languageserver-go/lsp/protocol"
 	"github.com/kevinswiber/languageserver-go/lsp/source"
 	"github.com/kevinswiber/languageserver-go/lsp/source/completion"
@@ -30,7 +31,18 @@ func (s *Server) completion(ctx context.Context, params *protocol.CompletionPara
 	case source.Go:
 		candidates, surrounding, err = completion.Completion(ctx, snapshot, fh, params.Position, params.Context)
 	case source.Mod:
-		candidates, surrounding = nil, nil
+		items, err := mod.Completion(ctx, snapshot, fh, params.Position)
+		if err != nil {
+			event.Error(ctx, "no completions found", err, tag.Position.Of(params.Position))
+		}
+		if items == nil {
+			items = []protocol.CompletionItem{}
+		}
+		return &protocol.CompletionList{
+			// The items are filtered by the prefix.
+			IsIncomplete: true,
+			Items:        items,
+		}, nil
 	}
 	if err != nil {
 		event.Error(ctx, "no completions found", err, tag.Position.Of(params.Position))
@@ -152,8 +164,16 @@ func toProtocolCompletionItems(candidates []completion.CompletionItem, rng proto
 			// filterText).
 			FilterText: strings.TrimLeft(candidate.InsertText, "&*"),
 
-			Preselect:     i == 0,
-			Documentation: candidate.Documentation,
+			Preselect:      i == 0,
+			Documentation:  candidate.Documentation,
+			InsertTextMode: candidate.InsertTextMode,
+		}
+		if candidate.Deprecated {
+			if options.CompletionDeprecatedTagSupported {
+				item.Tags = []protocol.CompletionItemTag{protocol.ComplDeprecated}
+			} else {
+				item.Deprecated = true
+			}
 		}
 		items = append(items, item)
 	}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/kevinswiber/languageserver-go/lsp/protocol"
 	"github.com/kevinswiber/languageserver-go/lsp/source"
+	"github.com/kevinswiber/languageserver-go/lsp/source/completion"
 	"github.com/kevinswiber/languageserver-go/lsp/tests"
 	"github.com/kevinswiber/languageserver-go/span"
 )
@@ -152,3 +153,26 @@ func (r *runner) callCompletion(t *testing.T, src span.Span, options func(*sourc
 	}
 	return list.Items
 }
+
+func TestDeprecatedCompletionItems(t *testing.T) {
+	candidates := []completion.CompletionItem{
+		{Label: "Old", InsertText: "Old", Deprecated: true},
+		{Label: "New", InsertText: "New"},
+	}
+	for _, tagSupported := range []bool{true, false} {
+		options := source.DefaultOptions().Clone()
+		options.CompletionDeprecatedTagSupported = tagSupported
+		items := toProtocolCompletionItems(candidates, protocol.Range{}, options)
+		old, new := items[0], items[1]
+		if tagSupported {
+			if len(old.Tags) != 1 || old.Tags[0] != protocol.ComplDeprecated || old.Deprecated {
+				t.Errorf("with tag support, Old has tags %v and deprecated %t, want the deprecated tag", old.Tags, old.Deprecated)
+			}
+		} else if len(old.Tags) != 0 || !old.Deprecated {
+			t.Errorf("without tag support, Old has tags %v and deprecated %t, want deprecated set", old.Tags, old.Deprecated)
+		}
+		if len(new.Tags) != 0 || new.Deprecated {
+			t.Errorf("New has tags %v and deprecated %t, want neither", new.Tags, new.Deprecated)
+		}
+	}
+}
@@ -19,6 +19,8 @@ import (
 	"github.com/kevinswiber/languageserver-go/lsp/mod"
 	"github.com/kevinswiber/languageserver-go/lsp/protocol"
 	"github.com/kevinswiber/languageserver-go/lsp/source"
+	"github.com/kevinswiber/languageserver-go/lsp/sum"
+	"github.com/kevinswiber/languageserver-go/lsp/work"
 	"github.com/kevinswiber/languageserver-go/span"
 	"github.com/kevinswiber/languageserver-go/xcontext"
 	errors "golang.org/x/xerrors"
@@ -33,6 +35,11 @@ const (
 	analysisSource
 	typeCheckSource
 	orphanedSource
+	unusedExportedSource
+	buildMatrixSource
+	excludedFileSource
+	workSource
+	sumSource
 )
 
 // A diagnosticReport holds results for a single diagnostic source.
@@ -50,6 +57,13 @@ type fileReports struct {
 	reports       map[diagnosticSource]diagnosticReport
 }
 
+// unusedExportedReports holds the unused exported identifier diagnostics
+// of a snapshot.
+type unusedExportedReports struct {
+	snapshotID uint64
+	reports    map[span.URI][]*source.Diagnostic
+}
+
 func (d diagnosticSource) String() string {
 	switch d {
 	case modSource:
@@ -62,6 +76,16 @@ func (d diagnosticSource) String() string {
 		return "FromTypeChecking"
 	case orphanedSource:
 		return "FromOrphans"
+	case unusedExportedSource:
+		return "FromUnusedExported"
+	case buildMatrixSource:
+		return "FromBuildMatrix"
+	case excludedFileSource:
+		return "FromExcludedFile"
+	case workSource:
+		return "FromWork"
+	case sumSource:
+		return "FromSum"
 	default:
 		return fmt.Sprintf("From?%d?", d)
 	}
@@ -88,6 +112,9 @@ func (s *Server) diagnoseDetached(snapshot source.Snapshot) {
 	ctx = xcontext.Detach(ctx)
 	s.diagnose(ctx, snapshot, false)
 	s.publishDiagnostics(ctx, true, snapshot)
+	if s.diagnoseLowPriority(ctx, snapshot) {
+		s.publishDiagnostics(ctx, true, snapshot)
+	}
 }
 
 func (s *Server) diagnoseSnapshot(snapshot source.Snapshot, changedURIs []span.URI, onDisk bool) {
@@ -109,6 +136,9 @@ func (s *Server) diagnoseSnapshot(snapshot source.Snapshot, changedURIs []span.U
 		s.debouncer.debounce(snapshot.View().Name(), snapshot.ID(), delay, func() {
 			s.diagnose(ctx, snapshot, false)
 			s.publishDiagnostics(ctx, true, snapshot)
+			if s.diagnoseLowPriority(ctx, snapshot) {
+				s.publishDiagnostics(ctx, true, snapshot)
+			}
 		})
 		return
 	}
@@ -116,6 +146,37 @@ func (s *Server) diagnoseSnapshot(snapshot source.Snapshot, changedURIs []span.U
 	// Ignore possible workspace configuration warnings in the normal flow.
 	s.diagnose(ctx, snapshot, false)
 	s.publishDiagnostics(ctx, true, snapshot)
+	if s.diagnoseLowPriority(ctx, snapshot) {
+		s.publishDiagnostics(ctx, true, snapshot)
+	}
+}
+
+// diagnoseLowPriority runs the diagnostics that type-check the workspace
+// under the build matrix or search the whole workspace, which are too
+// expensive to delay the others. It reports whether it stored new
+// diagnostics to publish.
+func (s *Server) diagnoseLowPriority(ctx context.Context, snapshot source.Snapshot) bool {
+	buildMatrix := len(snapshot.View().BuildMatrixViews()) > 0
+	unusedExported := snapshot.View().Options().UnusedExportedDiagnostics
+	if !buildMatrix && !unusedExported {
+		return false
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case s.diagnosticsSema <- struct{}{}:
+	}
+	defer func() {
+		<-s.diagnosticsSema
+	}()
+
+	if buildMatrix {
+		s.diagnoseBuildMatrix(ctx, snapshot, "")
+	}
+	if unusedExported {
+		s.diagnoseUnusedExported(ctx, snapshot)
+	}
+	return ctx.Err() == nil
 }
 
 func (s *Server) diagnoseChangedFiles(ctx context.Context, snapshot source.Snapshot, uris []span.URI, onDisk bool) {
@@ -174,21 +235,11 @@ func (s *Server) diagnose(ctx context.Context, snapshot source.Snapshot, forceAn
 	}()
 
 	// First, diagnose the go.mod file.
-	modReports, modErr := mod.Diagnostics(ctx, snapshot)
+	s.diagnoseModFiles(ctx, snapshot)
 	if ctx.Err() != nil {
 		log.Trace.Log(ctx, "diagnose cancelled")
 		return
 	}
-	if modErr != nil {
-		event.Error(ctx, "warning: diagnose go.mod", modErr, tag.Directory.Of(snapshot.View().Folder().Filename()), tag.Snapshot.Of(snapshot.ID()))
-	}
-	for id, diags := range modReports {
-		if id.URI == "" {
-			event.Error(ctx, "missing URI for module diagnostics", fmt.Errorf("empty URI"), tag.Directory.Of(snapshot.View().Folder().Filename()))
-			continue
-		}
-		s.storeDiagnostics(snapshot, id.URI, modSource, diags)
-	}
 
 	// Diagnose all of the packages in the workspace.
 	wsPkgs, err := snapshot.WorkspacePackages(ctx)
@@ -238,10 +289,255 @@ func (s *Server) diagnose(ctx context.Context, snapshot source.Snapshot, forceAn
 		if diagnostic == nil {
 			continue
 		}
+		// Files that only build under other configurations are not
+		// orphaned, and are diagnosed with the build matrix.
+		if inBuildMatrix(ctx, snapshot, o.URI()) {
+			continue
+		}
+		// Files excluded by build constraints are type-checked under a
+		// configuration that includes them.
+		if s.diagnoseExcludedFile(ctx, snapshot, o) {
+			continue
+		}
 		s.storeDiagnostics(snapshot, o.URI(), orphanedSource, []*source.Diagnostic{diagnostic})
 	}
 }
 
+// diagnoseExcludedFile diagnoses the open file, which the view's build
+// constraints exclude, in the view of a configuration that includes it. It
+// reports whether there is such a configuration.
+func (s *Server) diagnoseExcludedFile(ctx context.Context, snapshot source.Snapshot, fh source.VersionedFileHandle) bool {
+	view, err := snapshot.View().ExcludedFileView(ctx, fh)
+	if err != nil {
+		event.Error(ctx, "warning: creating view for excluded file", err, tag.URI.Of(fh.URI()))
+		return false
+	}
+	if view == nil {
+		return false
+	}
+	vsnapshot, release := view.Snapshot(ctx)
+	defer release()
+	pkgs, err := vsnapshot.PackagesForFile(ctx, fh.URI(), source.TypecheckWorkspace)
+	if err != nil || len(pkgs) == 0 {
+		return false
+	}
+	var diags []*source.Diagnostic
+	for _, pkg := range pkgs {
+		reports, err := vsnapshot.DiagnosePackage(ctx, pkg)
+		if err != nil {
+			event.Error(ctx, "warning: diagnosing package", err, tag.Package.Of(pkg.ID()))
+			continue
+		}
+		diags = append(diags, reports[fh.URI()]...)
+	}
+	s.storeDiagnostics(snapshot, fh.URI(), excludedFileSource, diags)
+	return true
+}
+
+// diagnoseBuildMatrix type-checks the workspace packages, or only the
+// packages of file if it is not empty, under the configurations of
+// the view's build matrix. It stores the diagnostics that the view's own
+// configuration does not produce, annotated with the configurations that
+// produce them.
+func (s *Server) diagnoseBuildMatrix(ctx context.Context, snapshot source.Snapshot, file span.URI) {
+	views := snapshot.View().BuildMatrixViews()
+	if len(views) == 0 {
+		return
+	}
+	type diagnosticKey struct {
+		uri     span.URI
+		rng     protocol.Range
+		source  source.DiagnosticSource
+		message string
+	}
+	var (
+		mu      sync.Mutex
+		files   = make(map[span.URI]struct{})
+		diags   = make(map[diagnosticKey]*source.Diagnostic)
+		configs = make(map[diagnosticKey][]string)
+	)
+	for _, view := range views {
+		config := view.BuildConfig().String()
+		vsnapshot, release := view.Snapshot(ctx)
+		var pkgs []source.Package
+		var err error
+		if file == "" {
+			pkgs, err = vsnapshot.WorkspacePackages(ctx)
+			if err != nil {
+				event.Error(ctx, "warning: build matrix", err, tag.Directory.Of(view.Folder().Filename()))
+			}
+		} else {
+			// The file may not build under every configuration.
+			pkgs, err = vsnapshot.PackagesForFile(ctx, file, source.TypecheckWorkspace)
+		}
+		if err != nil {
+			release()
+			continue
+		}
+		var wg sync.WaitGroup
+		for _, pkg := range pkgs {
+			wg.Add(1)
+			go func(pkg source.Package) {
+				defer wg.Done()
+
+				reports, err := vsnapshot.DiagnosePackage(ctx, pkg)
+				if err != nil {
+					event.Error(ctx, "warning: diagnosing package", err, tag.Package.Of(pkg.ID()))
+					return
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				for _, pgf := range pkg.CompiledGoFiles() {
+					files[pgf.URI] = struct{}{}
+				}
+				for _, pdiags := range reports {
+					for _, d := range pdiags {
+						key := diagnosticKey{d.URI, d.Range, d.Source, d.Message}
+						if _, ok := diags[key]; !ok {
+							diags[key] = d
+						}
+						if n := len(configs[key]); n == 0 || configs[key][n-1] != config {
+							configs[key] = append(configs[key], config)
+						}
+					}
+				}
+			}(pkg)
+		}
+		wg.Wait()
+		release()
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	// Leave out the diagnostics that the view reports itself.
+	reports := make(map[span.URI][]*source.Diagnostic)
+	own := make(map[diagnosticKey]bool)
+	for uri := range files {
+		for _, d := range s.snapshotDiagnostics(snapshot, uri) {
+			own[diagnosticKey{d.URI, d.Range, d.Source, d.Message}] = true
+		}
+	}
+	for key, d := range diags {
+		if own[key] {
+			continue
+		}
+		clone := *d
+		clone.Message = fmt.Sprintf("%s [%s]", d.Message, strings.Join(configs[key], ", "))
+		reports[d.URI] = append(reports[d.URI], &clone)
+	}
+	for uri := range files {
+		if snapshot.IgnoredFile(uri) {
+			continue
+		}
+		// Make sure that the view knows the files of other platforms, so
+		// that their diagnostics can be stored.
+		if _, err := snapshot.GetFile(ctx, uri); err != nil {
+			continue
+		}
+		s.storeDiagnostics(snapshot, uri, buildMatrixSource, reports[uri])
+	}
+}
+
+// inBuildMatrix reports whether the file belongs to a package under one of
+// the configurations of the view's build matrix.
+func inBuildMatrix(ctx context.Context, snapshot source.Snapshot, uri span.URI) bool {
+	for _, view := range snapshot.View().BuildMatrixViews() {
+		vsnapshot, release := view.Snapshot(ctx)
+		pkgs, err := vsnapshot.PackagesForFile(ctx, uri, source.TypecheckWorkspace)
+		release()
+		if err == nil && len(pkgs) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// diagnoseUnusedExported diagnoses the unused exported identifiers of the
+// workspace packages.
+func (s *Server) diagnoseUnusedExported(ctx context.Context, snapshot source.Snapshot) {
+	wsPkgs, err := snapshot.WorkspacePackages(ctx)
+	if err != nil {
+		return
+	}
+	reports, err := s.unusedExportedDiagnostics(ctx, snapshot)
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		event.Error(ctx, "warning: unused exported identifiers", err, tag.Snapshot.Of(snapshot.ID()))
+		return
+	}
+	for _, pkg := range wsPkgs {
+		for _, pgf := range pkg.CompiledGoFiles() {
+			s.storeDiagnostics(snapshot, pgf.URI, unusedExportedSource, reports[pgf.URI])
+		}
+	}
+}
+
+// unusedExportedDiagnostics returns the unused exported identifier
+// diagnostics of the snapshot, which are memoized for the latest snapshot
+// of each view, as the search for them covers the whole workspace.
+func (s *Server) unusedExportedDiagnostics(ctx context.Context, snapshot source.Snapshot) (map[span.URI][]*source.Diagnostic, error) {
+	s.unusedExportedMu.Lock()
+	memo, ok := s.unusedExported[snapshot.View()]
+	s.unusedExportedMu.Unlock()
+	if ok && memo.snapshotID == snapshot.ID() {
+		return memo.reports, nil
+	}
+	reports, err := source.UnusedExportedDiagnostics(ctx, snapshot)
+	if err != nil {
+		return nil, err
+	}
+	s.unusedExportedMu.Lock()
+	defer s.unusedExportedMu.Unlock()
+	if memo, ok := s.unusedExported[snapshot.View()]; !ok || memo.snapshotID < snapshot.ID() {
+		s.unusedExported[snapshot.View()] = unusedExportedReports{
+			snapshotID: snapshot.ID(),
+			reports:    reports,
+		}
+	}
+	return reports, nil
+}
+
+// diagnoseModFiles diagnoses the go.mod files of the snapshot.
+func (s *Server) diagnoseModFiles(ctx context.Context, snapshot source.Snapshot) {
+	modReports, modErr := mod.Diagnostics(ctx, snapshot)
+	if ctx.Err() != nil {
+		return
+	}
+	if modErr != nil {
+		event.Error(ctx, "warning: diagnose go.mod", modErr, tag.Directory.Of(snapshot.View().Folder().Filename()), tag.Snapshot.Of(snapshot.ID()))
+	}
+	for id, diags := range modReports {
+		if id.URI == "" {
+			event.Error(ctx, "missing URI for module diagnostics", fmt.Errorf("empty URI"), tag.Directory.Of(snapshot.View().Folder().Filename()))
+			continue
+		}
+		s.storeDiagnostics(snapshot, id.URI, modSource, diags)
+	}
+	workReports, workErr := work.Diagnostics(ctx, snapshot)
+	if ctx.Err() != nil {
+		return
+	}
+	if workErr != nil {
+		event.Error(ctx, "warning: diagnose go.work", workErr, tag.Directory.Of(snapshot.View().Folder().Filename()), tag.Snapshot.Of(snapshot.ID()))
+	}
+	for id, diags := range workReports {
+		s.storeDiagnostics(snapshot, id.URI, workSource, diags)
+	}
+	sumReports, sumErr := sum.Diagnostics(ctx, snapshot)
+	if ctx.Err() != nil {
+		return
+	}
+	if sumErr != nil {
+		event.Error(ctx, "warning: diagnose go.sum", sumErr, tag.Directory.Of(snapshot.View().Folder().Filename()), tag.Snapshot.Of(snapshot.ID()))
+	}
+	for id, diags := range sumReports {
+		s.storeDiagnostics(snapshot, id.URI, sumSource, diags)
+	}
+}
+
 func (s *Server) diagnosePkg(ctx context.Context, snapshot source.Snapshot, pkg source.Package, alwaysAnalyze bool) {
 	ctx, done := event.Start(ctx, "Server.diagnosePkg", tag.Snapshot.Of(snapshot.ID()), tag.Package.Of(pkg.ID()))
 	defer done()
@@ -297,6 +593,21 @@ func (s *Server) diagnosePkg(ctx context.Context, snapshot source.Snapshot, pkg
 	}
 }
 
+// forgetPublishedDiagnostics forgets which diagnostics were published, so
+// that all of them are published again.
+func (s *Server) forgetPublishedDiagnostics() {
+	s.diagnosticsMu.Lock()
+	defer s.diagnosticsMu.Unlock()
+
+	for _, r := range s.diagnostics {
+		r.publishedHash = ""
+		for dsource, report := range r.reports {
+			report.publishedHash = ""
+			r.reports[dsource] = report
+		}
+	}
+}
+
 // storeDiagnostics stores results from a single diagnostic source. If merge is
 // true, it merges results into any existing results for this snapshot.
 func (s *Server) storeDiagnostics(snapshot source.Snapshot, uri span.URI, dsource diagnosticSource, diags []*source.Diagnostic) {
@@ -418,6 +729,20 @@ Otherwise, see the troubleshooting guidelines for help investigating (https://gi
 func (s *Server) publishDiagnostics(ctx context.Context, final bool, snapshot source.Snapshot) {
 	ctx, done := event.Start(ctx, "Server.publishDiagnostics", tag.Snapshot.Of(snapshot.ID()))
 	defer done()
+
+	// Clients that pull diagnostics don't have them published. Instead,
+	// once the snapshot is fully diagnosed, they are asked to pull again
+	// if any file's diagnostics have changed.
+	pull := snapshot.View().Options().PullDiagnosticsSupported
+	pullChanged := false
+	defer func() {
+		if pullChanged && snapshot.View().Options().DiagnosticRefreshSupported {
+			if err := s.client.DiagnosticRefresh(ctx); err != nil {
+				event.Error(ctx, "publishReports: failed to refresh diagnostics", err)
+			}
+		}
+	}()
+
 	s.diagnosticsMu.Lock()
 	defer s.diagnosticsMu.Unlock()
 
@@ -467,12 +792,27 @@ func (s *Server) publishDiagnostics(ctx context.Context, final bool, snapshot so
 			r.snapshotID = snapshot.ID()
 			continue
 		}
+		if pull {
+			// Only the final diagnostics of the snapshot are worth pulling.
+			if !final {
+				continue
+			}
+			pullChanged = true
+			r.publishedHash = hash
+			r.snapshotID = snapshot.ID()
+			for dsource, hash := range reportHashes {
+				report := r.reports[dsource]
+				report.publishedHash = hash
+				r.reports[dsource] = report
+			}
+			continue
+		}
 		var version int32
 		if fh := snapshot.FindFile(uri); fh != nil { // file may have been deleted
 			version = fh.Version()
 		}
 		if err := s.client.PublishDiagnostics(ctx, &protocol.PublishDiagnosticsParams{
-			Diagnostics: toProtocolDiagnostics(diags),
+			Diagnostics: toProtocolDiagnostics(applyDiagnosticOverrides(snapshot.View().Options(), diags)),
 			URI:         protocol.URIFromSpanURI(uri),
 			Version:     version,
 		}); err == nil {
@@ -495,6 +835,53 @@ func (s *Server) publishDiagnostics(ctx context.Context, final bool, snapshot so
 	}
 }
 
+// applyDiagnosticOverrides returns the diagnostics with the severities and
+// tags of the DiagnosticSeverity and DiagnosticTags options applied. The
+// options are looked up by the source of each diagnostic, and then by the
+// name of its analyzer, so that they cover all of an analyzer's categories.
+func applyDiagnosticOverrides(options *source.Options, diagnostics []*source.Diagnostic) []*source.Diagnostic {
+	if len(options.DiagnosticSeverity) == 0 && len(options.DiagnosticTags) == 0 {
+		return diagnostics
+	}
+	result := make([]*source.Diagnostic, 0, len(diagnostics))
+	for _, diag := range diagnostics {
+		keys := []string{string(diag.Source)}
+		if diag.Analyzer != nil {
+			keys = append(keys, diag.Analyzer.Analyzer.Name)
+		}
+		severity, tags := diag.Severity, diag.Tags
+		for i := len(keys) - 1; i >= 0; i-- {
+			if s, ok := options.DiagnosticSeverity[keys[i]]; ok {
+				severity = s
+			}
+		}
+		for _, key := range keys {
+			for _, tag := range options.DiagnosticTags[key] {
+				if !hasDiagnosticTag(tags, tag) {
+					tags = append(tags[:len(tags):len(tags)], tag)
+				}
+			}
+		}
+		if severity != diag.Severity || len(tags) != len(diag.Tags) {
+			// The diagnostic is shared with the cache, so modify a copy.
+			clone := *diag
+			clone.Severity, clone.Tags = severity, tags
+			diag = &clone
+		}
+		result = append(result, diag)
+	}
+	return result
+}
+
+func hasDiagnosticTag(tags []protocol.DiagnosticTag, tag protocol.DiagnosticTag) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
 func toProtocolDiagnostics(diagnostics []*source.Diagnostic) []protocol.Diagnostic {
 	reports := []protocol.Diagnostic{}
 	for _, diag := range diagnostics {
@@ -0,0 +1,126 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lsp
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/kevinswiber/languageserver-go/lsp/cache"
+	"github.com/kevinswiber/languageserver-go/lsp/fake"
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+)
+
+const unusedExportedProgram = `
+-- go.mod --
+module mod.com
+
+go 1.12
+-- a/a.go --
+package a
+
+func Unused() {}
+`
+
+func TestUnusedExportedDiagnosticsMemoized(t *testing.T) {
+	sb, err := fake.NewSandbox(&fake.SandboxConfig{Files: unusedExportedProgram})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sb.Close()
+
+	ctx := context.Background()
+	session := cache.New(ctx, nil).NewSession(ctx)
+	options := source.DefaultOptions().Clone()
+	options.Env = map[string]string{"GOPACKAGESDRIVER": "off"}
+	view, snapshot, release, err := session.NewView(ctx, "lsp_test", sb.Workdir.RootURI().SpanURI(), "", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer view.Shutdown(ctx)
+	defer release()
+
+	s := NewServer(session, nil)
+	reports, err := s.unusedExportedDiagnostics(ctx, snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uri := sb.Workdir.URI("a/a.go").SpanURI()
+	if len(reports) != 1 || len(reports[uri]) != 1 {
+		t.Fatalf("unusedExportedDiagnostics = %v, want one diagnostic in %s", reports, uri)
+	}
+	// The search is not repeated for the same snapshot.
+	again, err := s.unusedExportedDiagnostics(ctx, snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reflect.ValueOf(again).Pointer() != reflect.ValueOf(reports).Pointer() {
+		t.Errorf("unusedExportedDiagnostics searched the snapshot again")
+	}
+}
+
+const typeErrorProgram = `
+-- go.mod --
+module mod.com
+
+go 1.12
+-- a/a.go --
+package a
+
+var x int = "x"
+`
+
+func TestPullDiagnosticOverrides(t *testing.T) {
+	sb, err := fake.NewSandbox(&fake.SandboxConfig{Files: typeErrorProgram})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sb.Close()
+
+	ctx := context.Background()
+	uri := sb.Workdir.URI("a/a.go")
+	pull := func(modify func(*source.Options)) *protocol.FullDocumentDiagnosticReport {
+		t.Helper()
+		session := cache.New(ctx, nil).NewSession(ctx)
+		options := source.DefaultOptions().Clone()
+		options.Env = map[string]string{"GOPACKAGESDRIVER": "off"}
+		modify(options)
+		view, _, release, err := session.NewView(ctx, "lsp_test", sb.Workdir.RootURI().SpanURI(), "", options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer view.Shutdown(ctx)
+		defer release()
+
+		s := NewServer(session, nil)
+		result, err := s.diagnostic(ctx, &protocol.DocumentDiagnosticParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		report, ok := result.(*protocol.FullDocumentDiagnosticReport)
+		if !ok || len(report.Items) != 1 {
+			t.Fatalf("diagnostic = %#v, want a full report with one diagnostic", result)
+		}
+		return report
+	}
+	plain := pull(func(*source.Options) {})
+	overridden := pull(func(options *source.Options) {
+		options.DiagnosticSeverity = map[string]protocol.DiagnosticSeverity{"compiler": protocol.SeverityWarning}
+		options.DiagnosticTags = map[string][]protocol.DiagnosticTag{"compiler": {protocol.Unnecessary}}
+	})
+	d := overridden.Items[0]
+	if d.Severity != protocol.SeverityWarning || !reflect.DeepEqual(d.Tags, []protocol.DiagnosticTag{protocol.Unnecessary}) {
+		t.Errorf("overridden diagnostic has severity %v and tags %v, want %v and %v", d.Severity, d.Tags, protocol.SeverityWarning, []protocol.DiagnosticTag{protocol.Unnecessary})
+	}
+	// The result id covers the overrides, so that the client does not keep
+	// the diagnostics of other options.
+	if plain.ResultID == overridden.ResultID {
+		t.Errorf("the result ids with and without overrides are both %q", plain.ResultID)
+	}
+}
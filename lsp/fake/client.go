@@ -22,6 +22,7 @@ type ClientHooks struct {
 	OnShowMessageRequest     func(context.Context, *protocol.ShowMessageRequestParams) error
 	OnRegistration           func(context.Context, *protocol.RegistrationParams) error
 	OnUnregistration         func(context.Context, *protocol.UnregistrationParams) error
+	OnDiagnosticRefresh      func(context.Context) error
 }
 
 // Client is an adapter that converts an *Editor into an LSP Client. It mosly
@@ -97,6 +98,13 @@ func (c *Client) UnregisterCapability(ctx context.Context, params *protocol.Unre
 	return nil
 }
 
+func (c *Client) DiagnosticRefresh(ctx context.Context) error {
+	if c.hooks.OnDiagnosticRefresh != nil {
+		return c.hooks.OnDiagnosticRefresh(ctx)
+	}
+	return nil
+}
+
 func (c *Client) Progress(ctx context.Context, params *protocol.ProgressParams) error {
 	if c.hooks.OnProgress != nil {
 		return c.hooks.OnProgress(ctx, params)
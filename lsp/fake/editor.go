@@ -111,6 +111,11 @@ type EditorConfig struct {
 	VerboseOutput bool
 
 	ImportShortcut string
+
+	// PullDiagnostics declares that the client pulls diagnostics, and
+	// supports being asked to pull them again, rather than having them
+	// published.
+	PullDiagnostics bool
 }
 
 // NewEditor Creates a new Editor.
@@ -269,6 +274,10 @@ func (e *Editor) initialize(ctx context.Context, workspaceFolders []string) erro
 
 	params.Capabilities.Workspace.Configuration = true
 	params.Capabilities.Window.WorkDoneProgress = true
+	if e.Config.PullDiagnostics {
+		params.Capabilities.TextDocument.Diagnostic = &protocol.DiagnosticClientCapabilities{}
+		params.Capabilities.Workspace.Diagnostics.RefreshSupport = true
+	}
 	// TODO: set client capabilities
 	params.InitializationOptions = e.configuration()
 	if e.Config.SendPID {
@@ -10,6 +10,7 @@ import (
 	"github.com/kevinswiber/languageserver-go/lsp/mod"
 	"github.com/kevinswiber/languageserver-go/lsp/protocol"
 	"github.com/kevinswiber/languageserver-go/lsp/source"
+	"github.com/kevinswiber/languageserver-go/lsp/work"
 )
 
 func (s *Server) formatting(ctx context.Context, params *protocol.DocumentFormattingParams) ([]protocol.TextEdit, error) {
@@ -21,6 +22,8 @@ func (s *Server) formatting(ctx context.Context, params *protocol.DocumentFormat
 	switch fh.Kind() {
 	case source.Mod:
 		return mod.Format(ctx, snapshot, fh)
+	case source.Work:
+		return work.Format(ctx, snapshot, fh)
 	case source.Go:
 		return source.Format(ctx, snapshot, fh)
 	}
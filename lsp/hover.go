@@ -10,6 +10,8 @@ import (
 	"github.com/kevinswiber/languageserver-go/lsp/mod"
 	"github.com/kevinswiber/languageserver-go/lsp/protocol"
 	"github.com/kevinswiber/languageserver-go/lsp/source"
+	"github.com/kevinswiber/languageserver-go/lsp/sum"
+	"github.com/kevinswiber/languageserver-go/lsp/work"
 )
 
 func (s *Server) hover(ctx context.Context, params *protocol.HoverParams) (*protocol.Hover, error) {
@@ -21,6 +23,10 @@ func (s *Server) hover(ctx context.Context, params *protocol.HoverParams) (*prot
 	switch fh.Kind() {
 	case source.Mod:
 		return mod.Hover(ctx, snapshot, fh, params.Position)
+	case source.Sum:
+		return sum.Hover(ctx, snapshot, fh, params.Position)
+	case source.Work:
+		return work.Hover(ctx, snapshot, fh, params.Position)
 	case source.Go:
 		return source.Hover(ctx, snapshot, fh, params.Position)
 	}
@@ -0,0 +1,280 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mod
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+	"github.com/kevinswiber/languageserver-go/event"
+	"github.com/kevinswiber/languageserver-go/lsp/fuzzy"
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+	"github.com/kevinswiber/languageserver-go/span"
+	errors "golang.org/x/xerrors"
+)
+
+// Completion offers completions in the require, exclude, and replace
+// directives of a go.mod file: the paths of the modules in the module
+// cache, the versions of a module that the cache knows of, and local
+// directories for the targets of replacements. It reads only the module
+// cache, so it works offline.
+func Completion(ctx context.Context, snapshot source.Snapshot, fh source.FileHandle, position protocol.Position) ([]protocol.CompletionItem, error) {
+	ctx, done := event.Start(ctx, "mod.Completion")
+	defer done()
+
+	src, err := fh.Read()
+	if err != nil {
+		return nil, err
+	}
+	m := &protocol.ColumnMapper{
+		URI:       fh.URI(),
+		Converter: span.NewContentConverter(fh.URI().Filename(), src),
+		Content:   src,
+	}
+	spn, err := m.PointSpan(position)
+	if err != nil {
+		return nil, errors.Errorf("computing cursor position: %w", err)
+	}
+	offset := spn.Start().Offset()
+
+	// Find the word being completed, and the words that precede it in
+	// its directive.
+	lineStart := bytes.LastIndexByte(src[:offset], '\n') + 1
+	start, end := offset, offset
+	for start > lineStart && !isSpace(src[start-1]) {
+		start--
+	}
+	for end < len(src) && !isSpace(src[end]) {
+		end++
+	}
+	if bytes.Contains(src[lineStart:start], []byte("//")) {
+		return nil, nil
+	}
+	verb, args := directiveAt(src, lineStart, start)
+	rng, err := m.Range(span.New(fh.URI(), span.NewPoint(0, 0, start), span.NewPoint(0, 0, end)))
+	if err != nil {
+		return nil, err
+	}
+	prefix := string(src[start:offset])
+
+	c := &modCompleter{
+		snapshot: snapshot,
+		modCache: snapshot.View().GoModCache(),
+		dir:      filepath.Dir(fh.URI().Filename()),
+		prefix:   prefix,
+		matcher:  fuzzy.NewMatcher(prefix),
+		rng:      rng,
+	}
+	switch verb {
+	case "require", "exclude":
+		switch len(args) {
+		case 0:
+			c.modulePaths(ctx)
+		case 1:
+			c.versions(args[0])
+		}
+	case "replace":
+		arrow := -1
+		for i, arg := range args {
+			if arg == "=>" {
+				arrow = i
+			}
+		}
+		switch {
+		case arrow < 0 && len(args) == 0:
+			c.modulePaths(ctx)
+		case arrow < 0 && len(args) == 1:
+			c.versions(args[0])
+		case arrow >= 0 && len(args) == arrow+1:
+			c.localDirs()
+			if !isLocalPath(prefix) {
+				c.modulePaths(ctx)
+			}
+		case arrow >= 0 && len(args) == arrow+2 && !isLocalPath(args[arrow+1]):
+			c.versions(args[arrow+1])
+		}
+	}
+	return c.items, nil
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
+
+// isLocalPath reports whether path is a file path, as opposed to a module
+// path, in the target of a replacement.
+func isLocalPath(path string) bool {
+	return path == "." || path == ".." || strings.HasPrefix(path, "./") || strings.HasPrefix(path, "../") || filepath.IsAbs(path)
+}
+
+// directiveAt returns the verb of the directive whose arguments include
+// the word that begins at offset start of the line at lineStart, and the
+// arguments that precede that word. A directive's verb is either on the
+// same line or at the start of the enclosing block.
+func directiveAt(src []byte, lineStart, start int) (string, []string) {
+	fields := strings.Fields(string(src[lineStart:start]))
+	if len(fields) > 0 {
+		switch fields[0] {
+		case "require", "exclude", "replace":
+			if len(fields) == 1 || fields[1] != "(" {
+				return fields[0], fields[1:]
+			}
+			return fields[0], fields[2:]
+		}
+	}
+	// Look for the start of an enclosing block.
+	for end := lineStart - 1; end > 0; {
+		begin := bytes.LastIndexByte(src[:end], '\n') + 1
+		line := strings.TrimSpace(string(src[begin:end]))
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == ")" {
+			return "", nil
+		}
+		if strings.HasSuffix(line, "(") {
+			return strings.TrimSpace(strings.TrimSuffix(line, "(")), fields
+		}
+		end = begin - 1
+	}
+	return "", nil
+}
+
+type modCompleter struct {
+	snapshot source.Snapshot
+	modCache string
+	dir      string // the directory of the go.mod file
+	prefix   string
+	matcher  *fuzzy.Matcher
+	rng      protocol.Range
+	items    []protocol.CompletionItem
+}
+
+// add adds an item for label, if it matches the prefix. Items are sorted
+// by their scores and then in the order they are added.
+func (c *modCompleter) add(label string, kind protocol.CompletionItemKind, detail string) {
+	score := c.matcher.Score(label)
+	if score <= 0 {
+		return
+	}
+	c.items = append(c.items, protocol.CompletionItem{
+		Label:  label,
+		Kind:   kind,
+		Detail: detail,
+		TextEdit: &protocol.TextEdit{
+			Range:   c.rng,
+			NewText: label,
+		},
+		// Sort by decreasing score, then by the order of the items.
+		SortText: fmt.Sprintf("%04d%05d", int((1-score)*1000), len(c.items)),
+	})
+}
+
+// downloadDir returns the directory of the module cache that holds the
+// downloaded versions of the module with the given path.
+func (c *modCompleter) downloadDir(path string) (string, error) {
+	escaped, err := module.EscapePath(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(c.modCache, "cache", "download", filepath.FromSlash(escaped), "@v"), nil
+}
+
+// modulePaths adds the paths of the modules that have been downloaded to
+// the module cache.
+func (c *modCompleter) modulePaths(ctx context.Context) {
+	paths, err := c.snapshot.DownloadedModules(ctx)
+	if err != nil {
+		event.Error(ctx, "listing downloaded modules", err)
+		return
+	}
+	for _, path := range paths {
+		c.add(path, protocol.ModuleCompletion, "module")
+	}
+}
+
+// versions adds the versions of the module with the given path that the
+// module cache knows of, from its @v/list file and the versions it has
+// downloaded, newest first.
+func (c *modCompleter) versions(path string) {
+	if c.modCache == "" {
+		return
+	}
+	dir, err := c.downloadDir(path)
+	if err != nil {
+		return
+	}
+	seen := make(map[string]bool)
+	var versions []string
+	addVersion := func(v string) {
+		if semver.IsValid(v) && !seen[v] {
+			seen[v] = true
+			versions = append(versions, v)
+		}
+	}
+	if f, err := os.Open(filepath.Join(dir, "list")); err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			addVersion(strings.TrimSpace(scanner.Text()))
+		}
+		f.Close()
+	}
+	if infos, err := ioutil.ReadDir(dir); err == nil {
+		for _, info := range infos {
+			if strings.HasSuffix(info.Name(), ".info") {
+				addVersion(strings.TrimSuffix(info.Name(), ".info"))
+			}
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return semver.Compare(versions[i], versions[j]) > 0
+	})
+	for _, v := range versions {
+		c.add(v, protocol.ConstantCompletion, path)
+	}
+}
+
+// localDirs adds the directories that the prefix, a path relative to the
+// go.mod file's directory, may name, as targets of replacements. The
+// directories that contain a go.mod file are marked as modules.
+func (c *modCompleter) localDirs() {
+	if c.prefix == "" {
+		c.add("./", protocol.FolderCompletion, "directory")
+		c.add("../", protocol.FolderCompletion, "directory")
+		return
+	}
+	if !isLocalPath(c.prefix) {
+		return
+	}
+	dirPart := c.prefix[:strings.LastIndex(c.prefix, "/")+1]
+	dir := filepath.FromSlash(dirPart)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(c.dir, dir)
+	}
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, info := range infos {
+		if !info.IsDir() || strings.HasPrefix(info.Name(), ".") {
+			continue
+		}
+		detail := "directory"
+		if _, err := os.Stat(filepath.Join(dir, info.Name(), "go.mod")); err == nil {
+			detail = "module"
+		}
+		c.add(dirPart+info.Name(), protocol.FolderCompletion, detail)
+	}
+}
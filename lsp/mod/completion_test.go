@@ -0,0 +1,169 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mod
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kevinswiber/languageserver-go/lsp/cache"
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+	"github.com/kevinswiber/languageserver-go/lsp/tests"
+	"github.com/kevinswiber/languageserver-go/span"
+)
+
+func TestDirectiveAt(t *testing.T) {
+	const src = `module a
+
+require example.com/a v1.0.0
+
+require (
+	example.com/b v1.0.0
+	example.com/c ‸
+)
+
+replace example.com/d => ../d ‸
+exclude ‸
+go ‸
+`
+	tests := []struct {
+		verb string
+		args []string
+	}{
+		{"require", []string{"example.com/c"}},
+		{"replace", []string{"example.com/d", "=>", "../d"}},
+		{"exclude", nil},
+		{"", nil},
+	}
+
+	rest := src
+	var offset int
+	for _, test := range tests {
+		i := strings.Index(rest, "‸")
+		offset += i
+		lineStart := strings.LastIndexByte(src[:offset], '\n') + 1
+		verb, args := directiveAt([]byte(src), lineStart, offset)
+		if len(args) == 0 {
+			args = nil
+		}
+		if verb != test.verb || !reflect.DeepEqual(args, test.args) {
+			t.Errorf("directiveAt(%q) = %q, %q, want %q, %q", src[lineStart:offset], verb, args, test.verb, test.args)
+		}
+		offset += len("‸")
+		rest = rest[i+len("‸"):]
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	ctx := tests.Context(t)
+	root, err := ioutil.TempDir("", "modcompletion")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	write := func(name, content string) {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("modcache/cache/download/example.com/a/@v/list", "v1.0.0\nv1.2.0\n")
+	write("modcache/cache/download/example.com/a/@v/v1.1.0.info", "{}")
+	write("modcache/cache/download/example.com/a/@v/v1.2.0.info", "{}")
+	write("modcache/cache/download/github.com/!user/b/@v/v0.1.0.info", "{}")
+	write("modcache/cache/download/sumdb/sum.golang.org/lookup/example.com/a@v1.0.0", "")
+	write("local/go.mod", "module example.com/local\n")
+	write("local/notes/README", "")
+	write("local/.git/HEAD", "")
+	write("lib/README", "")
+	write("m/go.mod", "module example.com/m\n\ngo 1.16\n")
+
+	options := source.DefaultOptions().Clone()
+	tests.DefaultOptions(options)
+	options.Env = map[string]string{
+		"GOPACKAGESDRIVER": "off",
+		"GOMODCACHE":       filepath.Join(root, "modcache"),
+		"GOFLAGS":          "-mod=mod",
+	}
+	session := cache.New(ctx, nil).NewSession(ctx)
+	view, snapshot, release, err := session.NewView(ctx, "completion_test", span.URIFromPath(filepath.Join(root, "m")), "", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer view.Shutdown(ctx)
+	defer release()
+
+	for _, test := range []struct {
+		line string // the last line of the go.mod file, with the cursor
+		want []string
+	}{
+		{"require ‸", []string{"example.com/a", "github.com/User/b"}},
+		{"require git‸", []string{"github.com/User/b"}},
+		{"require example.com/a ‸", []string{"v1.2.0", "v1.1.0", "v1.0.0"}},
+		{"exclude github.com/User/b ‸", []string{"v0.1.0"}},
+		{"require example.com/unknown ‸", nil},
+		{"require example.com/a v1.0.0 ‸", nil},
+		{"replace ‸", []string{"example.com/a", "github.com/User/b"}},
+		{"replace example.com/a ‸", []string{"v1.2.0", "v1.1.0", "v1.0.0"}},
+		{"replace example.com/a => ‸", []string{"./", "../", "example.com/a", "github.com/User/b"}},
+		{"replace example.com/a => ../‸", []string{"../lib", "../local", "../m", "../modcache"}},
+		{"replace example.com/a => ../local/‸", []string{"../local/notes"}},
+		{"replace example.com/a => example.com/a ‸", []string{"v1.2.0", "v1.1.0", "v1.0.0"}},
+		{"replace example.com/a => ../local ‸", nil},
+		{"go ‸", nil},
+		{"// require ‸", nil},
+	} {
+		content := "module example.com/m\n\ngo 1.16\n\n" + test.line + "\n"
+		i := strings.Index(content, "‸")
+		content = content[:i] + content[i+len("‸"):]
+		uri := span.URIFromPath(filepath.Join(root, "m", "go.mod"))
+		fh := &fakeFileHandle{uri: uri, content: []byte(content)}
+		pos := protocol.Position{Line: uint32(strings.Count(content[:i], "\n")), Character: uint32(i - strings.LastIndex(content[:i], "\n") - 1)}
+		items, err := Completion(ctx, snapshot, fh, pos)
+		if err != nil {
+			t.Fatalf("%s: %v", test.line, err)
+		}
+		var got []string
+		for _, item := range items {
+			got = append(got, item.Label)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %q, want %q", test.line, got, test.want)
+		}
+	}
+
+	// Local directories that are modules are marked as such.
+	content := "module example.com/m\n\nreplace example.com/a => ../l\n"
+	fh := &fakeFileHandle{uri: span.URIFromPath(filepath.Join(root, "m", "go.mod")), content: []byte(content)}
+	items, err := Completion(ctx, snapshot, fh, protocol.Position{Line: 2, Character: uint32(len("replace example.com/a => ../l"))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	details := make(map[string]string)
+	for _, item := range items {
+		details[item.Label] = item.Detail
+	}
+	if details["../local"] != "module" || details["../lib"] != "directory" {
+		t.Errorf("details = %v, want ../local to be a module and ../lib a directory", details)
+	}
+}
+
+// fakeFileHandle is a file handle for the unsaved content of a file.
+type fakeFileHandle struct {
+	source.FileHandle
+	uri     span.URI
+	content []byte
+}
+
+func (fh *fakeFileHandle) URI() span.URI         { return fh.uri }
+func (fh *fakeFileHandle) Read() ([]byte, error) { return fh.content, nil }
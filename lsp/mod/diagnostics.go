@@ -61,7 +61,7 @@ func DiagnosticsForMod(ctx context.Context, snapshot source.Snapshot, fh source.
 		if !ok || req.Mod.Version == ver {
 			continue
 		}
-		rng, err := lineToRange(pm.Mapper, fh.URI(), req.Syntax.Start, req.Syntax.End)
+		rng, err := source.LineToRange(pm.Mapper, fh.URI(), req.Syntax.Start, req.Syntax.End)
 		if err != nil {
 			return nil, err
 		}
@@ -85,6 +85,14 @@ func DiagnosticsForMod(ctx context.Context, snapshot source.Snapshot, fh source.
 		})
 	}
 
+	// Add the vulnerabilities of the required modules, if there is a
+	// vulnerability database.
+	vulns, err := vulnerabilityDiagnostics(ctx, snapshot, fh, pm)
+	if err != nil {
+		event.Error(ctx, "diagnosing go.mod", err)
+	}
+	diagnostics = append(diagnostics, vulns...)
+
 	// Packages in the workspace can contribute diagnostics to go.mod files.
 	wspkgs, err := snapshot.WorkspacePackages(ctx)
 	if err != nil && !source.IsNonFatalGoModError(err) {
@@ -0,0 +1,258 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mod
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+	"github.com/kevinswiber/languageserver-go/lsp/command"
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+	"github.com/kevinswiber/languageserver-go/span"
+)
+
+// A vulnerability is an OSV report that affects a module version.
+type vulnerability struct {
+	entry *source.OSVEntry
+
+	// fixed is the first version of the module that fixes the
+	// vulnerability, if any.
+	fixed string
+
+	// symbols are the qualified names of the affected symbols, or the
+	// affected packages if the report does not list their symbols.
+	symbols []string
+}
+
+// vulnerabilityDiagnostics reports the required modules of the go.mod file
+// whose selected versions are affected by the reports of the vulnerability
+// database. If the module graph is not available, it checks the required
+// versions instead.
+func vulnerabilityDiagnostics(ctx context.Context, snapshot source.Snapshot, fh source.FileHandle, pm *source.ParsedModule) ([]*source.Diagnostic, error) {
+	entries, err := snapshot.VulnerabilityDatabase(ctx)
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	selected := make(map[string]string)
+	for _, req := range pm.File.Require {
+		selected[req.Mod.Path] = req.Mod.Version
+	}
+	if graph, err := snapshot.ModGraph(ctx, fh); err == nil {
+		selected = graph.Selected
+	}
+	// Report the vulnerabilities of the modules that replace the required
+	// ones, unless they are in local directories.
+	var paths []string
+	for path, version := range selected {
+		mod := module.Version{Path: path, Version: version}
+		if r := replacementOf(pm, mod); r != nil {
+			mod = r.New
+		}
+		paths = append(paths, mod.Path)
+	}
+	sort.Strings(paths)
+	var diagnostics []*source.Diagnostic
+	for _, req := range pm.File.Require {
+		mod := req.Mod
+		if version, ok := selected[mod.Path]; ok {
+			mod.Version = version
+		}
+		r := replacementOf(pm, mod)
+		if r != nil {
+			if r.New.Version == "" {
+				continue
+			}
+			mod = r.New
+		}
+		vulns := findVulnerabilities(entries, mod, paths)
+		if len(vulns) == 0 {
+			continue
+		}
+		rng, err := source.LineToRange(pm.Mapper, pm.URI, req.Syntax.Start, req.Syntax.End)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range vulns {
+			d := &source.Diagnostic{
+				URI:      pm.URI,
+				Range:    rng,
+				Severity: protocol.SeverityWarning,
+				Code:     v.entry.ID,
+				CodeHref: v.entry.URL(),
+				Source:   source.Vulnerability,
+				Message:  v.message(mod),
+			}
+			if v.fixed != "" {
+				fix, err := upgradeFix(snapshot, pm, mod, r, v.fixed)
+				if err != nil {
+					return nil, err
+				}
+				d.SuggestedFixes = []source.SuggestedFix{fix}
+			}
+			diagnostics = append(diagnostics, d)
+		}
+	}
+	return diagnostics, nil
+}
+
+// upgradeFix returns the fix that upgrades the module to the fixed
+// version. The go command cannot upgrade a module that replaces a required
+// one, so the fix of a replacement edits its replace directive instead.
+func upgradeFix(snapshot source.Snapshot, pm *source.ParsedModule, mod module.Version, r *modfile.Replace, fixed string) (source.SuggestedFix, error) {
+	if r == nil {
+		title := fmt.Sprintf("Upgrade to %v", fixed)
+		cmd, err := command.NewUpgradeDependencyCommand(title, command.DependencyArgs{
+			URI:        protocol.URIFromSpanURI(pm.URI),
+			AddRequire: false,
+			GoCmdArgs:  []string{mod.Path + "@" + fixed},
+		})
+		if err != nil {
+			return source.SuggestedFix{}, err
+		}
+		return source.SuggestedFixFromCommand(cmd), nil
+	}
+	// We need a private copy of the parsed go.mod file, since we're going to
+	// modify it.
+	copied, err := modfile.Parse("", pm.Mapper.Content, nil)
+	if err != nil {
+		return source.SuggestedFix{}, err
+	}
+	if err := copied.AddReplace(r.Old.Path, r.Old.Version, r.New.Path, fixed); err != nil {
+		return source.SuggestedFix{}, err
+	}
+	newContent, err := copied.Format()
+	if err != nil {
+		return source.SuggestedFix{}, err
+	}
+	diff, err := snapshot.View().Options().ComputeEdits(pm.URI, string(pm.Mapper.Content), string(newContent))
+	if err != nil {
+		return source.SuggestedFix{}, err
+	}
+	edits, err := source.ToProtocolEdits(pm.Mapper, diff)
+	if err != nil {
+		return source.SuggestedFix{}, err
+	}
+	return source.SuggestedFix{
+		Title: fmt.Sprintf("Replace with %s@%s", r.New.Path, fixed),
+		Edits: map[span.URI][]protocol.TextEdit{pm.URI: edits},
+	}, nil
+}
+
+// replacementOf returns the replace directive of the module version, if
+// any.
+func replacementOf(pm *source.ParsedModule, mod module.Version) *modfile.Replace {
+	for _, r := range pm.File.Replace {
+		if r.Old.Path == mod.Path && (r.Old.Version == "" || r.Old.Version == mod.Version) {
+			return r
+		}
+	}
+	return nil
+}
+
+func (v *vulnerability) message(mod module.Version) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s is affected by %s", mod, v.entry.ID)
+	if len(v.entry.Aliases) > 0 {
+		fmt.Fprintf(&b, " (%s)", strings.Join(v.entry.Aliases, ", "))
+	}
+	summary := v.entry.Summary
+	if summary == "" {
+		// Older reports have no summary, but the first sentence of their
+		// details serves as one.
+		summary = strings.TrimSpace(v.entry.Details)
+		if i := strings.Index(summary, ". "); i >= 0 {
+			summary = summary[:i+1]
+		}
+		summary = strings.Join(strings.Fields(summary), " ")
+	}
+	if summary != "" {
+		fmt.Fprintf(&b, ": %s", summary)
+	}
+	if len(v.symbols) > 0 {
+		fmt.Fprintf(&b, "\nAffected symbols: %s", strings.Join(v.symbols, ", "))
+	}
+	if v.fixed != "" {
+		fmt.Fprintf(&b, "\nFixed in: %s@%s", mod.Path, v.fixed)
+	} else {
+		b.WriteString("\nNo fixed version is available.")
+	}
+	return b.String()
+}
+
+// findVulnerabilities returns the vulnerabilities of the reports that
+// affect the module version. The paths are the modules of the build list,
+// after replacement, which own the packages of older reports.
+func findVulnerabilities(entries []*source.OSVEntry, mod module.Version, paths []string) []*vulnerability {
+	var vulns []*vulnerability
+	unfixed := make(map[*vulnerability]bool)
+	for _, e := range entries {
+		if e.Withdrawn != "" {
+			continue
+		}
+		var v *vulnerability
+		for _, a := range e.Affected {
+			if a.Package.Ecosystem != "Go" {
+				continue
+			}
+			// Older reports, which list no imports, name the affected
+			// package rather than its module.
+			owner := a.Package.Name
+			if len(a.EcosystemSpecific.Imports) == 0 {
+				owner = owningModule(owner, paths)
+			}
+			if owner != mod.Path {
+				continue
+			}
+			fixed, ok := a.Affects(mod.Version)
+			if !ok {
+				continue
+			}
+			if v == nil {
+				v = &vulnerability{entry: e}
+				vulns = append(vulns, v)
+			}
+			// Upgrade past all of the affected packages, if they are all
+			// fixed.
+			if fixed == "" {
+				unfixed[v] = true
+			} else if v.fixed == "" || semver.Compare(fixed, v.fixed) > 0 {
+				v.fixed = fixed
+			}
+			v.symbols = append(v.symbols, a.Symbols()...)
+		}
+	}
+	for _, v := range vulns {
+		if unfixed[v] {
+			v.fixed = ""
+		}
+		sort.Strings(v.symbols)
+	}
+	return vulns
+}
+
+// owningModule returns the module of paths that contains the package
+// with the import path, which is the module whose path is its longest
+// prefix. It returns the import path itself if there is none.
+func owningModule(name string, paths []string) string {
+	owner := ""
+	for _, path := range paths {
+		if path == name {
+			return path
+		}
+		if strings.HasPrefix(name, path+"/") && len(path) > len(owner) {
+			owner = path
+		}
+	}
+	if owner == "" {
+		return name
+	}
+	return owner
+}
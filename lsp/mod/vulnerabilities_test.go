@@ -0,0 +1,281 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mod
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"golang.org/x/mod/module"
+	"github.com/kevinswiber/languageserver-go/lsp/cache"
+	"github.com/kevinswiber/languageserver-go/lsp/command"
+	"github.com/kevinswiber/languageserver-go/lsp/diff"
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+	"github.com/kevinswiber/languageserver-go/span"
+)
+
+var testVulnerabilityDatabase = map[string]string{
+	"index.json": `{"example.com/a": "2021-01-01T00:00:00Z"}`,
+	"ID/GO-2021-0001.json": `{
+	"id": "GO-2021-0001",
+	"aliases": ["CVE-2021-0001"],
+	"summary": "Denial of service in example.com/a",
+	"affected": [{
+		"package": {"ecosystem": "Go", "name": "example.com/a"},
+		"ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "1.2.0"}, {"introduced": "1.3.0"}, {"fixed": "1.3.4"}]}],
+		"ecosystem_specific": {"imports": [{"path": "example.com/a/b", "symbols": ["Parse", "T.Method"]}]}
+	}],
+	"database_specific": {"url": "https://pkg.go.dev/vuln/GO-2021-0001"}
+}`,
+	"ID/GO-2021-0002.json": `{
+	"id": "GO-2021-0002",
+	"details": "Crash in Decode. It happens on malformed input.",
+	"affected": [{
+		"package": {"ecosystem": "Go", "name": "example.com/a/c"},
+		"ranges": [{"type": "SEMVER", "events": [{"introduced": "1.1.0"}]}],
+		"ecosystem_specific": {"symbols": ["Decode"]}
+	}]
+}`,
+	"ID/GO-2021-0004.json": `{
+	"id": "GO-2021-0004",
+	"affected": [{
+		"package": {"ecosystem": "Go", "name": "example.com/a/sub"},
+		"ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}]}],
+		"ecosystem_specific": {"imports": [{"path": "example.com/a/sub/pkg"}]}
+	}]
+}`,
+	"ID/GO-2021-0003.json": `{
+	"id": "GO-2021-0003",
+	"withdrawn": "2021-06-01T00:00:00Z",
+	"affected": [{
+		"package": {"ecosystem": "Go", "name": "example.com/a"},
+		"ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}]}]
+	}]
+}`,
+}
+
+func TestFindVulnerabilities(t *testing.T) {
+	var entries []*source.OSVEntry
+	for _, content := range testVulnerabilityDatabase {
+		var e source.OSVEntry
+		if err := json.Unmarshal([]byte(content), &e); err != nil {
+			t.Fatal(err)
+		}
+		if e.ID == "" {
+			continue // the index
+		}
+		entries = append(entries, &e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ID < entries[j].ID
+	})
+	paths := []string{"example.com/a", "example.com/ab", "example.com/a/sub", "example.com/a/c/d"}
+
+	type want struct {
+		id, fixed string
+		symbols   []string
+	}
+	tests := []struct {
+		version string
+		want    []want
+	}{
+		{"v1.0.0", []want{{"GO-2021-0001", "v1.2.0", []string{"example.com/a/b.Parse", "example.com/a/b.T.Method"}}}},
+		{"v1.1.5", []want{
+			{"GO-2021-0001", "v1.2.0", []string{"example.com/a/b.Parse", "example.com/a/b.T.Method"}},
+			{"GO-2021-0002", "", []string{"example.com/a/c.Decode"}},
+		}},
+		{"v1.2.0", []want{{"GO-2021-0002", "", []string{"example.com/a/c.Decode"}}}},
+		{"v1.3.1", []want{
+			{"GO-2021-0001", "v1.3.4", []string{"example.com/a/b.Parse", "example.com/a/b.T.Method"}},
+			{"GO-2021-0002", "", []string{"example.com/a/c.Decode"}},
+		}},
+	}
+	for _, test := range tests {
+		mod := module.Version{Path: "example.com/a", Version: test.version}
+		var got []want
+		for _, v := range findVulnerabilities(entries, mod, paths) {
+			got = append(got, want{v.entry.ID, v.fixed, v.symbols})
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("findVulnerabilities(%s) = %v, want %v", mod, got, test.want)
+		}
+	}
+
+	// Reports name modules exactly, and the packages of older reports
+	// belong to the required module with the longest matching path.
+	for _, path := range []string{"example.com/ab", "example.com/a/sub", "example.com/a/c/d"} {
+		mod := module.Version{Path: path, Version: "v1.1.0"}
+		var got []string
+		for _, v := range findVulnerabilities(entries, mod, paths) {
+			got = append(got, v.entry.ID)
+		}
+		var want []string
+		if path == "example.com/a/sub" {
+			want = []string{"GO-2021-0004"}
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("findVulnerabilities(%s) = %v, want %v", mod, got, want)
+		}
+	}
+}
+
+func TestOwningModule(t *testing.T) {
+	paths := []string{"example.com/a", "example.com/a/b", "example.com/ab"}
+	tests := []struct {
+		name, want string
+	}{
+		{"example.com/a", "example.com/a"},
+		{"example.com/a/c", "example.com/a"},
+		{"example.com/a/b", "example.com/a/b"},
+		{"example.com/a/b/c", "example.com/a/b"},
+		{"example.com/abc", "example.com/abc"},
+	}
+	for _, test := range tests {
+		if got := owningModule(test.name, paths); got != test.want {
+			t.Errorf("owningModule(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+// vulnerableModContent is the go.mod file of TestVulnerabilityDiagnostics.
+// The selected version of example.com/b is v1.3.1, which example.com/a
+// requires.
+const vulnerableModContent = `module example.com/m
+
+go 1.16
+
+require (
+	example.com/a v1.0.0
+	example.com/b v1.1.0
+	example.com/old v1.0.0
+)
+
+replace example.com/old => example.com/c v1.0.0
+`
+
+func TestVulnerabilityDiagnostics(t *testing.T) {
+	ctx := context.Background()
+	root, err := ioutil.TempDir("", "vulnerabilities")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	write := func(name, content string) {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("modcache/cache/download/example.com/a/@v/v1.0.0.mod", "module example.com/a\n\nrequire example.com/b v1.3.1\n")
+	write("modcache/cache/download/example.com/b/@v/v1.1.0.mod", "module example.com/b\n")
+	write("modcache/cache/download/example.com/b/@v/v1.3.1.mod", "module example.com/b\n")
+	write("modcache/cache/download/example.com/c/@v/v1.0.0.mod", "module example.com/c\n")
+	write("vulndb/ID/GO-2021-0010.json", `{
+	"id": "GO-2021-0010",
+	"summary": "Panic in example.com/b",
+	"affected": [{
+		"package": {"ecosystem": "Go", "name": "example.com/b"},
+		"ranges": [{"type": "SEMVER", "events": [{"introduced": "1.3.0"}, {"fixed": "1.3.4"}]}]
+	}]
+}`)
+	write("vulndb/ID/GO-2021-0011.json", `{
+	"id": "GO-2021-0011",
+	"summary": "Panic in example.com/c",
+	"affected": [{
+		"package": {"ecosystem": "Go", "name": "example.com/c"},
+		"ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "1.0.1"}]}]
+	}]
+}`)
+	write("m/go.mod", vulnerableModContent)
+
+	options := source.DefaultOptions().Clone()
+	options.VulnerabilityDatabase = filepath.Join(root, "vulndb")
+	options.Env = map[string]string{
+		"GOPACKAGESDRIVER": "off",
+		"GOMODCACHE":       filepath.Join(root, "modcache"),
+		"GOPROXY":          "off",
+		"GOFLAGS":          "",
+	}
+	session := cache.New(ctx, nil).NewSession(ctx)
+	view, snapshot, release, err := session.NewView(ctx, "vulnerabilities_test", span.URIFromPath(filepath.Join(root, "m")), "", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer view.Shutdown(ctx)
+	defer release()
+
+	fh, err := snapshot.GetFile(ctx, span.URIFromPath(filepath.Join(root, "m", "go.mod")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pm, err := snapshot.ParseMod(ctx, fh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diagnostics, err := vulnerabilityDiagnostics(ctx, snapshot, fh, pm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diagnostics) != 2 {
+		t.Fatalf("vulnerabilityDiagnostics returned %d diagnostics, want 2", len(diagnostics))
+	}
+	for i, want := range []struct {
+		line    uint32
+		code    string
+		message string
+	}{
+		{6, "GO-2021-0010", "example.com/b@v1.3.1 is affected by GO-2021-0010: Panic in example.com/b\nFixed in: example.com/b@v1.3.4"},
+		{7, "GO-2021-0011", "example.com/c@v1.0.0 is affected by GO-2021-0011: Panic in example.com/c\nFixed in: example.com/c@v1.0.1"},
+	} {
+		d := diagnostics[i]
+		if d.Range.Start.Line != want.line || d.Range.End.Line != want.line || d.Range.Start.Character != 1 {
+			t.Errorf("diagnostic %d has range %v, want the require line %d", i, d.Range, want.line+1)
+		}
+		if d.Code != want.code || d.Message != want.message {
+			t.Errorf("diagnostic %d = %s: %q, want %s: %q", i, d.Code, d.Message, want.code, want.message)
+		}
+		if len(d.SuggestedFixes) != 1 {
+			t.Fatalf("diagnostic %d has %d fixes, want 1", i, len(d.SuggestedFixes))
+		}
+	}
+
+	// The selected version of a required module is upgraded by the go
+	// command.
+	fix := diagnostics[0].SuggestedFixes[0]
+	if fix.Command == nil || fix.Command.Command != command.UpgradeDependency.ID() {
+		t.Fatalf("fix %q has command %v, want %s", fix.Title, fix.Command, command.UpgradeDependency.ID())
+	}
+	var args command.DependencyArgs
+	if err := command.UnmarshalArgs(fix.Command.Arguments, &args); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"example.com/b@v1.3.4"}; args.AddRequire || !reflect.DeepEqual(args.GoCmdArgs, want) {
+		t.Errorf("fix %q has arguments %+v, want the go command arguments %v", fix.Title, args, want)
+	}
+
+	// A replacement is upgraded in its replace directive.
+	fix = diagnostics[1].SuggestedFixes[0]
+	if fix.Command != nil {
+		t.Errorf("fix %q of a replacement has command %v", fix.Title, fix.Command)
+	}
+	edits, err := source.FromProtocolEdits(pm.Mapper, fix.Edits[fh.URI()])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Replace(vulnerableModContent, "example.com/c v1.0.0", "example.com/c v1.0.1", 1)
+	if got := diff.ApplyEdits(vulnerableModContent, edits); got != want {
+		t.Errorf("fix %q produced\n%s\nwant\n%s", fix.Title, got, want)
+	}
+}
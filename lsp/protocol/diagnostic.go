@@ -0,0 +1,156 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protocol
+
+// This file declares the types of the pull diagnostics requests of LSP
+// 3.17, which are not yet in the version of vscode-languageserver-node
+// that the ts*.go files are generated from. Until they are regenerated,
+// the fields that refer to these types, and the dispatch of the requests,
+// are maintained by hand in the generated files:
+//
+//	- ServerCapabilities.DiagnosticProvider
+//	- TextDocumentClientCapabilities.Diagnostic
+//	- WorkspaceClientCapabilities.Diagnostics, and the same field of the
+//	  anonymous Workspace*Gn structs
+//	- Server.Diagnostic and Server.DiagnosticWorkspace
+//	- Client.DiagnosticRefresh
+//
+// Once the generated files declare these types, this file should be
+// deleted.
+
+// DiagnosticClientCapabilities are the client capabilities specific to
+// diagnostic pull requests.
+type DiagnosticClientCapabilities struct {
+	// DynamicRegistration reports whether the client supports dynamic
+	// registration of the diagnostic provider.
+	DynamicRegistration bool `json:"dynamicRegistration,omitempty"`
+	// RelatedDocumentSupport reports whether the client supports related
+	// documents for document diagnostic pulls.
+	RelatedDocumentSupport bool `json:"relatedDocumentSupport,omitempty"`
+}
+
+// DiagnosticWorkspaceClientCapabilities are the workspace client
+// capabilities specific to diagnostic pull requests.
+type DiagnosticWorkspaceClientCapabilities struct {
+	// RefreshSupport reports whether the client supports the
+	// workspace/diagnostic/refresh request, which asks it to pull all of
+	// the diagnostics it shows again.
+	RefreshSupport bool `json:"refreshSupport,omitempty"`
+}
+
+// DiagnosticOptions are the server options of pull diagnostics.
+type DiagnosticOptions struct {
+	// Identifier is an optional identifier under which the diagnostics are
+	// managed by the client.
+	Identifier string `json:"identifier,omitempty"`
+	// InterFileDependencies reports whether editing one file may change
+	// the diagnostics of another.
+	InterFileDependencies bool `json:"interFileDependencies"`
+	// WorkspaceDiagnostics reports whether the server supports the
+	// workspace/diagnostic request.
+	WorkspaceDiagnostics bool `json:"workspaceDiagnostics"`
+	WorkDoneProgressOptions
+}
+
+// DocumentDiagnosticParams are the parameters of the textDocument/diagnostic
+// request.
+type DocumentDiagnosticParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	// Identifier is the identifier provided during registration.
+	Identifier string `json:"identifier,omitempty"`
+	// PreviousResultID is the result id of the previous report of the
+	// document, if any.
+	PreviousResultID string `json:"previousResultId,omitempty"`
+	WorkDoneProgressParams
+	PartialResultParams
+}
+
+// DocumentDiagnosticReportKind is the kind of a document diagnostic report.
+type DocumentDiagnosticReportKind string
+
+const (
+	// DiagnosticFull is the kind of a report with the full set of
+	// diagnostics of a document.
+	DiagnosticFull DocumentDiagnosticReportKind = "full"
+	// DiagnosticUnchanged is the kind of a report indicating that the
+	// previous report of a document is still accurate.
+	DiagnosticUnchanged DocumentDiagnosticReportKind = "unchanged"
+)
+
+// FullDocumentDiagnosticReport is a report with the full set of diagnostics
+// of a document.
+type FullDocumentDiagnosticReport struct {
+	Kind DocumentDiagnosticReportKind `json:"kind"`
+	// ResultID is an optional result id, which the client sends with the
+	// next diagnostic request for the same document.
+	ResultID string       `json:"resultId,omitempty"`
+	Items    []Diagnostic `json:"items"`
+}
+
+// UnchangedDocumentDiagnosticReport is a report indicating that the
+// previous report of a document is still accurate. It may only be returned
+// if the client provided a previous result id.
+type UnchangedDocumentDiagnosticReport struct {
+	Kind DocumentDiagnosticReportKind `json:"kind"`
+	// ResultID is the result id, which the client sends with the next
+	// diagnostic request for the same document.
+	ResultID string `json:"resultId"`
+}
+
+// PreviousResultID is a previous result id of a document in a workspace
+// diagnostic request.
+type PreviousResultID struct {
+	URI   DocumentURI `json:"uri"`
+	Value string      `json:"value"`
+}
+
+// WorkspaceDiagnosticParams are the parameters of the workspace/diagnostic
+// request.
+type WorkspaceDiagnosticParams struct {
+	// Identifier is the identifier provided during registration.
+	Identifier string `json:"identifier,omitempty"`
+	// PreviousResultIds are the result ids of the reports the client
+	// already has.
+	PreviousResultIds []PreviousResultID `json:"previousResultIds"`
+	WorkDoneProgressParams
+	PartialResultParams
+}
+
+// WorkspaceDiagnosticReport is the result of the workspace/diagnostic
+// request.
+type WorkspaceDiagnosticReport struct {
+	Items []WorkspaceDocumentDiagnosticReport `json:"items"`
+}
+
+// WorkspaceDiagnosticReportPartialResult is a partial result of the
+// workspace/diagnostic request.
+type WorkspaceDiagnosticReportPartialResult struct {
+	Items []WorkspaceDocumentDiagnosticReport `json:"items"`
+}
+
+// WorkspaceDocumentDiagnosticReport is a
+// *WorkspaceFullDocumentDiagnosticReport or a
+// *WorkspaceUnchangedDocumentDiagnosticReport.
+type WorkspaceDocumentDiagnosticReport = interface{}
+
+// WorkspaceFullDocumentDiagnosticReport is a full report of a document in
+// a workspace diagnostic result.
+type WorkspaceFullDocumentDiagnosticReport struct {
+	URI DocumentURI `json:"uri"`
+	// Version is the version of the document the diagnostics are
+	// reported for, or 0 if it is not open.
+	Version int32 `json:"version"`
+	FullDocumentDiagnosticReport
+}
+
+// WorkspaceUnchangedDocumentDiagnosticReport is an unchanged report of a
+// document in a workspace diagnostic result.
+type WorkspaceUnchangedDocumentDiagnosticReport struct {
+	URI DocumentURI `json:"uri"`
+	// Version is the version of the document the diagnostics are
+	// reported for, or 0 if it is not open.
+	Version int32 `json:"version"`
+	UnchangedDocumentDiagnosticReport
+}
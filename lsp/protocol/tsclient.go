@@ -32,6 +32,7 @@ type Client interface {
 	UnregisterCapability(context.Context, *UnregistrationParams) error
 	ShowMessageRequest(context.Context, *ShowMessageRequestParams) (*MessageActionItem /*MessageActionItem | null*/, error)
 	ApplyEdit(context.Context, *ApplyWorkspaceEditParams) (*ApplyWorkspaceEditResponse, error)
+	DiagnosticRefresh(context.Context) error
 }
 
 func clientDispatch(ctx context.Context, client Client, reply jsonrpc2.Replier, r jsonrpc2.Request) (bool, error) {
@@ -119,6 +120,12 @@ func clientDispatch(ctx context.Context, client Client, reply jsonrpc2.Replier,
 		}
 		resp, err := client.ApplyEdit(ctx, &params)
 		return true, reply(ctx, resp, err)
+	case "workspace/diagnostic/refresh": // req
+		if len(r.Params()) > 0 {
+			return true, reply(ctx, nil, errors.Errorf("%w: expected no params", jsonrpc2.ErrInvalidParams))
+		}
+		err := client.DiagnosticRefresh(ctx)
+		return true, reply(ctx, nil, err)
 
 	default:
 		return false, nil
@@ -187,3 +194,7 @@ func (s *clientDispatcher) ApplyEdit(ctx context.Context, params *ApplyWorkspace
 	}
 	return result, nil
 }
+
+func (s *clientDispatcher) DiagnosticRefresh(ctx context.Context) error {
+	return Call(ctx, s.Conn, "workspace/diagnostic/refresh", nil, nil)
+}
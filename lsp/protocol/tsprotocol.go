@@ -3528,6 +3528,12 @@ type ServerCapabilities struct {
 	 * @since 3.16.0
 	 */
 	MonikerProvider interface{}/* bool | MonikerOptions | MonikerRegistrationOptions*/ `json:"monikerProvider,omitempty"`
+	/**
+	 * The server has support for pull model diagnostics.
+	 *
+	 * @since 3.17.0 - proposed state
+	 */
+	DiagnosticProvider *DiagnosticOptions `json:"diagnosticProvider,omitempty"`
 	/**
 	 * Experimental server capabilities.
 	 */
@@ -4011,6 +4017,12 @@ type TextDocumentClientCapabilities struct {
 	 * @since 3.16.0
 	 */
 	Moniker MonikerClientCapabilities `json:"moniker,omitempty"`
+	/**
+	 * Capabilities specific to the diagnostic pull model.
+	 *
+	 * @since 3.17.0 - proposed state
+	 */
+	Diagnostic *DiagnosticClientCapabilities `json:"diagnostic,omitempty"`
 }
 
 /**
@@ -4478,6 +4490,13 @@ type WorkspaceClientCapabilities struct {
 	 * @since 3.16.0.
 	 */
 	CodeLens CodeLensWorkspaceClientCapabilities `json:"codeLens,omitempty"`
+	/**
+	 * Capabilities specific to the diagnostic requests scoped to the
+	 * workspace.
+	 *
+	 * @since 3.17.0 - proposed state
+	 */
+	Diagnostics DiagnosticWorkspaceClientCapabilities `json:"diagnostics,omitempty"`
 	/**
 	 * The client has support for file notifications/requests for user operations on files.
 	 *
@@ -5183,6 +5202,14 @@ type Workspace2Gn struct {
 	 */
 	CodeLens CodeLensWorkspaceClientCapabilities `json:"codeLens,omitempty"`
 
+	/**
+	 * Capabilities specific to the diagnostic requests scoped to the
+	 * workspace.
+	 *
+	 * @since 3.17.0 - proposed state
+	 */
+	Diagnostics DiagnosticWorkspaceClientCapabilities `json:"diagnostics,omitempty"`
+
 	/**
 	 * The client has support for file notifications/requests for user operations on files.
 	 *
@@ -5253,6 +5280,14 @@ type Workspace3Gn struct {
 	 */
 	CodeLens CodeLensWorkspaceClientCapabilities `json:"codeLens,omitempty"`
 
+	/**
+	 * Capabilities specific to the diagnostic requests scoped to the
+	 * workspace.
+	 *
+	 * @since 3.17.0 - proposed state
+	 */
+	Diagnostics DiagnosticWorkspaceClientCapabilities `json:"diagnostics,omitempty"`
+
 	/**
 	 * The client has support for file notifications/requests for user operations on files.
 	 *
@@ -5350,6 +5385,14 @@ type Workspace6Gn struct {
 	 */
 	CodeLens CodeLensWorkspaceClientCapabilities `json:"codeLens,omitempty"`
 
+	/**
+	 * Capabilities specific to the diagnostic requests scoped to the
+	 * workspace.
+	 *
+	 * @since 3.17.0 - proposed state
+	 */
+	Diagnostics DiagnosticWorkspaceClientCapabilities `json:"diagnostics,omitempty"`
+
 	/**
 	 * The client has support for file notifications/requests for user operations on files.
 	 *
@@ -56,6 +56,8 @@ type Server interface {
 	WillRenameFiles(context.Context, *RenameFilesParams) (*WorkspaceEdit /*WorkspaceEdit | null*/, error)
 	WillDeleteFiles(context.Context, *DeleteFilesParams) (*WorkspaceEdit /*WorkspaceEdit | null*/, error)
 	Moniker(context.Context, *MonikerParams) ([]Moniker /*Moniker[] | null*/, error)
+	Diagnostic(context.Context, *DocumentDiagnosticParams) (interface{} /*FullDocumentDiagnosticReport | UnchangedDocumentDiagnosticReport*/, error)
+	DiagnosticWorkspace(context.Context, *WorkspaceDiagnosticParams) (*WorkspaceDiagnosticReport, error)
 	Initialize(context.Context, *ParamInitialize) (*InitializeResult, error)
 	Shutdown(context.Context) error
 	WillSaveWaitUntil(context.Context, *WillSaveTextDocumentParams) ([]TextEdit /*TextEdit[] | null*/, error)
@@ -333,6 +335,20 @@ func serverDispatch(ctx context.Context, server Server, reply jsonrpc2.Replier,
 		}
 		resp, err := server.Moniker(ctx, &params)
 		return true, reply(ctx, resp, err)
+	case "textDocument/diagnostic": // req
+		var params DocumentDiagnosticParams
+		if err := json.Unmarshal(r.Params(), &params); err != nil {
+			return true, sendParseError(ctx, reply, err)
+		}
+		resp, err := server.Diagnostic(ctx, &params)
+		return true, reply(ctx, resp, err)
+	case "workspace/diagnostic": // req
+		var params WorkspaceDiagnosticParams
+		if err := json.Unmarshal(r.Params(), &params); err != nil {
+			return true, sendParseError(ctx, reply, err)
+		}
+		resp, err := server.DiagnosticWorkspace(ctx, &params)
+		return true, reply(ctx, resp, err)
 	case "initialize": // req
 		var params ParamInitialize
 		if err := json.Unmarshal(r.Params(), &params); err != nil {
@@ -731,6 +747,22 @@ func (s *serverDispatcher) Moniker(ctx context.Context, params *MonikerParams) (
 	return result, nil
 }
 
+func (s *serverDispatcher) Diagnostic(ctx context.Context, params *DocumentDiagnosticParams) (interface{} /*FullDocumentDiagnosticReport | UnchangedDocumentDiagnosticReport*/, error) {
+	var result interface{} /*FullDocumentDiagnosticReport | UnchangedDocumentDiagnosticReport*/
+	if err := Call(ctx, s.Conn, "textDocument/diagnostic", params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *serverDispatcher) DiagnosticWorkspace(ctx context.Context, params *WorkspaceDiagnosticParams) (*WorkspaceDiagnosticReport, error) {
+	var result *WorkspaceDiagnosticReport
+	if err := Call(ctx, s.Conn, "workspace/diagnostic", params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 func (s *serverDispatcher) Initialize(ctx context.Context, params *ParamInitialize) (*InitializeResult, error) {
 	var result *InitializeResult
 	if err := Call(ctx, s.Conn, "initialize", params, &result); err != nil {
@@ -0,0 +1,237 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lsp
+
+import (
+	"context"
+	"sort"
+
+	"github.com/kevinswiber/languageserver-go/event"
+	"github.com/kevinswiber/languageserver-go/lsp/debug/tag"
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+	"github.com/kevinswiber/languageserver-go/span"
+)
+
+// diagnostic returns the diagnostics of a single document, as of the
+// latest snapshot. The result id of the report is the hash of its
+// diagnostics, so a report is unchanged if the client's previous result id
+// matches the hash.
+func (s *Server) diagnostic(ctx context.Context, params *protocol.DocumentDiagnosticParams) (interface{}, error) {
+	ctx, done := event.Start(ctx, "lsp.Server.diagnostic")
+	defer done()
+
+	snapshot, fh, ok, release, err := s.beginFileRequest(ctx, params.TextDocument.URI, source.UnknownKind)
+	defer release()
+	if !ok {
+		return nil, err
+	}
+	s.diagnoseFile(ctx, snapshot, fh)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	diags := s.snapshotDiagnostics(snapshot, fh.URI())
+	resultID := hashDiagnostics(diags...)
+	if resultID == params.PreviousResultID {
+		return &protocol.UnchangedDocumentDiagnosticReport{
+			Kind:     protocol.DiagnosticUnchanged,
+			ResultID: resultID,
+		}, nil
+	}
+	return &protocol.FullDocumentDiagnosticReport{
+		Kind:     protocol.DiagnosticFull,
+		ResultID: resultID,
+		Items:    toProtocolDiagnostics(diags),
+	}, nil
+}
+
+// diagnosticWorkspace returns the diagnostics of every file in the
+// workspace that has diagnostics, or that the client has a previous result
+// for. Each view is diagnosed in full before it is reported. If the client
+// provides a partial result token, the reports of each view are sent as
+// partial results as soon as they are ready, and the final result is empty.
+func (s *Server) diagnosticWorkspace(ctx context.Context, params *protocol.WorkspaceDiagnosticParams) (*protocol.WorkspaceDiagnosticReport, error) {
+	ctx, done := event.Start(ctx, "lsp.Server.diagnosticWorkspace")
+	defer done()
+
+	previous := make(map[span.URI]string)
+	for _, prev := range params.PreviousResultIds {
+		previous[prev.URI.SpanURI()] = prev.Value
+	}
+
+	result := &protocol.WorkspaceDiagnosticReport{
+		Items: []protocol.WorkspaceDocumentDiagnosticReport{},
+	}
+	for _, view := range s.session.Views() {
+		snapshot, release := view.Snapshot(ctx)
+		s.diagnose(ctx, snapshot, false)
+		s.diagnoseLowPriority(ctx, snapshot)
+		items := s.workspaceDiagnosticReports(snapshot, previous)
+		release()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if params.PartialResultToken == nil {
+			result.Items = append(result.Items, items...)
+			continue
+		}
+		if len(items) == 0 {
+			continue
+		}
+		if err := s.client.Progress(ctx, &protocol.ProgressParams{
+			Token: params.PartialResultToken,
+			Value: &protocol.WorkspaceDiagnosticReportPartialResult{Items: items},
+		}); err != nil {
+			event.Error(ctx, "diagnosticWorkspace: failed to report partial result", err, tag.Snapshot.Of(snapshot.ID()))
+		}
+	}
+	return result, nil
+}
+
+// diagnoseFile diagnoses the file, and the packages it belongs to, in the
+// snapshot. Unlike diagnosing the workspace, it always runs the analyses.
+func (s *Server) diagnoseFile(ctx context.Context, snapshot source.Snapshot, fh source.VersionedFileHandle) {
+	ctx, done := event.Start(ctx, "Server.diagnoseFile", tag.Snapshot.Of(snapshot.ID()), tag.URI.Of(fh.URI()))
+	defer done()
+
+	// Wait for a free diagnostics slot.
+	select {
+	case <-ctx.Done():
+		return
+	case s.diagnosticsSema <- struct{}{}:
+	}
+	defer func() {
+		<-s.diagnosticsSema
+	}()
+
+	switch fh.Kind() {
+	case source.Mod, source.Sum, source.Work:
+		s.diagnoseModFiles(ctx, snapshot)
+	case source.Go:
+		pkgs, err := snapshot.PackagesForFile(ctx, fh.URI(), source.TypecheckFull)
+		if err != nil || len(pkgs) == 0 {
+			diagnostic := s.checkForOrphanedFile(ctx, snapshot, fh)
+			if diagnostic == nil {
+				return
+			}
+			// As when diagnosing the workspace, files that build under
+			// other configurations are not orphaned.
+			if inBuildMatrix(ctx, snapshot, fh.URI()) {
+				s.diagnoseBuildMatrix(ctx, snapshot, fh.URI())
+				return
+			}
+			if s.diagnoseExcludedFile(ctx, snapshot, fh) {
+				return
+			}
+			s.storeDiagnostics(snapshot, fh.URI(), orphanedSource, []*source.Diagnostic{diagnostic})
+			return
+		}
+		for _, pkg := range pkgs {
+			s.diagnosePkg(ctx, snapshot, pkg, true)
+		}
+		s.diagnoseBuildMatrix(ctx, snapshot, fh.URI())
+		if snapshot.View().Options().UnusedExportedDiagnostics {
+			s.diagnoseUnusedExported(ctx, snapshot)
+		}
+	}
+}
+
+// snapshotDiagnostics returns the stored diagnostics of the file that are
+// current as of the snapshot, with the overrides of the view's options
+// applied, so that the result ids change with the overrides.
+func (s *Server) snapshotDiagnostics(snapshot source.Snapshot, uri span.URI) []*source.Diagnostic {
+	s.diagnosticsMu.Lock()
+	defer s.diagnosticsMu.Unlock()
+
+	r := s.diagnostics[uri]
+	if r == nil {
+		return nil
+	}
+	var diags []*source.Diagnostic
+	for _, report := range r.reports {
+		if report.snapshotID < snapshot.ID() {
+			continue
+		}
+		for _, d := range report.diags {
+			diags = append(diags, d)
+		}
+	}
+	source.SortDiagnostics(diags)
+	return applyDiagnosticOverrides(snapshot.View().Options(), diags)
+}
+
+// workspaceDiagnosticReports returns the reports of the files of the
+// snapshot that have diagnostics or previous results.
+func (s *Server) workspaceDiagnosticReports(snapshot source.Snapshot, previous map[span.URI]string) []protocol.WorkspaceDocumentDiagnosticReport {
+	s.diagnosticsMu.Lock()
+	uris := make(map[span.URI]bool)
+	for uri := range s.diagnostics {
+		uris[uri] = true
+	}
+	s.diagnosticsMu.Unlock()
+	for uri := range previous {
+		uris[uri] = true
+	}
+
+	var sorted []span.URI
+	for uri := range uris {
+		sorted = append(sorted, uri)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	reports := []protocol.WorkspaceDocumentDiagnosticReport{}
+	for _, uri := range sorted {
+		// Each file is reported once, by its own view.
+		if !s.ownsFile(snapshot.View(), uri) {
+			continue
+		}
+		fh := snapshot.FindFile(uri)
+		if fh == nil {
+			continue
+		}
+		diags := s.snapshotDiagnostics(snapshot, uri)
+		prev, ok := previous[uri]
+		if len(diags) == 0 && !ok {
+			continue
+		}
+		resultID := hashDiagnostics(diags...)
+		if resultID == prev {
+			reports = append(reports, &protocol.WorkspaceUnchangedDocumentDiagnosticReport{
+				URI:     protocol.URIFromSpanURI(uri),
+				Version: fh.Version(),
+				UnchangedDocumentDiagnosticReport: protocol.UnchangedDocumentDiagnosticReport{
+					Kind:     protocol.DiagnosticUnchanged,
+					ResultID: resultID,
+				},
+			})
+			continue
+		}
+		reports = append(reports, &protocol.WorkspaceFullDocumentDiagnosticReport{
+			URI:     protocol.URIFromSpanURI(uri),
+			Version: fh.Version(),
+			FullDocumentDiagnosticReport: protocol.FullDocumentDiagnosticReport{
+				Kind:     protocol.DiagnosticFull,
+				ResultID: resultID,
+				Items:    toProtocolDiagnostics(diags),
+			},
+		})
+	}
+	return reports
+}
+
+// ownsFile reports whether the diagnostics of the file are stored with the
+// snapshots of the view. The views of the files that the build constraints
+// exclude are derived from the view of the same folder.
+func (s *Server) ownsFile(view source.View, uri span.URI) bool {
+	v, err := s.session.ViewOf(uri)
+	if err != nil {
+		return false
+	}
+	if v.BuildConfig() != nil {
+		return v.Folder() == view.Folder() && view.BuildConfig() == nil
+	}
+	return v == view
+}
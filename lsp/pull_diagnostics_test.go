@@ -0,0 +1,277 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kevinswiber/languageserver-go/jsonrpc2"
+	"github.com/kevinswiber/languageserver-go/jsonrpc2/servertest"
+	"github.com/kevinswiber/languageserver-go/lsp/cache"
+	"github.com/kevinswiber/languageserver-go/lsp/fake"
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+)
+
+const pullDiagnosticsProgram = `
+-- go.mod --
+module mod.com
+
+go 1.12
+-- a.go --
+package a
+
+var x int = "x"
+-- b.go --
+package a
+
+var y = x
+`
+
+// newPipeServer returns a server of a new session over a pipe.
+func newPipeServer(ctx context.Context) *servertest.PipeServer {
+	c := cache.New(ctx, nil)
+	return servertest.NewPipeServer(ctx, jsonrpc2.ServerFunc(func(ctx context.Context, conn jsonrpc2.Conn) error {
+		server := NewServer(c.NewSession(ctx), protocol.ClientDispatcher(conn))
+		conn.Go(ctx, protocol.Handlers(protocol.ServerHandler(server, jsonrpc2.MethodNotFound)))
+		<-conn.Done()
+		return conn.Err()
+	}), nil)
+}
+
+func TestPullDiagnostics(t *testing.T) {
+	sb, err := fake.NewSandbox(&fake.SandboxConfig{Files: pullDiagnosticsProgram})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sb.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	ts := newPipeServer(ctx)
+
+	published := make(chan *protocol.PublishDiagnosticsParams, 100)
+	refreshed := make(chan struct{}, 100)
+	editor, err := fake.NewEditor(sb, fake.EditorConfig{PullDiagnostics: true}).Connect(ctx, ts.Connect(ctx), fake.ClientHooks{
+		OnDiagnostics: func(_ context.Context, params *protocol.PublishDiagnosticsParams) error {
+			published <- params
+			return nil
+		},
+		OnDiagnosticRefresh: func(context.Context) error {
+			refreshed <- struct{}{}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer editor.Close(ctx)
+
+	if err := editor.OpenFile(ctx, "a.go"); err != nil {
+		t.Fatal(err)
+	}
+	// Once the file is diagnosed, the client is asked to pull its
+	// diagnostics instead of having them published.
+	select {
+	case <-refreshed:
+	case <-ctx.Done():
+		t.Fatal("the client was not asked to refresh diagnostics")
+	}
+	select {
+	case params := <-published:
+		t.Errorf("diagnostics of %s were published to a client that pulls them", params.URI)
+	default:
+	}
+
+	pull := func(previous string) protocol.FullDocumentDiagnosticReport {
+		t.Helper()
+		result, err := editor.Server.Diagnostic(ctx, &protocol.DocumentDiagnosticParams{
+			TextDocument:     protocol.TextDocumentIdentifier{URI: sb.Workdir.URI("a.go")},
+			PreviousResultID: previous,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The dispatcher decodes the union of reports generically.
+		data, err := json.Marshal(result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var report protocol.FullDocumentDiagnosticReport
+		if err := json.Unmarshal(data, &report); err != nil {
+			t.Fatal(err)
+		}
+		return report
+	}
+	full := pull("")
+	if full.Kind != protocol.DiagnosticFull || len(full.Items) != 1 || full.ResultID == "" {
+		t.Fatalf("first pull = %+v, want a full report with one diagnostic", full)
+	}
+	if unchanged := pull(full.ResultID); unchanged.Kind != protocol.DiagnosticUnchanged || unchanged.ResultID != full.ResultID {
+		t.Errorf("pull with the current result id = %+v, want an unchanged report with id %q", unchanged, full.ResultID)
+	}
+	if stale := pull("stale"); stale.Kind != protocol.DiagnosticFull || len(stale.Items) != 1 {
+		t.Errorf("pull with a stale result id = %+v, want a full report", stale)
+	}
+
+	// Workspace reports include the files the client has previous results
+	// for, and are unchanged if the results are current.
+	workspace, err := editor.Server.DiagnosticWorkspace(ctx, &protocol.WorkspaceDiagnosticParams{
+		PreviousResultIds: []protocol.PreviousResultID{
+			{URI: sb.Workdir.URI("a.go"), Value: full.ResultID},
+			{URI: sb.Workdir.URI("b.go"), Value: "stale"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := make(map[protocol.DocumentURI]protocol.DocumentDiagnosticReportKind)
+	for _, item := range workspace.Items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var report protocol.WorkspaceFullDocumentDiagnosticReport
+		if err := json.Unmarshal(data, &report); err != nil {
+			t.Fatal(err)
+		}
+		kinds[report.URI] = report.Kind
+	}
+	want := map[protocol.DocumentURI]protocol.DocumentDiagnosticReportKind{
+		sb.Workdir.URI("a.go"): protocol.DiagnosticUnchanged,
+		sb.Workdir.URI("b.go"): protocol.DiagnosticFull,
+	}
+	for uri, kind := range want {
+		if kinds[uri] != kind {
+			t.Errorf("workspace report of %s has kind %q, want %q", uri, kinds[uri], kind)
+		}
+	}
+
+	// Fixing the error changes the report.
+	if err := editor.SetBufferContent(ctx, "a.go", "package a\n\nvar x int = 1\n"); err != nil {
+		t.Fatal(err)
+	}
+	if fixed := pull(full.ResultID); fixed.Kind != protocol.DiagnosticFull || len(fixed.Items) != 0 || fixed.ResultID == full.ResultID {
+		t.Errorf("pull after the fix = %+v, want a full report with no diagnostics", fixed)
+	}
+	select {
+	case params := <-published:
+		t.Errorf("diagnostics of %s were published to a client that pulls them", params.URI)
+	default:
+	}
+}
+
+const nestedModulesProgram = `
+-- go.mod --
+module mod.com
+
+go 1.12
+-- a.go --
+package a
+
+var x int = "x"
+-- b/go.mod --
+module b.com
+
+go 1.12
+-- b/b.go --
+package b
+
+var y int = "y"
+`
+
+func TestWorkspaceDiagnosticReportsByView(t *testing.T) {
+	sb, err := fake.NewSandbox(&fake.SandboxConfig{Files: nestedModulesProgram})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sb.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	ts := newPipeServer(ctx)
+	editor, err := fake.NewEditor(sb, fake.EditorConfig{
+		PullDiagnostics:  true,
+		WorkspaceFolders: []string{".", "b"},
+	}).Connect(ctx, ts.Connect(ctx), fake.ClientHooks{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer editor.Close(ctx)
+
+	// The open file of the nested module is in the snapshots of both views.
+	if err := editor.OpenFile(ctx, "b/b.go"); err != nil {
+		t.Fatal(err)
+	}
+	workspace, err := editor.Server.DiagnosticWorkspace(ctx, &protocol.WorkspaceDiagnosticParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reported := make(map[string]int)
+	for _, item := range workspace.Items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var report protocol.WorkspaceFullDocumentDiagnosticReport
+		if err := json.Unmarshal(data, &report); err != nil {
+			t.Fatal(err)
+		}
+		reported[sb.Workdir.URIToPath(report.URI)]++
+	}
+	want := map[string]int{"a.go": 1, "b/b.go": 1}
+	if !reflect.DeepEqual(reported, want) {
+		t.Errorf("reports per file = %v, want %v", reported, want)
+	}
+}
+
+const buildMatrixProgram = `
+-- go.mod --
+module mod.com
+
+go 1.12
+-- a.go --
+package a
+-- a_windows.go --
+package a
+
+var x int = "x"
+`
+
+func TestDiagnoseFileBuildMatrix(t *testing.T) {
+	sb, err := fake.NewSandbox(&fake.SandboxConfig{Files: buildMatrixProgram})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sb.Close()
+
+	ctx := context.Background()
+	session := cache.New(ctx, nil).NewSession(ctx)
+	options := source.DefaultOptions().Clone()
+	options.Env = map[string]string{"GOPACKAGESDRIVER": "off", "GOOS": "linux"}
+	options.BuildMatrix = []source.BuildConfig{{GOOS: "windows"}}
+	view, snapshot, release, err := session.NewView(ctx, "lsp_test", sb.Workdir.RootURI().SpanURI(), "", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer view.Shutdown(ctx)
+	defer release()
+
+	// Pulling the diagnostics of a file refreshes those of the build
+	// matrix too.
+	s := NewServer(session, nil)
+	fh, err := snapshot.GetVersionedFile(ctx, sb.Workdir.URI("a.go").SpanURI())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.diagnoseFile(ctx, snapshot, fh)
+	if diags := s.snapshotDiagnostics(snapshot, sb.Workdir.URI("a_windows.go").SpanURI()); len(diags) != 1 {
+		t.Errorf("diagnostics of a_windows.go = %v, want the error under windows", diags)
+	}
+}
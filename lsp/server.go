@@ -25,6 +25,7 @@ func NewServer(session source.Session, client protocol.Client) *Server {
 	return &Server{
 		diagnostics:           map[span.URI]*fileReports{},
 		gcOptimizationDetails: make(map[string]struct{}),
+		unusedExported:        make(map[source.View]unusedExportedReports),
 		watchedGlobPatterns:   make(map[string]struct{}),
 		changedFiles:          make(map[span.URI]struct{}),
 		session:               session,
@@ -95,6 +96,11 @@ type Server struct {
 	gcOptimizationDetailsMu sync.Mutex
 	gcOptimizationDetails   map[string]struct{}
 
+	// unusedExported memoizes the unused exported identifier diagnostics of
+	// the latest snapshot of each view.
+	unusedExportedMu sync.Mutex
+	unusedExported   map[source.View]unusedExportedReports
+
 	// diagnosticsSema limits the concurrency of diagnostics runs, which can be
 	// expensive.
 	diagnosticsSema chan struct{}
@@ -131,7 +137,7 @@ func (s *Server) nonstandardRequest(ctx context.Context, method string, params i
 			}
 			if err := s.client.PublishDiagnostics(ctx, &protocol.PublishDiagnosticsParams{
 				URI:         protocol.URIFromSpanURI(fh.URI()),
-				Diagnostics: toProtocolDiagnostics(diagnostics),
+				Diagnostics: toProtocolDiagnostics(applyDiagnosticOverrides(snapshot.View().Options(), diagnostics)),
 				Version:     fileID.Version,
 			}); err != nil {
 				return nil, err
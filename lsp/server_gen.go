@@ -40,6 +40,14 @@ func (s *Server) Definition(ctx context.Context, params *protocol.DefinitionPara
 	return s.definition(ctx, params)
 }
 
+func (s *Server) Diagnostic(ctx context.Context, params *protocol.DocumentDiagnosticParams) (interface{}, error) {
+	return s.diagnostic(ctx, params)
+}
+
+func (s *Server) DiagnosticWorkspace(ctx context.Context, params *protocol.WorkspaceDiagnosticParams) (*protocol.WorkspaceDiagnosticReport, error) {
+	return s.diagnosticWorkspace(ctx, params)
+}
+
 func (s *Server) DidChange(ctx context.Context, params *protocol.DidChangeTextDocumentParams) error {
 	return s.didChange(ctx, params)
 }
@@ -0,0 +1,324 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package snippet
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A Template is a parsed snippet in LSP snippet syntax, such as
+// "for ${1:i} := 0; $1 < ${2:n}; $1++ {\n\t$0\n}". Its variables, such as
+// $receiver, are expanded when the template is written to a Builder.
+type Template struct {
+	nodes []node
+}
+
+type node interface{}
+
+// textNode is literal text.
+type textNode string
+
+// tabStopNode is a tab stop, a placeholder, or a choice.
+type tabStopNode struct {
+	index       int
+	placeholder []node // nil for a plain tab stop
+	hasDefault  bool
+	choices     []string
+}
+
+// variableNode is a variable with optional default content.
+type variableNode struct {
+	name string
+	def  []node
+}
+
+// ParseTemplate parses a snippet in LSP snippet syntax.
+func ParseTemplate(s string) (*Template, error) {
+	p := &templateParser{s: s}
+	nodes, err := p.parse(false)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{nodes: nodes}, nil
+}
+
+type templateParser struct {
+	s   string
+	pos int
+}
+
+func (p *templateParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+// parse parses nodes up to the end of the input or, if nested, up to an
+// unescaped "}", which it consumes.
+func (p *templateParser) parse(nested bool) ([]node, error) {
+	var nodes []node
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			nodes = append(nodes, textNode(text.String()))
+			text.Reset()
+		}
+	}
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		switch {
+		case c == '\\' && p.pos+1 < len(p.s) && strings.IndexByte(`\$}`, p.s[p.pos+1]) >= 0:
+			text.WriteByte(p.s[p.pos+1])
+			p.pos += 2
+		case c == '}' && nested:
+			p.pos++
+			flush()
+			return nodes, nil
+		case c == '$':
+			n, err := p.parseDollar()
+			if err != nil {
+				return nil, err
+			}
+			if n == nil {
+				text.WriteByte('$')
+				continue
+			}
+			flush()
+			nodes = append(nodes, n)
+		default:
+			text.WriteByte(c)
+			p.pos++
+		}
+	}
+	if nested {
+		return nil, p.errorf("missing }")
+	}
+	flush()
+	return nodes, nil
+}
+
+// parseDollar parses a tab stop, placeholder, choice, or variable that
+// begins with the "$" at the current position. It returns nil if the "$"
+// does not begin one.
+func (p *templateParser) parseDollar() (node, error) {
+	p.pos++ // '$'
+	if p.pos < len(p.s) && p.s[p.pos] == '{' {
+		p.pos++
+		if i := p.scanInt(); i >= 0 {
+			if p.pos >= len(p.s) {
+				return nil, p.errorf("missing }")
+			}
+			switch p.s[p.pos] {
+			case '}':
+				p.pos++
+				return &tabStopNode{index: i}, nil
+			case ':':
+				p.pos++
+				children, err := p.parse(true)
+				if err != nil {
+					return nil, err
+				}
+				return &tabStopNode{index: i, placeholder: children, hasDefault: true}, nil
+			case '|':
+				p.pos++
+				choices, err := p.parseChoices()
+				if err != nil {
+					return nil, err
+				}
+				return &tabStopNode{index: i, choices: choices}, nil
+			}
+			return nil, p.errorf("unexpected %q in tab stop", p.s[p.pos])
+		}
+		name := p.scanVar()
+		if name == "" {
+			return nil, p.errorf("missing tab stop or variable name")
+		}
+		if p.pos >= len(p.s) {
+			return nil, p.errorf("missing }")
+		}
+		switch p.s[p.pos] {
+		case '}':
+			p.pos++
+			return &variableNode{name: name}, nil
+		case ':':
+			p.pos++
+			def, err := p.parse(true)
+			if err != nil {
+				return nil, err
+			}
+			return &variableNode{name: name, def: def}, nil
+		}
+		return nil, p.errorf("unexpected %q in variable", p.s[p.pos])
+	}
+	if i := p.scanInt(); i >= 0 {
+		return &tabStopNode{index: i}, nil
+	}
+	if name := p.scanVar(); name != "" {
+		return &variableNode{name: name}, nil
+	}
+	return nil, nil
+}
+
+// parseChoices parses the choices of a choice tab stop, after the "|".
+func (p *templateParser) parseChoices() ([]string, error) {
+	var choices []string
+	var choice strings.Builder
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		switch {
+		case c == '\\' && p.pos+1 < len(p.s) && strings.IndexByte(`\$}|,`, p.s[p.pos+1]) >= 0:
+			choice.WriteByte(p.s[p.pos+1])
+			p.pos += 2
+		case c == ',':
+			choices = append(choices, choice.String())
+			choice.Reset()
+			p.pos++
+		case c == '|':
+			if p.pos+1 >= len(p.s) || p.s[p.pos+1] != '}' {
+				return nil, p.errorf("missing } after choices")
+			}
+			p.pos += 2
+			return append(choices, choice.String()), nil
+		default:
+			choice.WriteByte(c)
+			p.pos++
+		}
+	}
+	return nil, p.errorf("missing |}")
+}
+
+// scanInt scans a decimal integer, returning -1 if there is none.
+func (p *templateParser) scanInt() int {
+	start := p.pos
+	for p.pos < len(p.s) && '0' <= p.s[p.pos] && p.s[p.pos] <= '9' {
+		p.pos++
+	}
+	if p.pos == start {
+		return -1
+	}
+	i, err := strconv.Atoi(p.s[start:p.pos])
+	if err != nil {
+		p.pos = start
+		return -1
+	}
+	return i
+}
+
+// scanVar scans a variable name: a letter or underscore followed by
+// letters, digits, and underscores.
+func (p *templateParser) scanVar() string {
+	start := p.pos
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		if c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || p.pos > start && '0' <= c && c <= '9' {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.s[start:p.pos]
+}
+
+// WriteTemplate writes the template t to the Builder. The tab stops of t
+// are renumbered to follow those already written, keeping their relative
+// order. Each variable is replaced by the text that vars returns for its
+// name; as in the LSP specification, a variable with no text becomes a
+// placeholder containing its default content, or its name.
+func (b *Builder) WriteTemplate(t *Template, vars func(name string) (string, bool)) {
+	w := &templateWriter{
+		b:       b,
+		vars:    vars,
+		indices: make(map[int]int),
+		written: make(map[int]bool),
+		unknown: make(map[string]int),
+	}
+
+	// Assign the template's numbered tab stops before any of the tab
+	// stops for unknown variables.
+	var numbers []int
+	var collect func([]node)
+	collect = func(nodes []node) {
+		for _, n := range nodes {
+			switch n := n.(type) {
+			case *tabStopNode:
+				if _, ok := w.indices[n.index]; !ok && n.index != 0 {
+					w.indices[n.index] = 0
+					numbers = append(numbers, n.index)
+				}
+				collect(n.placeholder)
+			case *variableNode:
+				collect(n.def)
+			}
+		}
+	}
+	collect(t.nodes)
+	sort.Ints(numbers)
+	for _, n := range numbers {
+		w.indices[n] = b.nextTabStop()
+	}
+	w.write(t.nodes)
+}
+
+type templateWriter struct {
+	b       *Builder
+	vars    func(string) (string, bool)
+	indices map[int]int    // template tab stop -> Builder tab stop
+	written map[int]bool   // Builder tab stops whose content was written
+	unknown map[string]int // unknown variable -> Builder tab stop
+}
+
+func (w *templateWriter) write(nodes []node) {
+	b := w.b
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case textNode:
+			b.WriteText(string(n))
+		case *tabStopNode:
+			if n.index == 0 {
+				b.WriteFinalTabstop()
+				continue
+			}
+			i := w.indices[n.index]
+			if w.written[i] || !n.hasDefault && n.choices == nil {
+				fmt.Fprintf(&b.sb, "$%d", i)
+				continue
+			}
+			w.written[i] = true
+			if n.choices != nil {
+				fmt.Fprintf(&b.sb, "${%d|", i)
+				for j, c := range n.choices {
+					if j != 0 {
+						b.sb.WriteByte(',')
+					}
+					choiceReplacer.WriteString(&b.sb, c)
+				}
+				b.sb.WriteString("|}")
+				continue
+			}
+			fmt.Fprintf(&b.sb, "${%d:", i)
+			w.write(n.placeholder)
+			b.sb.WriteByte('}')
+		case *variableNode:
+			if text, ok := w.vars(n.name); ok && text != "" {
+				b.WriteText(text)
+				continue
+			}
+			if i, ok := w.unknown[n.name]; ok {
+				fmt.Fprintf(&b.sb, "$%d", i)
+				continue
+			}
+			i := b.nextTabStop()
+			w.unknown[n.name] = i
+			fmt.Fprintf(&b.sb, "${%d:", i)
+			if n.def != nil {
+				w.write(n.def)
+			} else {
+				b.WriteText(n.name)
+			}
+			b.sb.WriteByte('}')
+		}
+	}
+}
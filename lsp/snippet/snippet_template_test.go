@@ -0,0 +1,78 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package snippet
+
+import (
+	"testing"
+)
+
+func TestWriteTemplate(t *testing.T) {
+	vars := func(name string) (string, bool) {
+		switch name {
+		case "receiver":
+			return "r", true
+		case "zeroValues":
+			return "0, nil", true
+		case "empty":
+			return "", true
+		}
+		return "", false
+	}
+	for _, test := range []struct {
+		tmpl, want string
+	}{
+		{"hi", "hi"},
+		{`a \$ \} \\ b $ c`, `a \$ \} \\ b \$ c`},
+		{"$0", "$0"},
+		{"${2:b} ${1:a} $2", "${2:b} ${1:a} $2"},
+		{"${5:x} $3 ${3:y}", "${2:x} $1 ${1:y}"},
+		{"${1|one,two\\,three|}", `${1|one,two\,three|}`},
+		{"${1:outer ${2:inner}}", "${1:outer ${2:inner}}"},
+		{"return $zeroValues", "return 0, nil"},
+		{"$receiver.${receiver}", "r.r"},
+		{"$empty $unknown ${other:x$receiver} $unknown", "${1:empty} ${2:unknown} ${3:xr} $2"},
+		{"${1:a} $unknown", "${1:a} ${2:unknown}"},
+		{"func (${receiver}) {}", `func (r) {\}`},
+	} {
+		tmpl, err := ParseTemplate(test.tmpl)
+		if err != nil {
+			t.Errorf("ParseTemplate(%q): %v", test.tmpl, err)
+			continue
+		}
+		var b Builder
+		b.WriteTemplate(tmpl, vars)
+		if got := b.String(); got != test.want {
+			t.Errorf("WriteTemplate(%q) = %q, want %q", test.tmpl, got, test.want)
+		}
+	}
+
+	// Tab stops follow those already written.
+	tmpl, err := ParseTemplate("${1:a} $0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b Builder
+	b.WritePlaceholder(nil)
+	b.WriteTemplate(tmpl, vars)
+	b.WritePlaceholder(nil)
+	if got, want := b.String(), "${1:}${2:a} $0${3:}"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestParseTemplateErrors(t *testing.T) {
+	for _, tmpl := range []string{
+		"${1:a",
+		"${1",
+		"${1|a,b}",
+		"${}",
+		"${x",
+		"${1x}",
+	} {
+		if _, err := ParseTemplate(tmpl); err == nil {
+			t.Errorf("ParseTemplate(%q) succeeded, want error", tmpl)
+		}
+	}
+}
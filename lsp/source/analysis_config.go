@@ -0,0 +1,407 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+)
+
+// AnalysisConfigFile is the name of the file that configures the analyzers
+// for the packages in its directory and its subdirectories. For example:
+//
+//	# Settings for the whole module.
+//	staticcheck: true
+//	analyses:
+//	  shadow: true
+//	  unusedparams: false
+//	severity:
+//	  printf: error
+//	exclude:
+//	  - "**/*_gen.go"
+//	  - testdata/**
+//
+// A file in a subdirectory overrides the settings of the files in the
+// directories above it, and the files override the user's settings.
+// Severities are one of "error", "warning", "information", and "hint".
+// Diagnostics are not reported for the files that match an exclude
+// pattern, relative to the directory of the file with the pattern, where
+// "**" matches any number of directories.
+//
+// The file is written in a subset of YAML: mappings nested at most two
+// deep, lists in block or flow style, and plain or quoted scalars.
+const AnalysisConfigFile = "gopls.yaml"
+
+// AnalysisConfig holds the settings of one or more analysis configuration
+// files.
+type AnalysisConfig struct {
+	// Staticcheck overrides the Staticcheck user setting, if set.
+	Staticcheck *bool
+
+	// Analyses overrides the Analyses user setting per analyzer.
+	Analyses map[string]bool
+
+	// Severity overrides the severity of the diagnostics of an analyzer.
+	Severity map[string]protocol.DiagnosticSeverity
+
+	// Exclude holds the patterns of the files not to report diagnostics
+	// for.
+	Exclude []ExcludePattern
+}
+
+// ExcludePattern is a path glob, relative to the directory of the file that
+// declares it.
+type ExcludePattern struct {
+	Dir  string
+	Glob string
+}
+
+// ParseAnalysisConfig parses the contents of the analysis configuration
+// file in dir.
+func ParseAnalysisConfig(dir string, data []byte) (*AnalysisConfig, error) {
+	doc, err := parseYAMLSubset(string(data))
+	if err != nil {
+		return nil, err
+	}
+	config := &AnalysisConfig{
+		Analyses: make(map[string]bool),
+		Severity: make(map[string]protocol.DiagnosticSeverity),
+	}
+	for _, entry := range doc {
+		switch entry.key {
+		case "staticcheck":
+			b, err := entry.bool()
+			if err != nil {
+				return nil, err
+			}
+			config.Staticcheck = &b
+		case "analyses":
+			if entry.scalar != nil || entry.list != nil {
+				return nil, fmt.Errorf("line %d: analyses must be a mapping", entry.line)
+			}
+			for _, a := range entry.mapping {
+				b, err := a.bool()
+				if err != nil {
+					return nil, err
+				}
+				config.Analyses[a.key] = b
+			}
+		case "severity":
+			if entry.scalar != nil || entry.list != nil {
+				return nil, fmt.Errorf("line %d: severity must be a mapping", entry.line)
+			}
+			for _, s := range entry.mapping {
+				if s.scalar == nil {
+					return nil, fmt.Errorf("line %d: severity of %s must be a string", s.line, s.key)
+				}
+				severity, ok := parseSeverity(*s.scalar)
+				if !ok {
+					return nil, fmt.Errorf("line %d: invalid severity %q for %s", s.line, *s.scalar, s.key)
+				}
+				config.Severity[s.key] = severity
+			}
+		case "exclude":
+			if entry.mapping != nil || entry.scalar != nil {
+				return nil, fmt.Errorf("line %d: exclude must be a list", entry.line)
+			}
+			for _, glob := range entry.list {
+				if _, err := path.Match(glob, ""); err != nil {
+					return nil, fmt.Errorf("line %d: invalid exclude pattern %q: %v", entry.line, glob, err)
+				}
+				config.Exclude = append(config.Exclude, ExcludePattern{Dir: dir, Glob: glob})
+			}
+		default:
+			return nil, fmt.Errorf("line %d: unknown setting %q", entry.line, entry.key)
+		}
+	}
+	return config, nil
+}
+
+func parseSeverity(s string) (protocol.DiagnosticSeverity, bool) {
+	switch strings.ToLower(s) {
+	case "error":
+		return protocol.SeverityError, true
+	case "warning":
+		return protocol.SeverityWarning, true
+	case "information", "info":
+		return protocol.SeverityInformation, true
+	case "hint":
+		return protocol.SeverityHint, true
+	}
+	return 0, false
+}
+
+// Merge returns the settings of c overridden by those of child, the
+// configuration of a subdirectory.
+func (c *AnalysisConfig) Merge(child *AnalysisConfig) *AnalysisConfig {
+	result := &AnalysisConfig{
+		Staticcheck: c.Staticcheck,
+		Analyses:    make(map[string]bool),
+		Severity:    make(map[string]protocol.DiagnosticSeverity),
+		Exclude:     append(append([]ExcludePattern{}, c.Exclude...), child.Exclude...),
+	}
+	if child.Staticcheck != nil {
+		result.Staticcheck = child.Staticcheck
+	}
+	for _, m := range []map[string]bool{c.Analyses, child.Analyses} {
+		for k, v := range m {
+			result.Analyses[k] = v
+		}
+	}
+	for _, m := range []map[string]protocol.DiagnosticSeverity{c.Severity, child.Severity} {
+		for k, v := range m {
+			result.Severity[k] = v
+		}
+	}
+	return result
+}
+
+// IsEnabled reports whether the analyzer is enabled by the configuration,
+// falling back to the user's settings for the view.
+func (c *AnalysisConfig) IsEnabled(a *Analyzer, view View) bool {
+	if c == nil {
+		return a.IsEnabled(view)
+	}
+	if _, ok := view.Options().StaticcheckAnalyzers[a.Analyzer.Name]; ok {
+		staticcheck := view.Options().Staticcheck
+		if c.Staticcheck != nil {
+			staticcheck = *c.Staticcheck
+		}
+		if !staticcheck {
+			return false
+		}
+	}
+	if enabled, ok := c.Analyses[a.Analyzer.Name]; ok {
+		return enabled
+	}
+	if enabled, ok := view.Options().Analyses[a.Analyzer.Name]; ok {
+		return enabled
+	}
+	return a.Enabled
+}
+
+// Excludes reports whether the file is excluded by one of the patterns of
+// the configuration.
+func (c *AnalysisConfig) Excludes(filename string) bool {
+	if c == nil {
+		return false
+	}
+	for _, pattern := range c.Exclude {
+		rel, err := filepath.Rel(pattern.Dir, filename)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if matchGlob(pattern.Glob, filepath.ToSlash(rel)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob reports whether the slash-separated path matches the glob,
+// where each element of the glob is a path.Match pattern, except for "**",
+// which matches any number of elements.
+func matchGlob(glob, name string) bool {
+	return matchElems(strings.Split(glob, "/"), strings.Split(name, "/"))
+}
+
+func matchElems(glob, name []string) bool {
+	for len(glob) > 0 {
+		if glob[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchElems(glob[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(glob[0], name[0]); !ok {
+			return false
+		}
+		glob, name = glob[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// A yamlEntry is a key of a YAML mapping and its value, which is either a
+// scalar, a mapping, or a list of scalars.
+type yamlEntry struct {
+	line    int
+	key     string
+	scalar  *string
+	mapping []*yamlEntry
+	list    []string
+}
+
+func (e *yamlEntry) bool() (bool, error) {
+	if e.scalar == nil {
+		return false, fmt.Errorf("line %d: %s must be true or false", e.line, e.key)
+	}
+	b, err := strconv.ParseBool(*e.scalar)
+	if err != nil {
+		return false, fmt.Errorf("line %d: %s must be true or false, got %q", e.line, e.key, *e.scalar)
+	}
+	return b, nil
+}
+
+// parseYAMLSubset parses the subset of YAML described by AnalysisConfigFile.
+func parseYAMLSubset(src string) ([]*yamlEntry, error) {
+	var (
+		entries []*yamlEntry
+		parent  *yamlEntry // the top-level entry whose value is being parsed
+		indent  = -1       // the indentation of the parent's nested lines
+	)
+	for i, line := range strings.Split(src, "\n") {
+		lineNum := i + 1
+		line = strings.TrimRight(stripYAMLComment(line), " \t\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		trimmed := strings.TrimLeft(line, " ")
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", lineNum)
+		}
+		lineIndent := len(line) - len(trimmed)
+		isItem := strings.HasPrefix(trimmed, "-") && (len(trimmed) == 1 || trimmed[1] == ' ')
+
+		// The items of a list may be indented as much as its key.
+		if lineIndent == 0 && !(isItem && parent != nil) {
+			entry, err := parseYAMLKeyValue(trimmed, lineNum)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry)
+			parent, indent = nil, -1
+			if entry.scalar == nil && entry.list == nil {
+				parent = entry
+			}
+			continue
+		}
+		if parent == nil {
+			return nil, fmt.Errorf("line %d: unexpected indentation", lineNum)
+		}
+		if indent < 0 {
+			indent = lineIndent
+		} else if lineIndent != indent {
+			return nil, fmt.Errorf("line %d: inconsistent indentation", lineNum)
+		}
+		if isItem {
+			if parent.mapping != nil {
+				return nil, fmt.Errorf("line %d: mixed list and mapping", lineNum)
+			}
+			item, err := parseYAMLScalar(strings.TrimSpace(trimmed[1:]), lineNum)
+			if err != nil {
+				return nil, err
+			}
+			parent.list = append(parent.list, item)
+			continue
+		}
+		if parent.list != nil {
+			return nil, fmt.Errorf("line %d: mixed list and mapping", lineNum)
+		}
+		entry, err := parseYAMLKeyValue(trimmed, lineNum)
+		if err != nil {
+			return nil, err
+		}
+		if entry.scalar == nil {
+			return nil, fmt.Errorf("line %d: %s must have a value", lineNum, entry.key)
+		}
+		parent.mapping = append(parent.mapping, entry)
+	}
+	return entries, nil
+}
+
+// parseYAMLKeyValue parses a "key: value" line, where the value is a
+// scalar, a flow list, or empty if it is given on the following lines.
+func parseYAMLKeyValue(s string, line int) (*yamlEntry, error) {
+	colon := strings.Index(s, ":")
+	if colon < 0 || (colon+1 < len(s) && s[colon+1] != ' ') {
+		return nil, fmt.Errorf("line %d: expected key: value", line)
+	}
+	key, err := parseYAMLScalar(strings.TrimSpace(s[:colon]), line)
+	if err != nil {
+		return nil, err
+	}
+	entry := &yamlEntry{line: line, key: key}
+	value := strings.TrimSpace(s[colon+1:])
+	switch {
+	case value == "":
+	case strings.HasPrefix(value, "["):
+		if !strings.HasSuffix(value, "]") {
+			return nil, fmt.Errorf("line %d: unterminated list", line)
+		}
+		entry.list = []string{}
+		for _, item := range strings.Split(value[1:len(value)-1], ",") {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			item, err := parseYAMLScalar(item, line)
+			if err != nil {
+				return nil, err
+			}
+			entry.list = append(entry.list, item)
+		}
+	default:
+		scalar, err := parseYAMLScalar(value, line)
+		if err != nil {
+			return nil, err
+		}
+		entry.scalar = &scalar
+	}
+	return entry, nil
+}
+
+func parseYAMLScalar(s string, line int) (string, error) {
+	if s == "" {
+		return "", fmt.Errorf("line %d: missing value", line)
+	}
+	switch s[0] {
+	case '"':
+		unquoted, err := strconv.Unquote(s)
+		if err != nil {
+			return "", fmt.Errorf("line %d: invalid string %s", line, s)
+		}
+		return unquoted, nil
+	case '\'':
+		if len(s) < 2 || s[len(s)-1] != '\'' {
+			return "", fmt.Errorf("line %d: invalid string %s", line, s)
+		}
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	case '{', '[', '&', '*', '!', '|', '>', '%', '@', '`':
+		return "", fmt.Errorf("line %d: unsupported value %s", line, s)
+	}
+	return s, nil
+}
+
+// stripYAMLComment removes the comment, if any, from the line. A comment
+// begins with a '#' at the start of the line or after a space, outside of
+// a quoted string.
+func stripYAMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
@@ -0,0 +1,133 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+)
+
+func TestParseAnalysisConfig(t *testing.T) {
+	const src = `# Lint policy.
+staticcheck: true
+analyses:
+  shadow: true   # not on by default
+  unusedparams: false
+severity:
+  printf: error
+  'unusedresult': "hint"
+exclude:
+  - "**/*_gen.go"
+  - testdata/**
+`
+	dir := filepath.FromSlash("/repo")
+	got, err := ParseAnalysisConfig(dir, []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	staticcheck := true
+	want := &AnalysisConfig{
+		Staticcheck: &staticcheck,
+		Analyses:    map[string]bool{"shadow": true, "unusedparams": false},
+		Severity: map[string]protocol.DiagnosticSeverity{
+			"printf":       protocol.SeverityError,
+			"unusedresult": protocol.SeverityHint,
+		},
+		Exclude: []ExcludePattern{{dir, "**/*_gen.go"}, {dir, "testdata/**"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseAnalysisConfig() = %+v, want %+v", got, want)
+	}
+
+	// The items of a list may be indented as much as its key.
+	got, err = ParseAnalysisConfig(dir, []byte("exclude:\n- a.go\n- \"b/**\"\nstaticcheck: false\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []ExcludePattern{{dir, "a.go"}, {dir, "b/**"}}; !reflect.DeepEqual(got.Exclude, want) {
+		t.Errorf("unindented exclude list = %v, want %v", got.Exclude, want)
+	}
+	if got.Staticcheck == nil || *got.Staticcheck {
+		t.Errorf("staticcheck after unindented list = %v, want false", got.Staticcheck)
+	}
+
+	for _, bad := range []string{
+		"- a.go\n",
+		"exclude:\n- a.go\n  - b.go\n",
+		"analyses:\n- shadow\n",
+		"staticcheck: yes please\n",
+		"analyses: true\n",
+		"analyses:\n  shadow:\n",
+		"severity:\n  printf: fatal\n",
+		"exclude: foo\n",
+		"exclude:\n  - \"[\"\n",
+		"unknown: true\n",
+		"analyses:\n  shadow: true\n    printf: true\n",
+		"analyses:\n\tshadow: true\n",
+		"  shadow: true\n",
+		"analyses: {shadow: true}\n",
+	} {
+		if _, err := ParseAnalysisConfig(dir, []byte(bad)); err == nil {
+			t.Errorf("ParseAnalysisConfig(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestAnalysisConfigMerge(t *testing.T) {
+	root, err := ParseAnalysisConfig("/repo", []byte("staticcheck: true\nanalyses:\n  shadow: true\n  printf: false\nexclude: [a.go]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub, err := ParseAnalysisConfig("/repo/sub", []byte("analyses:\n  shadow: false\nexclude: [b.go]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := root.Merge(sub)
+	if got.Staticcheck == nil || !*got.Staticcheck {
+		t.Errorf("Staticcheck = %v, want true", got.Staticcheck)
+	}
+	if want := map[string]bool{"shadow": false, "printf": false}; !reflect.DeepEqual(got.Analyses, want) {
+		t.Errorf("Analyses = %v, want %v", got.Analyses, want)
+	}
+	if !got.Excludes(filepath.FromSlash("/repo/a.go")) || !got.Excludes(filepath.FromSlash("/repo/sub/b.go")) || got.Excludes(filepath.FromSlash("/repo/b.go")) {
+		t.Errorf("Exclude = %v, want a.go in /repo and b.go in /repo/sub", got.Exclude)
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		glob, name string
+		want       bool
+	}{
+		{"*.go", "a.go", true},
+		{"*.go", "sub/a.go", false},
+		{"**/*.go", "a.go", true},
+		{"**/*.go", "sub/dir/a.go", true},
+		{"testdata/**", "testdata/x/y.go", true},
+		{"testdata/**", "src/testdata/y.go", false},
+		{"**/testdata/**", "src/testdata/y.go", true},
+		{"gen_?.go", "gen_a.go", true},
+		{"gen_?.go", "gen_ab.go", false},
+	}
+	for _, test := range tests {
+		if got := matchGlob(test.glob, test.name); got != test.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", test.glob, test.name, got, test.want)
+		}
+	}
+}
+
+func TestDetectAnalysisConfigFile(t *testing.T) {
+	for _, langID := range []string{"", "yaml"} {
+		if got := DetectLanguage(langID, filepath.FromSlash("/repo/sub/gopls.yaml")); got != Config {
+			t.Errorf("DetectLanguage(%q, gopls.yaml) = %v, want %v", langID, got, Config)
+		}
+	}
+	if got := DetectLanguage("", filepath.FromSlash("/repo/other.yaml")); got == Config {
+		t.Errorf("DetectLanguage(other.yaml) = %v", got)
+	}
+}
@@ -109,6 +109,19 @@ var GeneratedAPIJSON = &APIJSON{
 				Status:     "experimental",
 				Hierarchy:  "build",
 			},
+			{
+				Name: "buildMatrix",
+				Type: "[]BuildConfig",
+				Doc:  "buildMatrix lists additional build configurations under which the\nworkspace packages are type-checked, so that the errors in the files\nof other platforms are reported too. Each configuration has a `goos`,\na `goarch`, and a list of build `tags`; a missing GOOS or GOARCH is\nthat of the view. The errors that the view's own configuration does\nnot report are annotated with the configurations that produce them.\n\nExample Usage:\n\n```json5\n\"gopls\": {\n...\n  \"buildMatrix\": [\n    {\"goos\": \"windows\", \"goarch\": \"amd64\"},\n    {\"goos\": \"darwin\", \"goarch\": \"arm64\", \"tags\": [\"integration\"]},\n  ]\n...\n}\n```\n",
+				EnumKeys: EnumKeys{
+					ValueType: "",
+					Keys:      nil,
+				},
+				EnumValues: nil,
+				Default:    "[]",
+				Status:     "experimental",
+				Hierarchy:  "build",
+			},
 			{
 				Name: "hoverKind",
 				Type: "enum",
@@ -221,6 +234,84 @@ var GeneratedAPIJSON = &APIJSON{
 				Status:    "advanced",
 				Hierarchy: "ui.completion",
 			},
+			{
+				Name: "postfixCompletions",
+				Type: "bool",
+				Doc:  "postfixCompletions enables postfix completions, which transform the\nexpression before a trailing \".\", such as `xs.for` into a loop over\nxs or `err.ret` into a check that returns err.\n",
+				EnumKeys: EnumKeys{
+					ValueType: "",
+					Keys:      nil,
+				},
+				EnumValues: nil,
+				Default:    "true",
+				Status:     "experimental",
+				Hierarchy:  "ui.completion",
+			},
+			{
+				Name: "postfixTemplates",
+				Type: "map[string]string",
+				Doc:  "postfixTemplates maps the labels of additional postfix completions\nto their templates. A template uses the syntax of the text/template\npackage, and it produces a completion only if its output is not\nempty. The data passed to a template describes the expression:\n`.X` is its text, `.Kind` is the kind of its type (such as \"slice\",\n\"map\", or \"bool\"), and `.StmtOK` reports whether the expression is a\nstatement. Templates can call methods of the data such as\n`.Placeholder \"text\"`, `.Cursor`, `.VarName \"name\"`, `.TypeName\n.ElemType`, `.Zero .Type`, and `.Import \"path\"`; see the built-in\ntemplates for examples.\n\nExample Usage:\n\n```json5\n\"gopls\": {\n...\n  \"postfixTemplates\": {\n    \"debug\": \"{{if .StmtOK}}{{.Import \\\"log\\\"}}.Printf(\\\"%#v\\\\n\\\", {{.X}}){{end}}\",\n  }\n...\n}\n```\n",
+				EnumKeys: EnumKeys{
+					ValueType: "",
+					Keys:      nil,
+				},
+				EnumValues: nil,
+				Default:    "{}",
+				Status:     "experimental",
+				Hierarchy:  "ui.completion",
+			},
+			{
+				Name: "snippetTemplates",
+				Type: "[]SnippetTemplate",
+				Doc:  "snippetTemplates are snippets that completion offers at the start of\na statement or of a top-level declaration. Each has a `name`, which\nis its label, a `context`, which is \"statement\" or \"declaration\", and\na `body` in LSP snippet syntax, with tab stops such as `$1`, `${2:x}`,\nand `$0`. The body may use the variables `$receiver` and\n`$receiverType`, the receiver of the enclosing method; `$funcName`,\nthe name of the enclosing function; `$pkgName`, the name of the\ncurrent package; `$zeroValues`, the zero values of the enclosing\nfunction's results; and `$testVar`, the testing.TB parameter of the\nenclosing function. A variable with no value becomes a placeholder.\n\nExample Usage:\n\n```json5\n\"gopls\": {\n...\n  \"snippetTemplates\": [{\n    \"name\": \"logerr\",\n    \"context\": \"statement\",\n    \"body\": \"if err != nil {\\n\\tlog.Printf(\\\"$funcName: %v\\\", err)\\n\\treturn $zeroValues\\n}\",\n  }]\n...\n}\n```\n",
+				EnumKeys: EnumKeys{
+					ValueType: "",
+					Keys:      nil,
+				},
+				EnumValues: nil,
+				Default:    "[]",
+				Status:     "experimental",
+				Hierarchy:  "ui.completion",
+			},
+			{
+				Name: "workspaceRanking",
+				Type: "bool",
+				Doc:  "workspaceRanking ranks the methods, fields, and package members in\ncompletion results by how often the workspace packages select them,\nso that the commonly used members of large method sets come first.\n",
+				EnumKeys: EnumKeys{
+					ValueType: "",
+					Keys:      nil,
+				},
+				EnumValues: nil,
+				Default:    "false",
+				Status:     "experimental",
+				Hierarchy:  "ui.completion",
+			},
+			{
+				Name: "structFieldsSnippet",
+				Type: "bool",
+				Doc:  "structFieldsSnippet offers, when completing the first field name of\na struct literal, a snippet that sets all of the remaining fields of\nthe struct. Each value is a placeholder with the value that the\nfillstruct code action would use. Fields whose documentation begins\nwith \"optional\" are left out, as are the fields that match\nOptionalFieldTags.\n",
+				EnumKeys: EnumKeys{
+					ValueType: "",
+					Keys:      nil,
+				},
+				EnumValues: nil,
+				Default:    "false",
+				Status:     "experimental",
+				Hierarchy:  "ui.completion",
+			},
+			{
+				Name: "optionalFieldTags",
+				Type: "[]string",
+				Doc:  "optionalFieldTags lists the struct tags that mark the fields left out\nof the struct fields snippet. An entry is either a tag key, such as\n\"optional\", which matches any field with that key, or a key and an\noption, such as \"json:omitempty\", which matches the fields whose tag\nfor that key has that option.\n",
+				EnumKeys: EnumKeys{
+					ValueType: "",
+					Keys:      nil,
+				},
+				EnumValues: nil,
+				Default:    "[]",
+				Status:     "experimental",
+				Hierarchy:  "ui.completion",
+			},
 			{
 				Name: "importShortcut",
 				Type: "enum",
@@ -356,6 +447,16 @@ var GeneratedAPIJSON = &APIJSON{
 							Doc:     "check for calls of reflect.DeepEqual on error values\n\nThe deepequalerrors checker looks for calls of the form:\n\n    reflect.DeepEqual(err1, err2)\n\nwhere err1 and err2 are errors. Using reflect.DeepEqual to compare\nerrors is discouraged.",
 							Default: "true",
 						},
+						{
+							Name:    "\"deprecated\"",
+							Doc:     "report uses of deprecated identifiers\n\nThis analyzer reports uses of functions, types, variables, constants,\nfields and methods from other packages whose doc comment contains a\nparagraph beginning with \"Deprecated:\". For example, given\n\t// Dial connects to the address.\n\t//\n\t// Deprecated: use DialContext instead.\n\tfunc Dial(addr string) (Conn, error)\na call to Dial is reported with the message \"Dial is deprecated: use\nDialContext instead.\", and editors may strike the identifier through.",
+							Default: "true",
+						},
+						{
+							Name:    "\"enumstring\"",
+							Doc:     "note out-of-date generated String methods\n\nThis analyzer reports String methods generated by gopls for an integer type\nwith constants, marked by a //gopls:enumstring directive, that no longer\nmatch the type's constants. For example, after adding Yellow to\n\ttype Color int\n\tconst (\n\t\tRed Color = iota\n\t\tGreen\n\t\tBlue\n\t\tYellow\n\t)\nthe String method generated for Color does not return \"Yellow\" for Yellow.\nThe suggested fix generates the method again.",
+							Default: "true",
+						},
 						{
 							Name:    "\"errorsas\"",
 							Doc:     "report passing non-pointer or non-error values to errors.As\n\nThe errorsas analysis reports calls to errors.As where the type\nof the second argument is not a pointer to a type implementing error.",
@@ -366,6 +467,11 @@ var GeneratedAPIJSON = &APIJSON{
 							Doc:     "find structs that would take less memory if their fields were sorted\n\nThis analyzer find structs that can be rearranged to take less memory, and provides\na suggested edit with the optimal order.\n",
 							Default: "false",
 						},
+						{
+							Name:    "\"fillswitch\"",
+							Doc:     "note non-exhaustive switch statements over enum-like types\n\nThis analyzer reports switch statements without a default case whose tag\nis of a named type with package-level constants (such as a sequence of iota\nconstants), but which do not have a case for each of the constants.\nFor example:\n\ttype Color int\n\tconst (\n\t\tRed Color = iota\n\t\tGreen\n\t\tBlue\n\t)\n\tswitch c {\n\tcase Red:\n\tcase Green:\n\t}\nis missing a case for Blue. The suggested fix adds the missing cases.",
+							Default: "false",
+						},
 						{
 							Name:    "\"httpresponse\"",
 							Doc:     "check for mistakes using HTTP responses\n\nA common mistake when using the net/http package is to defer a function\ncall to close the http.Response Body before checking the error that\ndetermines whether the response is valid:\n\n\tresp, err := http.Head(url)\n\tdefer resp.Body.Close()\n\tif err != nil {\n\t\tlog.Fatal(err)\n\t}\n\t// (defer statement belongs here)\n\nThis checker helps uncover latent nil dereference bugs by reporting a\ndiagnostic for such mistakes.",
@@ -501,6 +607,11 @@ var GeneratedAPIJSON = &APIJSON{
 							Doc:     "suggested fixes for \"no result values expected\"\n\nThis checker provides suggested fixes for type errors of the\ntype \"no result values expected\". For example:\n\tfunc z() { return nil }\nwill turn into\n\tfunc z() { return }\n",
 							Default: "true",
 						},
+						{
+							Name:    "\"stubmethods\"",
+							Doc:     "suggested fixes for \"missing method\" type errors\n\nThis checker provides suggested fixes for type errors in which a value\nof a concrete type is used where an interface is expected, but the\nconcrete type does not implement all of the interface's methods. For\nexample:\n\tvar _ io.Reader = (*T)(nil)\nwill add\n\tfunc (t *T) Read(p []byte) (n int, err error) {\n\t\tpanic(\"unimplemented\")\n\t}\nto the file that declares T.",
+							Default: "true",
+						},
 						{
 							Name:    "\"undeclaredname\"",
 							Doc:     "suggested fixes for \"undeclared name: <>\"\n\nThis checker provides suggested fixes for type errors of the\ntype \"undeclared name: <>\". It will insert a new statement:\n\"<> := \".",
@@ -531,6 +642,71 @@ var GeneratedAPIJSON = &APIJSON{
 				Status:     "experimental",
 				Hierarchy:  "ui.diagnostic",
 			},
+			{
+				Name: "vetTools",
+				Type: "[]string",
+				Doc:  "vetTools lists the paths of external analysis tools that speak the\nprotocol of `go vet -vettool`, such as those built with unitchecker.\nEach tool is run on every workspace package, and its analyzers can\nbe enabled or disabled by name in the analyses setting, like gopls's\nown.\n",
+				EnumKeys: EnumKeys{
+					ValueType: "",
+					Keys:      nil,
+				},
+				EnumValues: nil,
+				Default:    "[]",
+				Status:     "experimental",
+				Hierarchy:  "ui.diagnostic",
+			},
+			{
+				Name: "unusedExportedDiagnostics",
+				Type: "bool",
+				Doc:  "unusedExportedDiagnostics reports the exported identifiers of\nworkspace packages that no workspace package uses, as found by the\nunused_exported command. The diagnostics are hints, computed after\nthe other diagnostics of the workspace.\n",
+				EnumKeys: EnumKeys{
+					ValueType: "",
+					Keys:      nil,
+				},
+				EnumValues: nil,
+				Default:    "false",
+				Status:     "experimental",
+				Hierarchy:  "ui.diagnostic",
+			},
+			{
+				Name: "vulnerabilityDatabase",
+				Type: "string",
+				Doc:  "vulnerabilityDatabase is the absolute path of a directory of\nvulnerability reports in the OSV JSON format, such as a local mirror\nof the Go vulnerability database. The required module versions of\ngo.mod files that its reports affect are diagnosed, without network\naccess, along with the affected symbols and an upgrade to the first\nfixed version.\n",
+				EnumKeys: EnumKeys{
+					ValueType: "",
+					Keys:      nil,
+				},
+				EnumValues: nil,
+				Default:    "\"\"",
+				Status:     "experimental",
+				Hierarchy:  "ui.diagnostic",
+			},
+			{
+				Name: "diagnosticSeverity",
+				Type: "map[string]string",
+				Doc:  "diagnosticSeverity overrides the severity of diagnostics by their\nsource: the name of an analyzer, or one of \"compiler\", \"syntax\",\n\"go list\", \"go mod tidy\", and the other sources of gopls's own\ndiagnostics. The severity is one of \"error\", \"warning\",\n\"information\", and \"hint\".\n\nExample Usage:\n\n```json5\n\"diagnosticSeverity\": {\n  \"unusedparams\": \"hint\",\n  \"printf\": \"error\"\n}\n```\n",
+				EnumKeys: EnumKeys{
+					ValueType: "",
+					Keys:      nil,
+				},
+				EnumValues: nil,
+				Default:    "{}",
+				Status:     "experimental",
+				Hierarchy:  "ui.diagnostic",
+			},
+			{
+				Name: "diagnosticTags",
+				Type: "map[string][]string",
+				Doc:  "diagnosticTags adds tags to diagnostics by their source, as for\ndiagnosticSeverity. The tags are \"unnecessary\", which clients\nusually show by fading the code out, and \"deprecated\", which they\nusually show by striking it through.\n\nExample Usage:\n\n```json5\n\"diagnosticTags\": {\n  \"unusedparams\": [\"unnecessary\"]\n}\n```\n",
+				EnumKeys: EnumKeys{
+					ValueType: "",
+					Keys:      nil,
+				},
+				EnumValues: nil,
+				Default:    "{}",
+				Status:     "experimental",
+				Hierarchy:  "ui.diagnostic",
+			},
 			{
 				Name: "annotations",
 				Type: "map[string]bool",
@@ -595,6 +771,11 @@ var GeneratedAPIJSON = &APIJSON{
 							Doc:     "Runs `go generate` for a given directory.",
 							Default: "true",
 						},
+						{
+							Name:    "\"generate_go_work\"",
+							Doc:     "(Re)generate the go.work file for a workspace, using all of the\nmodules in the workspace folder.",
+							Default: "true",
+						},
 						{
 							Name:    "\"regenerate_cgo\"",
 							Doc:     "Regenerates cgo definitions.",
@@ -623,7 +804,7 @@ var GeneratedAPIJSON = &APIJSON{
 					},
 				},
 				EnumValues: nil,
-				Default:    "{\"gc_details\":false,\"generate\":true,\"regenerate_cgo\":true,\"tidy\":true,\"upgrade_dependency\":true,\"vendor\":true}",
+				Default:    "{\"gc_details\":false,\"generate\":true,\"generate_go_work\":true,\"regenerate_cgo\":true,\"tidy\":true,\"upgrade_dependency\":true,\"vendor\":true}",
 				Status:     "",
 				Hierarchy:  "ui",
 			},
@@ -666,6 +847,66 @@ var GeneratedAPIJSON = &APIJSON{
 				Status:     "",
 				Hierarchy:  "formatting",
 			},
+			{
+				Name: "structTags",
+				Type: "[]string",
+				Doc:  "structTags is the list of struct tag keys, such as `json` or `yaml`,\nfor which the struct tag code actions are offered.\n",
+				EnumKeys: EnumKeys{
+					ValueType: "",
+					Keys:      nil,
+				},
+				EnumValues: nil,
+				Default:    "[\"json\"]",
+				Status:     "",
+				Hierarchy:  "formatting",
+			},
+			{
+				Name: "structTagCase",
+				Type: "enum",
+				Doc:  "structTagCase controls how field names are transformed into the names\nused in generated struct tags.\n",
+				EnumKeys: EnumKeys{
+					ValueType: "",
+					Keys:      nil,
+				},
+				EnumValues: []EnumValue{
+					{
+						Value: "\"CamelCase\"",
+						Doc:   "`\"CamelCase\"` transforms field names to camelCase, e.g. \"fooBar\".\n",
+					},
+					{
+						Value: "\"KebabCase\"",
+						Doc:   "`\"KebabCase\"` transforms field names to kebab-case, e.g. \"foo-bar\".\n",
+					},
+					{
+						Value: "\"Keep\"",
+						Doc:   "`\"Keep\"` uses field names unchanged, e.g. \"FooBar\".\n",
+					},
+					{
+						Value: "\"PascalCase\"",
+						Doc:   "`\"PascalCase\"` transforms field names to PascalCase, e.g. \"FooBar\".\n",
+					},
+					{
+						Value: "\"SnakeCase\"",
+						Doc:   "`\"SnakeCase\"` transforms field names to snake_case, e.g. \"foo_bar\".\n",
+					},
+				},
+				Default:   "\"CamelCase\"",
+				Status:    "",
+				Hierarchy: "formatting",
+			},
+			{
+				Name: "structTagOptions",
+				Type: "map[string]string",
+				Doc:  "structTagOptions maps a struct tag key to the options, such as\n`omitempty`, that are appended to the names in generated tags.\n\nExample Usage:\n\n```json5\n\"gopls\": {\n...\n  \"structTagOptions\": {\n    \"json\": \"omitempty\",\n  }\n...\n}\n```\n",
+				EnumKeys: EnumKeys{
+					ValueType: "",
+					Keys:      nil,
+				},
+				EnumValues: nil,
+				Default:    "{}",
+				Status:     "",
+				Hierarchy:  "formatting",
+			},
 			{
 				Name: "verboseOutput",
 				Type: "bool",
@@ -694,6 +935,12 @@ var GeneratedAPIJSON = &APIJSON{
 			Doc:     "",
 			ArgDoc:  "{\n\t\"ImportPath\": string,\n\t\"URI\": string,\n}",
 		},
+		{
+			Command: "gopls.add_test",
+			Title:   "Add a test for a function",
+			Doc:     "Generates a table-driven test skeleton for the function or method at\nthe given location, in the _test.go file next to its declaration.",
+			ArgDoc:  "{\n\t\"uri\": string,\n\t\"range\": {\n\t\t\"start\": {\n\t\t\t\"line\": uint32,\n\t\t\t\"character\": uint32,\n\t\t},\n\t\t\"end\": {\n\t\t\t\"line\": uint32,\n\t\t\t\"character\": uint32,\n\t\t},\n\t},\n}",
+		},
 		{
 			Command: "gopls.apply_fix",
 			Title:   "Apply a fix",
@@ -706,6 +953,12 @@ var GeneratedAPIJSON = &APIJSON{
 			Doc:     "Checks for module upgrades.",
 			ArgDoc:  "{\n\t// The go.mod file URI.\n\t\"URI\": string,\n\t// The modules to check.\n\t\"Modules\": []string,\n}",
 		},
+		{
+			Command: "gopls.extract_interface",
+			Title:   "Extract an interface from a type",
+			Doc:     "Declares a new interface with a subset of the exported methods of the\ntype at the given location, and optionally changes the parameters of\nworkspace functions that only use those methods to the interface.",
+			ArgDoc:  "{\n\t// The location of the concrete type.\n\t\"Location\": {\n\t\t\"uri\": string,\n\t\t\"range\": {\n\t\t\t\"start\": {\n\t\t\t\t\"line\": uint32,\n\t\t\t\t\"character\": uint32,\n\t\t\t},\n\t\t\t\"end\": {\n\t\t\t\t\"line\": uint32,\n\t\t\t\t\"character\": uint32,\n\t\t\t},\n\t\t},\n\t},\n\t// The name of the interface. If empty, a name is derived from the type.\n\t\"Name\": string,\n\t// The names of the methods of the interface. If empty, all of the\n\t// type's exported methods are included.\n\t\"Methods\": []string,\n\t// The file to add the interface to. It is created if it does not\n\t// exist. If empty, the interface is added after the type.\n\t\"Target\": string,\n\t// Whether to change the types of parameters that are only used to call\n\t// the interface's methods to the interface.\n\t\"RewriteParams\": bool,\n}",
+		},
 		{
 			Command: "gopls.gc_details",
 			Title:   "Toggle gc_details",
@@ -718,12 +971,24 @@ var GeneratedAPIJSON = &APIJSON{
 			Doc:     "Runs `go generate` for a given directory.",
 			ArgDoc:  "{\n\t// URI for the directory to generate.\n\t\"Dir\": string,\n\t// Whether to generate recursively (go generate ./...)\n\t\"Recursive\": bool,\n}",
 		},
+		{
+			Command: "gopls.generate_go_work",
+			Title:   "Generate go.work",
+			Doc:     "(Re)generate the go.work file for a workspace, using all of the\nmodules in the workspace folder.",
+			ArgDoc:  "{\n\t// The file URI.\n\t\"URI\": string,\n}",
+		},
 		{
 			Command: "gopls.generate_gopls_mod",
 			Title:   "Generate gopls.mod",
 			Doc:     "(Re)generate the gopls.mod file for a workspace.",
 			ArgDoc:  "{\n\t// The file URI.\n\t\"URI\": string,\n}",
 		},
+		{
+			Command: "gopls.generate_mock",
+			Title:   "Generate a mock implementation",
+			Doc:     "Generates a mock implementation of the interface type at the given\nlocation, and adds it to the target file.",
+			ArgDoc:  "{\n\t// The location of the interface type to mock.\n\t\"Location\": {\n\t\t\"uri\": string,\n\t\t\"range\": {\n\t\t\t\"start\": {\n\t\t\t\t\"line\": uint32,\n\t\t\t\t\"character\": uint32,\n\t\t\t},\n\t\t\t\"end\": {\n\t\t\t\t\"line\": uint32,\n\t\t\t\t\"character\": uint32,\n\t\t\t},\n\t\t},\n\t},\n\t// The file to add the mock to. It is created if it does not exist.\n\t\"Target\": string,\n\t// Whether the mock records the arguments of each call.\n\t\"RecordCalls\": bool,\n}",
+		},
 		{
 			Command: "gopls.go_get_package",
 			Title:   "go get package",
@@ -772,6 +1037,12 @@ var GeneratedAPIJSON = &APIJSON{
 			Doc:     "Toggle the calculation of gc annotations.",
 			ArgDoc:  "{\n\t// The file URI.\n\t\"URI\": string,\n}",
 		},
+		{
+			Command: "gopls.unused_exported",
+			Title:   "Find unused exported identifiers",
+			Doc:     "Lists the exported functions, types, variables, constants, methods,\nand fields of workspace packages that no workspace package uses.",
+			ArgDoc:  "{\n\t// Any file of the workspace folder to search.\n\t\"URI\": string,\n\t// The directory of the packages to search, including those in its\n\t// subdirectories. If empty, all workspace packages are searched.\n\t\"Dir\": string,\n}",
+		},
 		{
 			Command: "gopls.update_go_sum",
 			Title:   "Update go.sum",
@@ -808,6 +1079,11 @@ var GeneratedAPIJSON = &APIJSON{
 			Title: "Run go generate",
 			Doc:   "Runs `go generate` for a given directory.",
 		},
+		{
+			Lens:  "generate_go_work",
+			Title: "Generate go.work",
+			Doc:   "(Re)generate the go.work file for a workspace, using all of the\nmodules in the workspace folder.",
+		},
 		{
 			Lens:  "regenerate_cgo",
 			Title: "Regenerate cgo",
@@ -885,6 +1161,16 @@ var GeneratedAPIJSON = &APIJSON{
 			Doc:     "check for calls of reflect.DeepEqual on error values\n\nThe deepequalerrors checker looks for calls of the form:\n\n    reflect.DeepEqual(err1, err2)\n\nwhere err1 and err2 are errors. Using reflect.DeepEqual to compare\nerrors is discouraged.",
 			Default: true,
 		},
+		{
+			Name:    "deprecated",
+			Doc:     "report uses of deprecated identifiers\n\nThis analyzer reports uses of functions, types, variables, constants,\nfields and methods from other packages whose doc comment contains a\nparagraph beginning with \"Deprecated:\". For example, given\n\t// Dial connects to the address.\n\t//\n\t// Deprecated: use DialContext instead.\n\tfunc Dial(addr string) (Conn, error)\na call to Dial is reported with the message \"Dial is deprecated: use\nDialContext instead.\", and editors may strike the identifier through.",
+			Default: true,
+		},
+		{
+			Name:    "enumstring",
+			Doc:     "note out-of-date generated String methods\n\nThis analyzer reports String methods generated by gopls for an integer type\nwith constants, marked by a //gopls:enumstring directive, that no longer\nmatch the type's constants. For example, after adding Yellow to\n\ttype Color int\n\tconst (\n\t\tRed Color = iota\n\t\tGreen\n\t\tBlue\n\t\tYellow\n\t)\nthe String method generated for Color does not return \"Yellow\" for Yellow.\nThe suggested fix generates the method again.",
+			Default: true,
+		},
 		{
 			Name:    "errorsas",
 			Doc:     "report passing non-pointer or non-error values to errors.As\n\nThe errorsas analysis reports calls to errors.As where the type\nof the second argument is not a pointer to a type implementing error.",
@@ -895,6 +1181,11 @@ var GeneratedAPIJSON = &APIJSON{
 			Doc:     "find structs that would take less memory if their fields were sorted\n\nThis analyzer find structs that can be rearranged to take less memory, and provides\na suggested edit with the optimal order.\n",
 			Default: false,
 		},
+		{
+			Name:    "fillswitch",
+			Doc:     "note non-exhaustive switch statements over enum-like types\n\nThis analyzer reports switch statements without a default case whose tag\nis of a named type with package-level constants (such as a sequence of iota\nconstants), but which do not have a case for each of the constants.\nFor example:\n\ttype Color int\n\tconst (\n\t\tRed Color = iota\n\t\tGreen\n\t\tBlue\n\t)\n\tswitch c {\n\tcase Red:\n\tcase Green:\n\t}\nis missing a case for Blue. The suggested fix adds the missing cases.",
+			Default: false,
+		},
 		{
 			Name:    "httpresponse",
 			Doc:     "check for mistakes using HTTP responses\n\nA common mistake when using the net/http package is to defer a function\ncall to close the http.Response Body before checking the error that\ndetermines whether the response is valid:\n\n\tresp, err := http.Head(url)\n\tdefer resp.Body.Close()\n\tif err != nil {\n\t\tlog.Fatal(err)\n\t}\n\t// (defer statement belongs here)\n\nThis checker helps uncover latent nil dereference bugs by reporting a\ndiagnostic for such mistakes.",
@@ -1030,6 +1321,11 @@ var GeneratedAPIJSON = &APIJSON{
 			Doc:     "suggested fixes for \"no result values expected\"\n\nThis checker provides suggested fixes for type errors of the\ntype \"no result values expected\". For example:\n\tfunc z() { return nil }\nwill turn into\n\tfunc z() { return }\n",
 			Default: true,
 		},
+		{
+			Name:    "stubmethods",
+			Doc:     "suggested fixes for \"missing method\" type errors\n\nThis checker provides suggested fixes for type errors in which a value\nof a concrete type is used where an interface is expected, but the\nconcrete type does not implement all of the interface's methods. For\nexample:\n\tvar _ io.Reader = (*T)(nil)\nwill add\n\tfunc (t *T) Read(p []byte) (n int, err error) {\n\t\tpanic(\"unimplemented\")\n\t}\nto the file that declares T.",
+			Default: true,
+		},
 		{
 			Name:    "undeclaredname",
 			Doc:     "suggested fixes for \"undeclared name: <>\"\n\nThis checker provides suggested fixes for type errors of the\ntype \"undeclared name: <>\". It will insert a new statement:\n\"<> := \".",
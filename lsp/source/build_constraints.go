@@ -0,0 +1,365 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/build"
+	"path/filepath"
+	"strings"
+)
+
+// KnownOS and KnownArch are the values of GOOS and GOARCH, which are
+// implicitly build tags.
+var (
+	KnownOS = []string{
+		"aix", "android", "darwin", "dragonfly", "freebsd", "hurd", "illumos",
+		"ios", "js", "linux", "nacl", "netbsd", "openbsd", "plan9", "solaris",
+		"windows", "zos",
+	}
+	KnownArch = []string{
+		"386", "amd64", "amd64p32", "arm", "armbe", "arm64", "arm64be",
+		"mips", "mipsle", "mips64", "mips64le", "mips64p32", "mips64p32le",
+		"ppc", "ppc64", "ppc64le", "riscv", "riscv64", "s390", "s390x",
+		"sparc", "sparc64", "wasm",
+	}
+)
+
+// maxInferredTags limits the number of the custom build tags of a file that
+// are considered when inferring a configuration that includes it.
+const maxInferredTags = 8
+
+// BuildConfigForFile infers a build configuration under which the Go file is
+// included in the build, if its name or build constraints exclude it under
+// goos and goarch. The configuration sets only the GOOS, GOARCH, and tags
+// that differ from those of the current configuration, preferring the
+// fewest changes. It reports false if the file is not excluded, or if no
+// configuration includes it, such as for files with an "ignore" tag.
+func BuildConfigForFile(filename string, content []byte, goos, goarch string) (BuildConfig, bool) {
+	osCandidates, archCandidates := []string{goos}, []string{goarch}
+	fileOS, fileArch := fileNameConstraint(filename)
+	if fileOS != "" {
+		osCandidates = []string{fileOS}
+	}
+	if fileArch != "" {
+		archCandidates = []string{fileArch}
+	}
+
+	expr, err := parseFileConstraint(content)
+	if err != nil {
+		return BuildConfig{}, false
+	}
+	var tags []string
+	if expr != nil {
+		for _, tag := range expr.tags(nil) {
+			switch {
+			case contains(KnownOS, tag):
+				if fileOS == "" && !contains(osCandidates, tag) {
+					osCandidates = append(osCandidates, tag)
+				}
+			case contains(KnownArch, tag):
+				if fileArch == "" && !contains(archCandidates, tag) {
+					archCandidates = append(archCandidates, tag)
+				}
+			case tag == "gc" || tag == "gccgo" || tag == "ignore" || contains(build.Default.ReleaseTags, tag):
+				// These tags are fixed by the toolchain.
+			case len(tags) < maxInferredTags && !contains(tags, tag):
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	var (
+		best     BuildConfig
+		bestCost = -1
+	)
+	for _, candOS := range osCandidates {
+		for _, arch := range archCandidates {
+			for mask := 0; mask < 1<<len(tags); mask++ {
+				config := BuildConfig{}
+				cost := 0
+				if candOS != goos {
+					config.GOOS = candOS
+					cost++
+				}
+				if arch != goarch {
+					config.GOARCH = arch
+					cost++
+				}
+				for i, tag := range tags {
+					if mask&(1<<i) != 0 {
+						config.Tags = append(config.Tags, tag)
+						cost++
+					}
+				}
+				if bestCost >= 0 && cost >= bestCost {
+					continue
+				}
+				if expr == nil || expr.eval(func(tag string) bool {
+					return matchTag(tag, candOS, arch, config.Tags)
+				}) {
+					best, bestCost = config, cost
+				}
+			}
+		}
+	}
+	if bestCost <= 0 {
+		return BuildConfig{}, false
+	}
+	return best, true
+}
+
+// matchTag reports whether the build tag is satisfied under the GOOS,
+// GOARCH, and custom tags, following the rules of go/build.
+func matchTag(tag, goos, goarch string, tags []string) bool {
+	switch {
+	case tag == goos || tag == goarch || tag == "gc":
+		return true
+	case tag == "linux" && goos == "android",
+		tag == "solaris" && goos == "illumos",
+		tag == "darwin" && goos == "ios":
+		return true
+	}
+	return contains(build.Default.ReleaseTags, tag) || contains(tags, tag)
+}
+
+// fileNameConstraint returns the GOOS and GOARCH implied by the _GOOS,
+// _GOARCH, or _GOOS_GOARCH suffix of the file name, if any.
+func fileNameConstraint(filename string) (goos, goarch string) {
+	name := strings.TrimSuffix(filepath.Base(filename), ".go")
+	// As with go/build, the part of the name before the first underscore
+	// is not a constraint, so that a file named "linux.go" matches any OS.
+	i := strings.Index(name, "_")
+	if i < 0 {
+		return "", ""
+	}
+	l := strings.Split(strings.TrimSuffix(name[i:], "_test"), "_")
+	n := len(l)
+	if n >= 2 && contains(KnownOS, l[n-2]) && contains(KnownArch, l[n-1]) {
+		return l[n-2], l[n-1]
+	}
+	if n >= 1 && contains(KnownOS, l[n-1]) {
+		return l[n-1], ""
+	}
+	if n >= 1 && contains(KnownArch, l[n-1]) {
+		return "", l[n-1]
+	}
+	return "", ""
+}
+
+// parseFileConstraint parses the build constraint of the file from its
+// //go:build line or, if it has none, from its // +build lines. It returns
+// nil if the file has no build constraint.
+func parseFileConstraint(content []byte) (constraintExpr, error) {
+	var (
+		goBuild    constraintExpr
+		plusBuilds []constraintExpr
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		// Build constraints may only be preceded by blank lines and other
+		// line comments.
+		if !strings.HasPrefix(line, "//") {
+			break
+		}
+		switch {
+		case strings.HasPrefix(line, "//go:build ") && goBuild == nil:
+			p := &constraintParser{text: line[len("//go:build "):]}
+			expr, err := p.parse()
+			if err != nil {
+				return nil, err
+			}
+			goBuild = expr
+		case strings.HasPrefix(line, "// +build "):
+			plusBuilds = append(plusBuilds, parsePlusBuild(line[len("// +build "):]))
+		}
+	}
+	if goBuild != nil {
+		return goBuild, nil
+	}
+	var expr constraintExpr
+	for _, x := range plusBuilds {
+		if expr == nil {
+			expr = x
+		} else {
+			expr = &andExpr{expr, x}
+		}
+	}
+	return expr, nil
+}
+
+// parsePlusBuild parses the options of a // +build line, which are
+// satisfied if any of them is. An option is a comma-separated list of
+// possibly negated tags, which must all be satisfied.
+func parsePlusBuild(text string) constraintExpr {
+	var expr constraintExpr
+	for _, option := range strings.Fields(text) {
+		var x constraintExpr
+		for _, term := range strings.Split(option, ",") {
+			var y constraintExpr
+			if strings.HasPrefix(term, "!") {
+				y = &notExpr{&tagExpr{strings.TrimPrefix(term, "!")}}
+			} else {
+				y = &tagExpr{term}
+			}
+			if x == nil {
+				x = y
+			} else {
+				x = &andExpr{x, y}
+			}
+		}
+		if expr == nil {
+			expr = x
+		} else {
+			expr = &orExpr{expr, x}
+		}
+	}
+	if expr == nil {
+		// A line without options is never satisfied.
+		return &tagExpr{"ignore"}
+	}
+	return expr
+}
+
+// A constraintExpr is a build constraint expression.
+type constraintExpr interface {
+	// eval reports whether the expression is satisfied when the tags for
+	// which ok returns true are set.
+	eval(ok func(tag string) bool) bool
+
+	// tags appends the tags of the expression to list.
+	tags(list []string) []string
+}
+
+type (
+	tagExpr struct{ tag string }
+	notExpr struct{ x constraintExpr }
+	andExpr struct{ x, y constraintExpr }
+	orExpr  struct{ x, y constraintExpr }
+)
+
+func (e *tagExpr) eval(ok func(string) bool) bool { return ok(e.tag) }
+func (e *notExpr) eval(ok func(string) bool) bool { return !e.x.eval(ok) }
+func (e *andExpr) eval(ok func(string) bool) bool { return e.x.eval(ok) && e.y.eval(ok) }
+func (e *orExpr) eval(ok func(string) bool) bool  { return e.x.eval(ok) || e.y.eval(ok) }
+
+func (e *tagExpr) tags(list []string) []string { return append(list, e.tag) }
+func (e *notExpr) tags(list []string) []string { return e.x.tags(list) }
+func (e *andExpr) tags(list []string) []string { return e.y.tags(e.x.tags(list)) }
+func (e *orExpr) tags(list []string) []string  { return e.y.tags(e.x.tags(list)) }
+
+// constraintParser parses the expression of a //go:build line:
+//
+//	expr = and { "||" and }
+//	and  = not { "&&" not }
+//	not  = "!" not | "(" expr ")" | tag
+type constraintParser struct {
+	text string
+	pos  int
+}
+
+func (p *constraintParser) parse() (constraintExpr, error) {
+	x, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpace(); p.pos < len(p.text) {
+		return nil, fmt.Errorf("unexpected %q in build constraint", p.text[p.pos:])
+	}
+	return x, nil
+}
+
+func (p *constraintParser) or() (constraintExpr, error) {
+	x, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.consume("||") {
+		y, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		x = &orExpr{x, y}
+	}
+	return x, nil
+}
+
+func (p *constraintParser) and() (constraintExpr, error) {
+	x, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for p.consume("&&") {
+		y, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		x = &andExpr{x, y}
+	}
+	return x, nil
+}
+
+func (p *constraintParser) not() (constraintExpr, error) {
+	switch {
+	case p.consume("!"):
+		x, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{x}, nil
+	case p.consume("("):
+		x, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume(")") {
+			return nil, fmt.Errorf("missing ) in build constraint")
+		}
+		return x, nil
+	}
+	start := p.pos
+	for p.pos < len(p.text) && isBuildTagByte(p.text[p.pos]) {
+		p.pos++
+	}
+	if p.pos == start {
+		return nil, fmt.Errorf("missing build tag in build constraint")
+	}
+	return &tagExpr{p.text[start:p.pos]}, nil
+}
+
+// consume skips the token if it is next, and reports whether it was.
+func (p *constraintParser) consume(token string) bool {
+	p.skipSpace()
+	if strings.HasPrefix(p.text[p.pos:], token) {
+		p.pos += len(token)
+		return true
+	}
+	return false
+}
+
+func (p *constraintParser) skipSpace() {
+	for p.pos < len(p.text) && (p.text[p.pos] == ' ' || p.text[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func isBuildTagByte(b byte) bool {
+	return 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || '0' <= b && b <= '9' || b == '_' || b == '.'
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
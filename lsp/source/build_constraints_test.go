@@ -0,0 +1,51 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildConfigForFile(t *testing.T) {
+	tests := []struct {
+		filename string
+		content  string
+		want     *BuildConfig
+	}{
+		{"a.go", "package a\n", nil},
+		{"linux.go", "package a\n", nil},
+		{"a_linux.go", "package a\n", nil},
+		{"a_windows.go", "package a\n", &BuildConfig{GOOS: "windows"}},
+		{"a_windows_test.go", "package a\n", &BuildConfig{GOOS: "windows"}},
+		{"a_arm64.go", "package a\n", &BuildConfig{GOARCH: "arm64"}},
+		{"a_darwin_arm64.go", "package a\n", &BuildConfig{GOOS: "darwin", GOARCH: "arm64"}},
+		{"a.go", "//go:build windows\n\npackage a\n", &BuildConfig{GOOS: "windows"}},
+		{"a.go", "//go:build linux && amd64\n\npackage a\n", nil},
+		{"a.go", "//go:build !linux\n\npackage a\n", nil},
+		{"a.go", "//go:build darwin || windows\n\npackage a\n", &BuildConfig{GOOS: "darwin"}},
+		{"a.go", "//go:build linux && integration\n\npackage a\n", &BuildConfig{Tags: []string{"integration"}}},
+		{"a.go", "//go:build (foo || bar) && !baz\n\npackage a\n", &BuildConfig{Tags: []string{"foo"}}},
+		{"a.go", "//go:build foo\n// +build bar\n\npackage a\n", &BuildConfig{Tags: []string{"foo"}}},
+		{"a.go", "// Copyright\n\n// +build windows,386 darwin\n// +build !cgo\n\npackage a\n", &BuildConfig{GOOS: "darwin"}},
+		{"a.go", "//go:build ignore\n\npackage main\n", nil},
+		{"a.go", "//go:build gccgo\n\npackage a\n", nil},
+		{"a.go", "//go:build linux &&\n\npackage a\n", nil},
+		{"a.go", "package a\n\n//go:build windows\n", nil},
+		{"a_windows.go", "//go:build integration\n\npackage a\n", &BuildConfig{GOOS: "windows", Tags: []string{"integration"}}},
+	}
+	for _, test := range tests {
+		got, ok := BuildConfigForFile(test.filename, []byte(test.content), "linux", "amd64")
+		if test.want == nil {
+			if ok {
+				t.Errorf("BuildConfigForFile(%q, %q) = %v, want none", test.filename, test.content, got)
+			}
+			continue
+		}
+		if !ok || !reflect.DeepEqual(got, *test.want) {
+			t.Errorf("BuildConfigForFile(%q, %q) = %v, %v, want %v", test.filename, test.content, got, ok, *test.want)
+		}
+	}
+}
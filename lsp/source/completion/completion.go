@@ -19,6 +19,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"text/template"
 	"time"
 	"unicode"
 
@@ -83,6 +84,14 @@ type CompletionItem struct {
 	// Documentation is the documentation for the completion item.
 	Documentation string
 
+	// Deprecated reports whether the documentation of the completion item
+	// marks it as deprecated.
+	Deprecated bool
+
+	// InsertTextMode is how the client should treat the indentation of
+	// the new lines of the item, if not as it does by default.
+	InsertTextMode protocol.InsertTextMode
+
 	// obj is the object from which this candidate was derived, if any.
 	// obj is for internal use only.
 	obj types.Object
@@ -98,6 +107,13 @@ type completionOptions struct {
 	snippets          bool
 	matcher           source.Matcher
 	budget            time.Duration
+	postfix           bool
+	postfixTemplates  map[string]*template.Template
+	snippetTemplates  []source.SnippetTemplate
+	workspaceRanking  bool
+	structFields      bool
+	optionalFieldTags []string
+	insertAsIs        bool
 }
 
 // Snippet is a convenience returns the snippet if available, otherwise
@@ -212,6 +228,10 @@ type completer struct {
 	// for deep completions.
 	methodSetCache map[methodSetKey]*types.MethodSet
 
+	// usage ranks methods, fields, and package members by how often the
+	// workspace selects them, if workspace ranking is enabled.
+	usage *usageRanking
+
 	// mapper converts the positions in the file from which the completion originated.
 	mapper *protocol.ColumnMapper
 
@@ -428,6 +448,12 @@ func Completion(ctx context.Context, snapshot source.Snapshot, fh source.FileHan
 
 	pkg, pgf, err := source.GetParsedFile(ctx, snapshot, fh, source.NarrowestPackage)
 	if err != nil || pgf.File.Package == token.NoPos {
+		// A file that is excluded by its build constraints, for example
+		// because of a misspelled tag, belongs to no package, but its
+		// constraints may still be completed.
+		if items, surrounding := excludedFileCompletions(ctx, snapshot, fh, protoPos); items != nil {
+			return items, surrounding, nil
+		}
 		// If we can't parse this file or find position for the package
 		// keyword, it may be missing a package declaration. Try offering
 		// suggestions for the package declaration.
@@ -460,11 +486,15 @@ func Completion(ctx context.Context, snapshot source.Snapshot, fh source.FileHan
 	// Check if completion at this position is valid. If not, return early.
 	switch n := path[0].(type) {
 	case *ast.BasicLit:
-		// Skip completion inside literals except for ImportSpec
+		// Skip completion inside literals except for ImportSpec and struct
+		// field tags.
 		if len(path) > 1 {
 			if _, ok := path[1].(*ast.ImportSpec); ok {
 				break
 			}
+			if field, ok := path[1].(*ast.Field); ok && field.Tag == n {
+				break
+			}
 		}
 		return nil, nil, nil
 	case *ast.CallExpr:
@@ -521,6 +551,13 @@ func Completion(ctx context.Context, snapshot source.Snapshot, fh source.FileHan
 			literal:           opts.LiteralCompletions && opts.InsertTextFormat == protocol.SnippetTextFormat,
 			budget:            opts.CompletionBudget,
 			snippets:          opts.InsertTextFormat == protocol.SnippetTextFormat,
+			postfix:           opts.PostfixCompletions,
+			postfixTemplates:  opts.ParsedPostfixTemplates,
+			snippetTemplates:  opts.SnippetTemplates,
+			workspaceRanking:  opts.WorkspaceRanking,
+			structFields:      opts.StructFieldsSnippet,
+			optionalFieldTags: opts.OptionalFieldTags,
+			insertAsIs:        opts.CompletionInsertAsIsSupported,
 		},
 		// default to a matcher that always matches
 		matcher:        prefixMatcher(""),
@@ -549,6 +586,10 @@ func Completion(ctx context.Context, snapshot source.Snapshot, fh source.FileHan
 
 	c.inference = expectedCandidate(ctx, c)
 
+	if c.opts.workspaceRanking {
+		c.usage = newUsageRanking(ctx, c.snapshot)
+	}
+
 	err = c.collectCompletions(ctx)
 	if err != nil {
 		return nil, nil, err
@@ -588,9 +629,19 @@ func (c *completer) collectCompletions(ctx context.Context) error {
 		return c.populateImportCompletions(ctx, importSpec)
 	}
 
-	// Inside comments, offer completions for the name of the relevant symbol.
+	// Inside struct tags, offer completions for tag keys and values.
+	if field := c.inStructTag(); field != nil {
+		c.structTagCompletions(field)
+		return nil
+	}
+
+	// Inside comments, offer completions for directives or for the name of
+	// the relevant symbol.
 	for _, comment := range c.file.Comments {
 		if comment.Pos() < c.pos && c.pos <= comment.End() {
+			if c.directiveCompletions(ctx, comment) {
+				return nil
+			}
 			c.populateCommentCompletions(ctx, comment)
 			return nil
 		}
@@ -1104,6 +1155,9 @@ func (c *completer) selector(ctx context.Context, sel *ast.SelectorExpr) error {
 		for _, cand := range candidates {
 			c.deepState.enqueue(cand)
 		}
+		if !tv.IsType() {
+			c.addPostfixSnippetCandidates(ctx, sel, tv.Type)
+		}
 		return nil
 	}
 
@@ -1214,7 +1268,7 @@ func (c *completer) packageMembers(pkg *types.Package, score float64, imp *impor
 		obj := scope.Lookup(name)
 		candidates = append(candidates, candidate{
 			obj:         obj,
-			score:       score,
+			score:       score * c.usage.boost(pkg.Path(), name),
 			imp:         imp,
 			addressable: isVar(obj),
 		})
@@ -1235,11 +1289,13 @@ func (c *completer) methodsAndFields(typ types.Type, addressable bool, imp *impo
 		c.methodSetCache[methodSetKey{typ, addressable}] = mset
 	}
 
+	recv := source.NamedTypeKey(typ)
 	var candidates []candidate
 	for i := 0; i < mset.Len(); i++ {
+		obj := mset.At(i).Obj()
 		candidates = append(candidates, candidate{
-			obj:         mset.At(i).Obj(),
-			score:       stdScore,
+			obj:         obj,
+			score:       stdScore * c.usage.boost(recv, obj.Name()),
 			imp:         imp,
 			addressable: addressable || isPointer(typ),
 		})
@@ -1249,7 +1305,7 @@ func (c *completer) methodsAndFields(typ types.Type, addressable bool, imp *impo
 	eachField(typ, func(v *types.Var) {
 		candidates = append(candidates, candidate{
 			obj:         v,
-			score:       stdScore - 0.01,
+			score:       (stdScore - 0.01) * c.usage.boost(recv, v.Name()),
 			imp:         imp,
 			addressable: addressable || isPointer(typ),
 		})
@@ -1554,6 +1610,7 @@ func (c *completer) structLiteralFieldName(ctx context.Context) error {
 	deltaScore := 0.0001
 	switch t := clInfo.clType.(type) {
 	case *types.Struct:
+		c.addStructFieldsCandidate(ctx, t, addedFields)
 		for i := 0; i < t.NumFields(); i++ {
 			field := t.Field(i)
 			if !addedFields[field] {
@@ -0,0 +1,113 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package completion
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kevinswiber/languageserver-go/lsp/cache"
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+	"github.com/kevinswiber/languageserver-go/span"
+)
+
+// complete returns the completions at the "‸" in the files of a module,
+// which are keyed by their slash-separated paths. The settings are
+// applied to the default options.
+func complete(t *testing.T, files map[string]string, settings map[string]interface{}) ([]CompletionItem, *Selection) {
+	t.Helper()
+	return completeWithOptions(t, files, func(options *source.Options) {
+		for _, r := range source.SetOptions(options, settings) {
+			if r.Error != nil {
+				t.Fatalf("setting %s: %v", r.Name, r.Error)
+			}
+		}
+	})
+}
+
+// completeWithOptions is like complete, but modifies the default options
+// with the function, which may also set the client options.
+func completeWithOptions(t *testing.T, files map[string]string, modify func(*source.Options)) ([]CompletionItem, *Selection) {
+	t.Helper()
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "gopls-completion-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	if _, ok := files["go.mod"]; !ok {
+		files["go.mod"] = "module example.com/m\n\ngo 1.16\n"
+	}
+	var uri span.URI
+	var pos protocol.Position
+	for name, content := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if i := strings.Index(content, "‸"); i >= 0 {
+			uri = span.URIFromPath(filename)
+			before := content[:i]
+			pos.Line = uint32(strings.Count(before, "\n"))
+			pos.Character = uint32(len(before) - strings.LastIndex(before, "\n") - 1)
+			content = before + content[i+len("‸"):]
+		}
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if uri == "" {
+		t.Fatal("no ‸ in files")
+	}
+
+	options := source.DefaultOptions().Clone()
+	options.Env = map[string]string{"GOPACKAGESDRIVER": "off"}
+	options.CompleteUnimported = false
+	options.InsertTextFormat = protocol.SnippetTextFormat
+	modify(options)
+	tmp, err := ioutil.TempDir("", "gopls-completion-workspace-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(tmp) })
+	session := cache.New(ctx, nil).NewSession(ctx)
+	view, snapshot, release, err := session.NewView(ctx, "completion_test", span.URIFromPath(dir), span.URIFromPath(tmp), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		release()
+		view.Shutdown(ctx)
+	})
+	// The workspace is type-checked, as it is once it is diagnosed, so
+	// that the completions are ranked by its selector counts.
+	if _, err := snapshot.WorkspacePackages(ctx); err != nil {
+		t.Fatal(err)
+	}
+	fh, err := snapshot.GetFile(ctx, uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, surrounding, err := Completion(ctx, snapshot, fh, pos, protocol.CompletionContext{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return items, surrounding
+}
+
+// findItem returns the item with the label, or nil.
+func findItem(items []CompletionItem, label string) *CompletionItem {
+	for i := range items {
+		if items[i].Label == label {
+			return &items[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package completion
+
+import "testing"
+
+func TestDeprecatedCompletion(t *testing.T) {
+	const dep = `package dep
+
+// Deprecated: use New.
+func Old() {}
+
+func New() {}
+
+type T struct {
+	// Deprecated: use G.
+	F int
+	G int
+}
+
+// Deprecated: use N.
+func (T) M() {}
+
+func (T) N() {}
+`
+	for _, test := range []struct {
+		src  string
+		want map[string]bool // whether each label is deprecated
+	}{
+		{"dep.‸", map[string]bool{"Old": true, "New": false}},
+		{"t.‸", map[string]bool{"F": true, "G": false, "M": true, "N": false}},
+	} {
+		items, _ := complete(t, map[string]string{
+			"dep/dep.go": dep,
+			"a.go":       "package m\n\nimport \"example.com/m/dep\"\n\nfunc f(t dep.T) {\n\t" + test.src + "\n}\n",
+		}, nil)
+		for label, want := range test.want {
+			item := findItem(items, label)
+			if item == nil {
+				t.Errorf("%s: no completion %q", test.src, label)
+				continue
+			}
+			if item.Deprecated != want {
+				t.Errorf("%s: completion %q deprecated = %t, want %t", test.src, label, item.Deprecated, want)
+			}
+		}
+	}
+}
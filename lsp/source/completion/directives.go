@@ -0,0 +1,467 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package completion
+
+import (
+	"context"
+	"go/ast"
+	"go/build"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/module"
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+	"github.com/kevinswiber/languageserver-go/span"
+)
+
+// directives are the directives whose names are completed after "//go:".
+var directives = []string{"go:build", "go:embed", "go:generate", "go:linkname"}
+
+// knownTags are the build tags other than GOOS and GOARCH that are set by
+// the go command.
+var knownTags = []string{"cgo", "gc", "gccgo", "ignore"}
+
+// directiveCompletions offers completions within a directive comment
+// of the group, such as //go:build or //go:embed. It reports whether the
+// position is within a directive.
+func (c *completer) directiveCompletions(ctx context.Context, group *ast.CommentGroup) bool {
+	var comment *ast.Comment
+	for _, cm := range group.List {
+		if cm.Pos() <= c.pos && c.pos <= cm.End() {
+			comment = cm
+			break
+		}
+	}
+	if comment == nil {
+		return false
+	}
+	text := comment.Text
+	offset := int(c.pos - comment.Pos())
+
+	var name string
+	switch {
+	case strings.HasPrefix(text, "// +build"):
+		name = "+build"
+	case strings.HasPrefix(text, "//go:"):
+		name = text[len("//"):]
+		if i := strings.IndexAny(name, " \t"); i >= 0 {
+			name = name[:i]
+		}
+	default:
+		return false
+	}
+	if name != "+build" && offset > len("//")+len(name) && !isCompletedDirective(name) {
+		// There is nothing to complete in the arguments of other
+		// directives.
+		return false
+	}
+
+	c.deepState.enabled = false
+	c.completionContext.commentCompletion = true
+	c.opts.documentation = false
+
+	// The position is in the directive's name.
+	if name != "+build" && offset <= len("//")+len(name) {
+		c.setSurroundingForDirective(comment, 2, func(b byte) bool { return b != ' ' && b != '\t' })
+		for _, d := range directives {
+			c.addDirectiveItem(d, protocol.KeywordCompletion, "directive")
+		}
+		return true
+	}
+
+	switch name {
+	case "go:build", "+build":
+		c.buildConstraintCompletions(ctx, comment, name == "go:build")
+	case "go:embed":
+		c.embedCompletions(comment)
+	case "go:generate":
+		c.generateCompletions(ctx, comment)
+	case "go:linkname":
+		c.linknameCompletions(comment)
+	}
+	return true
+}
+
+// isCompletedDirective reports whether the arguments of the directive
+// are completed.
+func isCompletedDirective(name string) bool {
+	for _, d := range directives {
+		if d == name {
+			return true
+		}
+	}
+	return false
+}
+
+// excludedFileCompletions offers completions within the build
+// constraints of a file that belongs to no package, since those
+// constraints may exclude it. It returns no items if the position is not
+// within a build constraint.
+func excludedFileCompletions(ctx context.Context, snapshot source.Snapshot, fh source.FileHandle, protoPos protocol.Position) ([]CompletionItem, *Selection) {
+	pgf, err := snapshot.ParseGo(ctx, fh, source.ParseHeader)
+	if err != nil {
+		return nil, nil
+	}
+	spn, err := pgf.Mapper.PointSpan(protoPos)
+	if err != nil {
+		return nil, nil
+	}
+	rng, err := spn.Range(pgf.Mapper.Converter)
+	if err != nil {
+		return nil, nil
+	}
+	for _, group := range pgf.File.Comments {
+		if !(group.Pos() < rng.Start && rng.Start <= group.End()) {
+			continue
+		}
+		c := &completer{
+			snapshot: snapshot,
+			fh:       fh,
+			filename: fh.URI().Filename(),
+			file:     pgf.File,
+			pos:      rng.Start,
+			opts: &completionOptions{
+				matcher: snapshot.View().Options().Matcher,
+			},
+			matcher: prefixMatcher(""),
+			mapper:  pgf.Mapper,
+		}
+		for _, comment := range group.List {
+			if comment.Pos() <= c.pos && c.pos <= comment.End() && isBuildConstraint(comment.Text) {
+				c.directiveCompletions(ctx, group)
+				c.sortItems()
+				return c.items, c.surrounding
+			}
+		}
+	}
+	return nil, nil
+}
+
+// isBuildConstraint reports whether the comment text is a build
+// constraint line.
+func isBuildConstraint(text string) bool {
+	return strings.HasPrefix(text, "//go:build") || strings.HasPrefix(text, "// +build")
+}
+
+// setSurroundingForDirective sets the surrounding to the run of
+// characters of comment, beginning at or after the offset start, that
+// contains the position and that satisfy isWordChar. It returns the
+// offset of the start of the surrounding.
+func (c *completer) setSurroundingForDirective(comment *ast.Comment, start int, isWordChar func(byte) bool) int {
+	text := comment.Text
+	offset := int(c.pos - comment.Pos())
+	begin, end := offset, offset
+	for begin > start && isWordChar(text[begin-1]) {
+		begin--
+	}
+	for end < len(text) && isWordChar(text[end]) {
+		end++
+	}
+	c.surrounding = &Selection{
+		content: text[begin:end],
+		cursor:  c.pos,
+		MappedRange: source.NewMappedRange(c.snapshot.FileSet(), c.mapper,
+			comment.Pos()+token.Pos(begin), comment.Pos()+token.Pos(end)),
+	}
+	c.setMatcherFromPrefix(c.surrounding.Prefix())
+	return begin
+}
+
+// addDirectiveItem adds an item for label, if it matches the prefix.
+func (c *completer) addDirectiveItem(label string, kind protocol.CompletionItemKind, detail string) {
+	if score := c.matcher.Score(label); score > 0 {
+		c.items = append(c.items, CompletionItem{
+			Label:      label,
+			Detail:     detail,
+			InsertText: label,
+			Kind:       kind,
+			Score:      stdScore * float64(score),
+		})
+	}
+}
+
+// directiveArgs returns the space-separated arguments of the directive
+// in comment that precede the position.
+func directiveArgs(comment *ast.Comment, pos token.Pos) []string {
+	before := comment.Text[:pos-comment.Pos()]
+	fields := strings.Fields(before)
+	if len(fields) > 0 && !strings.HasSuffix(before, " ") && !strings.HasSuffix(before, "\t") {
+		// The last field is being completed.
+		fields = fields[:len(fields)-1]
+	}
+	if len(fields) > 0 {
+		// Drop the directive itself.
+		fields = fields[1:]
+	}
+	if len(fields) > 0 && fields[0] == "+build" {
+		fields = fields[1:]
+	}
+	return fields
+}
+
+// buildConstraintCompletions offers build tags, and operators for
+// //go:build lines. The tags are the known values of GOOS and GOARCH,
+// release tags, and the custom tags used in the module.
+func (c *completer) buildConstraintCompletions(ctx context.Context, comment *ast.Comment, goBuild bool) {
+	begin := c.setSurroundingForDirective(comment, 0, isBuildTagChar)
+
+	// Offer operators after a complete term of a //go:build expression.
+	if goBuild && c.surrounding.Prefix() == "" {
+		prev := strings.TrimRight(comment.Text[:begin], " \t")
+		if !strings.HasSuffix(prev, "//go:build") && prev != "" {
+			if last := prev[len(prev)-1]; isBuildTagChar(last) || last == ')' {
+				for _, op := range []string{"&&", "||"} {
+					c.items = append(c.items, CompletionItem{
+						Label:      op,
+						InsertText: op,
+						Kind:       protocol.OperatorCompletion,
+						Score:      stdScore,
+					})
+				}
+				return
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	add := func(tags []string, detail string) {
+		for _, tag := range tags {
+			if !seen[tag] {
+				seen[tag] = true
+				c.addDirectiveItem(tag, protocol.ConstantCompletion, detail)
+			}
+		}
+	}
+	add(source.KnownOS, "GOOS")
+	add(source.KnownArch, "GOARCH")
+	add(knownTags, "build tag")
+	add(build.Default.ReleaseTags, "release tag")
+	add(c.workspaceBuildTags(ctx, comment), "custom build tag")
+}
+
+// isBuildTagChar reports whether b may occur in a build tag.
+func isBuildTagChar(b byte) bool {
+	return isValidIdentifierChar(b) || b == '.'
+}
+
+// workspaceBuildTags returns the build tags used by the .go files of the
+// module or view that contains the current file, other than the tags
+// that are set by the go command and those of the current line.
+func (c *completer) workspaceBuildTags(ctx context.Context, current *ast.Comment) []string {
+	known := make(map[string]bool)
+	for _, tags := range [][]string{source.KnownOS, source.KnownArch, knownTags, build.Default.ReleaseTags} {
+		for _, tag := range tags {
+			known[tag] = true
+		}
+	}
+	seen := make(map[string]bool)
+	var tags []string
+	addTags := func(lines []string) {
+		for _, line := range lines {
+			for _, tag := range buildConstraintTags(line) {
+				if !known[tag] && !seen[tag] {
+					seen[tag] = true
+					tags = append(tags, tag)
+				}
+			}
+		}
+	}
+	c.walkModuleGoFiles(ctx, func(filename string, f *ast.File) {
+		if filename != c.filename {
+			addTags(buildConstraintLines(f))
+		}
+	})
+	// The current file may be modified; omit the line being completed,
+	// so that its partial tag is not offered.
+	var lines []string
+	for _, line := range buildConstraintLines(c.file) {
+		if line != current.Text {
+			lines = append(lines, line)
+		}
+	}
+	addTags(lines)
+	sort.Strings(tags)
+	return tags
+}
+
+// walkModuleGoFiles calls fn for each .go file of the module, or view,
+// that contains the current file, parsed up to its imports. It
+// skips the directories that the go command ignores.
+func (c *completer) walkModuleGoFiles(ctx context.Context, fn func(filename string, f *ast.File)) {
+	root := c.snapshot.View().Folder()
+	if modURI := c.snapshot.GoModForFile(c.fh.URI()); modURI != "" {
+		root = span.URIFromPath(filepath.Dir(modURI.Filename()))
+	}
+	uris, err := c.snapshot.GoFilesInTree(ctx, root)
+	if err != nil {
+		return
+	}
+	for _, uri := range uris {
+		fh, err := c.snapshot.GetFile(ctx, uri)
+		if err != nil {
+			continue
+		}
+		pgf, err := c.snapshot.ParseGo(ctx, fh, source.ParseHeader)
+		if err != nil {
+			continue
+		}
+		fn(uri.Filename(), pgf.File)
+	}
+}
+
+// buildConstraintLines returns the text of the build constraint lines
+// of f, which precede its package clause.
+func buildConstraintLines(f *ast.File) []string {
+	var lines []string
+	for _, group := range f.Comments {
+		if group.Pos() >= f.Package {
+			break
+		}
+		for _, comment := range group.List {
+			if isBuildConstraint(comment.Text) {
+				lines = append(lines, comment.Text)
+			}
+		}
+	}
+	return lines
+}
+
+// buildConstraintTags returns the tags in a build constraint line.
+func buildConstraintTags(line string) []string {
+	line = strings.TrimPrefix(line, "//go:build")
+	line = strings.TrimPrefix(line, "// +build")
+	return strings.FieldsFunc(line, func(r rune) bool {
+		return r >= 0x80 || !isBuildTagChar(byte(r))
+	})
+}
+
+// embedCompletions offers the files and directories that may be embedded
+// by a //go:embed pattern. Like the go command, it omits files outside
+// of the package's directory tree and in other modules, and names that
+// are not valid in a module or that begin with "." or "_", unless the
+// prefix does.
+func (c *completer) embedCompletions(comment *ast.Comment) {
+	begin := c.setSurroundingForDirective(comment, len("//go:embed"), func(b byte) bool {
+		return b != ' ' && b != '\t' && b != '"' && b != '`'
+	})
+	prefix := c.surrounding.Prefix()
+	if strings.HasPrefix(prefix, "/") || begin <= len("//go:embed") {
+		return
+	}
+	dirPart, base := path.Split(prefix)
+	for _, elem := range strings.Split(strings.TrimSuffix(dirPart, "/"), "/") {
+		if elem == "." || elem == ".." {
+			return
+		}
+	}
+	pkgDir := filepath.Dir(c.filename)
+	dir := filepath.Join(pkgDir, filepath.FromSlash(dirPart))
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, info := range infos {
+		name := info.Name()
+		if (name[0] == '.' || name[0] == '_') && !strings.HasPrefix(base, name[:1]) {
+			continue
+		}
+		rel := dirPart + name
+		if module.CheckFilePath(rel) != nil {
+			continue
+		}
+		switch mode := info.Mode(); {
+		case mode.IsDir():
+			if _, err := os.Stat(filepath.Join(dir, name, "go.mod")); err == nil {
+				// The directory is in another module.
+				continue
+			}
+			c.addDirectiveItem(rel, protocol.FolderCompletion, "directory")
+		case mode.IsRegular():
+			c.addDirectiveItem(rel, protocol.FileCompletion, "file")
+		}
+	}
+}
+
+// generateCompletions offers commands for //go:generate: the tools that
+// the module depends on, by the convention of blank imports in a file
+// constrained by the "tools" build tag, as "go run <path>" or as the
+// name of the installed command.
+func (c *completer) generateCompletions(ctx context.Context, comment *ast.Comment) {
+	c.setSurroundingForDirective(comment, len("//go:generate"), func(b byte) bool { return b != ' ' && b != '\t' })
+	args := directiveArgs(comment, c.pos)
+	var tools []string
+	c.walkModuleGoFiles(ctx, func(filename string, f *ast.File) {
+		isTools := false
+		for _, line := range buildConstraintLines(f) {
+			for _, tag := range buildConstraintTags(line) {
+				isTools = isTools || tag == "tools"
+			}
+		}
+		if !isTools {
+			return
+		}
+		for _, imp := range f.Imports {
+			if imp.Name != nil && imp.Name.Name == "_" {
+				tools = append(tools, source.ImportPath(imp))
+			}
+		}
+	})
+	sort.Strings(tools)
+
+	switch {
+	case len(args) == 0:
+		c.addDirectiveItem("go", protocol.KeywordCompletion, "command")
+		for _, tool := range tools {
+			c.addDirectiveItem(path.Base(tool), protocol.KeywordCompletion, tool)
+		}
+	case len(args) == 1 && args[0] == "go":
+		c.addDirectiveItem("run", protocol.KeywordCompletion, "go run")
+	case len(args) == 2 && args[0] == "go" && args[1] == "run":
+		for _, tool := range tools {
+			c.addDirectiveItem(tool, protocol.ModuleCompletion, "tool")
+		}
+	}
+}
+
+// linknameCompletions offers the package-level functions and variables
+// of the current package as the first argument of //go:linkname, and
+// those of its imports, qualified by their paths, as the second.
+func (c *completer) linknameCompletions(comment *ast.Comment) {
+	c.setSurroundingForDirective(comment, len("//go:linkname"), func(b byte) bool { return b != ' ' && b != '\t' })
+	addScope := func(pkg *types.Package, qualify bool) {
+		scope := pkg.Scope()
+		for _, name := range scope.Names() {
+			var kind protocol.CompletionItemKind
+			switch scope.Lookup(name).(type) {
+			case *types.Func:
+				kind = protocol.FunctionCompletion
+			case *types.Var:
+				kind = protocol.VariableCompletion
+			default:
+				continue
+			}
+			label := name
+			if qualify {
+				label = pkg.Path() + "." + name
+			}
+			c.addDirectiveItem(label, kind, "")
+		}
+	}
+	switch len(directiveArgs(comment, c.pos)) {
+	case 0:
+		addScope(c.pkg.GetTypes(), false)
+	case 1:
+		for _, imp := range c.pkg.GetTypes().Imports() {
+			addScope(imp, true)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package completion
+
+import (
+	"go/ast"
+	"go/token"
+	"reflect"
+	"testing"
+)
+
+func TestBuildConstraintTags(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"//go:build linux && !cgo", []string{"linux", "cgo"}},
+		{"//go:build (darwin || freebsd) && go1.16", []string{"darwin", "freebsd", "go1.16"}},
+		{"// +build mytag,!windows 386", []string{"mytag", "windows", "386"}},
+	}
+
+	for _, test := range tests {
+		if got := buildConstraintTags(test.line); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("buildConstraintTags(%q) = %q, want %q", test.line, got, test.want)
+		}
+	}
+}
+
+func TestDirectiveArgs(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"//go:linkname ", nil},
+		{"//go:linkname lo", nil},
+		{"//go:linkname local ", []string{"local"}},
+		{"//go:linkname local runtime.na", []string{"local"}},
+		{"//go:generate go run ", []string{"go", "run"}},
+	}
+
+	for _, test := range tests {
+		comment := &ast.Comment{Slash: 1, Text: test.text}
+		got := directiveArgs(comment, comment.Pos()+token.Pos(len(test.text)))
+		if len(got) == 0 && len(test.want) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("directiveArgs(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
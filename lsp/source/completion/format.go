@@ -238,6 +238,7 @@ func (c *completer) item(ctx context.Context, cand candidate) (CompletionItem, e
 	if c.opts.fullDocumentation {
 		item.Documentation = hover.FullDocumentation
 	}
+	item.Deprecated = hover.Deprecated
 
 	return item, nil
 }
@@ -0,0 +1,456 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package completion
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/kevinswiber/languageserver-go/event"
+	"github.com/kevinswiber/languageserver-go/imports"
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/lsp/snippet"
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+	errors "golang.org/x/xerrors"
+)
+
+// A postfixTmpl is a postfix completion: a template that transforms the
+// expression before a trailing ".", as in "xs.for" or "err.ret".
+type postfixTmpl struct {
+	// label is the text that is typed after the "." to select the
+	// completion.
+	label string
+
+	// details is a short description of the completion.
+	details string
+
+	// body is the text/template that produces the completion. The
+	// template is executed with a *postfixTmplArgs; if it produces no
+	// output, the completion is not offered.
+	body string
+
+	tmpl *template.Template
+}
+
+// postfixTmpls are the built-in postfix completions.
+var postfixTmpls = []postfixTmpl{{
+	label:   "if",
+	details: "if expr {}",
+	body: `{{if and (eq .Kind "bool") .StmtOK -}}
+if {{.X}} {
+	{{.Cursor}}
+}
+{{- end}}`,
+}, {
+	label:   "not",
+	details: "!expr",
+	body:    `{{if eq .Kind "bool"}}!{{.X}}{{end}}`,
+}, {
+	label:   "for",
+	details: "for k, v := range expr {}",
+	body: `{{if and (eq .Kind "slice" "array" "string") .StmtOK -}}
+for {{.VarName "i"}}, {{.VarName "v"}} := range {{.X}} {
+	{{.Cursor}}
+}
+{{- else if and (eq .Kind "map") .StmtOK -}}
+for {{.VarName "k"}}, {{.VarName "v"}} := range {{.X}} {
+	{{.Cursor}}
+}
+{{- else if and (eq .Kind "chan") .StmtOK -}}
+for {{.VarName "v"}} := range {{.X}} {
+	{{.Cursor}}
+}
+{{- end}}`,
+}, {
+	label:   "len",
+	details: "len(expr)",
+	body:    `{{if eq .Kind "slice" "array" "map" "string" "chan"}}len({{.X}}){{end}}`,
+}, {
+	label:   "keys",
+	details: "collect the keys of a map",
+	body: `{{if and (eq .Kind "map") .StmtOK -}}
+{{$keys := .VarName "keys"}}{{$k := .VarName "k"}}{{$keys}} := make([]{{.TypeName .KeyType}}, 0, len({{.X}}))
+for {{$k}} := range {{.X}} {
+	{{$keys}} = append({{$keys}}, {{$k}})
+}
+{{.Cursor}}
+{{- end}}`,
+}, {
+	label:   "append",
+	details: "expr = append(expr, )",
+	body:    `{{if and (eq .Kind "slice") .StmtOK}}{{.X}} = append({{.X}}, {{.Cursor}}){{end}}`,
+}, {
+	label:   "print",
+	details: "print the value of expr",
+	body:    `{{if and .StmtOK (not (eq .Kind "tuple" "void"))}}{{.Import "fmt"}}.Printf("%s: %v\n", {{printf "%q" .X}}, {{.X}}){{end}}`,
+}, {
+	label:   "ret",
+	details: "if expr != nil { return expr }",
+	body: `{{if and .IsError .StmtOK -}}
+{{if .TestVar -}}
+if {{.X}} != nil {
+	{{.TestVar}}.Fatal({{.X}})
+}
+{{- else if .ReturnsError -}}
+if {{.X}} != nil {
+	return {{.ReturnZeros}}{{.Placeholder .X}}
+}
+{{- end}}
+{{- end}}`,
+}, {
+	label:   "err",
+	details: "v, err := expr; if err != nil {}",
+	body: `{{if and .ErrorCall .StmtOK -}}
+{{if .TestVar -}}
+{{.ResultVars}} := {{.X}}
+if {{.ErrVar}} != nil {
+	{{.TestVar}}.Fatal({{.ErrVar}})
+}
+{{.Cursor}}
+{{- else if .ReturnsError -}}
+{{.ResultVars}} := {{.X}}
+if {{.ErrVar}} != nil {
+	return {{.ReturnZeros}}{{.Placeholder .ErrVar}}
+}
+{{.Cursor}}
+{{- end}}
+{{- end}}`,
+}}
+
+func init() {
+	for i := range postfixTmpls {
+		postfixTmpls[i].tmpl = template.Must(template.New(postfixTmpls[i].label).Parse(postfixTmpls[i].body))
+	}
+}
+
+// postfixTmplArgs is the data passed to postfix templates. Its exported
+// fields and methods are available to user-defined templates.
+type postfixTmplArgs struct {
+	// X is the text of the expression before the ".".
+	X string
+
+	// Kind is the kind of the type of the expression: "bool", "string",
+	// "number", "slice", "array", "map", "chan", "pointer", "func",
+	// "struct", "interface", "tuple", or "void".
+	Kind string
+
+	// StmtOK reports whether the expression is a statement, so that the
+	// completion may produce statements.
+	StmtOK bool
+
+	// Type is the type of the expression.
+	Type types.Type
+
+	// IsError reports whether the expression is of type error.
+	IsError bool
+
+	// ErrorCall reports whether the expression is a call whose last
+	// result is an error.
+	ErrorCall bool
+
+	// ReturnsError reports whether the enclosing function's last result
+	// is an error.
+	ReturnsError bool
+
+	// TestVar is the name of the testing.TB parameter of the enclosing
+	// function, if any.
+	TestVar string
+
+	ctx     context.Context
+	c       *completer
+	snip    *snippet.Builder
+	edits   []protocol.TextEdit
+	names   map[string]bool
+	results []string
+}
+
+// Write writes the output of the template to the snippet.
+func (a *postfixTmplArgs) Write(b []byte) (int, error) {
+	a.snip.WriteText(string(b))
+	return len(b), nil
+}
+
+// Placeholder writes a placeholder with the given text to the snippet.
+func (a *postfixTmplArgs) Placeholder(text string) string {
+	a.snip.WritePlaceholder(func(b *snippet.Builder) {
+		b.WriteText(text)
+	})
+	return ""
+}
+
+// Cursor writes the final cursor position to the snippet.
+func (a *postfixTmplArgs) Cursor() string {
+	a.snip.WriteFinalTabstop()
+	return ""
+}
+
+// ElemType returns the element type of a slice, array, map, channel, or
+// pointer, or nil.
+func (a *postfixTmplArgs) ElemType() types.Type {
+	if e, ok := a.Type.Underlying().(interface{ Elem() types.Type }); ok {
+		return e.Elem()
+	}
+	return nil
+}
+
+// KeyType returns the key type of a map, or nil.
+func (a *postfixTmplArgs) KeyType() types.Type {
+	if m, ok := a.Type.Underlying().(*types.Map); ok {
+		return m.Key()
+	}
+	return nil
+}
+
+// TypeName returns the name of t, qualified for use in the current file.
+func (a *postfixTmplArgs) TypeName(t types.Type) (string, error) {
+	if t == nil {
+		return "", errors.New("no type")
+	}
+	return types.TypeString(t, a.c.qf), nil
+}
+
+// Zero returns the zero value of t.
+func (a *postfixTmplArgs) Zero(t types.Type) (string, error) {
+	if t == nil {
+		return "", errors.New("no type")
+	}
+	return a.c.zeroValue(t), nil
+}
+
+// ReturnZeros returns the zero values of all but the last result of the
+// enclosing function, each followed by ", ".
+func (a *postfixTmplArgs) ReturnZeros() string {
+	if a.c.enclosingFunc == nil {
+		return ""
+	}
+	var b strings.Builder
+	results := a.c.enclosingFunc.sig.Results()
+	for i := 0; i < results.Len()-1; i++ {
+		b.WriteString(a.c.zeroValue(results.At(i).Type()))
+		b.WriteString(", ")
+	}
+	return b.String()
+}
+
+// VarName returns name, or name with a numeric suffix if name is already
+// declared at the position or has been returned before.
+func (a *postfixTmplArgs) VarName(name string) string {
+	scope := a.c.pkg.GetTypes().Scope().Innermost(a.c.pos)
+	for i := 0; ; i++ {
+		n := name
+		if i > 0 {
+			n = fmt.Sprintf("%s%d", name, i)
+		}
+		if a.names[n] {
+			continue
+		}
+		if scope != nil {
+			if _, obj := scope.LookupParent(n, a.c.pos); obj != nil {
+				continue
+			}
+		}
+		a.names[n] = true
+		return n
+	}
+}
+
+// ResultVars returns the comma-separated names of variables for the
+// results of an ErrorCall expression. The last variable, which holds the
+// error, is named by ErrVar.
+func (a *postfixTmplArgs) ResultVars() string {
+	if a.results == nil {
+		tuple, ok := a.Type.(*types.Tuple)
+		if !ok {
+			// A call with a single result.
+			tuple = types.NewTuple(types.NewVar(token.NoPos, nil, "", a.Type))
+		}
+		for i := 0; i < tuple.Len()-1; i++ {
+			a.results = append(a.results, a.VarName("v"))
+		}
+		a.results = append(a.results, a.VarName("err"))
+	}
+	return strings.Join(a.results, ", ")
+}
+
+// ErrVar returns the name of the error variable of ResultVars.
+func (a *postfixTmplArgs) ErrVar() string {
+	a.ResultVars()
+	return a.results[len(a.results)-1]
+}
+
+// Import returns the name by which the package with the given path is
+// referred to in the current file, adding an import of it if necessary.
+func (a *postfixTmplArgs) Import(path string) (string, error) {
+	for _, imp := range a.c.file.Imports {
+		if source.ImportPath(imp) != path {
+			continue
+		}
+		if imp.Name != nil {
+			if imp.Name.Name == "_" || imp.Name.Name == "." {
+				continue
+			}
+			return imp.Name.Name, nil
+		}
+		for _, p := range a.c.pkg.GetTypes().Imports() {
+			if p.Path() == path {
+				return p.Name(), nil
+			}
+		}
+	}
+	// Like goimports, name the import if the package's name is not the
+	// one that its path suggests.
+	name := imports.ImportPathToAssumedName(path)
+	imp := &importInfo{importPath: path}
+	if known, err := a.c.snapshot.CachedImportPaths(a.ctx); err == nil {
+		if pkg, ok := known[path]; ok && pkg.GetTypes().Name() != name {
+			name = pkg.GetTypes().Name()
+			imp.name = name
+		}
+	}
+	edits, err := a.c.importEdits(imp)
+	if err != nil {
+		return "", err
+	}
+	a.edits = append(a.edits, edits...)
+	return name, nil
+}
+
+// addPostfixSnippetCandidates adds the postfix completions that apply to
+// the expression sel.X, whose type is typ.
+func (c *completer) addPostfixSnippetCandidates(ctx context.Context, sel *ast.SelectorExpr, typ types.Type) {
+	if !c.opts.postfix || !c.opts.snippets {
+		return
+	}
+	ctx, done := event.Start(ctx, "completion.addPostfixSnippetCandidates")
+	defer done()
+
+	// The "X." that precedes the completion is removed by an additional
+	// edit, since the completion replaces only the selected identifier.
+	fset := c.snapshot.FileSet()
+	sr, err := c.getSurrounding().Range()
+	if err != nil {
+		return
+	}
+	xr, err := source.NewMappedRange(fset, c.mapper, sel.X.Pos(), sel.X.Pos()).Range()
+	if err != nil {
+		return
+	}
+	deleteX := protocol.TextEdit{
+		Range: protocol.Range{Start: xr.Start, End: sr.Start},
+	}
+
+	tmpls := append([]postfixTmpl(nil), postfixTmpls...)
+	var labels []string
+	for label := range c.opts.postfixTemplates {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		tmpls = append(tmpls, postfixTmpl{label: label, details: "postfix template", tmpl: c.opts.postfixTemplates[label]})
+	}
+
+	for _, tmpl := range tmpls {
+		if c.matcher.Score(tmpl.label) <= 0 {
+			continue
+		}
+		args := c.postfixTmplArgs(ctx, sel, typ)
+		if err := tmpl.tmpl.Execute(args, args); err != nil {
+			event.Error(ctx, "executing postfix template", err)
+			continue
+		}
+		if strings.TrimSpace(args.snip.String()) == "" {
+			continue
+		}
+		c.items = append(c.items, CompletionItem{
+			Label:               tmpl.label,
+			Detail:              tmpl.details,
+			Kind:                protocol.SnippetCompletion,
+			Score:               lowScore,
+			AdditionalTextEdits: append([]protocol.TextEdit{deleteX}, args.edits...),
+			snippet:             args.snip,
+		})
+	}
+}
+
+// postfixTmplArgs returns the template data for the expression sel.X.
+func (c *completer) postfixTmplArgs(ctx context.Context, sel *ast.SelectorExpr, typ types.Type) *postfixTmplArgs {
+	errorType := types.Universe.Lookup("error").Type()
+	a := &postfixTmplArgs{
+		X:       source.FormatNode(c.snapshot.FileSet(), sel.X),
+		Kind:    typeKind(typ),
+		Type:    typ,
+		IsError: types.Identical(typ, errorType),
+		TestVar: getTestVar(c.enclosingFunc, c.pkg),
+		ctx:     ctx,
+		c:       c,
+		snip:    &snippet.Builder{},
+		names:   make(map[string]bool),
+	}
+	for i, n := range c.path {
+		if n == sel && i+1 < len(c.path) {
+			_, a.StmtOK = c.path[i+1].(*ast.ExprStmt)
+			break
+		}
+	}
+	if _, ok := sel.X.(*ast.CallExpr); ok {
+		switch t := typ.(type) {
+		case *types.Tuple:
+			a.ErrorCall = t.Len() > 0 && types.Identical(t.At(t.Len()-1).Type(), errorType)
+		default:
+			a.ErrorCall = a.IsError
+		}
+	}
+	if c.enclosingFunc != nil {
+		results := c.enclosingFunc.sig.Results()
+		a.ReturnsError = results.Len() > 0 && types.Identical(results.At(results.Len()-1).Type(), errorType)
+	}
+	return a
+}
+
+// typeKind returns the kind of t for postfix templates.
+func typeKind(t types.Type) string {
+	if t == nil {
+		return "void"
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return "bool"
+		case u.Info()&types.IsString != 0:
+			return "string"
+		case u.Info()&types.IsNumeric != 0:
+			return "number"
+		}
+	case *types.Slice:
+		return "slice"
+	case *types.Array:
+		return "array"
+	case *types.Map:
+		return "map"
+	case *types.Chan:
+		return "chan"
+	case *types.Pointer:
+		return "pointer"
+	case *types.Signature:
+		return "func"
+	case *types.Struct:
+		return "struct"
+	case *types.Interface:
+		return "interface"
+	case *types.Tuple:
+		if u.Len() == 0 {
+			return "void"
+		}
+		return "tuple"
+	}
+	return ""
+}
@@ -0,0 +1,155 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package completion
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPostfixSnippets(t *testing.T) {
+	userTemplates := map[string]interface{}{
+		"postfixTemplates": map[string]interface{}{
+			"dump":  `{{if .StmtOK}}{{.Import "example.com/m/util"}}.Dump({{.X}}){{end}}`,
+			"debug": `{{if .StmtOK}}{{.Import "log"}}.Printf("%#v\n", {{.X}}){{end}}`,
+			"slice": `{{if eq .Kind "slice"}}{{.X}}[{{.Placeholder "i"}}:]{{end}}`,
+		},
+	}
+	util := "package helpers\n\nfunc Dump(interface{}) {}\n"
+	tests := []struct {
+		name     string
+		src      string
+		settings map[string]interface{}
+		label    string
+		want     string // the snippet, or "" if the completion is not offered
+		imp      string // the added import, if any
+	}{
+		{
+			name:  "for over slice",
+			src:   "package m\n\nfunc F(xs []int) {\n\txs.fo‸\n}\n",
+			label: "for",
+			want:  "for i, v := range xs {\n\t$0\n\\}",
+		},
+		{
+			name:  "for over map avoids declared names",
+			src:   "package m\n\nfunc F(m map[string]int, k int) {\n\tm.fo‸\n}\n",
+			label: "for",
+			want:  "for k1, v := range m {\n\t$0\n\\}",
+		},
+		{
+			name:  "for over bool",
+			src:   "package m\n\nfunc F(b bool) {\n\tb.fo‸\n}\n",
+			label: "for",
+		},
+		{
+			name:  "keys",
+			src:   "package m\n\nfunc F(m map[string]int) {\n\tm.key‸\n}\n",
+			label: "keys",
+			want:  "keys := make([]string, 0, len(m))\nfor k := range m {\n\tkeys = append(keys, k)\n\\}\n$0",
+		},
+		{
+			name:  "ret",
+			src:   "package m\n\nfunc F() (int, error) {\n\tvar err error\n\terr.re‸\n}\n",
+			label: "ret",
+			want:  "if err != nil {\n\treturn 0, ${1:err}\n\\}",
+		},
+		{
+			name:  "ret without error result",
+			src:   "package m\n\nfunc F() int {\n\tvar err error\n\terr.re‸\n}\n",
+			label: "ret",
+		},
+		{
+			name:  "not in expression",
+			src:   "package m\n\nfunc F(b bool) bool {\n\treturn b.no‸\n}\n",
+			label: "not",
+			want:  "!b",
+		},
+		{
+			name:  "if needs a statement",
+			src:   "package m\n\nfunc F(b bool) bool {\n\treturn b.i‸\n}\n",
+			label: "if",
+		},
+		{
+			name:  "print imports fmt",
+			src:   "package m\n\nfunc F(x int) {\n\tx.pri‸\n}\n",
+			label: "print",
+			want:  `fmt.Printf("%s: %v\\n", "x", x)`,
+			imp:   `import "fmt"`,
+		},
+		{
+			// The expression is not part of the format string.
+			name:  "print expression with percent",
+			src:   "package m\n\nfunc F(x int) {\n\t(x % 2).pri‸\n}\n",
+			label: "print",
+			want:  `fmt.Printf("%s: %v\\n", "(x % 2)", (x % 2))`,
+			imp:   `import "fmt"`,
+		},
+		{
+			name:     "user template",
+			src:      "package m\n\nfunc F(xs []int) []int {\n\treturn xs.sl‸\n}\n",
+			settings: userTemplates,
+			label:    "slice",
+			want:     "xs[${1:i}:]",
+		},
+		{
+			name:     "user template not applicable",
+			src:      "package m\n\nfunc F(x int) int {\n\treturn x.sl‸\n}\n",
+			settings: userTemplates,
+			label:    "slice",
+		},
+		{
+			name:     "user template with existing import",
+			src:      "package m\n\nimport \"log\"\n\nvar _ = log.Print\n\nfunc F(x int) {\n\tx.deb‸\n}\n",
+			settings: userTemplates,
+			label:    "debug",
+			want:     `log.Printf("%#v\\n", x)`,
+		},
+		{
+			name:     "user template imports package named unlike its path",
+			src:      "package m\n\nfunc F(x int) {\n\tx.du‸\n}\n",
+			settings: userTemplates,
+			label:    "dump",
+			want:     "helpers.Dump(x)",
+			imp:      `import helpers "example.com/m/util"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			items, _ := complete(t, map[string]string{
+				"a.go": test.src,
+				// Load the package, so that its name is known.
+				"b.go":         "package m\n\nimport \"example.com/m/util\"\n\nvar _ = helpers.Dump\n",
+				"util/util.go": util,
+			}, test.settings)
+			item := findItem(items, test.label)
+			if test.want == "" {
+				if item != nil {
+					t.Fatalf("got completion %q with snippet %q, want none", item.Label, item.Snippet())
+				}
+				return
+			}
+			if item == nil {
+				t.Fatalf("no completion %q", test.label)
+			}
+			if got := item.Snippet(); got != test.want {
+				t.Errorf("snippet = %q, want %q", got, test.want)
+			}
+			// The first edit deletes the expression and the ".".
+			edits := item.AdditionalTextEdits
+			if len(edits) == 0 || edits[0].NewText != "" || edits[0].Range.Start.Line != edits[0].Range.End.Line {
+				t.Fatalf("additional edits = %v, want a deletion of the expression first", edits)
+			}
+			var imports []string
+			for _, e := range edits[1:] {
+				if s := strings.TrimSpace(e.NewText); s != "" {
+					imports = append(imports, s)
+				}
+			}
+			if got := strings.Join(imports, "\n"); got != test.imp {
+				t.Errorf("added import = %q, want %q", got, test.imp)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package completion
+
+import (
+	"context"
+
+	"github.com/kevinswiber/languageserver-go/event"
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+)
+
+// usageRanking boosts the scores of methods, fields, and package members
+// by how often the workspace packages select them.
+type usageRanking struct {
+	stats source.SelectorStats
+
+	// max caches the highest count of a member of each receiver.
+	max map[string]int
+}
+
+// newUsageRanking returns the ranking for the workspace of the snapshot,
+// or nil if its statistics are not available.
+func newUsageRanking(ctx context.Context, snapshot source.Snapshot) *usageRanking {
+	stats, err := snapshot.SelectorStats(ctx)
+	if err != nil {
+		event.Error(ctx, "computing selector statistics", err)
+		return nil
+	}
+	return &usageRanking{
+		stats: stats,
+		max:   make(map[string]int),
+	}
+}
+
+// boost returns the factor by which to multiply the score of the member
+// name of recv, a named type or a package path. The factor grows from 1,
+// for a member the workspace never selects, to 2, for the most selected
+// member of recv, so that usage orders candidates that are otherwise
+// equally relevant without overriding the type-based ranking.
+func (u *usageRanking) boost(recv, name string) float64 {
+	if u == nil || recv == "" {
+		return 1
+	}
+	n := u.stats[source.SelectorKey{Recv: recv, Name: name}]
+	if n == 0 {
+		return 1
+	}
+	max, ok := u.max[recv]
+	if !ok {
+		for k, n := range u.stats {
+			if k.Recv == recv && n > max {
+				max = n
+			}
+		}
+		u.max[recv] = max
+	}
+	return 1 + float64(n)/float64(max)
+}
@@ -0,0 +1,93 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package completion
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+)
+
+func TestUsageRankingBoost(t *testing.T) {
+	u := &usageRanking{
+		stats: source.SelectorStats{
+			{Recv: "net/http.Client", Name: "Do"}:    4,
+			{Recv: "net/http.Client", Name: "Get"}:   2,
+			{Recv: "net/http.Client", Name: "Close"}: 1,
+			{Recv: "net/http", Name: "Get"}:          10,
+		},
+		max: make(map[string]int),
+	}
+	for _, test := range []struct {
+		recv, name string
+		want       float64
+	}{
+		{"net/http.Client", "Do", 2},
+		{"net/http.Client", "Get", 1.5},
+		{"net/http.Client", "Close", 1.25},
+		{"net/http.Client", "Head", 1},
+		{"net/http", "Get", 2},
+		{"net/http", "Post", 1},
+		{"", "Do", 1},
+	} {
+		if got := u.boost(test.recv, test.name); got != test.want {
+			t.Errorf("boost(%q, %q) = %v, want %v", test.recv, test.name, got, test.want)
+		}
+	}
+
+	// Without statistics, no candidate is boosted.
+	var none *usageRanking
+	if got := none.boost("net/http.Client", "Do"); got != 1 {
+		t.Errorf("boost without statistics = %v, want 1", got)
+	}
+}
+
+func TestUsageRankingOrder(t *testing.T) {
+	files := map[string]string{
+		"a/a.go": `package a
+
+type T struct{}
+
+func (T) Alpha() {}
+
+func (T) Zeta() {}
+`,
+		"b/b.go": `package b
+
+import "example.com/m/a"
+
+func f(t a.T) {
+	t.Zeta()
+	t.Zeta()
+	t.Alpha()
+}
+`,
+		"c/c.go": `package c
+
+import "example.com/m/a"
+
+func g(t a.T) {
+	t.‸
+}
+`,
+	}
+	order := func(settings map[string]interface{}) []string {
+		t.Helper()
+		items, _ := complete(t, files, settings)
+		if len(items) < 2 {
+			t.Fatalf("got %d completions, want at least 2", len(items))
+		}
+		return []string{items[0].Label, items[1].Label}
+	}
+	if got, want := order(nil), []string{"Alpha", "Zeta"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("completions without workspace ranking = %v, want %v", got, want)
+	}
+	// The method the workspace selects most often comes first, although
+	// the candidates are otherwise equally relevant.
+	if got, want := order(map[string]interface{}{"workspaceRanking": true}), []string{"Zeta", "Alpha"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("completions with workspace ranking = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package completion
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/lsp/snippet"
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+)
+
+// addSnippetTemplateCandidates offers the user-defined snippet templates
+// whose context matches the position, expanding their variables.
+func (c *completer) addSnippetTemplateCandidates() {
+	if len(c.opts.snippetTemplates) == 0 || !c.opts.snippets {
+		return
+	}
+	context := c.snippetContext()
+	if context == "" {
+		return
+	}
+	for _, tmpl := range c.opts.snippetTemplates {
+		if tmpl.Context != context {
+			continue
+		}
+		matchScore := c.matcher.Score(tmpl.Name)
+		if matchScore <= 0 {
+			continue
+		}
+		t, err := snippet.ParseTemplate(tmpl.Body)
+		if err != nil {
+			// The template was validated when the option was set.
+			continue
+		}
+		var snip snippet.Builder
+		snip.WriteTemplate(t, c.snippetVariable)
+		c.items = append(c.items, CompletionItem{
+			Label:   tmpl.Name,
+			Detail:  "snippet",
+			Kind:    protocol.SnippetCompletion,
+			Score:   stdScore * float64(matchScore),
+			snippet: &snip,
+		})
+	}
+}
+
+// snippetContext returns the context of snippet templates that may be
+// offered at the position, if any.
+func (c *completer) snippetContext() source.SnippetContext {
+	// Like the other candidates, templates are not offered in comments,
+	// struct tags or other literals.
+	if c.inComment() || c.inStructTag() != nil {
+		return ""
+	}
+	switch n := c.path[0].(type) {
+	case *ast.BasicLit:
+		return ""
+	case *ast.BadDecl, *ast.File:
+		// At the file scope, non-keyword identifiers become bad
+		// declarations.
+		return source.DeclarationSnippet
+	case *ast.BlockStmt:
+		if n.Lbrace < c.pos && c.pos <= n.Rbrace {
+			return source.StatementSnippet
+		}
+	case *ast.Ident:
+		if len(c.path) < 2 {
+			return ""
+		}
+		// The identifier must begin a statement.
+		switch p := c.path[1].(type) {
+		case *ast.BlockStmt:
+			return source.StatementSnippet
+		case *ast.ExprStmt:
+			if p.X == n {
+				return source.StatementSnippet
+			}
+		case *ast.CaseClause:
+			if p.Colon.IsValid() && c.pos > p.Colon {
+				return source.StatementSnippet
+			}
+		case *ast.CommClause:
+			if p.Colon.IsValid() && c.pos > p.Colon {
+				return source.StatementSnippet
+			}
+		}
+	}
+	return ""
+}
+
+// inComment reports whether the position is inside a comment.
+func (c *completer) inComment() bool {
+	for _, comment := range c.file.Comments {
+		if comment.Pos() < c.pos && c.pos <= comment.End() {
+			return true
+		}
+	}
+	return false
+}
+
+// snippetVariable returns the value of the named snippet template
+// variable at the position.
+func (c *completer) snippetVariable(name string) (string, bool) {
+	switch name {
+	case "pkgName":
+		return c.pkg.GetTypes().Name(), true
+	case "receiver", "receiverType":
+		fn := c.enclosingFuncDecl()
+		if fn == nil {
+			return "", false
+		}
+		recv := fn.Type().(*types.Signature).Recv()
+		if recv == nil {
+			return "", false
+		}
+		if name == "receiverType" {
+			return types.TypeString(recv.Type(), c.qf), true
+		}
+		if recv.Name() == "_" {
+			return "", true
+		}
+		return recv.Name(), true
+	case "funcName":
+		fn := c.enclosingFuncDecl()
+		if fn == nil {
+			return "", false
+		}
+		return fn.Name(), true
+	case "zeroValues":
+		if c.enclosingFunc == nil {
+			return "", false
+		}
+		var zeros []string
+		results := c.enclosingFunc.sig.Results()
+		for i := 0; i < results.Len(); i++ {
+			zeros = append(zeros, c.zeroValue(results.At(i).Type()))
+		}
+		return strings.Join(zeros, ", "), true
+	case "testVar":
+		return getTestVar(c.enclosingFunc, c.pkg), true
+	}
+	return "", false
+}
+
+// enclosingFuncDecl returns the function or method whose declaration
+// encloses the position, even within a function literal.
+func (c *completer) enclosingFuncDecl() *types.Func {
+	for _, n := range c.path {
+		if decl, ok := n.(*ast.FuncDecl); ok {
+			fn, _ := c.pkg.GetTypesInfo().Defs[decl.Name].(*types.Func)
+			return fn
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package completion
+
+import "testing"
+
+func TestSnippetTemplates(t *testing.T) {
+	settings := map[string]interface{}{
+		"snippetTemplates": []interface{}{
+			map[string]interface{}{
+				"name":    "logerr",
+				"context": "statement",
+				"body":    "if err != nil {\n\tlog.Printf(\"$funcName: %v\", err)\n\treturn $zeroValues\n}",
+			},
+			map[string]interface{}{
+				"name":    "loghandler",
+				"context": "declaration",
+				"body":    "func ${1:handler}(w http.ResponseWriter, r *http.Request) {\n\t$0\n}",
+			},
+		},
+	}
+	tests := []struct {
+		name  string
+		src   string
+		label string
+		want  string // the snippet, or "" if it is not offered
+	}{
+		{
+			name:  "statement",
+			src:   "package m\n\nfunc F() (int, error) {\n\tlog‸\n}\n",
+			label: "logerr",
+			want:  "if err != nil {\n\tlog.Printf(\"F: %v\", err)\n\treturn 0, nil\n\\}",
+		},
+		{
+			name:  "declaration",
+			src:   "package m\n\nlog‸\n",
+			label: "loghandler",
+			want:  "func ${1:handler}(w http.ResponseWriter, r *http.Request) {\n\t$0\n\\}",
+		},
+		{
+			name:  "statement not in declaration context",
+			src:   "package m\n\nlog‸\n",
+			label: "logerr",
+		},
+		{
+			name:  "comment",
+			src:   "package m\n\nfunc F() error {\n\t// log‸\n\treturn nil\n}\n",
+			label: "logerr",
+		},
+		{
+			name:  "comment at file scope",
+			src:   "package m\n\n// log‸\n",
+			label: "loghandler",
+		},
+		{
+			name:  "struct tag",
+			src:   "package m\n\ntype T struct {\n\tF int `log‸`\n}\n",
+			label: "loghandler",
+		},
+		{
+			name:  "string literal",
+			src:   "package m\n\nfunc F() error {\n\t_ = \"log‸\"\n\treturn nil\n}\n",
+			label: "logerr",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			items, _ := complete(t, map[string]string{"a.go": test.src}, settings)
+			item := findItem(items, test.label)
+			if test.want == "" {
+				if item != nil {
+					t.Fatalf("got template %q, want none", item.Snippet())
+				}
+				return
+			}
+			if item == nil {
+				t.Fatalf("no completion %q", test.label)
+			}
+			if got := item.Snippet(); got != test.want {
+				t.Errorf("snippet = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
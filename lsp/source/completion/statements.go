@@ -20,6 +20,7 @@ import (
 func (c *completer) addStatementCandidates() {
 	c.addErrCheck()
 	c.addAssignAppend()
+	c.addSnippetTemplateCandidates()
 }
 
 // addAssignAppend offers a completion candidate of the form:
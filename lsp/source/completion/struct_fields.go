@@ -0,0 +1,194 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package completion
+
+import (
+	"context"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strings"
+
+	"github.com/kevinswiber/languageserver-go/analysisinternal"
+	"github.com/kevinswiber/languageserver-go/lsp/analysis/fillstruct"
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/lsp/snippet"
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+)
+
+// addStructFieldsCandidate offers a snippet that sets the fields of the
+// struct literal that are not yet set, for example turning "Foo{<>}" into
+// "Foo{Name: ${1:""}, Age: ${2:0}}". Each value is what the fillstruct
+// code action would use: a variable in scope whose name is most like the
+// field's, or else a value of the field's type.
+func (c *completer) addStructFieldsCandidate(ctx context.Context, st *types.Struct, addedFields map[*types.Var]bool) {
+	if !c.opts.structFields || !c.opts.snippets {
+		return
+	}
+	clInfo := c.enclosingCompositeLiteral
+	if clInfo.kv != nil {
+		return
+	}
+	// Keyed and positional fields can't be mixed, so the literal's other
+	// elements must all be keyed.
+	for _, el := range clInfo.cl.Elts {
+		if _, ok := el.(*ast.KeyValueExpr); !ok && !(el.Pos() <= c.pos && c.pos <= el.End()) {
+			return
+		}
+	}
+
+	var fields []*types.Var
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		if addedFields[field] {
+			continue
+		}
+		// Ignore fields that are not accessible in the current package.
+		if field.Pkg() != nil && field.Pkg() != c.pkg.GetTypes() && !field.Exported() {
+			continue
+		}
+		if c.isOptionalField(ctx, field, st.Tag(i)) {
+			continue
+		}
+		fields = append(fields, field)
+	}
+	if len(fields) < 2 {
+		// A single field is already offered by itself.
+		return
+	}
+
+	names := make([]string, len(fields))
+	typs := make([]types.Type, len(fields))
+	for i, field := range fields {
+		names[i] = field.Name()
+		typs[i] = field.Type()
+	}
+	label := strings.Join(names, ", ")
+	matchScore := c.matcher.Score(label)
+	if matchScore <= 0 {
+		return
+	}
+
+	var (
+		fset    = c.snapshot.FileSet()
+		info    = c.pkg.GetTypesInfo()
+		matches = analysisinternal.FindMatchingIdents(typs, c.file, c.pos, info, c.pkg.GetTypes())
+		// If the cursor is on a different line from the literal's opening
+		// brace, the literal is multiline and each field gets a line.
+		multiline = fset.Position(c.pos).Line != fset.Position(clInfo.cl.Lbrace).Line
+		// The lines are indented one level deeper than the literal, unless
+		// the client indents them like the cursor's line itself.
+		indent string
+		mode   protocol.InsertTextMode
+	)
+	if multiline && c.opts.insertAsIs {
+		indent, mode = c.lineIndent(clInfo.cl.Lbrace)+"\t", protocol.AsIs
+	}
+	var snip snippet.Builder
+	for i, field := range fields {
+		if i > 0 {
+			if multiline {
+				snip.WriteText("\n" + indent)
+			} else {
+				snip.WriteText(", ")
+			}
+		}
+		expr := analysisinternal.FindBestMatch(field.Name(), matches[field.Type()])
+		if expr == nil {
+			expr = fillstruct.PopulateValue(fset, c.file, c.pkg.GetTypes(), field.Type())
+		}
+		var value string
+		if expr != nil {
+			value = source.FormatNode(fset, expr)
+		}
+		if value == "" {
+			value = c.zeroValue(field.Type())
+		}
+		snip.WriteText(field.Name() + ": ")
+		snip.WritePlaceholder(func(b *snippet.Builder) {
+			b.WriteText(value)
+		})
+		if multiline {
+			snip.WriteText(",")
+		}
+	}
+
+	// Until a prefix is typed, rank the snippet above the individual
+	// fields, which get a high score both for their position and for
+	// matching. Once it is, the fields that it matches come first.
+	score := stdScore
+	if c.surrounding == nil || c.surrounding.Prefix() == "" {
+		score = highScore * highScore * highScore
+	}
+	c.items = append(c.items, CompletionItem{
+		Label:          label,
+		Detail:         "remaining fields",
+		Kind:           protocol.SnippetCompletion,
+		Score:          score * float64(matchScore),
+		InsertTextMode: mode,
+		snippet:        &snip,
+	})
+}
+
+// lineIndent returns the leading whitespace of the line of pos in the
+// current file.
+func (c *completer) lineIndent(pos token.Pos) string {
+	tok := c.snapshot.FileSet().File(pos)
+	if tok == nil {
+		return ""
+	}
+	content := c.mapper.Content
+	start := tok.Offset(tok.LineStart(tok.Line(pos)))
+	end := start
+	for end < len(content) && (content[end] == ' ' || content[end] == '\t') {
+		end++
+	}
+	return string(content[start:end])
+}
+
+// isOptionalField reports whether the field, with the given struct tag,
+// is left out of the struct fields snippet: its documentation begins with
+// "optional", or its tag matches one of the OptionalFieldTags.
+func (c *completer) isOptionalField(ctx context.Context, field *types.Var, tag string) bool {
+	for _, opt := range c.opts.optionalFieldTags {
+		key, option := opt, ""
+		if i := strings.IndexByte(opt, ':'); i >= 0 {
+			key, option = opt[:i], opt[i+1:]
+		}
+		value, ok := reflect.StructTag(tag).Lookup(key)
+		if !ok {
+			continue
+		}
+		if option == "" {
+			return true
+		}
+		for _, o := range strings.Split(value, ",")[1:] {
+			if o == option {
+				return true
+			}
+		}
+	}
+
+	pgf, _, err := source.FindPosInPackage(c.snapshot, c.pkg, field.Pos())
+	if err != nil {
+		return false
+	}
+	posToField, err := c.snapshot.PosToField(ctx, pgf)
+	if err != nil {
+		return false
+	}
+	f := posToField[field.Pos()]
+	if f == nil {
+		return false
+	}
+	for _, group := range []*ast.CommentGroup{f.Doc, f.Comment} {
+		words := strings.Fields(group.Text())
+		if len(words) > 0 && strings.EqualFold(strings.TrimRight(words[0], ".,:;"), "optional") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package completion
+
+import (
+	"testing"
+
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+)
+
+func TestStructFieldsSnippet(t *testing.T) {
+	const types = `package m
+
+type T struct {
+	Name string
+	Age  int
+	// Optional nickname.
+	Nick string
+	Note string ` + "`json:\"note,omitempty\"`" + `
+}
+`
+	tests := []struct {
+		name   string
+		src    string
+		tags   []string
+		asIs   bool
+		label  string
+		want   string // the snippet, or "" if it is not offered
+		indent bool   // whether the item is inserted as is
+	}{
+		{
+			name:  "single line",
+			src:   "package m\n\nvar _ = T{‸}\n",
+			label: "Name, Age, Note",
+			want:  `Name: ${1:""}, Age: ${2:0}, Note: ${3:""}`,
+		},
+		{
+			name:  "optional field tags",
+			src:   "package m\n\nvar _ = T{‸}\n",
+			tags:  []string{"json:omitempty"},
+			label: "Name, Age",
+			want:  `Name: ${1:""}, Age: ${2:0}`,
+		},
+		{
+			name:  "variables in scope",
+			src:   "package m\n\nfunc F(name string, age int) T {\n\treturn T{‸}\n}\n",
+			tags:  []string{"json"},
+			label: "Name, Age",
+			want:  "Name: ${1:name}, Age: ${2:age}",
+		},
+		{
+			name:  "set fields",
+			src:   "package m\n\nvar _ = T{Age: 1, ‸}\n",
+			tags:  []string{"json"},
+			label: "Name",
+		},
+		{
+			name:  "positional fields",
+			src:   "package m\n\nvar _ = T{\"x\", ‸}\n",
+			tags:  []string{"json"},
+			label: "Age",
+		},
+		{
+			name:  "multiline",
+			src:   "package m\n\nfunc F() {\n\t_ = T{\n\t\t‸\n\t}\n}\n",
+			tags:  []string{"json"},
+			label: "Name, Age",
+			want:  "Name: ${1:\"\"},\nAge: ${2:0},",
+		},
+		{
+			name:   "multiline as is",
+			src:    "package m\n\nfunc F() {\n\t_ = T{\n\t\t‸\n\t}\n}\n",
+			tags:   []string{"json"},
+			asIs:   true,
+			label:  "Name, Age",
+			want:   "Name: ${1:\"\"},\n\t\tAge: ${2:0},",
+			indent: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			items, _ := completeWithOptions(t, map[string]string{
+				"a.go": test.src,
+				"t.go": types,
+			}, func(options *source.Options) {
+				options.StructFieldsSnippet = true
+				options.OptionalFieldTags = test.tags
+				options.CompletionInsertAsIsSupported = test.asIs
+			})
+			item := findItem(items, test.label)
+			if test.want == "" {
+				if item != nil && item.Kind == protocol.SnippetCompletion {
+					t.Fatalf("got snippet %q, want none", item.Snippet())
+				}
+				return
+			}
+			if item == nil {
+				t.Fatalf("no completion %q", test.label)
+			}
+			if got := item.Snippet(); got != test.want {
+				t.Errorf("snippet = %q, want %q", got, test.want)
+			}
+			if got := item.InsertTextMode == protocol.AsIs; got != test.indent {
+				t.Errorf("inserted as is = %t, want %t", got, test.indent)
+			}
+			if items[0].Label != test.label {
+				t.Errorf("first completion = %q, want the snippet", items[0].Label)
+			}
+		})
+	}
+}
+
+func TestStructFieldsSnippetRanking(t *testing.T) {
+	// Once a field name is typed, the field ranks above the snippet.
+	items, _ := completeWithOptions(t, map[string]string{
+		"a.go": "package m\n\ntype T struct {\n\tName string\n\tAge  int\n}\n\nvar _ = T{Name‸}\n",
+	}, func(options *source.Options) {
+		options.StructFieldsSnippet = true
+	})
+	field, snippet := findItem(items, "Name"), findItem(items, "Name, Age")
+	if field == nil || snippet == nil {
+		t.Fatalf("completions = %v, want the field Name and the snippet", items)
+	}
+	if snippet.Score >= field.Score {
+		t.Errorf("snippet score %v >= field score %v, want it lower", snippet.Score, field.Score)
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package completion
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/lsp/snippet"
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+)
+
+// structTagKeys are the commonly used struct tag keys, each with the
+// options that its value may contain after the name.
+var structTagKeys = map[string][]string{
+	"bson":         {"omitempty", "minsize", "truncate", "inline"},
+	"db":           nil,
+	"env":          nil,
+	"form":         {"omitempty"},
+	"json":         {"omitempty", "string"},
+	"mapstructure": {"omitempty", "squash", "remain"},
+	"toml":         {"omitempty"},
+	"xml":          {"attr", "chardata", "innerxml", "comment", "omitempty", "any"},
+	"yaml":         {"omitempty", "flow", "inline"},
+}
+
+// structTagCases are the cases offered for the names in struct tag values.
+var structTagCases = []source.StructTagCase{
+	source.CamelCase,
+	source.SnakeCase,
+	source.PascalCase,
+	source.KebabCase,
+	source.KeepCase,
+}
+
+// inStructTag reports whether the cursor is inside the raw string literal
+// of a struct field's tag, returning the field.
+func (c *completer) inStructTag() *ast.Field {
+	if len(c.path) < 2 {
+		return nil
+	}
+	lit, ok := c.path[0].(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING || !strings.HasPrefix(lit.Value, "`") {
+		return nil
+	}
+	field, ok := c.path[1].(*ast.Field)
+	if !ok || field.Tag != lit || c.pos <= lit.Pos() || c.pos >= lit.End() {
+		return nil
+	}
+	return field
+}
+
+// structTagCompletions offers completions inside a struct field's tag: tag
+// keys in key position, names derived from the field in value position,
+// and the key's options after a comma in the value.
+func (c *completer) structTagCompletions(field *ast.Field) {
+	c.deepState.enabled = false
+
+	lit := field.Tag
+	text := lit.Value[1 : c.pos-lit.Pos()]
+	opts := c.snapshot.View().Options()
+
+	// Scan the key:"value" pairs preceding the cursor to find out what is
+	// being completed.
+	used := usedTagKeys(lit.Value[1 : len(lit.Value)-1])
+	var i int
+	for {
+		for i < len(text) && text[i] == ' ' {
+			i++
+		}
+		keyStart := i
+		for i < len(text) && text[i] != ':' && text[i] != ' ' {
+			i++
+		}
+		if i == len(text) {
+			c.setStructTagSurrounding(lit, keyStart, text[keyStart:])
+			c.structTagKeyItems(field, used, opts)
+			return
+		}
+		if text[i] == ' ' || i+1 == len(text) || text[i+1] != '"' {
+			return // not a well-formed tag
+		}
+		key := text[keyStart:i]
+		i += 2
+		valueStart := i
+		for i < len(text) && text[i] != '"' {
+			if text[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(text) {
+			value := text[valueStart:]
+			if comma := strings.LastIndex(value, ","); comma >= 0 {
+				c.setStructTagSurrounding(lit, valueStart+comma+1, value[comma+1:])
+				c.structTagOptionItems(key, strings.Split(value[:comma], ","))
+			} else {
+				c.setStructTagSurrounding(lit, valueStart, value)
+				c.structTagNameItems(field, opts)
+			}
+			return
+		}
+		i++
+	}
+}
+
+// usedTagKeys returns the keys of the key:"value" pairs in the tag.
+func usedTagKeys(tag string) map[string]bool {
+	used := make(map[string]bool)
+	for _, part := range strings.Fields(tag) {
+		if i := strings.Index(part, `:"`); i > 0 {
+			used[part[:i]] = true
+		}
+	}
+	return used
+}
+
+// setStructTagSurrounding sets the surrounding text to the given prefix,
+// which starts at offset start in the tag's content.
+func (c *completer) setStructTagSurrounding(lit *ast.BasicLit, start int, prefix string) {
+	pos := lit.Pos() + 1 + token.Pos(start)
+	c.surrounding = &Selection{
+		content:     prefix,
+		cursor:      c.pos,
+		MappedRange: source.NewMappedRange(c.snapshot.FileSet(), c.mapper, pos, c.pos),
+	}
+	c.setMatcherFromPrefix(prefix)
+}
+
+// structTagKeyItems adds an item for each known tag key not yet used in the
+// tag, inserting a complete key:"name" pair. The keys configured in the
+// StructTags option are preferred.
+func (c *completer) structTagKeyItems(field *ast.Field, used map[string]bool, opts *source.Options) {
+	var name string
+	if len(field.Names) > 0 {
+		name = source.TransformTagName(field.Names[0].Name, opts.StructTagCase)
+	}
+	preferred := make(map[string]bool)
+	for _, key := range opts.StructTags {
+		preferred[key] = true
+	}
+	keys := make(map[string]bool)
+	for key := range structTagKeys {
+		keys[key] = true
+	}
+	for key := range preferred {
+		keys[key] = true
+	}
+	for key := range keys {
+		if used[key] {
+			continue
+		}
+		matchScore := c.matcher.Score(key)
+		if matchScore <= 0 {
+			continue
+		}
+		var suffix string
+		if o := opts.StructTagOptions[key]; o != "" {
+			suffix = "," + o
+		}
+		value := name + suffix
+		score := stdScore
+		if preferred[key] {
+			score = highScore
+		}
+		item := CompletionItem{
+			Label:      key,
+			Detail:     key + `:"` + value + `"`,
+			Kind:       protocol.KeywordCompletion,
+			InsertText: key + `:"` + value + `"`,
+			Score:      score * float64(matchScore),
+		}
+		if c.opts.snippets {
+			snip := &snippet.Builder{}
+			snip.WriteText(key + `:"`)
+			snip.WritePlaceholder(func(b *snippet.Builder) {
+				b.WriteText(name)
+			})
+			snip.WriteText(suffix + `"`)
+			item.snippet = snip
+		}
+		c.items = append(c.items, item)
+	}
+}
+
+// structTagNameItems adds an item for each of the names derived from the
+// field's name, preferring the case configured by the StructTagCase option.
+// It also offers "-", which excludes the field.
+func (c *completer) structTagNameItems(field *ast.Field, opts *source.Options) {
+	seen := make(map[string]bool)
+	add := func(label string, score float64) {
+		if seen[label] {
+			return
+		}
+		seen[label] = true
+		if matchScore := c.matcher.Score(label); matchScore > 0 {
+			c.items = append(c.items, CompletionItem{
+				Label:      label,
+				Kind:       protocol.ValueCompletion,
+				InsertText: label,
+				Score:      score * float64(matchScore),
+			})
+		}
+	}
+	if len(field.Names) > 0 {
+		name := field.Names[0].Name
+		add(source.TransformTagName(name, opts.StructTagCase), highScore)
+		for _, tc := range structTagCases {
+			add(source.TransformTagName(name, tc), stdScore)
+		}
+	}
+	add("-", lowScore)
+}
+
+// structTagOptionItems adds an item for each option of the tag key that is
+// not yet present in the value's existing parts.
+func (c *completer) structTagOptionItems(key string, parts []string) {
+	present := make(map[string]bool)
+	for _, p := range parts[1:] {
+		present[p] = true
+	}
+	for _, opt := range structTagKeys[key] {
+		if present[opt] {
+			continue
+		}
+		if matchScore := c.matcher.Score(opt); matchScore > 0 {
+			c.items = append(c.items, CompletionItem{
+				Label:      opt,
+				Kind:       protocol.KeywordCompletion,
+				InsertText: opt,
+				Score:      stdScore * float64(matchScore),
+			})
+		}
+	}
+}
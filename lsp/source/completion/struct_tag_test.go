@@ -0,0 +1,87 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package completion
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStructTagCompletion(t *testing.T) {
+	tests := []struct {
+		name     string
+		tag      string // the tag of the field FirstName, with a ‸
+		settings map[string]interface{}
+		want     []string // the labels of the completions, in order
+		snippet  string   // the snippet of the first completion, if set
+	}{
+		{
+			name:    "key",
+			tag:     "`‸`",
+			want:    []string{"json", "bson", "db", "env", "form", "mapstructure", "toml", "xml", "yaml"},
+			snippet: `json:"${1:firstName}"`,
+		},
+		{
+			name: "key prefix",
+			tag:  "`ya‸`",
+			want: []string{"yaml"},
+		},
+		{
+			name: "used key",
+			tag:  "`json:\"first\" ‸`",
+			want: []string{"bson", "db", "env", "form", "mapstructure", "toml", "xml", "yaml"},
+		},
+		{
+			name:     "configured key",
+			tag:      "`‸`",
+			settings: map[string]interface{}{"structTags": []interface{}{"yaml"}, "structTagOptions": map[string]interface{}{"yaml": "omitempty"}},
+			want:     []string{"yaml", "bson", "db", "env", "form", "json", "mapstructure", "toml", "xml"},
+			snippet:  `yaml:"${1:firstName},omitempty"`,
+		},
+		{
+			name: "value",
+			tag:  "`json:\"‸\"`",
+			want: []string{"firstName", "FirstName", "first-name", "first_name", "-"},
+		},
+		{
+			name:     "value case",
+			tag:      "`json:\"‸\"`",
+			settings: map[string]interface{}{"structTagCase": "SnakeCase"},
+			want:     []string{"first_name", "FirstName", "first-name", "firstName", "-"},
+		},
+		{
+			name: "option",
+			tag:  "`json:\"first,‸\"`",
+			want: []string{"omitempty", "string"},
+		},
+		{
+			name: "present option",
+			tag:  "`json:\"first,omitempty,‸\"`",
+			want: []string{"string"},
+		},
+		{
+			name: "unknown key options",
+			tag:  "`db:\"first,‸\"`",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			src := "package m\n\ntype T struct {\n\tFirstName string " + test.tag + "\n}\n"
+			items, _ := complete(t, map[string]string{"a.go": src}, test.settings)
+			var labels []string
+			for _, item := range items {
+				labels = append(labels, item.Label)
+			}
+			if !reflect.DeepEqual(labels, test.want) {
+				t.Errorf("completions = %q, want %q", labels, test.want)
+			}
+			if test.snippet != "" && len(items) > 0 {
+				if got := items[0].Snippet(); got != test.snippet {
+					t.Errorf("snippet = %q, want %q", got, test.snippet)
+				}
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"go/token"
 	"go/types"
 
+	"github.com/kevinswiber/languageserver-go/analysisinternal"
 	"github.com/kevinswiber/languageserver-go/lsp/source"
 )
 
@@ -305,6 +306,15 @@ func formatZeroValue(T types.Type, qf types.Qualifier) string {
 	}
 }
 
+// zeroValue returns Go code representing the zero value of T in the
+// current file, as the fillreturns analyzer does.
+func (c *completer) zeroValue(T types.Type) string {
+	if z := analysisinternal.ZeroValue(c.snapshot.FileSet(), c.file, c.pkg.GetTypes(), T); z != nil {
+		return source.FormatNode(c.snapshot.FileSet(), z)
+	}
+	return formatZeroValue(T, c.qf)
+}
+
 // isBasicKind returns whether t is a basic type of kind k.
 func isBasicKind(t types.Type, k types.BasicInfo) bool {
 	b, _ := t.Underlying().(*types.Basic)
@@ -0,0 +1,136 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/types"
+
+	"github.com/kevinswiber/languageserver-go/lsp/analysis/deprecated"
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+)
+
+// DeprecatedDiagnostics reports the uses in pkg of objects declared in
+// other packages whose documentation marks them as deprecated. Uses inside
+// declarations that are deprecated themselves are not reported.
+func DeprecatedDiagnostics(ctx context.Context, snapshot Snapshot, pkg Package) ([]*Diagnostic, error) {
+	info := pkg.GetTypesInfo()
+	if info == nil {
+		return nil, nil
+	}
+	type notice struct {
+		message    string
+		deprecated bool
+	}
+	notices := make(map[types.Object]notice)
+
+	var diagnostics []*Diagnostic
+	for _, pgf := range pkg.CompiledGoFiles() {
+		var err error
+		ast.Inspect(pgf.File, func(n ast.Node) bool {
+			if err != nil {
+				return false
+			}
+			switch n := n.(type) {
+			case *ast.FuncDecl:
+				_, isDeprecated := deprecated.Message(n.Doc)
+				return !isDeprecated
+			case *ast.GenDecl:
+				_, isDeprecated := deprecated.Message(n.Doc)
+				return !isDeprecated
+			case *ast.Ident:
+				obj := info.Uses[n]
+				if obj == nil || obj.Pkg() == nil || obj.Pkg() == pkg.GetTypes() {
+					return false
+				}
+				if _, ok := obj.(*types.PkgName); ok {
+					return false
+				}
+				nt, ok := notices[obj]
+				if !ok {
+					nt.message, nt.deprecated, err = DeprecationMessage(ctx, snapshot, pkg, obj)
+					if err != nil {
+						return false
+					}
+					notices[obj] = nt
+				}
+				if !nt.deprecated {
+					return false
+				}
+				var rng protocol.Range
+				rng, err = NewMappedRange(snapshot.FileSet(), pgf.Mapper, n.Pos(), n.End()).Range()
+				if err != nil {
+					return false
+				}
+				message := fmt.Sprintf("%s is deprecated", qualifiedName(obj))
+				if nt.message != "" {
+					message += ": " + nt.message
+				}
+				diagnostics = append(diagnostics, &Diagnostic{
+					URI:      pgf.URI,
+					Range:    rng,
+					Severity: protocol.SeverityHint,
+					Source:   DiagnosticSource(deprecated.Analyzer.Name),
+					Message:  message,
+					Tags:     []protocol.DiagnosticTag{protocol.Deprecated},
+				})
+			}
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return diagnostics, nil
+}
+
+// DeprecationMessage reports whether the documentation of obj, which must
+// be declared in pkg or one of its dependencies, marks it as deprecated, and
+// returns the deprecation notice.
+func DeprecationMessage(ctx context.Context, snapshot Snapshot, pkg Package, obj types.Object) (string, bool, error) {
+	if !obj.Pos().IsValid() {
+		return "", false, nil
+	}
+	pgf, declPkg, err := FindPosInPackage(snapshot, pkg, obj.Pos())
+	if err != nil {
+		// The object may be declared in a package without syntax, such as
+		// unsafe.
+		return "", false, nil
+	}
+	posToDecl, err := snapshot.PosToDecl(ctx, pgf)
+	if err != nil {
+		return "", false, err
+	}
+	decl := posToDecl[obj.Pos()]
+	if decl == nil {
+		return "", false, nil
+	}
+	hover, err := HoverInfo(ctx, declPkg, obj, decl)
+	if err != nil {
+		return "", false, nil
+	}
+	return hover.Deprecation, hover.Deprecated, nil
+}
+
+// qualifiedName returns the name of obj qualified by its package, or by its
+// receiver type for methods. Fields are not qualified.
+func qualifiedName(obj types.Object) string {
+	switch obj := obj.(type) {
+	case *types.Func:
+		if recv := obj.Type().(*types.Signature).Recv(); recv != nil {
+			if named, ok := Deref(recv.Type()).(*types.Named); ok {
+				return named.Obj().Name() + "." + obj.Name()
+			}
+			return obj.Name()
+		}
+	case *types.Var:
+		if obj.IsField() {
+			return obj.Name()
+		}
+	}
+	return obj.Pkg().Name() + "." + obj.Name()
+}
@@ -0,0 +1,173 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+	"github.com/kevinswiber/languageserver-go/span"
+)
+
+const deprecatedDep = `package dep
+
+// Old does nothing.
+//
+// Deprecated: use New.
+func Old() {}
+
+func New() {}
+
+type T struct {
+	// Deprecated: use G.
+	F int
+	G int
+}
+
+// Deprecated: use N.
+func (T) M() {}
+
+func (T) N() {}
+
+// Deprecated: use New.
+var V int
+
+func f() {
+	Old()
+	_ = V
+}
+`
+
+func TestDeprecatedDiagnostics(t *testing.T) {
+	ctx := context.Background()
+	snapshot, dir := newSnapshot(t, map[string]string{
+		"go.mod":     "module example.com/m\n\ngo 1.16\n",
+		"dep/dep.go": deprecatedDep,
+		"a/a.go": `package a
+
+import "example.com/m/dep"
+
+func f(t dep.T) {
+	dep.Old()
+	dep.New()
+	_ = t.F
+	_ = t.G
+	t.M()
+	t.N()
+	_ = dep.V
+}
+
+// Deprecated: use f.
+func g(t dep.T) {
+	dep.Old()
+	t.M()
+}
+
+// Deprecated: use f.
+var h = dep.Old
+`,
+	})
+	pkgs, err := snapshot.WorkspacePackages(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, pkg := range pkgs {
+		diagnostics, err := source.DeprecatedDiagnostics(ctx, snapshot, pkg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, d := range diagnostics {
+			rel, err := filepath.Rel(dir, d.URI.Filename())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(d.Tags, []protocol.DiagnosticTag{protocol.Deprecated}) {
+				t.Errorf("%s: tags = %v, want Deprecated", d.Message, d.Tags)
+			}
+			got = append(got, fmt.Sprintf("%s:%d:%d: %s", filepath.ToSlash(rel), d.Range.Start.Line+1, d.Range.Start.Character+1, d.Message))
+		}
+	}
+	sort.Strings(got)
+	// The uses in dep itself and inside the deprecated declarations of a are
+	// not reported.
+	want := []string{
+		"a/a.go:10:4: T.M is deprecated: use N.",
+		"a/a.go:12:10: dep.V is deprecated: use New.",
+		"a/a.go:6:6: dep.Old is deprecated: use New.",
+		"a/a.go:8:8: F is deprecated: use G.",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DeprecatedDiagnostics =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestDeprecatedHover(t *testing.T) {
+	ctx := context.Background()
+	snapshot, dir := newSnapshot(t, map[string]string{
+		"go.mod":     "module example.com/m\n\ngo 1.16\n",
+		"dep/dep.go": deprecatedDep,
+		"a/a.go":     "package a\n\nimport \"example.com/m/dep\"\n\nvar _, _ = dep.Old, dep.New\n",
+	})
+	fh, err := snapshot.GetFile(ctx, span.URIFromPath(filepath.Join(dir, "a", "a.go")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hover := func(character uint32) *source.HoverInformation {
+		t.Helper()
+		ident, err := source.Identifier(ctx, snapshot, fh, protocol.Position{Line: 4, Character: character})
+		if err != nil {
+			t.Fatal(err)
+		}
+		h, err := source.HoverIdentifier(ctx, ident)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+	old, new := hover(15), hover(24)
+	if !old.Deprecated || old.Deprecation != "use New." {
+		t.Errorf("hover of dep.Old: deprecated = %t, notice = %q, want true, %q", old.Deprecated, old.Deprecation, "use New.")
+	}
+	if new.Deprecated {
+		t.Errorf("hover of dep.New is deprecated")
+	}
+
+	for _, test := range []struct {
+		kind   source.HoverKind
+		format protocol.MarkupKind
+		want   string
+	}{
+		// The full documentation already includes the notice.
+		{source.FullDocumentation, protocol.Markdown, "**Deprecated**\n"},
+		{source.SynopsisDocumentation, protocol.Markdown, "**Deprecated:** use New\\.\n"},
+		{source.SynopsisDocumentation, protocol.PlainText, "Deprecated: use New.\n"},
+	} {
+		options := snapshot.View().Options().Clone()
+		options.HoverKind = test.kind
+		options.PreferredContentFormat = test.format
+		got, err := source.FormatHover(old, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(got, test.want) {
+			t.Errorf("%s %s hover of dep.Old = %q, want it to contain %q", test.kind, test.format, got, test.want)
+		}
+		got, err = source.FormatHover(new, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(got, "Deprecated") {
+			t.Errorf("%s %s hover of dep.New = %q, want no deprecation", test.kind, test.format, got)
+		}
+	}
+}
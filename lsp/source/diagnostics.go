@@ -34,8 +34,10 @@ func Analyze(ctx context.Context, snapshot Snapshot, pkg Package, includeConveni
 	if includeConvenience {
 		categories = append(categories, snapshot.View().Options().ConvenienceAnalyzers)
 	}
-	// If we had type errors, don't run any other analyzers.
-	if !pkg.HasTypeErrors() {
+	// If we had type errors, don't run any other analyzers, nor the
+	// external analysis tools.
+	vetTools := !pkg.HasTypeErrors()
+	if vetTools {
 		categories = append(categories, snapshot.View().Options().DefaultAnalyzers, snapshot.View().Options().StaticcheckAnalyzers)
 	}
 	var analyzers []*Analyzer
@@ -45,7 +47,7 @@ func Analyze(ctx context.Context, snapshot Snapshot, pkg Package, includeConveni
 		}
 	}
 
-	analysisDiagnostics, err := snapshot.Analyze(ctx, pkg.ID(), analyzers)
+	analysisDiagnostics, err := snapshot.Analyze(ctx, pkg.ID(), analyzers, vetTools)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,156 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"github.com/kevinswiber/languageserver-go/event"
+	"github.com/kevinswiber/languageserver-go/lsp/analysis/enumstring"
+	"github.com/kevinswiber/languageserver-go/lsp/analysis/fillswitch"
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/span"
+	errors "golang.org/x/xerrors"
+)
+
+// EnumStringFixes returns suggested fixes that generate a String method for
+// the integer type with constants that is declared at pRng, or whose
+// constants are declared at pRng. If the names of all of the constants
+// begin with the type's name, another fix generates a String method that
+// trims that prefix from the names, like stringer's -trimprefix flag.
+//
+// No fixes are offered for a type that already has a String method; once
+// generated, the method is kept up to date by the enumstring analyzer.
+func EnumStringFixes(ctx context.Context, snapshot Snapshot, fh FileHandle, pRng protocol.Range) ([]SuggestedFix, error) {
+	ctx, done := event.Start(ctx, "source.EnumStringFixes")
+	defer done()
+
+	pkg, pgf, err := GetParsedFile(ctx, snapshot, fh, NarrowestPackage)
+	if err != nil {
+		return nil, errors.Errorf("getting file for EnumStringFixes: %w", err)
+	}
+	rng, err := pgf.Mapper.RangeToSpanRange(pRng)
+	if err != nil {
+		return nil, err
+	}
+	named := enumTypeAt(pkg, pgf, rng.Start, rng.End)
+	if named == nil {
+		return nil, nil
+	}
+	if obj, _, _ := types.LookupFieldOrMethod(named, true, pkg.GetTypes(), "String"); obj != nil {
+		return nil, nil
+	}
+	consts := fillswitch.EnumConstants(named, pkg.GetTypes())
+	if len(consts) == 0 {
+		return nil, nil
+	}
+
+	prefixes := []string{""}
+	if prefix := named.Obj().Name(); hasCommonPrefix(consts, prefix) {
+		prefixes = append(prefixes, prefix)
+	}
+	var fixes []SuggestedFix
+	for _, prefix := range prefixes {
+		fix, _, err := enumstring.SuggestedFix(snapshot.FileSet(), pgf.File, pkg.GetTypes(), named, prefix)
+		if err != nil {
+			return nil, err
+		}
+		title := fix.Message
+		if prefix != "" {
+			title = fmt.Sprintf("%s (trim prefix %q)", title, prefix)
+		}
+		var edits []protocol.TextEdit
+		for _, e := range fix.TextEdits {
+			rng, err := NewMappedRange(snapshot.FileSet(), pgf.Mapper, e.Pos, e.End).Range()
+			if err != nil {
+				return nil, err
+			}
+			edits = append(edits, protocol.TextEdit{
+				Range:   rng,
+				NewText: string(e.NewText),
+			})
+		}
+		fixes = append(fixes, SuggestedFix{
+			Title: title,
+			Edits: map[span.URI][]protocol.TextEdit{fh.URI(): edits},
+		})
+	}
+	return fixes, nil
+}
+
+// enumTypeAt returns the named integer type whose declaration, or the
+// declaration of one of whose constants, encloses the given range of pgf.
+func enumTypeAt(pkg Package, pgf *ParsedGoFile, start, end token.Pos) *types.Named {
+	path, _ := astutil.PathEnclosingInterval(pgf.File, start, end)
+	info := pkg.GetTypesInfo()
+	var obj types.Object
+	for _, n := range path {
+		switch n := n.(type) {
+		case *ast.TypeSpec:
+			obj = info.Defs[n.Name]
+		case *ast.ValueSpec:
+			obj = constTypeName(info, n)
+		case *ast.GenDecl:
+			// The range is in the declaration but outside of its specs, such
+			// as on the keyword of a declaration of a single type, or on the
+			// parentheses of a const declaration.
+			for _, spec := range n.Specs {
+				if ts, ok := spec.(*ast.TypeSpec); ok && len(n.Specs) == 1 {
+					obj = info.Defs[ts.Name]
+				} else if vs, ok := spec.(*ast.ValueSpec); ok && obj == nil {
+					obj = constTypeName(info, vs)
+				}
+			}
+		case *ast.BlockStmt, *ast.FuncDecl:
+			return nil
+		default:
+			continue
+		}
+		break
+	}
+	tname, ok := obj.(*types.TypeName)
+	if !ok || tname.Pkg() != pkg.GetTypes() {
+		return nil
+	}
+	named, ok := tname.Type().(*types.Named)
+	if !ok || !enumstring.IsInteger(named) {
+		return nil
+	}
+	return named
+}
+
+// constTypeName returns the name of the named type of the constants
+// declared by spec, or nil if they are not of a named type.
+func constTypeName(info *types.Info, spec *ast.ValueSpec) types.Object {
+	for _, id := range spec.Names {
+		if c, ok := info.Defs[id].(*types.Const); ok {
+			if named, ok := c.Type().(*types.Named); ok {
+				return named.Obj()
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// hasCommonPrefix reports whether the names of all of the constants begin
+// with, and are longer than, prefix.
+func hasCommonPrefix(consts []*types.Const, prefix string) bool {
+	for _, c := range consts {
+		if c.Name() == "_" {
+			continue
+		}
+		if len(c.Name()) <= len(prefix) || !strings.HasPrefix(c.Name(), prefix) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,632 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+	"github.com/kevinswiber/languageserver-go/analysisinternal"
+	"github.com/kevinswiber/languageserver-go/event"
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/span"
+	errors "golang.org/x/xerrors"
+)
+
+// ConcreteTypeAt returns the named non-interface type declared at pRng in
+// the file, and its exported methods, including those of its pointer type,
+// in the order in which they are declared. It returns a nil type if there
+// is no such type or it has no exported methods.
+func ConcreteTypeAt(ctx context.Context, snapshot Snapshot, fh FileHandle, pRng protocol.Range) (*types.Named, []*types.Func, error) {
+	pkg, pgf, err := GetParsedFile(ctx, snapshot, fh, NarrowestPackage)
+	if err != nil {
+		return nil, nil, errors.Errorf("getting file for ConcreteTypeAt: %w", err)
+	}
+	rng, err := pgf.Mapper.RangeToSpanRange(pRng)
+	if err != nil {
+		return nil, nil, err
+	}
+	path, _ := astutil.PathEnclosingInterval(pgf.File, rng.Start, rng.End)
+	var obj types.Object
+	for _, n := range path {
+		switch n := n.(type) {
+		case *ast.TypeSpec:
+			obj = pkg.GetTypesInfo().Defs[n.Name]
+		case *ast.GenDecl:
+			if len(n.Specs) == 1 {
+				if ts, ok := n.Specs[0].(*ast.TypeSpec); ok {
+					obj = pkg.GetTypesInfo().Defs[ts.Name]
+				}
+			}
+		case *ast.BlockStmt, *ast.FuncDecl:
+			return nil, nil, nil
+		default:
+			continue
+		}
+		break
+	}
+	tname, ok := obj.(*types.TypeName)
+	if !ok || tname.IsAlias() {
+		return nil, nil, nil
+	}
+	named, ok := tname.Type().(*types.Named)
+	if !ok || types.IsInterface(named) {
+		return nil, nil, nil
+	}
+	methods := exportedMethods(named)
+	if len(methods) == 0 {
+		return nil, nil, nil
+	}
+	return named, methods, nil
+}
+
+// exportedMethods returns the exported methods of the named type and its
+// pointer type: first those declared by the type, in declaration order,
+// then those promoted from embedded fields, sorted by name.
+func exportedMethods(named *types.Named) []*types.Func {
+	var declared, promoted []*types.Func
+	mset := types.NewMethodSet(types.NewPointer(named))
+	for i := 0; i < mset.Len(); i++ {
+		sel := mset.At(i)
+		m := sel.Obj().(*types.Func)
+		if !m.Exported() {
+			continue
+		}
+		if len(sel.Index()) == 1 {
+			declared = append(declared, m)
+		} else {
+			promoted = append(promoted, m)
+		}
+	}
+	sort.Slice(declared, func(i, j int) bool { return declared[i].Pos() < declared[j].Pos() })
+	return append(declared, promoted...)
+}
+
+// ExtractInterface returns the changes that declare a new interface type
+// with the given methods of the concrete type at pRng. If name is empty, a
+// name is derived from the type or, for a single method, from the method.
+// If methods is empty, all of the type's exported methods are included.
+//
+// The interface is added to the target file, which may be in another
+// package and may not exist yet, or after the type's declaration if target
+// is empty. If rewriteParams is set, the parameters of type T or *T of the
+// workspace functions that only call the interface's methods on them are
+// changed to the interface type.
+func ExtractInterface(ctx context.Context, snapshot Snapshot, fh FileHandle, pRng protocol.Range, name string, methods []string, target span.URI, rewriteParams bool) ([]*FileChange, error) {
+	ctx, done := event.Start(ctx, "source.ExtractInterface")
+	defer done()
+
+	named, all, err := ConcreteTypeAt(ctx, snapshot, fh, pRng)
+	if err != nil {
+		return nil, err
+	}
+	if named == nil {
+		return nil, fmt.Errorf("no type with exported methods found at %v", pRng.Start)
+	}
+	selected, err := selectMethods(named, all, methods)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = InterfaceName(named, selected)
+	}
+	if !token.IsIdentifier(name) {
+		return nil, fmt.Errorf("%q is not a valid identifier", name)
+	}
+
+	pkg, pgf, err := GetParsedFile(ctx, snapshot, fh, NarrowestPackage)
+	if err != nil {
+		return nil, errors.Errorf("getting file for ExtractInterface: %w", err)
+	}
+	pos := token.NoPos
+	if target == "" || target == fh.URI() {
+		target = fh.URI()
+		for _, decl := range pgf.File.Decls {
+			if decl.Pos() <= named.Obj().Pos() && named.Obj().Pos() < decl.End() {
+				pos = decl.End()
+			}
+		}
+	}
+	targetPkg, targetPGF, err := targetFile(ctx, snapshot, target)
+	if err != nil {
+		return nil, err
+	}
+	if targetPkg.GetTypes().Scope().Lookup(name) != nil {
+		return nil, fmt.Errorf("%s is already declared in package %s", name, targetPkg.Name())
+	}
+	if targetPkg.PkgPath() != pkg.PkgPath() {
+		if !ast.IsExported(name) {
+			return nil, fmt.Errorf("cannot declare unexported %s in package %s for use in package %s", name, targetPkg.Name(), pkg.Name())
+		}
+		for _, m := range selected {
+			if p := unexportedTypeIn(m.Type().(*types.Signature)); p != "" {
+				return nil, fmt.Errorf("cannot move method %s to package %s: it refers to unexported %s", m.Name(), targetPkg.Name(), p)
+			}
+		}
+	}
+
+	docs := methodDocs(snapshot, pkg, selected)
+	var cycle error
+	change, err := generatedFileChange(snapshot, target, targetPkg, targetPGF, pos, func(adder *analysisinternal.ImportAdder) string {
+		qf := func(p *types.Package) string {
+			if p != targetPkg.GetTypes() && importsPath(p, targetPkg.PkgPath(), map[*types.Package]bool{}) {
+				cycle = fmt.Errorf("cannot declare %s in package %s: import cycle not allowed", name, targetPkg.Name())
+			}
+			return adder.Qualifier(p)
+		}
+		typeName := named.Obj().Name()
+		if named.Obj().Pkg() != targetPkg.GetTypes() {
+			typeName = named.Obj().Pkg().Name() + "." + typeName
+		}
+		return writeInterface(name, typeName, selected, docs, qf)
+	}, named.Obj().Pkg())
+	if cycle != nil {
+		return nil, cycle
+	}
+	if err != nil {
+		return nil, err
+	}
+	changes := []*FileChange{change}
+	if !rewriteParams {
+		return changes, nil
+	}
+
+	var ifaceMethods []*types.Func
+	for _, m := range selected {
+		sig := m.Type().(*types.Signature)
+		ifaceMethods = append(ifaceMethods, types.NewFunc(m.Pos(), m.Pkg(), m.Name(), types.NewSignature(nil, sig.Params(), sig.Results(), sig.Variadic())))
+	}
+	iface := types.NewInterfaceType(ifaceMethods, nil).Complete()
+	rewrites, err := rewriteParamsToInterface(ctx, snapshot, pkg, named, iface, name, targetPkg)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range rewrites {
+		if c.URI == change.URI {
+			// The file of the interface is also rewritten. Both sets of
+			// edits apply to its current content.
+			change.Edits = append(change.Edits, c.Edits...)
+			continue
+		}
+		changes = append(changes, c)
+	}
+	return changes, nil
+}
+
+// selectMethods returns the methods among all with the given names, in the
+// order of all, or all of them if names is empty.
+func selectMethods(named *types.Named, all []*types.Func, names []string) ([]*types.Func, error) {
+	if len(names) == 0 {
+		return all, nil
+	}
+	want := make(map[string]bool)
+	for _, n := range names {
+		want[n] = true
+	}
+	var selected []*types.Func
+	for _, m := range all {
+		if want[m.Name()] {
+			selected = append(selected, m)
+			delete(want, m.Name())
+		}
+	}
+	for n := range want {
+		return nil, fmt.Errorf("%s has no exported method %s", named.Obj().Name(), n)
+	}
+	return selected, nil
+}
+
+// InterfaceName returns the default name of an interface extracted from the
+// named type with the given methods. By convention, an interface with a
+// single method is named after it, with an -er suffix, as in io.Reader or
+// a NameGetter for GetName; otherwise, or if the method name has several
+// words or the suffix is ambiguous, the interface is named after the type.
+func InterfaceName(named *types.Named, methods []*types.Func) string {
+	if len(methods) == 1 {
+		name := methods[0].Name()
+		if rest := strings.TrimPrefix(name, "Get"); rest != name && rest != "" && unicode.IsUpper(rune(rest[0])) {
+			return rest + "Getter"
+		}
+		if agent, ok := agentNoun(name); ok {
+			return agent
+		}
+	}
+	return named.Obj().Name() + "Interface"
+}
+
+// agentNoun returns the name, a single word, with an -er suffix, following
+// the spelling rules of English: Close becomes Closer, Copy becomes Copier,
+// and Stop becomes Stopper. It reports false if the name has several
+// words, as WriteTo or SetFlag, whose agent nouns are not spelled with a
+// suffix, if the spelling depends on the stress of the word, as the final
+// consonant of Format is doubled but that of Open is not, or if the word is
+// too short to tell.
+func agentNoun(name string) (string, bool) {
+	for _, r := range name[1:] {
+		if unicode.IsUpper(r) {
+			return "", false
+		}
+	}
+	isVowel := func(i int) bool {
+		return i >= 0 && strings.ContainsRune("aeiouAEIOU", rune(name[i]))
+	}
+	n := len(name)
+	switch last := name[n-1]; {
+	case last == 'e':
+		return name + "r", true
+	case last == 'y' && n > 1 && !isVowel(n-2):
+		return name[:n-1] + "ier", true
+	case isVowel(n-1) || strings.ContainsRune("wxy", rune(last)):
+		return name + "er", true
+	case !isVowel(n-2) || isVowel(n-3):
+		// The word does not end with a single vowel and a consonant.
+		return name + "er", true
+	}
+	// The final consonant is doubled in words of one syllable.
+	syllables := 0
+	for i := 0; i < n; i++ {
+		if isVowel(i) && !isVowel(i-1) {
+			syllables++
+		}
+	}
+	if syllables > 1 || n < 3 {
+		return "", false
+	}
+	return name + name[n-1:] + "er", true
+}
+
+// unexportedTypeIn returns the name of an unexported named type referred to
+// by the signature, or "" if there is none.
+func unexportedTypeIn(sig *types.Signature) string {
+	var found string
+	var visit func(t types.Type)
+	seen := make(map[types.Type]bool)
+	visit = func(t types.Type) {
+		if found != "" || seen[t] {
+			return
+		}
+		seen[t] = true
+		switch t := t.(type) {
+		case *types.Named:
+			if !t.Obj().Exported() && t.Obj().Pkg() != nil {
+				found = t.Obj().Name()
+			}
+		case *types.Pointer:
+			visit(t.Elem())
+		case *types.Slice:
+			visit(t.Elem())
+		case *types.Array:
+			visit(t.Elem())
+		case *types.Chan:
+			visit(t.Elem())
+		case *types.Map:
+			visit(t.Key())
+			visit(t.Elem())
+		case *types.Signature:
+			for i := 0; i < t.Params().Len(); i++ {
+				visit(t.Params().At(i).Type())
+			}
+			for i := 0; i < t.Results().Len(); i++ {
+				visit(t.Results().At(i).Type())
+			}
+		case *types.Struct:
+			for i := 0; i < t.NumFields(); i++ {
+				visit(t.Field(i).Type())
+			}
+		case *types.Interface:
+			for i := 0; i < t.NumMethods(); i++ {
+				visit(t.Method(i).Type())
+			}
+		}
+	}
+	visit(sig)
+	return found
+}
+
+// methodDocs returns the doc comments of the methods declared in pkg.
+func methodDocs(snapshot Snapshot, pkg Package, methods []*types.Func) map[*types.Func]string {
+	docs := make(map[*types.Func]string)
+	for _, m := range methods {
+		if m.Pkg() != pkg.GetTypes() {
+			continue
+		}
+		uri := span.URIFromPath(snapshot.FileSet().Position(m.Pos()).Filename)
+		pgf, err := pkg.File(uri)
+		if err != nil {
+			continue
+		}
+		for _, decl := range pgf.File.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Name.Pos() == m.Pos() && fn.Doc != nil {
+				docs[m] = fn.Doc.Text()
+			}
+		}
+	}
+	return docs
+}
+
+// writeInterface returns the source of the declaration of an interface
+// named name, with the given methods of the type and their docs.
+func writeInterface(name, typeName string, methods []*types.Func, docs map[*types.Func]string, qf types.Qualifier) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s is the interface of the methods of %s.\n", name, typeName)
+	fmt.Fprintf(&b, "type %s interface {\n", name)
+	for i, m := range methods {
+		if doc := docs[m]; doc != "" {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			for _, line := range strings.Split(strings.TrimSuffix(doc, "\n"), "\n") {
+				b.WriteString(strings.TrimSuffix("// "+line, " ") + "\n")
+			}
+		}
+		fmt.Fprintf(&b, "%s%s\n", m.Name(), strings.TrimPrefix(types.TypeString(m.Type(), qf), "func"))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// rewriteParamsToInterface returns the changes that replace the types of
+// the parameters of type T or *T, for the named type T, with the interface
+// iface named name and declared in targetPkg, in the workspace functions
+// whose only uses of such a parameter are calls of the interface's methods.
+//
+// Methods are left alone, as changing their signatures may break the
+// implementation of other interfaces, as are functions that are used other
+// than by calling them.
+func rewriteParamsToInterface(ctx context.Context, snapshot Snapshot, pkg Package, named *types.Named, iface *types.Interface, name string, targetPkg Package) ([]*FileChange, error) {
+	qos := []qualifiedObject{{obj: named.Obj(), pkg: pkg}}
+	refs, err := references(ctx, snapshot, qos, false, false, false)
+	if err != nil {
+		return nil, err
+	}
+
+	type paramEdit struct {
+		typ ast.Expr // the parameter's type expression: T, *T, or a selector
+		pkg Package
+		pgf *ParsedGoFile
+	}
+	edits := make(map[span.URI][]paramEdit)
+	seen := make(map[ast.Expr]bool)
+	for _, ref := range refs {
+		pgf, err := ref.pkg.File(ref.URI())
+		if err != nil {
+			return nil, err
+		}
+		path, _ := astutil.PathEnclosingInterval(pgf.File, ref.ident.Pos(), ref.ident.End())
+		typ, fn := paramTypeOf(path)
+		if fn == nil || seen[typ] {
+			continue
+		}
+		seen[typ] = true
+		if ref.pkg.GetTypes() != targetPkg.GetTypes() && importsPath(targetPkg.GetTypes(), ref.pkg.PkgPath(), map[*types.Package]bool{}) {
+			continue // the file cannot import the interface
+		}
+		if !canRewriteParam(ctx, snapshot, ref.pkg, fn, typ, iface) {
+			continue
+		}
+		edits[ref.URI()] = append(edits[ref.URI()], paramEdit{typ, ref.pkg, pgf})
+	}
+
+	var changes []*FileChange
+	for uri, pes := range edits {
+		pgf, p := pes[0].pgf, pes[0].pkg
+		ifaceName := name
+		var missingImport string
+		if p.GetTypes() != targetPkg.GetTypes() {
+			pkgName, ok := importedName(pgf.File, targetPkg.GetTypes())
+			if !ok {
+				pkgName, missingImport = targetPkg.GetTypes().Name(), targetPkg.PkgPath()
+			}
+			ifaceName = pkgName + "." + name
+		}
+		var textEdits []analysis.TextEdit
+		for _, pe := range pes {
+			textEdits = append(textEdits, analysis.TextEdit{Pos: pe.typ.Pos(), End: pe.typ.End(), NewText: []byte(ifaceName)})
+		}
+		src := applyTextEdits(pgf.Tok, pgf.Src, textEdits)
+
+		// Fix the imports: the file may need to import the interface's
+		// package, and may no longer use the package of the type.
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, pgf.URI.Filename(), src, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		changed := false
+		if missingImport != "" {
+			changed = astutil.AddImport(fset, f, missingImport)
+		}
+		if p.GetTypes() != named.Obj().Pkg() {
+			if typePkgName, ok := importedName(f, named.Obj().Pkg()); ok && !usesPackageName(f, typePkgName) {
+				changed = astutil.DeleteNamedImport(fset, f, importSpecName(f, named.Obj().Pkg().Path()), named.Obj().Pkg().Path()) || changed
+			}
+		}
+		var protocolEdits []protocol.TextEdit
+		if changed {
+			var buf strings.Builder
+			if err := format.Node(&buf, fset, f); err != nil {
+				return nil, err
+			}
+			if protocolEdits, err = computeTextEdits(ctx, snapshot, pgf, buf.String()); err != nil {
+				return nil, err
+			}
+		} else {
+			for _, e := range textEdits {
+				rng, err := NewMappedRange(snapshot.FileSet(), pgf.Mapper, e.Pos, e.End).Range()
+				if err != nil {
+					return nil, err
+				}
+				protocolEdits = append(protocolEdits, protocol.TextEdit{Range: rng, NewText: string(e.NewText)})
+			}
+		}
+		changes = append(changes, &FileChange{URI: uri, Edits: protocolEdits})
+	}
+	sort.Slice(changes, func(i, j int) bool { return CompareURI(changes[i].URI, changes[j].URI) < 0 })
+	return changes, nil
+}
+
+// paramTypeOf returns the type expression of the parameter and the
+// function declaration, which is not a method, if path leads from a
+// reference to a type to the type of a non-variadic parameter.
+func paramTypeOf(path []ast.Node) (ast.Expr, *ast.FuncDecl) {
+	if len(path) < 6 {
+		return nil, nil
+	}
+	i := 0
+	typ := path[0].(ast.Expr)
+	if sel, ok := path[1].(*ast.SelectorExpr); ok && sel.Sel == path[0] {
+		typ, i = sel, 1
+	}
+	if star, ok := path[i+1].(*ast.StarExpr); ok {
+		typ, i = star, i+1
+	}
+	field, ok := path[i+1].(*ast.Field)
+	if !ok || field.Type != typ || len(field.Names) == 0 {
+		return nil, nil
+	}
+	// PathEnclosingInterval omits the FuncType of a FuncDecl.
+	fn, ok := path[i+3].(*ast.FuncDecl)
+	if !ok || fn.Type.Params != path[i+2] || fn.Recv != nil || fn.Body == nil {
+		return nil, nil
+	}
+	return typ, fn
+}
+
+// canRewriteParam reports whether the parameters of fn with the type
+// expression typ can be changed to iface: the parameters' type must
+// implement iface, every use of them must be a call of one of its methods,
+// and fn must only be called, not used as a value.
+func canRewriteParam(ctx context.Context, snapshot Snapshot, pkg Package, fn *ast.FuncDecl, typ ast.Expr, iface *types.Interface) bool {
+	info := pkg.GetTypesInfo()
+	t := info.TypeOf(typ)
+	if t == nil || !types.Implements(t, iface) {
+		return false
+	}
+	var field *ast.Field
+	for _, f := range fn.Type.Params.List {
+		if f.Type == typ {
+			field = f
+		}
+	}
+	params := make(map[types.Object]bool)
+	for _, id := range field.Names {
+		if obj := info.Defs[id]; obj != nil {
+			params[obj] = true
+		}
+	}
+
+	ok := true
+	var visit func(n ast.Node) bool
+	visit = func(n ast.Node) bool {
+		if !ok {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.CallExpr:
+			sel, isSel := n.Fun.(*ast.SelectorExpr)
+			if !isSel {
+				break
+			}
+			if id, isIdent := sel.X.(*ast.Ident); isIdent && params[info.Uses[id]] {
+				if obj, _, _ := types.LookupFieldOrMethod(iface, false, nil, sel.Sel.Name); obj == nil {
+					ok = false
+				}
+				for _, arg := range n.Args {
+					ast.Inspect(arg, visit)
+				}
+				return false
+			}
+		case *ast.Ident:
+			if params[info.Uses[n]] {
+				ok = false
+			}
+		}
+		return ok
+	}
+	ast.Inspect(fn.Body, visit)
+	if !ok {
+		return false
+	}
+
+	// Check that fn is only ever called.
+	obj := info.Defs[fn.Name]
+	if obj == nil {
+		return false
+	}
+	refs, err := references(ctx, snapshot, []qualifiedObject{{obj: obj, pkg: pkg}}, false, false, false)
+	if err != nil {
+		return false
+	}
+	for _, ref := range refs {
+		pgf, err := ref.pkg.File(ref.URI())
+		if err != nil {
+			return false
+		}
+		path, _ := astutil.PathEnclosingInterval(pgf.File, ref.ident.Pos(), ref.ident.End())
+		var fun ast.Node = ref.ident
+		if len(path) > 1 {
+			if sel, isSel := path[1].(*ast.SelectorExpr); isSel && sel.Sel == ref.ident {
+				fun, path = sel, path[1:]
+			}
+		}
+		if len(path) < 2 {
+			return false
+		}
+		if call, isCall := path[1].(*ast.CallExpr); !isCall || call.Fun != fun {
+			return false
+		}
+	}
+	return true
+}
+
+// importedName returns the name by which the file refers to the package p,
+// and whether the file imports it.
+func importedName(f *ast.File, p *types.Package) (string, bool) {
+	for _, imp := range f.Imports {
+		if ImportPath(imp) != p.Path() {
+			continue
+		}
+		if imp.Name != nil {
+			return imp.Name.Name, imp.Name.Name != "_" && imp.Name.Name != "."
+		}
+		return p.Name(), true
+	}
+	return "", false
+}
+
+// importSpecName returns the explicit name of the import of path, if any.
+func importSpecName(f *ast.File, path string) string {
+	for _, imp := range f.Imports {
+		if ImportPath(imp) == path && imp.Name != nil {
+			return imp.Name.Name
+		}
+	}
+	return ""
+}
+
+// usesPackageName reports whether the file has a selector expression that
+// qualifies an identifier with the given package name.
+func usesPackageName(f *ast.File, name string) bool {
+	found := false
+	ast.Inspect(f, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok && id.Name == name {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
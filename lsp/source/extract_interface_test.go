@@ -0,0 +1,102 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"go/format"
+	"go/token"
+	"go/types"
+	"testing"
+)
+
+func TestWriteInterface(t *testing.T) {
+	const src = `package a
+
+type T struct{}
+
+func (t *T) Close() error { return nil }
+
+func (t T) Read(p []byte) (n int, err error) { return 0, nil }
+
+func (t T) private() {}
+`
+	_, pkg := typeCheck(t, src)
+	T := pkg.Scope().Lookup("T").Type().(*types.Named)
+	methods := exportedMethods(T)
+	if len(methods) != 2 || methods[0].Name() != "Close" || methods[1].Name() != "Read" {
+		t.Fatalf("exportedMethods(T) = %v, want [Close Read]", methods)
+	}
+	for _, tt := range []struct {
+		methods []*types.Func
+		name    string
+		want    string
+	}{
+		{methods, "TInterface", `// TInterface is the interface of the methods of T.
+type TInterface interface {
+	// Close closes t.
+	Close() error
+	Read(p []byte) (n int, err error)
+}
+`},
+		{methods[:1], "Closer", `// Closer is the interface of the methods of T.
+type Closer interface {
+	// Close closes t.
+	Close() error
+}
+`},
+	} {
+		if name := InterfaceName(T, tt.methods); name != tt.name {
+			t.Errorf("InterfaceName(T, %v) = %q, want %q", tt.methods, name, tt.name)
+		}
+		docs := map[*types.Func]string{methods[0]: "Close closes t.\n"}
+		got, err := format.Source([]byte(writeInterface(tt.name, "T", tt.methods, docs, types.RelativeTo(pkg))))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("writeInterface(%s) = \n%s\nwant:\n%s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestInterfaceName(t *testing.T) {
+	pkg := types.NewPackage("example.com/a", "a")
+	T := types.NewNamed(types.NewTypeName(token.NoPos, pkg, "T", nil), types.NewStruct(nil, nil), nil)
+	method := func(name string) *types.Func {
+		return types.NewFunc(token.NoPos, pkg, name, types.NewSignature(nil, nil, nil, false))
+	}
+	for _, tt := range []struct {
+		methods []string
+		want    string
+	}{
+		{[]string{"Close"}, "Closer"},
+		{[]string{"Read"}, "Reader"},
+		{[]string{"Flush"}, "Flusher"},
+		{[]string{"Do"}, "Doer"},
+		{[]string{"Copy"}, "Copier"},
+		{[]string{"Play"}, "Player"},
+		{[]string{"Run"}, "Runner"},
+		{[]string{"Stop"}, "Stopper"},
+		{[]string{"WriteTo"}, "TInterface"},
+		{[]string{"SetFlag"}, "TInterface"},
+		{[]string{"ServeHTTP"}, "TInterface"},
+		{[]string{"Get"}, "Getter"},
+		{[]string{"GetName"}, "NameGetter"},
+		{[]string{"Getaway"}, "Getawayer"},
+		{[]string{"Fix"}, "Fixer"},
+		{[]string{"Open"}, "TInterface"},
+		{[]string{"Format"}, "TInterface"},
+		{[]string{"ReadAt"}, "TInterface"},
+		{[]string{"Read", "Close"}, "TInterface"},
+	} {
+		var methods []*types.Func
+		for _, name := range tt.methods {
+			methods = append(methods, method(name))
+		}
+		if got := InterfaceName(T, methods); got != tt.want {
+			t.Errorf("InterfaceName(T, %v) = %q, want %q", tt.methods, got, tt.want)
+		}
+	}
+}
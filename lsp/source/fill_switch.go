@@ -0,0 +1,268 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+	"github.com/kevinswiber/languageserver-go/analysisinternal"
+	"github.com/kevinswiber/languageserver-go/event"
+	"github.com/kevinswiber/languageserver-go/lsp/analysis/fillswitch"
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/span"
+	errors "golang.org/x/xerrors"
+)
+
+// FillSwitchFixes returns a suggested fix that adds the missing cases to the
+// switch statement whose header contains pRng. For an expression switch over
+// an enum-like type, the missing cases are the type's unhandled constants.
+// For a type switch over an interface, they are the types declared in the
+// workspace that implement the interface.
+func FillSwitchFixes(ctx context.Context, snapshot Snapshot, fh FileHandle, pRng protocol.Range) ([]SuggestedFix, error) {
+	ctx, done := event.Start(ctx, "source.FillSwitchFixes")
+	defer done()
+
+	pkg, pgf, err := GetParsedFile(ctx, snapshot, fh, NarrowestPackage)
+	if err != nil {
+		return nil, errors.Errorf("getting file for FillSwitchFixes: %w", err)
+	}
+	rng, err := pgf.Mapper.RangeToSpanRange(pRng)
+	if err != nil {
+		return nil, err
+	}
+	path, _ := astutil.PathEnclosingInterval(pgf.File, rng.Start, rng.End)
+	var fix *analysis.SuggestedFix
+	for _, n := range path {
+		switch stmt := n.(type) {
+		case *ast.SwitchStmt:
+			if rng.Start >= stmt.Body.Lbrace {
+				continue
+			}
+			if _, missing := fillswitch.MissingEnumCases(pkg.GetTypesInfo(), stmt, pkg.GetTypes()); len(missing) == 0 {
+				return nil, nil
+			}
+			fix, err = fillswitch.SuggestedFix(snapshot.FileSet(), rng, pgf.Src, pgf.File, pkg.GetTypes(), pkg.GetTypesInfo())
+		case *ast.TypeSwitchStmt:
+			if rng.Start >= stmt.Body.Lbrace {
+				continue
+			}
+			fix, err = fillTypeSwitch(ctx, snapshot, pkg, pgf, stmt)
+		default:
+			continue
+		}
+		break
+	}
+	if err != nil {
+		return nil, err
+	}
+	if fix == nil {
+		return nil, nil
+	}
+	var edits []protocol.TextEdit
+	for _, e := range fix.TextEdits {
+		rng, err := NewMappedRange(snapshot.FileSet(), pgf.Mapper, e.Pos, e.End).Range()
+		if err != nil {
+			return nil, err
+		}
+		edits = append(edits, protocol.TextEdit{
+			Range:   rng,
+			NewText: string(e.NewText),
+		})
+	}
+	return []SuggestedFix{{
+		Title: fix.Message,
+		Edits: map[span.URI][]protocol.TextEdit{fh.URI(): edits},
+	}}, nil
+}
+
+// fillTypeSwitch returns a suggested fix that adds a case to the type switch
+// for each workspace type that implements the switch's interface and is not
+// already handled.
+func fillTypeSwitch(ctx context.Context, snapshot Snapshot, pkg Package, pgf *ParsedGoFile, stmt *ast.TypeSwitchStmt) (*analysis.SuggestedFix, error) {
+	var assert *ast.TypeAssertExpr
+	switch s := stmt.Assign.(type) {
+	case *ast.ExprStmt:
+		assert, _ = s.X.(*ast.TypeAssertExpr)
+	case *ast.AssignStmt:
+		if len(s.Rhs) == 1 {
+			assert, _ = s.Rhs[0].(*ast.TypeAssertExpr)
+		}
+	}
+	if assert == nil {
+		return nil, nil
+	}
+	info := pkg.GetTypesInfo()
+	ifaceType := info.TypeOf(assert.X)
+	if ifaceType == nil {
+		return nil, nil
+	}
+	iface, ok := ifaceType.Underlying().(*types.Interface)
+	if !ok || iface.NumMethods() == 0 {
+		// Every type implements the empty interface.
+		return nil, nil
+	}
+	handled := make(map[string]bool)
+	for _, s := range stmt.Body.List {
+		cc, ok := s.(*ast.CaseClause)
+		if !ok {
+			continue
+		}
+		for _, e := range cc.List {
+			if t := info.TypeOf(e); t != nil {
+				handled[types.TypeString(t, nil)] = true
+			}
+		}
+	}
+
+	impls, err := workspaceImplementations(ctx, snapshot, pkg, ifaceType)
+	if err != nil {
+		return nil, err
+	}
+	adder := analysisinternal.NewImportAdder(pgf.File, pkg.GetTypes())
+	var cases []string
+	for _, t := range impls {
+		if handled[types.TypeString(t, nil)] {
+			continue
+		}
+		cases = append(cases, types.TypeString(t, adder.Qualifier))
+	}
+	if len(cases) == 0 {
+		return nil, nil
+	}
+	edit, err := fillswitch.InsertCases(snapshot.FileSet(), pgf.Src, stmt.Body, cases)
+	if err != nil {
+		return nil, err
+	}
+	return &analysis.SuggestedFix{
+		Message:   fmt.Sprintf("Add cases for types implementing %s", types.TypeString(ifaceType, types.RelativeTo(pkg.GetTypes()))),
+		TextEdits: append(adder.Edits(), edit),
+	}, nil
+}
+
+// workspaceImplementations returns the package-level named types of the
+// workspace packages that implement the interface type ifaceType, as seen
+// from pkg. Types that cannot be referred to from pkg, because they are
+// unexported or because their package imports pkg, are omitted. A type is
+// returned as a pointer if only its pointer type implements the interface.
+func workspaceImplementations(ctx context.Context, snapshot Snapshot, pkg Package, ifaceType types.Type) ([]types.Type, error) {
+	wsPkgs, err := snapshot.WorkspacePackages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Packages imported by pkg share its type-checked objects, so
+	// implementations can be checked directly against ifaceType.
+	deps := make(map[string]*types.Package)
+	var addDeps func(*types.Package)
+	addDeps = func(p *types.Package) {
+		if _, ok := deps[p.Path()]; ok {
+			return
+		}
+		deps[p.Path()] = p
+		for _, imp := range p.Imports() {
+			addDeps(imp)
+		}
+	}
+	addDeps(pkg.GetTypes())
+
+	var result []types.Type
+	seen := make(map[string]bool)
+	for _, wsPkg := range wsPkgs {
+		if seen[wsPkg.PkgPath()] || strings.HasSuffix(wsPkg.Name(), "_test") {
+			continue
+		}
+		seen[wsPkg.PkgPath()] = true
+
+		tpkg, target := deps[wsPkg.PkgPath()], ifaceType
+		if tpkg == nil {
+			if importsPath(wsPkg.GetTypes(), pkg.PkgPath(), map[*types.Package]bool{}) {
+				continue // referring to its types would create an import cycle
+			}
+			// The package was type-checked separately from pkg, so find
+			// the interface as it is known to that package. If the package
+			// does not know it, its types may still implement it, as long
+			// as the methods' signatures use types they share.
+			tpkg = wsPkg.GetTypes()
+			if t := lookupNamedIn(tpkg, ifaceType); t != nil {
+				target = t
+			}
+		}
+		iface := target.Underlying().(*types.Interface)
+		scope := tpkg.Scope()
+		for _, name := range scope.Names() {
+			tname, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || tname.IsAlias() || (tpkg != pkg.GetTypes() && !tname.Exported()) {
+				continue
+			}
+			if types.IsInterface(tname.Type()) {
+				continue
+			}
+			switch {
+			case types.Implements(tname.Type(), iface):
+				result = append(result, tname.Type())
+			case types.Implements(types.NewPointer(tname.Type()), iface):
+				result = append(result, types.NewPointer(tname.Type()))
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return types.TypeString(result[i], nil) < types.TypeString(result[j], nil)
+	})
+	return result, nil
+}
+
+// importsPath reports whether p transitively imports the package with the
+// given path.
+func importsPath(p *types.Package, path string, seen map[*types.Package]bool) bool {
+	for _, imp := range p.Imports() {
+		if seen[imp] {
+			continue
+		}
+		seen[imp] = true
+		if imp.Path() == path || importsPath(imp, path, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupNamedIn returns the named type that corresponds to T among the
+// packages transitively imported by p, or nil if there is none.
+func lookupNamedIn(p *types.Package, T types.Type) types.Type {
+	named, ok := T.(*types.Named)
+	if !ok {
+		return nil
+	}
+	obj := named.Obj()
+	if obj.Pkg() == nil {
+		return T // a predeclared type, such as error
+	}
+	var find func(*types.Package, map[*types.Package]bool) types.Type
+	find = func(p *types.Package, seen map[*types.Package]bool) types.Type {
+		if p.Path() == obj.Pkg().Path() {
+			if tname, ok := p.Scope().Lookup(obj.Name()).(*types.TypeName); ok {
+				return tname.Type()
+			}
+			return nil
+		}
+		for _, imp := range p.Imports() {
+			if seen[imp] {
+				continue
+			}
+			seen[imp] = true
+			if t := find(imp, seen); t != nil {
+				return t
+			}
+		}
+		return nil
+	}
+	return find(p, map[*types.Package]bool{})
+}
@@ -0,0 +1,73 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+	"github.com/kevinswiber/languageserver-go/span"
+)
+
+func TestFillSwitchFixes(t *testing.T) {
+	ctx := context.Background()
+	snapshot, dir := newSnapshot(t, testdataFiles(t, "fillswitch"))
+	for _, test := range []struct {
+		name, switchStmt string
+	}{
+		{"enum", "switch c"},
+		{"typeswitch", "switch s :="},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			uri, rng := rangeOf(t, dir, "a/a.go", test.switchStmt)
+			fh, err := snapshot.GetFile(ctx, uri)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fixes, err := source.FillSwitchFixes(ctx, snapshot, fh, rng)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(fixes) != 1 {
+				t.Fatalf("got %d fixes, want 1", len(fixes))
+			}
+			checkEditsGolden(t, dir, fixes[0].Edits, "fillswitch/"+test.name+".golden")
+		})
+	}
+}
+
+func TestFillSwitchAnalyzer(t *testing.T) {
+	// The analyzer is published as an opt-in diagnostic, whose fix is
+	// computed by a command.
+	options := source.DefaultOptions()
+	a := options.DefaultAnalyzers["fillswitch"]
+	if a == nil || a.Enabled || a.Fix != source.FillSwitch {
+		t.Fatalf("fillswitch default analyzer = %+v, want it disabled with the %s fix", a, source.FillSwitch)
+	}
+	if _, ok := options.ConvenienceAnalyzers["fillswitch"]; ok {
+		t.Errorf("fillswitch is a convenience analyzer, which does not publish diagnostics")
+	}
+
+	// The command makes the same edits as the code action.
+	ctx := context.Background()
+	snapshot, dir := newSnapshot(t, testdataFiles(t, "fillswitch"))
+	uri, rng := rangeOf(t, dir, "a/a.go", "switch c")
+	fh, err := snapshot.GetVersionedFile(ctx, uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	docEdits, err := source.ApplyFix(ctx, source.FillSwitch, snapshot, fh, rng)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edits := make(map[span.URI][]protocol.TextEdit)
+	for _, e := range docEdits {
+		uri := e.TextDocument.URI.SpanURI()
+		edits[uri] = append(edits[uri], e.Edits...)
+	}
+	checkEditsGolden(t, dir, edits, "fillswitch/enum.golden")
+}
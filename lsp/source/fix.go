@@ -13,6 +13,8 @@ import (
 
 	"golang.org/x/tools/go/analysis"
 	"github.com/kevinswiber/languageserver-go/lsp/analysis/fillstruct"
+	"github.com/kevinswiber/languageserver-go/lsp/analysis/fillswitch"
+	"github.com/kevinswiber/languageserver-go/lsp/analysis/stubmethods"
 	"github.com/kevinswiber/languageserver-go/lsp/analysis/undeclaredname"
 	"github.com/kevinswiber/languageserver-go/lsp/protocol"
 	"github.com/kevinswiber/languageserver-go/span"
@@ -29,6 +31,8 @@ type SuggestedFixFunc func(fset *token.FileSet, rng span.Range, src []byte, file
 
 const (
 	FillStruct      = "fill_struct"
+	FillSwitch      = "fill_switch"
+	StubMethods     = "stub_methods"
 	UndeclaredName  = "undeclared_name"
 	ExtractVariable = "extract_variable"
 	ExtractFunction = "extract_function"
@@ -37,6 +41,8 @@ const (
 // suggestedFixes maps a suggested fix command id to its handler.
 var suggestedFixes = map[string]SuggestedFixFunc{
 	FillStruct:      fillstruct.SuggestedFix,
+	FillSwitch:      fillswitch.SuggestedFix,
+	StubMethods:     stubmethods.SuggestedFix,
 	UndeclaredName:  undeclaredname.SuggestedFix,
 	ExtractVariable: extractVariable,
 	ExtractFunction: extractFunction,
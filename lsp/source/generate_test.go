@@ -0,0 +1,130 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+	"github.com/kevinswiber/languageserver-go/span"
+)
+
+func TestAddTestPackage(t *testing.T) {
+	ctx := context.Background()
+	snapshot, dir := newSnapshot(t, map[string]string{
+		"go.mod":            "module example.com/m\n\ngo 1.16\n",
+		"ext/ext.go":        "package ext\n\nfunc F() {}\n",
+		"ext/other_test.go": "package ext_test\n",
+		"both/both.go":      "package both\n\nfunc F() {}\n",
+		"both/int_test.go":  "package both\n",
+		"both/ext_test.go":  "package both_test\n",
+		"none/none.go":      "package none\n\nfunc F() {}\n",
+		"unexp/unexp.go":    "package unexp\n\nfunc f() {}\n",
+		"unexp/ext_test.go": "package unexp_test\n",
+	})
+	// A new test file is written in the external test package only if the
+	// package's tests are all external, and the function is exported.
+	for _, test := range []struct {
+		file, fn, want string
+	}{
+		{"ext/ext.go", "F()", "package ext_test"},
+		{"both/both.go", "F()", "package both\n"},
+		{"none/none.go", "F()", "package none\n"},
+		{"unexp/unexp.go", "f()", "package unexp\n"},
+	} {
+		uri, rng := rangeOf(t, dir, test.file, test.fn)
+		fh, err := snapshot.GetFile(ctx, uri)
+		if err != nil {
+			t.Fatal(err)
+		}
+		change, err := source.AddTest(ctx, snapshot, fh, rng)
+		if err != nil {
+			t.Fatalf("%s: %v", test.file, err)
+		}
+		if !strings.HasPrefix(string(change.Content), test.want) {
+			t.Errorf("%s: new test file begins with %q, want %q", test.file, firstLine(change.Content), test.want)
+		}
+	}
+}
+
+func firstLine(content []byte) string {
+	return strings.SplitN(string(content), "\n", 2)[0]
+}
+
+func TestGenerateMock(t *testing.T) {
+	ctx := context.Background()
+	snapshot, dir := newSnapshot(t, map[string]string{
+		"go.mod": "module example.com/m\n\ngo 1.16\n",
+		"a/a.go": `package a
+
+import "context"
+
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+`,
+		"b/b.go": `package b
+
+// MockStoreGetCall conflicts with the mock that records calls.
+type MockStoreGetCall struct{}
+`,
+	})
+	uri, rng := rangeOf(t, dir, "a/a.go", "Store")
+	fh, err := snapshot.GetFile(ctx, uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := span.URIFromPath(filepath.Join(dir, "b", "mock.go"))
+
+	// The mock in a new file of another package imports the packages of
+	// the interface and of its methods' types.
+	change, err := source.GenerateMock(ctx, snapshot, fh, rng, target, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"package b",
+		`"context"`,
+		`"example.com/m/a"`,
+		"GetFunc func(ctx context.Context, key string) ([]byte, error)",
+		"var _ a.Store = (*MockStore)(nil)",
+	} {
+		if !strings.Contains(string(change.Content), want) {
+			t.Errorf("mock does not contain %q:\n%s", want, change.Content)
+		}
+	}
+
+	// The mock is type-checked with the target package before it is
+	// returned.
+	if _, err := source.GenerateMock(ctx, snapshot, fh, rng, target, true); err == nil || !strings.Contains(err.Error(), "does not type-check") {
+		t.Errorf("GenerateMock with a conflicting declaration returned error %v, want a type-check error", err)
+	}
+}
+
+func TestExtractInterfaceRewriteParams(t *testing.T) {
+	ctx := context.Background()
+	snapshot, dir := newSnapshot(t, testdataFiles(t, "extractinterface"))
+	uri, rng := rangeOf(t, dir, "a/a.go", "T struct")
+	fh, err := snapshot.GetFile(ctx, uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := span.URIFromPath(filepath.Join(dir, "iface", "iface.go"))
+	changes, err := source.ExtractInterface(ctx, snapshot, fh, rng, "Closer", []string{"Close"}, target, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The parameters that are only used to call Close become Closers, in
+	// the package of the type and in the packages that import it.
+	edits := make(map[span.URI][]protocol.TextEdit)
+	for _, c := range changes {
+		edits[c.URI] = append(edits[c.URI], c.Edits...)
+	}
+	checkEditsGolden(t, dir, edits, "extractinterface/rewrite.golden")
+}
@@ -0,0 +1,122 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/kevinswiber/languageserver-go/lsp/diff"
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+	"github.com/kevinswiber/languageserver-go/lsp/tests"
+	"github.com/kevinswiber/languageserver-go/span"
+)
+
+// testdataFiles returns the files of the directory testdata/dir, keyed by
+// their slash-separated paths relative to it. Golden files are omitted.
+func testdataFiles(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	root := filepath.Join("testdata", dir)
+	files := make(map[string]string)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || strings.HasSuffix(path, ".golden") {
+			return err
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = string(content)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+// rangeOf returns the range of the first occurrence of substr in the file
+// of the workspace directory dir with the slash-separated path name.
+func rangeOf(t *testing.T, dir, name, substr string) (span.URI, protocol.Range) {
+	t.Helper()
+	filename := filepath.Join(dir, filepath.FromSlash(name))
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := strings.Index(string(content), substr)
+	if i < 0 {
+		t.Fatalf("no %q in %s", substr, name)
+	}
+	position := func(offset int) protocol.Position {
+		before := string(content[:offset])
+		return protocol.Position{
+			Line:      uint32(strings.Count(before, "\n")),
+			Character: uint32(len(before) - strings.LastIndex(before, "\n") - 1),
+		}
+	}
+	return span.URIFromPath(filename), protocol.Range{Start: position(i), End: position(i + len(substr))}
+}
+
+// checkEditsGolden applies the edits to the files of the workspace
+// directory dir, and compares the edited files with the golden file
+// testdata/golden, in which each file follows a "-- name --" header. The
+// golden file is rewritten with the -golden flag.
+func checkEditsGolden(t *testing.T, dir string, edits map[span.URI][]protocol.TextEdit, golden string) {
+	t.Helper()
+	var names []string
+	for uri := range edits {
+		rel, err := filepath.Rel(dir, uri.Filename())
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, filepath.ToSlash(rel))
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		uri := span.URIFromPath(filepath.Join(dir, filepath.FromSlash(name)))
+		content, err := ioutil.ReadFile(uri.Filename())
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := &protocol.ColumnMapper{
+			URI:       uri,
+			Converter: span.NewContentConverter(uri.Filename(), content),
+			Content:   content,
+		}
+		diffEdits, err := source.FromProtocolEdits(m, edits[uri])
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.WriteString("-- " + name + " --\n")
+		b.WriteString(diff.ApplyEdits(string(content), diffEdits))
+	}
+	got := b.String()
+
+	filename := filepath.Join("testdata", filepath.FromSlash(golden))
+	if *tests.UpdateGolden {
+		if err := ioutil.WriteFile(filename, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := tests.Diff(t, string(want), got); d != "" {
+		t.Errorf("edits do not match %s:\n%s", golden, d)
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"time"
 
 	"github.com/kevinswiber/languageserver-go/event"
+	"github.com/kevinswiber/languageserver-go/lsp/analysis/deprecated"
 	"github.com/kevinswiber/languageserver-go/lsp/protocol"
 	errors "golang.org/x/xerrors"
 )
@@ -35,6 +36,12 @@ type HoverInformation struct {
 	// FullDocumentation is the symbol's full documentation.
 	FullDocumentation string `json:"fullDocumentation"`
 
+	// Deprecated reports whether the symbol's documentation has a paragraph
+	// beginning with "Deprecated:", and Deprecation is the rest of the
+	// paragraph.
+	Deprecated  bool   `json:"deprecated,omitempty"`
+	Deprecation string `json:"deprecation,omitempty"`
+
 	// LinkPath is the pkg.go.dev link for the given symbol.
 	// For example, the "go/ast" part of "pkg.go.dev/go/ast#Node".
 	LinkPath string `json:"linkPath"`
@@ -314,6 +321,7 @@ func HoverInfo(ctx context.Context, pkg Package, obj types.Object, node ast.Node
 	if info.comment != nil {
 		info.FullDocumentation = info.comment.Text()
 		info.Synopsis = doc.Synopsis(info.FullDocumentation)
+		info.Deprecation, info.Deprecated = deprecated.Message(info.comment)
 	}
 
 	return info, nil
@@ -417,14 +425,34 @@ func FormatHover(h *HoverInformation, options *Options) (string, error) {
 	switch options.HoverKind {
 	case SynopsisDocumentation:
 		doc := formatDoc(h.Synopsis, options)
-		return formatHover(options, signature, link, doc), nil
+		return formatHover(options, signature, formatDeprecation(h, options, true), link, doc), nil
 	case FullDocumentation:
+		// The full documentation includes the deprecation notice.
 		doc := formatDoc(h.FullDocumentation, options)
-		return formatHover(options, signature, link, doc), nil
+		return formatHover(options, signature, formatDeprecation(h, options, false), link, doc), nil
 	}
 	return "", errors.Errorf("no hover for %v", h.source)
 }
 
+// formatDeprecation returns the marker of a deprecated symbol, followed by
+// its deprecation notice if withNotice is set.
+func formatDeprecation(h *HoverInformation, options *Options, withNotice bool) string {
+	if !h.Deprecated {
+		return ""
+	}
+	markdown := options.PreferredContentFormat == protocol.Markdown
+	if !withNotice || h.Deprecation == "" {
+		if markdown {
+			return "**Deprecated**"
+		}
+		return "Deprecated"
+	}
+	if markdown {
+		return "**Deprecated:** " + strings.TrimSpace(CommentToMarkdown(h.Deprecation))
+	}
+	return "Deprecated: " + h.Deprecation
+}
+
 func formatLink(h *HoverInformation, options *Options) string {
 	if !options.LinksInHover || options.LinkTarget == "" || h.LinkPath == "" {
 		return ""
@@ -0,0 +1,224 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strings"
+	"unicode"
+
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/span"
+)
+
+const (
+	ignorePrefix     = "//gopls:ignore"
+	ignoreFilePrefix = "//gopls:ignore-file"
+)
+
+// An IgnoreDirective is a comment that suppresses the diagnostics of the
+// named analyzers:
+//
+//	//gopls:ignore printf,unusedresult reason
+//
+// suppresses them on the line of the comment and on the line after it, and
+//
+//	//gopls:ignore-file printf reason
+//
+// suppresses them in the whole file. The reason is optional.
+type IgnoreDirective struct {
+	URI       span.URI
+	Analyzers []string
+	Reason    string
+
+	// File reports whether the directive applies to the whole file.
+	File bool
+
+	// Line is the zero-based line of the comment.
+	Line uint32
+
+	// Comment is the comment that holds the directive.
+	Comment *ast.Comment
+}
+
+// IgnoreDirectives returns the ignore directives of the file.
+func IgnoreDirectives(pgf *ParsedGoFile) []*IgnoreDirective {
+	var directives []*IgnoreDirective
+	for _, group := range pgf.File.Comments {
+		for _, c := range group.List {
+			analyzers, reason, file, ok := parseIgnoreDirective(c.Text)
+			if !ok {
+				continue
+			}
+			directives = append(directives, &IgnoreDirective{
+				URI:       pgf.URI,
+				Analyzers: analyzers,
+				Reason:    reason,
+				File:      file,
+				Line:      uint32(pgf.Tok.Line(c.Slash) - 1),
+				Comment:   c,
+			})
+		}
+	}
+	return directives
+}
+
+// parseIgnoreDirective parses the text of a comment as an ignore
+// directive. It reports false if the comment is not a directive, or names
+// no analyzers.
+func parseIgnoreDirective(text string) (analyzers []string, reason string, file, ok bool) {
+	var rest string
+	switch {
+	case strings.HasPrefix(text, ignoreFilePrefix):
+		rest, file = text[len(ignoreFilePrefix):], true
+	case strings.HasPrefix(text, ignorePrefix):
+		rest = text[len(ignorePrefix):]
+	default:
+		return nil, "", false, false
+	}
+	if rest == "" || !unicode.IsSpace(rune(rest[0])) {
+		return nil, "", false, false
+	}
+	rest = strings.TrimSpace(rest)
+	names := rest
+	if i := strings.IndexFunc(rest, unicode.IsSpace); i >= 0 {
+		names, reason = rest[:i], strings.TrimSpace(rest[i:])
+	}
+	for _, name := range strings.Split(names, ",") {
+		if name != "" {
+			analyzers = append(analyzers, name)
+		}
+	}
+	if len(analyzers) == 0 {
+		return nil, "", false, false
+	}
+	return analyzers, reason, file, true
+}
+
+// Suppresses reports whether the directive suppresses diagnostics of the
+// named analyzer at the given zero-based line of the directive's file.
+func (d *IgnoreDirective) Suppresses(analyzer string, uri span.URI, line uint32) bool {
+	if uri != d.URI || (!d.File && line != d.Line && line != d.Line+1) {
+		return false
+	}
+	for _, a := range d.Analyzers {
+		if a == analyzer {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveAnalyzersFix returns a fix that removes the given analyzers from
+// the directive, or the whole comment if it names no others.
+func (d *IgnoreDirective) RemoveAnalyzersFix(fset *token.FileSet, pgf *ParsedGoFile, remove map[string]bool) (SuggestedFix, error) {
+	var keep []string
+	for _, a := range d.Analyzers {
+		if !remove[a] {
+			keep = append(keep, a)
+		}
+	}
+	start, end := d.Comment.Pos(), d.Comment.End()
+	var newText string
+	if len(keep) > 0 {
+		prefix := ignorePrefix
+		if d.File {
+			prefix = ignoreFilePrefix
+		}
+		newText = prefix + " " + strings.Join(keep, ",")
+		if d.Reason != "" {
+			newText += " " + d.Reason
+		}
+	} else {
+		// Delete the line if the comment is all it holds, or else the
+		// comment and the space before it.
+		startOff, endOff := pgf.Tok.Offset(start), pgf.Tok.Offset(end)
+		lineStart := startOff
+		for lineStart > 0 && (pgf.Src[lineStart-1] == ' ' || pgf.Src[lineStart-1] == '\t') {
+			lineStart--
+		}
+		if (lineStart == 0 || pgf.Src[lineStart-1] == '\n') && (endOff == len(pgf.Src) || pgf.Src[endOff] == '\n') {
+			if endOff < len(pgf.Src) {
+				endOff++
+			}
+			end = pgf.Tok.Pos(endOff)
+		}
+		start = pgf.Tok.Pos(lineStart)
+	}
+	rng, err := NewMappedRange(fset, pgf.Mapper, start, end).Range()
+	if err != nil {
+		return SuggestedFix{}, err
+	}
+	title := "Remove unused ignore directive"
+	if len(keep) > 0 {
+		title = fmt.Sprintf("Remove %s from ignore directive", strings.Join(filterOrdered(remove, d.Analyzers), ", "))
+	}
+	return SuggestedFix{
+		Title: title,
+		Edits: map[span.URI][]protocol.TextEdit{
+			pgf.URI: {{Range: rng, NewText: newText}},
+		},
+	}, nil
+}
+
+// filterOrdered returns the elements of order that are in set.
+func filterOrdered(set map[string]bool, order []string) []string {
+	var keys []string
+	for _, k := range order {
+		if set[k] {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// IgnoreDiagnosticFix returns a fix that suppresses diagnostics of the
+// named analyzer on the given zero-based line, by adding the analyzer to
+// the directive on the line before it, or else by inserting a directive
+// there.
+func IgnoreDiagnosticFix(fset *token.FileSet, pgf *ParsedGoFile, analyzer string, line uint32) (SuggestedFix, error) {
+	if int(line) >= pgf.Tok.LineCount() {
+		return SuggestedFix{}, fmt.Errorf("line %d is out of range", line+1)
+	}
+	var (
+		pos     token.Pos
+		newText string
+	)
+	for _, d := range IgnoreDirectives(pgf) {
+		if d.File || d.Line+1 != line || pgf.Tok.Line(d.Comment.Slash) != pgf.Tok.Line(d.Comment.End()) {
+			continue
+		}
+		// Append the analyzer to the list of names of the directive.
+		text := d.Comment.Text
+		names := strings.TrimLeftFunc(text[len(ignorePrefix):], unicode.IsSpace)
+		if i := strings.IndexFunc(names, unicode.IsSpace); i >= 0 {
+			names = names[:i]
+		}
+		offset := strings.Index(text, names) + len(names)
+		pos, newText = d.Comment.Slash+token.Pos(offset), ","+analyzer
+		break
+	}
+	if !pos.IsValid() {
+		lineStart := pgf.Tok.LineStart(int(line) + 1)
+		offset := pgf.Tok.Offset(lineStart)
+		indent := offset
+		for indent < len(pgf.Src) && (pgf.Src[indent] == ' ' || pgf.Src[indent] == '\t') {
+			indent++
+		}
+		pos, newText = lineStart, string(pgf.Src[offset:indent])+ignorePrefix+" "+analyzer+"\n"
+	}
+	rng, err := NewMappedRange(fset, pgf.Mapper, pos, pos).Range()
+	if err != nil {
+		return SuggestedFix{}, err
+	}
+	return SuggestedFix{
+		Title: fmt.Sprintf("Ignore %s diagnostics on this line", analyzer),
+		Edits: map[span.URI][]protocol.TextEdit{
+			pgf.URI: {{Range: rng, NewText: newText}},
+		},
+	}, nil
+}
@@ -0,0 +1,234 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+
+	"github.com/kevinswiber/languageserver-go/lsp/diff"
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/span"
+)
+
+func TestParseIgnoreDirective(t *testing.T) {
+	tests := []struct {
+		text      string
+		analyzers []string
+		reason    string
+		file      bool
+		ok        bool
+	}{
+		{"//gopls:ignore printf", []string{"printf"}, "", false, true},
+		{"//gopls:ignore printf,assign", []string{"printf", "assign"}, "", false, true},
+		{"//gopls:ignore  printf  wrapper of  fmt.Printf ", []string{"printf"}, "wrapper of  fmt.Printf", false, true},
+		{"//gopls:ignore-file unusedresult generated", []string{"unusedresult"}, "generated", true, true},
+		{"//gopls:ignore", nil, "", false, false},
+		{"//gopls:ignore ,", nil, "", false, false},
+		{"//gopls:ignoreprintf", nil, "", false, false},
+		{"// gopls:ignore printf", nil, "", false, false},
+		{"/*gopls:ignore printf*/", nil, "", false, false},
+	}
+	for _, test := range tests {
+		analyzers, reason, file, ok := parseIgnoreDirective(test.text)
+		if ok != test.ok || file != test.file || reason != test.reason || !reflect.DeepEqual(analyzers, test.analyzers) {
+			t.Errorf("parseIgnoreDirective(%q) = %q, %q, %v, %v, want %q, %q, %v, %v", test.text, analyzers, reason, file, ok, test.analyzers, test.reason, test.file, test.ok)
+		}
+	}
+}
+
+// parseIgnoreFile parses src as the file a.go.
+func parseIgnoreFile(t *testing.T, src string) (*token.FileSet, *ParsedGoFile) {
+	t.Helper()
+	fset := token.NewFileSet()
+	uri := span.URIFromPath("/a.go")
+	file, err := parser.ParseFile(fset, uri.Filename(), src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok := fset.File(file.Pos())
+	return fset, &ParsedGoFile{
+		URI:  uri,
+		File: file,
+		Tok:  tok,
+		Src:  []byte(src),
+		Mapper: &protocol.ColumnMapper{
+			URI:       uri,
+			Converter: span.NewTokenConverter(fset, tok),
+			Content:   []byte(src),
+		},
+	}
+}
+
+// applyFix returns the source of pgf after the edits of fix.
+func applyFix(t *testing.T, pgf *ParsedGoFile, fix SuggestedFix) string {
+	t.Helper()
+	edits, err := FromProtocolEdits(pgf.Mapper, fix.Edits[pgf.URI])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return diff.ApplyEdits(string(pgf.Src), edits)
+}
+
+func TestIgnoreDirectiveSuppresses(t *testing.T) {
+	const src = `package a
+
+//gopls:ignore-file unusedresult
+
+func f() {
+	//gopls:ignore printf,assign
+	_ = 1
+	_ = 2 //gopls:ignore shadow
+	_ = 3
+}
+`
+	_, pgf := parseIgnoreFile(t, src)
+	directives := IgnoreDirectives(pgf)
+	if len(directives) != 3 {
+		t.Fatalf("got %d directives, want 3", len(directives))
+	}
+	file, block, trailing := directives[0], directives[1], directives[2]
+	other := span.URIFromPath("/b.go")
+	for _, test := range []struct {
+		directive *IgnoreDirective
+		analyzer  string
+		uri       span.URI
+		line      uint32
+		want      bool
+	}{
+		{file, "unusedresult", pgf.URI, 0, true},
+		{file, "unusedresult", pgf.URI, 8, true},
+		{file, "unusedresult", other, 8, false},
+		{file, "printf", pgf.URI, 8, false},
+		// A directive applies to its own line and the next.
+		{block, "printf", pgf.URI, 4, false},
+		{block, "printf", pgf.URI, 5, true},
+		{block, "assign", pgf.URI, 6, true},
+		{block, "printf", pgf.URI, 7, false},
+		{block, "shadow", pgf.URI, 6, false},
+		{block, "printf", other, 6, false},
+		{trailing, "shadow", pgf.URI, 7, true},
+		{trailing, "shadow", pgf.URI, 8, true},
+		{trailing, "shadow", pgf.URI, 6, false},
+	} {
+		if got := test.directive.Suppresses(test.analyzer, test.uri, test.line); got != test.want {
+			t.Errorf("%q.Suppresses(%q, %s, %d) = %t, want %t", test.directive.Comment.Text, test.analyzer, test.uri, test.line, got, test.want)
+		}
+	}
+}
+
+func TestRemoveAnalyzersFix(t *testing.T) {
+	tests := []struct {
+		name   string
+		src    string
+		remove []string
+		want   string
+	}{
+		{
+			name:   "some analyzers",
+			src:    "package a\n\n//gopls:ignore printf,assign,shadow why\nvar _ = 1\n",
+			remove: []string{"shadow", "printf"},
+			want:   "package a\n\n//gopls:ignore assign why\nvar _ = 1\n",
+		},
+		{
+			name:   "file directive",
+			src:    "package a\n\n//gopls:ignore-file printf,assign\n",
+			remove: []string{"assign"},
+			want:   "package a\n\n//gopls:ignore-file printf\n",
+		},
+		{
+			name:   "whole line",
+			src:    "package a\n\nfunc f() {\n\t//gopls:ignore printf why\n\t_ = 1\n}\n",
+			remove: []string{"printf"},
+			want:   "package a\n\nfunc f() {\n\t_ = 1\n}\n",
+		},
+		{
+			name:   "end of file",
+			src:    "package a\n\n//gopls:ignore printf",
+			remove: []string{"printf"},
+			want:   "package a\n\n",
+		},
+		{
+			name:   "trailing comment",
+			src:    "package a\n\nvar _ = 1 \t//gopls:ignore printf\n",
+			remove: []string{"printf"},
+			want:   "package a\n\nvar _ = 1\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fset, pgf := parseIgnoreFile(t, test.src)
+			directives := IgnoreDirectives(pgf)
+			if len(directives) != 1 {
+				t.Fatalf("got %d directives, want 1", len(directives))
+			}
+			remove := make(map[string]bool)
+			for _, name := range test.remove {
+				remove[name] = true
+			}
+			fix, err := directives[0].RemoveAnalyzersFix(fset, pgf, remove)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := applyFix(t, pgf, fix); got != test.want {
+				t.Errorf("fix %q produced\n%q\nwant\n%q", fix.Title, got, test.want)
+			}
+		})
+	}
+}
+
+func TestIgnoreDiagnosticFix(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		line uint32
+		want string
+	}{
+		{
+			name: "new directive",
+			src:  "package a\n\nfunc f() {\n\t_ = 1\n}\n",
+			line: 3,
+			want: "package a\n\nfunc f() {\n\t//gopls:ignore printf\n\t_ = 1\n}\n",
+		},
+		{
+			name: "existing directive",
+			src:  "package a\n\nfunc f() {\n\t//gopls:ignore assign why\n\t_ = 1\n}\n",
+			line: 4,
+			want: "package a\n\nfunc f() {\n\t//gopls:ignore assign,printf why\n\t_ = 1\n}\n",
+		},
+		{
+			name: "existing directive without reason",
+			src:  "package a\n\n//gopls:ignore assign\nvar _ = 1\n",
+			line: 3,
+			want: "package a\n\n//gopls:ignore assign,printf\nvar _ = 1\n",
+		},
+		{
+			// A file directive is not extended.
+			name: "file directive",
+			src:  "package a\n\n//gopls:ignore-file assign\nvar _ = 1\n",
+			line: 3,
+			want: "package a\n\n//gopls:ignore-file assign\n//gopls:ignore printf\nvar _ = 1\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fset, pgf := parseIgnoreFile(t, test.src)
+			fix, err := IgnoreDiagnosticFix(fset, pgf, "printf", test.line)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := applyFix(t, pgf, fix); got != test.want {
+				t.Errorf("fix produced\n%q\nwant\n%q", got, test.want)
+			}
+		})
+	}
+
+	fset, pgf := parseIgnoreFile(t, "package a\n")
+	if _, err := IgnoreDiagnosticFix(fset, pgf, "printf", 5); err == nil {
+		t.Errorf("IgnoreDiagnosticFix succeeded on a line out of range")
+	}
+}
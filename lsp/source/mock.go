@@ -0,0 +1,402 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+	"github.com/kevinswiber/languageserver-go/analysisinternal"
+	"github.com/kevinswiber/languageserver-go/event"
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/span"
+	errors "golang.org/x/xerrors"
+)
+
+// GenerateMock returns the change that adds a mock implementation of the
+// interface type at pRng to the target file. The mock is a struct with a
+// function-valued field for each method of the interface, including the
+// methods of embedded interfaces, that the method calls. If recordCalls is
+// set, the mock also records the arguments of each call.
+//
+// The target file may not exist yet, in which case it belongs to the
+// package in its directory. The generated code is type-checked against the
+// snapshot's view of the target package before it is returned.
+func GenerateMock(ctx context.Context, snapshot Snapshot, fh FileHandle, pRng protocol.Range, target span.URI, recordCalls bool) (*FileChange, error) {
+	ctx, done := event.Start(ctx, "source.GenerateMock")
+	defer done()
+
+	pkg, pgf, err := GetParsedFile(ctx, snapshot, fh, NarrowestPackage)
+	if err != nil {
+		return nil, errors.Errorf("getting file for GenerateMock: %w", err)
+	}
+	rng, err := pgf.Mapper.RangeToSpanRange(pRng)
+	if err != nil {
+		return nil, err
+	}
+	named := interfaceAt(pkg, pgf, rng.Start, rng.End)
+	if named == nil {
+		return nil, fmt.Errorf("no interface type found at %v", pRng.Start)
+	}
+	iface := named.Underlying().(*types.Interface)
+	if iface.NumMethods() == 0 {
+		return nil, fmt.Errorf("%s has no methods to mock", named.Obj().Name())
+	}
+
+	targetPkg, targetPGF, err := targetFile(ctx, snapshot, target)
+	if err != nil {
+		return nil, err
+	}
+
+	if targetPkg.PkgPath() != pkg.PkgPath() {
+		for i := 0; i < iface.NumMethods(); i++ {
+			if m := iface.Method(i); !m.Exported() {
+				return nil, fmt.Errorf("cannot mock %s outside of package %s: method %s is unexported", named.Obj().Name(), pkg.Name(), m.Name())
+			}
+		}
+		if !named.Obj().Exported() {
+			return nil, fmt.Errorf("cannot mock unexported %s outside of package %s", named.Obj().Name(), pkg.Name())
+		}
+		if importsPath(named.Obj().Pkg(), targetPkg.PkgPath(), map[*types.Package]bool{}) {
+			return nil, fmt.Errorf("cannot mock %s in package %s: import cycle not allowed", named.Obj().Name(), targetPkg.Name())
+		}
+	}
+	mockName := "Mock" + upperFirst(named.Obj().Name())
+	if targetPkg.GetTypes().Scope().Lookup(mockName) != nil {
+		return nil, fmt.Errorf("%s is already declared in package %s", mockName, targetPkg.Name())
+	}
+
+	return generatedFileChange(snapshot, target, targetPkg, targetPGF, token.NoPos, func(adder *analysisinternal.ImportAdder) string {
+		return writeMock(named, mockName, recordCalls, adder)
+	}, named.Obj().Pkg())
+}
+
+// targetFile returns the package of the target file for generated code,
+// and the parsed file if it exists. A file that does not exist yet belongs
+// to the package in its directory.
+func targetFile(ctx context.Context, snapshot Snapshot, target span.URI) (Package, *ParsedGoFile, error) {
+	targetFH, err := snapshot.GetFile(ctx, target)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := targetFH.Read(); err != nil {
+		pkg, err := packageInDir(ctx, snapshot, filepath.Dir(target.Filename()))
+		return pkg, nil, err
+	}
+	pkg, pgf, err := GetParsedFile(ctx, snapshot, targetFH, NarrowestPackage)
+	if err != nil {
+		return nil, nil, errors.Errorf("getting target file: %w", err)
+	}
+	return pkg, pgf, nil
+}
+
+// generatedFileChange returns the change that inserts the declarations
+// returned by gen at pos in the target file, or at its end if pos is not
+// valid. If targetPGF is nil, the change creates the file. The declarations
+// are formatted, and type-checked against the snapshot's view of the target
+// package, resolving imports with its dependencies and the other packages.
+func generatedFileChange(snapshot Snapshot, target span.URI, targetPkg Package, targetPGF *ParsedGoFile, pos token.Pos, gen func(*analysisinternal.ImportAdder) string, others ...*types.Package) (*FileChange, error) {
+	// Generate the declarations into the target file, or into a new file
+	// that consists of only a package clause.
+	var (
+		src  []byte
+		tok  *token.File
+		file *ast.File
+		err  error
+	)
+	if targetPGF != nil {
+		src, tok, file = targetPGF.Src, targetPGF.Tok, targetPGF.File
+	} else {
+		src = []byte(fmt.Sprintf("package %s\n", targetPkg.Name()))
+		fset := token.NewFileSet()
+		if file, err = parser.ParseFile(fset, target.Filename(), src, parser.ImportsOnly); err != nil {
+			return nil, err
+		}
+		tok = fset.File(file.Pos())
+	}
+	adder := analysisinternal.NewImportAdder(file, targetPkg.GetTypes())
+	decls, err := format.Source([]byte(gen(adder)))
+	if err != nil {
+		return nil, errors.Errorf("formatting generated code: %w", err)
+	}
+	if !pos.IsValid() {
+		pos = tok.Pos(tok.Size())
+	}
+	prefix := "\n\n"
+	if pos == tok.Pos(tok.Size()) {
+		if bytes.HasSuffix(src, []byte("\n")) {
+			prefix = "\n"
+		}
+	} else {
+		decls = bytes.TrimSuffix(decls, []byte("\n"))
+	}
+	text := append([]byte(prefix), decls...)
+	edits := append(adder.Edits(), analysis.TextEdit{Pos: pos, End: pos, NewText: text})
+	content := applyTextEdits(tok, src, edits)
+	from := tok.Offset(pos) + len(content) - len(src) - len(text)
+	to := from + len(text)
+	if targetPGF == nil {
+		if formatted, err := format.Source(content); err == nil {
+			content = formatted
+		}
+		from, to = 0, len(content)
+	}
+
+	if err := checkFileInPackage(targetPkg, target, content, from, to, others...); err != nil {
+		return nil, errors.Errorf("generated code does not type-check: %w", err)
+	}
+
+	if targetPGF == nil {
+		return &FileChange{URI: target, Content: content}, nil
+	}
+	var protocolEdits []protocol.TextEdit
+	for _, e := range edits {
+		rng, err := NewMappedRange(snapshot.FileSet(), targetPGF.Mapper, e.Pos, e.End).Range()
+		if err != nil {
+			return nil, err
+		}
+		protocolEdits = append(protocolEdits, protocol.TextEdit{Range: rng, NewText: string(e.NewText)})
+	}
+	return &FileChange{URI: target, Edits: protocolEdits}, nil
+}
+
+// interfaceAt returns the named interface type declared or referred to at
+// the given range of pgf, or nil if there is none.
+func interfaceAt(pkg Package, pgf *ParsedGoFile, start, end token.Pos) *types.Named {
+	path, _ := astutil.PathEnclosingInterval(pgf.File, start, end)
+	var obj types.Object
+	for _, n := range path {
+		switch n := n.(type) {
+		case *ast.Ident:
+			if tname, ok := pkg.GetTypesInfo().ObjectOf(n).(*types.TypeName); ok {
+				obj = tname
+			}
+		case *ast.TypeSpec:
+			obj = pkg.GetTypesInfo().Defs[n.Name]
+		default:
+			continue
+		}
+		if obj != nil {
+			break
+		}
+	}
+	tname, ok := obj.(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	named, ok := tname.Type().(*types.Named)
+	if !ok || !types.IsInterface(named) {
+		return nil
+	}
+	return named
+}
+
+// packageInDir returns a workspace package, other than a test package,
+// whose files are in dir.
+func packageInDir(ctx context.Context, snapshot Snapshot, dir string) (Package, error) {
+	pkgs, err := snapshot.WorkspacePackages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range pkgs {
+		if strings.HasSuffix(p.Name(), "_test") {
+			continue
+		}
+		for _, pgf := range p.CompiledGoFiles() {
+			if filepath.Dir(pgf.URI.Filename()) == dir && !strings.HasSuffix(pgf.URI.Filename(), "_test.go") {
+				return p, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no package found in %s", dir)
+}
+
+// checkFileInPackage type-checks pkg with the given content for the file
+// uri, which replaces or is added to the package's files, and returns the
+// first error in that file between the offsets from and to. Errors elsewhere
+// existed before the change and are ignored. Imports are resolved to the
+// packages already type-checked by the snapshot: the dependencies of pkg,
+// and the other given packages and their dependencies.
+func checkFileInPackage(pkg Package, uri span.URI, content []byte, from, to int, others ...*types.Package) error {
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, pgf := range pkg.CompiledGoFiles() {
+		if pgf.URI == uri {
+			continue
+		}
+		f, err := parser.ParseFile(fset, pgf.URI.Filename(), pgf.Src, 0)
+		if err != nil {
+			return err
+		}
+		files = append(files, f)
+	}
+	f, err := parser.ParseFile(fset, uri.Filename(), content, 0)
+	if err != nil {
+		return err
+	}
+	files = append(files, f)
+
+	deps := make(map[string]*types.Package)
+	var addDeps func(*types.Package)
+	addDeps = func(p *types.Package) {
+		for _, imp := range p.Imports() {
+			if _, ok := deps[imp.Path()]; !ok {
+				deps[imp.Path()] = imp
+				addDeps(imp)
+			}
+		}
+	}
+	addDeps(pkg.GetTypes())
+	for _, p := range others {
+		if _, ok := deps[p.Path()]; !ok {
+			deps[p.Path()] = p
+			addDeps(p)
+		}
+	}
+	var firstErr error
+	cfg := &types.Config{
+		Error: func(err error) {
+			terr, ok := err.(types.Error)
+			if !ok || firstErr != nil {
+				return
+			}
+			if posn := fset.Position(terr.Pos); posn.Filename == uri.Filename() && from <= posn.Offset && posn.Offset < to {
+				firstErr = err
+			}
+		},
+		Importer: importerFunc(func(path string) (*types.Package, error) {
+			if p, ok := deps[path]; ok {
+				return p, nil
+			}
+			return nil, fmt.Errorf("package %s is not a dependency of %s", path, pkg.PkgPath())
+		}),
+		Sizes: pkg.GetTypesSizes(),
+	}
+	cfg.Check(pkg.PkgPath(), fset, files, nil)
+	return firstErr
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) { return f(path) }
+
+// writeMock returns the source of a mock implementation named mockName of
+// the named interface type, qualifying types with the adder.
+func writeMock(named *types.Named, mockName string, recordCalls bool, adder *analysisinternal.ImportAdder) string {
+	iface := named.Underlying().(*types.Interface)
+	qf := adder.Qualifier
+	ifaceName := types.TypeString(named, qf)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s is a mock implementation of %s.\n", mockName, ifaceName)
+	fmt.Fprintf(&b, "type %s struct {\n", mockName)
+	for i := 0; i < iface.NumMethods(); i++ {
+		m := iface.Method(i)
+		sig := m.Type().(*types.Signature)
+		fmt.Fprintf(&b, "// %sFunc is called by %s.\n", m.Name(), m.Name())
+		fmt.Fprintf(&b, "%sFunc func%s\n", m.Name(), strings.TrimPrefix(types.TypeString(mockSignature(sig), qf), "func"))
+		if recordCalls {
+			fmt.Fprintf(&b, "// %sCalls records the arguments of each call to %s.\n", m.Name(), m.Name())
+			fmt.Fprintf(&b, "%sCalls []%s%sCall\n", m.Name(), mockName, m.Name())
+		}
+	}
+	if recordCalls {
+		fmt.Fprintf(&b, "\nmu %s.Mutex\n", adder.Qualifier(types.NewPackage("sync", "sync")))
+	}
+	b.WriteString("}\n")
+	fmt.Fprintf(&b, "\nvar _ %s = (*%s)(nil)\n", ifaceName, mockName)
+
+	if recordCalls {
+		for i := 0; i < iface.NumMethods(); i++ {
+			m := iface.Method(i)
+			sig := m.Type().(*types.Signature)
+			fmt.Fprintf(&b, "\n// %s%sCall records the arguments of a call to %s.%s.\n", mockName, m.Name(), mockName, m.Name())
+			fmt.Fprintf(&b, "type %s%sCall struct {\n", mockName, m.Name())
+			for j, name := range mockParamNames(sig) {
+				fmt.Fprintf(&b, "%s %s\n", upperFirst(name), types.TypeString(sig.Params().At(j).Type(), qf))
+			}
+			b.WriteString("}\n")
+		}
+	}
+
+	for i := 0; i < iface.NumMethods(); i++ {
+		m := iface.Method(i)
+		sig := mockSignature(m.Type().(*types.Signature))
+		names := mockParamNames(sig)
+
+		fmt.Fprintf(&b, "\n// %s calls %sFunc.\n", m.Name(), m.Name())
+		fmt.Fprintf(&b, "func (m *%s) %s%s {\n", mockName, m.Name(), strings.TrimPrefix(types.TypeString(sig, qf), "func"))
+		if recordCalls {
+			b.WriteString("m.mu.Lock()\n")
+			fmt.Fprintf(&b, "m.%sCalls = append(m.%sCalls, %s%sCall{", m.Name(), m.Name(), mockName, m.Name())
+			for j, name := range names {
+				if j > 0 {
+					b.WriteString(", ")
+				}
+				fmt.Fprintf(&b, "%s: %s", upperFirst(name), name)
+			}
+			b.WriteString("})\n")
+			b.WriteString("m.mu.Unlock()\n")
+		}
+		fmt.Fprintf(&b, "if m.%sFunc == nil {\n", m.Name())
+		fmt.Fprintf(&b, "panic(%q)\n}\n", fmt.Sprintf("%s.%s: %sFunc is not set", mockName, m.Name(), m.Name()))
+		var args []string
+		for j, name := range names {
+			if sig.Variadic() && j == len(names)-1 {
+				name += "..."
+			}
+			args = append(args, name)
+		}
+		call := fmt.Sprintf("m.%sFunc(%s)", m.Name(), strings.Join(args, ", "))
+		if sig.Results().Len() > 0 {
+			b.WriteString("return ")
+		}
+		b.WriteString(call + "\n}\n")
+	}
+	return b.String()
+}
+
+// mockSignature returns sig with a name for each parameter, and without
+// result names, so that the results need not be assigned in the body.
+func mockSignature(sig *types.Signature) *types.Signature {
+	names := mockParamNames(sig)
+	params := make([]*types.Var, sig.Params().Len())
+	for i := range params {
+		p := sig.Params().At(i)
+		params[i] = types.NewParam(p.Pos(), p.Pkg(), names[i], p.Type())
+	}
+	results := make([]*types.Var, sig.Results().Len())
+	for i := range results {
+		r := sig.Results().At(i)
+		results[i] = types.NewParam(r.Pos(), r.Pkg(), "", r.Type())
+	}
+	return types.NewSignature(nil, types.NewTuple(params...), types.NewTuple(results...), sig.Variadic())
+}
+
+// mockParamNames returns a distinct name for each parameter of sig, other
+// than the receiver name "m".
+func mockParamNames(sig *types.Signature) []string {
+	used := map[string]bool{"m": true}
+	var names []string
+	for i := 0; i < sig.Params().Len(); i++ {
+		name := sig.Params().At(i).Name()
+		if name == "" || name == "_" || used[name] {
+			name = fmt.Sprintf("arg%d", i)
+		}
+		used[name] = true
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,39 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"go/format"
+	"go/types"
+	"testing"
+
+	"github.com/kevinswiber/languageserver-go/analysisinternal"
+)
+
+func TestWriteMock(t *testing.T) {
+	const src = `package a
+
+type Store interface {
+	Get(key string) ([]byte, error)
+	Put(string, []byte, ...int)
+}
+`
+	files, pkg := typeCheck(t, src)
+	named := pkg.Scope().Lookup("Store").Type().(*types.Named)
+
+	for _, recordCalls := range []bool{false, true} {
+		adder := analysisinternal.NewImportAdder(files[0], pkg)
+		mock, err := format.Source([]byte(writeMock(named, "MockStore", recordCalls, adder)))
+		if err != nil {
+			t.Fatalf("recordCalls=%v: formatting mock: %v", recordCalls, err)
+		}
+		imports := ""
+		if recordCalls {
+			imports = "import \"sync\"\n\n"
+		}
+		// The mock type-checks along with the interface.
+		typeCheck(t, src, "package a\n\n"+imports+string(mock))
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"text/template"
 	"time"
 
 	"golang.org/x/tools/go/analysis"
@@ -46,19 +47,24 @@ import (
 	"golang.org/x/tools/go/analysis/passes/unsafeptr"
 	"golang.org/x/tools/go/analysis/passes/unusedresult"
 	"golang.org/x/tools/go/analysis/passes/unusedwrite"
+	"github.com/kevinswiber/languageserver-go/lsp/analysis/deprecated"
+	"github.com/kevinswiber/languageserver-go/lsp/analysis/enumstring"
 	"github.com/kevinswiber/languageserver-go/lsp/analysis/fillreturns"
 	"github.com/kevinswiber/languageserver-go/lsp/analysis/fillstruct"
+	"github.com/kevinswiber/languageserver-go/lsp/analysis/fillswitch"
 	"github.com/kevinswiber/languageserver-go/lsp/analysis/nonewvars"
 	"github.com/kevinswiber/languageserver-go/lsp/analysis/noresultvalues"
 	"github.com/kevinswiber/languageserver-go/lsp/analysis/simplifycompositelit"
 	"github.com/kevinswiber/languageserver-go/lsp/analysis/simplifyrange"
 	"github.com/kevinswiber/languageserver-go/lsp/analysis/simplifyslice"
+	"github.com/kevinswiber/languageserver-go/lsp/analysis/stubmethods"
 	"github.com/kevinswiber/languageserver-go/lsp/analysis/undeclaredname"
 	"github.com/kevinswiber/languageserver-go/lsp/analysis/unusedparams"
 	"github.com/kevinswiber/languageserver-go/lsp/command"
 	"github.com/kevinswiber/languageserver-go/lsp/diff"
 	"github.com/kevinswiber/languageserver-go/lsp/diff/myers"
 	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/lsp/snippet"
 	errors "golang.org/x/xerrors"
 )
 
@@ -99,7 +105,10 @@ func DefaultOptions() *Options {
 						protocol.SourceOrganizeImports: true,
 						protocol.QuickFix:              true,
 					},
-					Sum: {},
+					Sum: {
+						protocol.QuickFix: true,
+					},
+					Work: {},
 				},
 				SupportedCommands: commands,
 			},
@@ -108,6 +117,10 @@ func DefaultOptions() *Options {
 					ExpandWorkspaceToModule:     true,
 					ExperimentalPackageCacheKey: true,
 				},
+				FormattingOptions: FormattingOptions{
+					StructTags:    []string{"json"},
+					StructTagCase: CamelCase,
+				},
 				UIOptions: UIOptions{
 					DiagnosticOptions: DiagnosticOptions{
 						ExperimentalDiagnosticsDelay: 250 * time.Millisecond,
@@ -129,11 +142,13 @@ func DefaultOptions() *Options {
 						SymbolStyle:    DynamicSymbols,
 					},
 					CompletionOptions: CompletionOptions{
-						Matcher:          Fuzzy,
-						CompletionBudget: 100 * time.Millisecond,
+						Matcher:            Fuzzy,
+						CompletionBudget:   100 * time.Millisecond,
+						PostfixCompletions: true,
 					},
 					Codelenses: map[string]bool{
 						string(command.Generate):          true,
+						string(command.GenerateGoWork):    true,
 						string(command.RegenerateCgo):     true,
 						string(command.Tidy):              true,
 						string(command.GCDetails):         false,
@@ -186,6 +201,10 @@ type ClientOptions struct {
 	SemanticTypes                     []string
 	SemanticMods                      []string
 	RelatedInformationSupported       bool
+	PullDiagnosticsSupported          bool
+	DiagnosticRefreshSupported        bool
+	CompletionDeprecatedTagSupported  bool
+	CompletionInsertAsIsSupported     bool
 }
 
 // ServerOptions holds LSP-specific configuration that is provided by the
@@ -246,6 +265,47 @@ type BuildOptions struct {
 	// downloads rather than requiring user action. This option will eventually
 	// be removed.
 	AllowImplicitNetworkAccess bool `status:"experimental"`
+
+	// BuildMatrix lists additional build configurations under which the
+	// workspace packages are type-checked, so that the errors in the files
+	// of other platforms are reported too. Each configuration has a `goos`,
+	// a `goarch`, and a list of build `tags`; a missing GOOS or GOARCH is
+	// that of the view. The errors that the view's own configuration does
+	// not report are annotated with the configurations that produce them.
+	//
+	// Example Usage:
+	//
+	// ```json5
+	// "gopls": {
+	// ...
+	//   "buildMatrix": [
+	//     {"goos": "windows", "goarch": "amd64"},
+	//     {"goos": "darwin", "goarch": "arm64", "tags": ["integration"]},
+	//   ]
+	// ...
+	// }
+	// ```
+	BuildMatrix []BuildConfig `status:"experimental"`
+}
+
+// A BuildConfig is a build configuration of the BuildMatrix option.
+type BuildConfig struct {
+	GOOS   string
+	GOARCH string
+	Tags   []string
+}
+
+// String returns the configuration in the form "GOOS/GOARCH tags=a,b",
+// leaving out the parts that are not set.
+func (c BuildConfig) String() string {
+	var parts []string
+	if platform := strings.Trim(c.GOOS+"/"+c.GOARCH, "/"); platform != "" {
+		parts = append(parts, platform)
+	}
+	if len(c.Tags) > 0 {
+		parts = append(parts, "tags="+strings.Join(c.Tags, ","))
+	}
+	return strings.Join(parts, " ")
 }
 
 type UIOptions struct {
@@ -293,8 +353,105 @@ type CompletionOptions struct {
 	// Matcher sets the algorithm that is used when calculating completion
 	// candidates.
 	Matcher Matcher `status:"advanced"`
+
+	// PostfixCompletions enables postfix completions, which transform the
+	// expression before a trailing ".", such as `xs.for` into a loop over
+	// xs or `err.ret` into a check that returns err.
+	PostfixCompletions bool `status:"experimental"`
+
+	// PostfixTemplates maps the labels of additional postfix completions
+	// to their templates. A template uses the syntax of the text/template
+	// package, and it produces a completion only if its output is not
+	// empty. The data passed to a template describes the expression:
+	// `.X` is its text, `.Kind` is the kind of its type (such as "slice",
+	// "map", or "bool"), and `.StmtOK` reports whether the expression is a
+	// statement. Templates can call methods of the data such as
+	// `.Placeholder "text"`, `.Cursor`, `.VarName "name"`, `.TypeName
+	// .ElemType`, `.Zero .Type`, and `.Import "path"`; see the built-in
+	// templates for examples.
+	//
+	// Example Usage:
+	//
+	// ```json5
+	// "gopls": {
+	// ...
+	//   "postfixTemplates": {
+	//     "debug": "{{if .StmtOK}}{{.Import \"log\"}}.Printf(\"%#v\\n\", {{.X}}){{end}}",
+	//   }
+	// ...
+	// }
+	// ```
+	PostfixTemplates map[string]string `status:"experimental"`
+
+	// SnippetTemplates are snippets that completion offers at the start of
+	// a statement or of a top-level declaration. Each has a `name`, which
+	// is its label, a `context`, which is "statement" or "declaration", and
+	// a `body` in LSP snippet syntax, with tab stops such as `$1`, `${2:x}`,
+	// and `$0`. The body may use the variables `$receiver` and
+	// `$receiverType`, the receiver of the enclosing method; `$funcName`,
+	// the name of the enclosing function; `$pkgName`, the name of the
+	// current package; `$zeroValues`, the zero values of the enclosing
+	// function's results; and `$testVar`, the testing.TB parameter of the
+	// enclosing function. A variable with no value becomes a placeholder.
+	//
+	// Example Usage:
+	//
+	// ```json5
+	// "gopls": {
+	// ...
+	//   "snippetTemplates": [{
+	//     "name": "logerr",
+	//     "context": "statement",
+	//     "body": "if err != nil {\n\tlog.Printf(\"$funcName: %v\", err)\n\treturn $zeroValues\n}",
+	//   }]
+	// ...
+	// }
+	// ```
+	SnippetTemplates []SnippetTemplate `status:"experimental"`
+
+	// WorkspaceRanking ranks the methods, fields, and package members in
+	// completion results by how often the workspace packages select them,
+	// so that the commonly used members of large method sets come first.
+	WorkspaceRanking bool `status:"experimental"`
+
+	// StructFieldsSnippet offers, when completing the first field name of
+	// a struct literal, a snippet that sets all of the remaining fields of
+	// the struct. Each value is a placeholder with the value that the
+	// fillstruct code action would use. Fields whose documentation begins
+	// with "optional" are left out, as are the fields that match
+	// OptionalFieldTags.
+	StructFieldsSnippet bool `status:"experimental"`
+
+	// OptionalFieldTags lists the struct tags that mark the fields left out
+	// of the struct fields snippet. An entry is either a tag key, such as
+	// "optional", which matches any field with that key, or a key and an
+	// option, such as "json:omitempty", which matches the fields whose tag
+	// for that key has that option.
+	OptionalFieldTags []string `status:"experimental"`
+}
+
+// A SnippetTemplate is a user-defined snippet offered by completion.
+type SnippetTemplate struct {
+	// Name is the label of the completion item.
+	Name string
+
+	// Context is where the snippet is offered.
+	Context SnippetContext
+
+	// Body is the snippet, in LSP snippet syntax.
+	Body string
 }
 
+type SnippetContext string
+
+const (
+	// StatementSnippet is offered at the start of a statement.
+	StatementSnippet SnippetContext = "statement"
+
+	// DeclarationSnippet is offered at the start of a top-level declaration.
+	DeclarationSnippet SnippetContext = "declaration"
+)
+
 type DocumentationOptions struct {
 	// HoverKind controls the information that appears in the hover text.
 	// SingleLine and Structured are intended for use only by authors of editor plugins.
@@ -322,6 +479,30 @@ type FormattingOptions struct {
 
 	// Gofumpt indicates if we should run gofumpt formatting.
 	Gofumpt bool
+
+	// StructTags is the list of struct tag keys, such as `json` or `yaml`,
+	// for which the struct tag code actions are offered.
+	StructTags []string
+
+	// StructTagCase controls how field names are transformed into the names
+	// used in generated struct tags.
+	StructTagCase StructTagCase
+
+	// StructTagOptions maps a struct tag key to the options, such as
+	// `omitempty`, that are appended to the names in generated tags.
+	//
+	// Example Usage:
+	//
+	// ```json5
+	// "gopls": {
+	// ...
+	//   "structTagOptions": {
+	//     "json": "omitempty",
+	//   }
+	// ...
+	// }
+	// ```
+	StructTagOptions map[string]string
 }
 
 type DiagnosticOptions struct {
@@ -345,6 +526,57 @@ type DiagnosticOptions struct {
 	// Staticcheck enables additional analyses from staticcheck.io.
 	Staticcheck bool `status:"experimental"`
 
+	// VetTools lists the paths of external analysis tools that speak the
+	// protocol of `go vet -vettool`, such as those built with unitchecker.
+	// Each tool is run on every workspace package, and its analyzers can
+	// be enabled or disabled by name in the analyses setting, like gopls's
+	// own.
+	VetTools []string `status:"experimental"`
+
+	// UnusedExportedDiagnostics reports the exported identifiers of
+	// workspace packages that no workspace package uses, as found by the
+	// unused_exported command. The diagnostics are hints, computed after
+	// the other diagnostics of the workspace.
+	UnusedExportedDiagnostics bool `status:"experimental"`
+
+	// VulnerabilityDatabase is the absolute path of a directory of
+	// vulnerability reports in the OSV JSON format, such as a local mirror
+	// of the Go vulnerability database. The required module versions of
+	// go.mod files that its reports affect are diagnosed, without network
+	// access, along with the affected symbols and an upgrade to the first
+	// fixed version.
+	VulnerabilityDatabase string `status:"experimental"`
+
+	// DiagnosticSeverity overrides the severity of diagnostics by their
+	// source: the name of an analyzer, or one of "compiler", "syntax",
+	// "go list", "go mod tidy", and the other sources of gopls's own
+	// diagnostics. The severity is one of "error", "warning",
+	// "information", and "hint".
+	//
+	// Example Usage:
+	//
+	// ```json5
+	// "diagnosticSeverity": {
+	//   "unusedparams": "hint",
+	//   "printf": "error"
+	// }
+	// ```
+	DiagnosticSeverity map[string]protocol.DiagnosticSeverity `status:"experimental"`
+
+	// DiagnosticTags adds tags to diagnostics by their source, as for
+	// diagnosticSeverity. The tags are "unnecessary", which clients
+	// usually show by fading the code out, and "deprecated", which they
+	// usually show by striking it through.
+	//
+	// Example Usage:
+	//
+	// ```json5
+	// "diagnosticTags": {
+	//   "unusedparams": ["unnecessary"]
+	// }
+	// ```
+	DiagnosticTags map[string][]protocol.DiagnosticTag `status:"experimental"`
+
 	// Annotations specifies the various kinds of optimization diagnostics
 	// that should be reported by the gc_details command.
 	Annotations map[Annotation]bool `status:"experimental"`
@@ -391,6 +623,34 @@ type UserOptions struct {
 	VerboseOutput bool `status:"debug"`
 }
 
+// ForBuildConfig returns a copy of the options for type-checking under the
+// build configuration, without a build matrix of its own.
+func (o *Options) ForBuildConfig(config BuildConfig) *Options {
+	result := o.Clone()
+	if config.GOOS != "" {
+		result.Env["GOOS"] = config.GOOS
+	}
+	if config.GOARCH != "" {
+		result.Env["GOARCH"] = config.GOARCH
+	}
+	if len(config.Tags) > 0 {
+		tags := strings.Join(config.Tags, ",")
+		added := false
+		for i, flag := range result.BuildFlags {
+			if strings.HasPrefix(flag, "-tags=") || strings.HasPrefix(flag, "--tags=") {
+				result.BuildFlags[i] = flag + "," + tags
+				added = true
+				break
+			}
+		}
+		if !added {
+			result.BuildFlags = append(result.BuildFlags, "-tags="+tags)
+		}
+	}
+	result.BuildMatrix = nil
+	return result
+}
+
 // EnvSlice returns Env as a slice of k=v strings.
 func (u *UserOptions) EnvSlice() []string {
 	var result []string
@@ -476,6 +736,11 @@ type InternalOptions struct {
 
 	// TempModfile controls the use of the -modfile flag in Go 1.14.
 	TempModfile bool
+
+	// ParsedPostfixTemplates are the parsed PostfixTemplates, by label.
+	// They are parsed when the PostfixTemplates option is set, and are
+	// not modified afterwards.
+	ParsedPostfixTemplates map[string]*template.Template
 }
 
 type ImportShortcut string
@@ -526,6 +791,21 @@ const (
 	DynamicSymbols SymbolStyle = "Dynamic"
 )
 
+type StructTagCase string
+
+const (
+	// CamelCase transforms field names to camelCase, e.g. "fooBar".
+	CamelCase StructTagCase = "CamelCase"
+	// PascalCase transforms field names to PascalCase, e.g. "FooBar".
+	PascalCase StructTagCase = "PascalCase"
+	// SnakeCase transforms field names to snake_case, e.g. "foo_bar".
+	SnakeCase StructTagCase = "SnakeCase"
+	// KebabCase transforms field names to kebab-case, e.g. "foo-bar".
+	KebabCase StructTagCase = "KebabCase"
+	// KeepCase uses field names unchanged, e.g. "FooBar".
+	KeepCase StructTagCase = "Keep"
+)
+
 type HoverKind string
 
 const (
@@ -600,6 +880,20 @@ func (o *Options) ForClientCapabilities(caps protocol.ClientCapabilities) {
 	if c := caps.TextDocument.Completion; c.CompletionItem.SnippetSupport {
 		o.InsertTextFormat = protocol.SnippetTextFormat
 	}
+	// Check if the client can mark completion items as deprecated with a tag.
+	for _, t := range caps.TextDocument.Completion.CompletionItem.TagSupport.ValueSet {
+		if t == protocol.ComplDeprecated {
+			o.CompletionDeprecatedTagSupported = true
+		}
+	}
+	// Check if the client inserts the new lines of completion items as they
+	// are, or can be told to, rather than adjusting their indentation.
+	o.CompletionInsertAsIsSupported = caps.TextDocument.Completion.InsertTextMode == protocol.AsIs
+	for _, m := range caps.TextDocument.Completion.CompletionItem.InsertTextModeSupport.ValueSet {
+		if m == protocol.AsIs {
+			o.CompletionInsertAsIsSupported = true
+		}
+	}
 	// Check if the client supports configuration messages.
 	o.ConfigurationSupported = caps.Workspace.Configuration
 	o.DynamicConfigurationSupported = caps.Workspace.DidChangeConfiguration.DynamicRegistration
@@ -622,6 +916,11 @@ func (o *Options) ForClientCapabilities(caps protocol.ClientCapabilities) {
 
 	// Check if the client supports diagnostic related information.
 	o.RelatedInformationSupported = caps.TextDocument.PublishDiagnostics.RelatedInformation
+
+	// Check if the client pulls diagnostics instead of having them published,
+	// and if it can be told to pull them again.
+	o.PullDiagnosticsSupported = caps.TextDocument.Diagnostic != nil
+	o.DiagnosticRefreshSupported = caps.Workspace.Diagnostics.RefreshSupport
 }
 
 func (o *Options) Clone() *Options {
@@ -657,6 +956,33 @@ func (o *Options) Clone() *Options {
 	result.SetEnvSlice(o.EnvSlice())
 	result.BuildFlags = copySlice(o.BuildFlags)
 	result.DirectoryFilters = copySlice(o.DirectoryFilters)
+	result.StructTags = copySlice(o.StructTags)
+	result.OptionalFieldTags = copySlice(o.OptionalFieldTags)
+	result.VetTools = copySlice(o.VetTools)
+
+	copyStringStringMap := func(src map[string]string) map[string]string {
+		dst := make(map[string]string)
+		for k, v := range src {
+			dst[k] = v
+		}
+		return dst
+	}
+	result.StructTagOptions = copyStringStringMap(o.StructTagOptions)
+	result.DiagnosticSeverity = make(map[string]protocol.DiagnosticSeverity)
+	for k, v := range o.DiagnosticSeverity {
+		result.DiagnosticSeverity[k] = v
+	}
+	result.DiagnosticTags = make(map[string][]protocol.DiagnosticTag)
+	for k, v := range o.DiagnosticTags {
+		result.DiagnosticTags[k] = append([]protocol.DiagnosticTag(nil), v...)
+	}
+	result.PostfixTemplates = copyStringStringMap(o.PostfixTemplates)
+	result.SnippetTemplates = append([]SnippetTemplate(nil), o.SnippetTemplates...)
+	result.BuildMatrix = nil
+	for _, config := range o.BuildMatrix {
+		config.Tags = copySlice(config.Tags)
+		result.BuildMatrix = append(result.BuildMatrix, config)
+	}
 
 	copyAnalyzerMap := func(src map[string]*Analyzer) map[string]*Analyzer {
 		dst := make(map[string]*Analyzer)
@@ -763,6 +1089,89 @@ func (o *Options) set(name string, value interface{}, seen map[string]struct{})
 			o.Matcher = Matcher(s)
 		}
 
+	case "postfixCompletions":
+		result.setBool(&o.PostfixCompletions)
+
+	case "postfixTemplates":
+		mtmpls, ok := value.(map[string]interface{})
+		if !ok {
+			result.errorf("invalid type %T, expect map", value)
+			break
+		}
+		tmpls := make(map[string]string)
+		parsed := make(map[string]*template.Template)
+		for k, v := range mtmpls {
+			body := fmt.Sprint(v)
+			tmpl, err := template.New(k).Parse(body)
+			if err != nil {
+				result.errorf("invalid template %q: %v", k, err)
+				continue
+			}
+			tmpls[k] = body
+			parsed[k] = tmpl
+		}
+		o.PostfixTemplates = tmpls
+		o.ParsedPostfixTemplates = parsed
+
+	case "snippetTemplates":
+		itmpls, ok := value.([]interface{})
+		if !ok {
+			result.errorf("invalid type %T, expect list", value)
+			break
+		}
+		tmpls := make([]SnippetTemplate, 0, len(itmpls))
+		for _, itmpl := range itmpls {
+			m, ok := itmpl.(map[string]interface{})
+			if !ok {
+				result.errorf("invalid snippet template type %T, expect object", itmpl)
+				continue
+			}
+			if m["name"] == nil || m["body"] == nil {
+				result.errorf("snippet template must have a name and a body")
+				continue
+			}
+			tmpl := SnippetTemplate{
+				Name:    fmt.Sprint(m["name"]),
+				Context: SnippetContext(fmt.Sprint(m["context"])),
+				Body:    fmt.Sprint(m["body"]),
+			}
+			switch tmpl.Context {
+			case StatementSnippet, DeclarationSnippet:
+			default:
+				result.errorf("invalid context %q for snippet template %q, expect %q or %q", tmpl.Context, tmpl.Name, StatementSnippet, DeclarationSnippet)
+				continue
+			}
+			if _, err := snippet.ParseTemplate(tmpl.Body); err != nil {
+				result.errorf("invalid snippet template %q: %v", tmpl.Name, err)
+				continue
+			}
+			tmpls = append(tmpls, tmpl)
+		}
+		o.SnippetTemplates = tmpls
+
+	case "workspaceRanking":
+		result.setBool(&o.WorkspaceRanking)
+
+	case "structFieldsSnippet":
+		result.setBool(&o.StructFieldsSnippet)
+
+	case "optionalFieldTags":
+		itags, ok := value.([]interface{})
+		if !ok {
+			result.errorf("invalid type %T, expect list", value)
+			break
+		}
+		tags := make([]string, 0, len(itags))
+		for _, itag := range itags {
+			tag := fmt.Sprint(itag)
+			if tag == "" || strings.HasPrefix(tag, ":") || strings.HasSuffix(tag, ":") {
+				result.errorf("invalid optional field tag %q, expect key or key:option", tag)
+				continue
+			}
+			tags = append(tags, tag)
+		}
+		o.OptionalFieldTags = tags
+
 	case "symbolMatcher":
 		if s, ok := result.asOneOf(
 			string(SymbolFuzzy),
@@ -809,6 +1218,49 @@ func (o *Options) set(name string, value interface{}, seen map[string]struct{})
 	case "annotations":
 		result.setAnnotationMap(&o.Annotations)
 
+	case "diagnosticSeverity":
+		all, ok := value.(map[string]interface{})
+		if !ok {
+			result.errorf("invalid type %T, expect map", value)
+			break
+		}
+		m := make(map[string]protocol.DiagnosticSeverity)
+		for source, v := range all {
+			severity, ok := parseSeverity(fmt.Sprint(v))
+			if !ok {
+				result.errorf("invalid severity %q for %q, expect error, warning, information, or hint", v, source)
+				continue
+			}
+			m[source] = severity
+		}
+		o.DiagnosticSeverity = m
+
+	case "diagnosticTags":
+		all, ok := value.(map[string]interface{})
+		if !ok {
+			result.errorf("invalid type %T, expect map", value)
+			break
+		}
+		m := make(map[string][]protocol.DiagnosticTag)
+		for source, v := range all {
+			names, ok := v.([]interface{})
+			if !ok {
+				result.errorf("invalid type %T for %q, expect list", v, source)
+				continue
+			}
+			for _, name := range names {
+				switch fmt.Sprint(name) {
+				case "unnecessary":
+					m[source] = append(m[source], protocol.Unnecessary)
+				case "deprecated":
+					m[source] = append(m[source], protocol.Deprecated)
+				default:
+					result.errorf("invalid tag %q for %q, expect unnecessary or deprecated", name, source)
+				}
+			}
+		}
+		o.DiagnosticTags = m
+
 	case "codelenses", "codelens":
 		var lensOverrides map[string]bool
 		result.setBoolMap(&lensOverrides)
@@ -831,6 +1283,33 @@ func (o *Options) set(name string, value interface{}, seen map[string]struct{})
 	case "staticcheck":
 		result.setBool(&o.Staticcheck)
 
+	case "vetTools":
+		itools, ok := value.([]interface{})
+		if !ok {
+			result.errorf("invalid type %T, expect list", value)
+			break
+		}
+		tools := make([]string, 0, len(itools))
+		for _, itool := range itools {
+			tool := fmt.Sprint(itool)
+			if !filepath.IsAbs(tool) {
+				result.errorf("invalid vet tool %q, expect absolute path", tool)
+				continue
+			}
+			tools = append(tools, tool)
+		}
+		o.VetTools = tools
+
+	case "unusedExportedDiagnostics":
+		result.setBool(&o.UnusedExportedDiagnostics)
+
+	case "vulnerabilityDatabase":
+		result.setString(&o.VulnerabilityDatabase)
+		if o.VulnerabilityDatabase != "" && !filepath.IsAbs(o.VulnerabilityDatabase) {
+			result.errorf("invalid vulnerability database %q, expect absolute path", o.VulnerabilityDatabase)
+			o.VulnerabilityDatabase = ""
+		}
+
 	case "local":
 		result.setString(&o.Local)
 
@@ -846,6 +1325,41 @@ func (o *Options) set(name string, value interface{}, seen map[string]struct{})
 	case "gofumpt":
 		result.setBool(&o.Gofumpt)
 
+	case "structTags":
+		ikeys, ok := value.([]interface{})
+		if !ok {
+			result.errorf("invalid type %T, expect list", value)
+			break
+		}
+		keys := make([]string, 0, len(ikeys))
+		for _, key := range ikeys {
+			keys = append(keys, fmt.Sprint(key))
+		}
+		o.StructTags = keys
+
+	case "structTagCase":
+		if s, ok := result.asOneOf(
+			string(CamelCase),
+			string(PascalCase),
+			string(SnakeCase),
+			string(KebabCase),
+			string(KeepCase),
+		); ok {
+			o.StructTagCase = StructTagCase(s)
+		}
+
+	case "structTagOptions":
+		mopts, ok := value.(map[string]interface{})
+		if !ok {
+			result.errorf("invalid type %T, expect map", value)
+			break
+		}
+		opts := make(map[string]string)
+		for k, v := range mopts {
+			opts[k] = fmt.Sprint(v)
+		}
+		o.StructTagOptions = opts
+
 	case "semanticTokens":
 		result.setBool(&o.SemanticTokens)
 
@@ -867,6 +1381,44 @@ func (o *Options) set(name string, value interface{}, seen map[string]struct{})
 	case "allowImplicitNetworkAccess":
 		result.setBool(&o.AllowImplicitNetworkAccess)
 
+	case "buildMatrix":
+		iconfigs, ok := value.([]interface{})
+		if !ok {
+			result.errorf("invalid type %T, expect list", value)
+			break
+		}
+		configs := make([]BuildConfig, 0, len(iconfigs))
+		for _, iconfig := range iconfigs {
+			m, ok := iconfig.(map[string]interface{})
+			if !ok {
+				result.errorf("invalid build configuration type %T, expect object", iconfig)
+				continue
+			}
+			var config BuildConfig
+			if m["goos"] != nil {
+				config.GOOS = fmt.Sprint(m["goos"])
+			}
+			if m["goarch"] != nil {
+				config.GOARCH = fmt.Sprint(m["goarch"])
+			}
+			if m["tags"] != nil {
+				itags, ok := m["tags"].([]interface{})
+				if !ok {
+					result.errorf("invalid build tags type %T, expect list", m["tags"])
+					continue
+				}
+				for _, tag := range itags {
+					config.Tags = append(config.Tags, fmt.Sprint(tag))
+				}
+			}
+			if config.String() == "" {
+				result.errorf("empty build configuration")
+				continue
+			}
+			configs = append(configs, config)
+		}
+		o.BuildMatrix = configs
+
 	case "allExperiments":
 		// This setting should be handled before all of the other options are
 		// processed, so do nothing here.
@@ -1091,6 +1643,11 @@ func typeErrorAnalyzers() map[string]*Analyzer {
 			Analyzer: noresultvalues.Analyzer,
 			Enabled:  true,
 		},
+		stubmethods.Analyzer.Name: {
+			Analyzer: stubmethods.Analyzer,
+			Fix:      StubMethods,
+			Enabled:  true,
+		},
 		undeclaredname.Analyzer.Name: {
 			Analyzer: undeclaredname.Analyzer,
 			Fix:      UndeclaredName,
@@ -1141,7 +1698,10 @@ func defaultAnalyzers() map[string]*Analyzer {
 		// Non-vet analyzers:
 		atomicalign.Analyzer.Name:      {Analyzer: atomicalign.Analyzer, Enabled: true},
 		deepequalerrors.Analyzer.Name:  {Analyzer: deepequalerrors.Analyzer, Enabled: true},
+		deprecated.Analyzer.Name:       {Analyzer: deprecated.Analyzer, Enabled: true, Diagnose: DeprecatedDiagnostics},
+		enumstring.Analyzer.Name:       {Analyzer: enumstring.Analyzer, Enabled: true},
 		fieldalignment.Analyzer.Name:   {Analyzer: fieldalignment.Analyzer, Enabled: false},
+		fillswitch.Analyzer.Name:       {Analyzer: fillswitch.Analyzer, Enabled: false, Fix: FillSwitch},
 		nilness.Analyzer.Name:          {Analyzer: nilness.Analyzer, Enabled: false},
 		shadow.Analyzer.Name:           {Analyzer: shadow.Analyzer, Enabled: false},
 		sortslice.Analyzer.Name:        {Analyzer: sortslice.Analyzer, Enabled: true},
@@ -7,6 +7,8 @@ package source
 import (
 	"testing"
 	"time"
+
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
 )
 
 func TestSetOption(t *testing.T) {
@@ -166,6 +168,65 @@ func TestSetOption(t *testing.T) {
 				return !o.Annotations[Nil] && !o.Annotations[Bounds]
 			},
 		},
+		{
+			name: "diagnosticSeverity",
+			value: map[string]interface{}{
+				"printf":   "error",
+				"compiler": "hint",
+			},
+			check: func(o Options) bool {
+				return o.DiagnosticSeverity["printf"] == protocol.SeverityError && o.DiagnosticSeverity["compiler"] == protocol.SeverityHint
+			},
+		},
+		{
+			name: "diagnosticSeverity",
+			value: map[string]interface{}{
+				"printf": "fatal",
+			},
+			wantError: true,
+			check: func(o Options) bool {
+				_, ok := o.DiagnosticSeverity["printf"]
+				return !ok
+			},
+		},
+		{
+			name: "diagnosticTags",
+			value: map[string]interface{}{
+				"unusedparams": []interface{}{"unnecessary"},
+				"SA1019":       []interface{}{"deprecated", "faded"},
+			},
+			wantError: true,
+			check: func(o Options) bool {
+				return len(o.DiagnosticTags["unusedparams"]) == 1 && o.DiagnosticTags["unusedparams"][0] == protocol.Unnecessary &&
+					len(o.DiagnosticTags["SA1019"]) == 1 && o.DiagnosticTags["SA1019"][0] == protocol.Deprecated
+			},
+		},
+		{
+			name: "buildMatrix",
+			value: []interface{}{
+				map[string]interface{}{"goos": "windows", "goarch": "amd64"},
+				map[string]interface{}{"tags": []interface{}{"integration"}},
+				map[string]interface{}{},
+			},
+			wantError: true,
+			check: func(o Options) bool {
+				return len(o.BuildMatrix) == 2 &&
+					o.BuildMatrix[0].String() == "windows/amd64" &&
+					o.BuildMatrix[1].String() == "tags=integration"
+			},
+		},
+		{
+			name: "postfixTemplates",
+			value: map[string]interface{}{
+				"neg": "{{if eq .Kind \"number\"}}-{{.X}}{{end}}",
+				"bad": "{{if}}",
+			},
+			wantError: true,
+			check: func(o Options) bool {
+				return len(o.PostfixTemplates) == 1 && len(o.ParsedPostfixTemplates) == 1 &&
+					o.ParsedPostfixTemplates["neg"] != nil
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -181,3 +242,27 @@ func TestSetOption(t *testing.T) {
 		}
 	}
 }
+
+func TestForBuildConfig(t *testing.T) {
+	opts := &Options{}
+	opts.Env = map[string]string{"GOFLAGS": "-mod=mod"}
+	opts.BuildFlags = []string{"-tags=foo"}
+	opts.BuildMatrix = []BuildConfig{{GOOS: "darwin"}}
+
+	got := opts.ForBuildConfig(BuildConfig{GOOS: "windows", Tags: []string{"bar", "baz"}})
+	if got.Env["GOOS"] != "windows" || got.Env["GOFLAGS"] != "-mod=mod" {
+		t.Errorf("Env = %v, want GOOS=windows and GOFLAGS=-mod=mod", got.Env)
+	}
+	if _, ok := got.Env["GOARCH"]; ok {
+		t.Errorf("Env = %v, want no GOARCH", got.Env)
+	}
+	if len(got.BuildFlags) != 1 || got.BuildFlags[0] != "-tags=foo,bar,baz" {
+		t.Errorf("BuildFlags = %v, want [-tags=foo,bar,baz]", got.BuildFlags)
+	}
+	if got.BuildMatrix != nil {
+		t.Errorf("BuildMatrix = %v, want none", got.BuildMatrix)
+	}
+	if opts.Env["GOOS"] != "" || opts.BuildFlags[0] != "-tags=foo" {
+		t.Errorf("ForBuildConfig modified the original options")
+	}
+}
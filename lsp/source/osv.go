@@ -0,0 +1,162 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// An OSVEntry is a vulnerability report in the OSV format, as documented
+// at https://ossf.github.io/osv-schema. Only the fields that are needed to
+// diagnose Go modules are decoded.
+type OSVEntry struct {
+	ID         string        `json:"id"`
+	Aliases    []string      `json:"aliases"`
+	Summary    string        `json:"summary"`
+	Details    string        `json:"details"`
+	Withdrawn  string        `json:"withdrawn"`
+	Affected   []OSVAffected `json:"affected"`
+	References []struct {
+		Type string `json:"type"`
+		URL  string `json:"url"`
+	} `json:"references"`
+	DatabaseSpecific struct {
+		URL string `json:"url"`
+	} `json:"database_specific"`
+}
+
+// An OSVAffected describes the affected versions and symbols of a module,
+// or of a package in older reports.
+type OSVAffected struct {
+	Package struct {
+		Ecosystem string `json:"ecosystem"`
+		// Name is the module path, or in older reports the import path
+		// of the affected package.
+		Name string `json:"name"`
+	} `json:"package"`
+	Ranges []struct {
+		Type   string     `json:"type"`
+		Events []OSVEvent `json:"events"`
+	} `json:"ranges"`
+	Versions          []string `json:"versions"`
+	EcosystemSpecific struct {
+		Imports []struct {
+			Path    string   `json:"path"`
+			Symbols []string `json:"symbols"`
+		} `json:"imports"`
+		// Symbols are the affected symbols of the package of older
+		// reports.
+		Symbols []string `json:"symbols"`
+	} `json:"ecosystem_specific"`
+}
+
+// An OSVEvent introduces or ends a range of affected versions.
+type OSVEvent struct {
+	Introduced   string `json:"introduced"`
+	Fixed        string `json:"fixed"`
+	LastAffected string `json:"last_affected"`
+}
+
+// URL returns the link to the report in the database, or to its advisory.
+func (e *OSVEntry) URL() string {
+	if e.DatabaseSpecific.URL != "" {
+		return e.DatabaseSpecific.URL
+	}
+	for _, ref := range e.References {
+		if ref.Type == "ADVISORY" || ref.Type == "WEB" {
+			return ref.URL
+		}
+	}
+	return ""
+}
+
+// Affects reports whether the version is affected, and returns the first
+// version after it that is not, if any.
+func (a *OSVAffected) Affects(version string) (fixed string, ok bool) {
+	for _, v := range a.Versions {
+		if canonicalOSVVersion(v) == version {
+			ok = true
+		}
+	}
+	for _, r := range a.Ranges {
+		if r.Type != "SEMVER" {
+			continue
+		}
+		events := append([]OSVEvent{}, r.Events...)
+		sort.SliceStable(events, func(i, j int) bool {
+			return semver.Compare(events[i].version(), events[j].version()) < 0
+		})
+		affected, rangeFixed := false, ""
+		for _, e := range events {
+			switch {
+			case e.Introduced != "":
+				if semver.Compare(version, e.version()) >= 0 {
+					affected = true
+				}
+			case e.Fixed != "":
+				if semver.Compare(version, e.version()) >= 0 {
+					affected = false
+				} else if affected && rangeFixed == "" {
+					rangeFixed = e.version()
+				}
+			case e.LastAffected != "":
+				if semver.Compare(version, e.version()) > 0 {
+					affected = false
+				}
+			}
+		}
+		if affected {
+			ok, fixed = true, rangeFixed
+		}
+	}
+	return fixed, ok
+}
+
+// Symbols returns the qualified names of the affected symbols, or the
+// affected packages if the report does not list their symbols.
+func (a *OSVAffected) Symbols() []string {
+	var symbols []string
+	qualify := func(path string, names []string) {
+		if len(names) == 0 {
+			symbols = append(symbols, path)
+		}
+		for _, name := range names {
+			symbols = append(symbols, path+"."+name)
+		}
+	}
+	for _, imp := range a.EcosystemSpecific.Imports {
+		qualify(imp.Path, imp.Symbols)
+	}
+	if len(a.EcosystemSpecific.Imports) == 0 && len(a.EcosystemSpecific.Symbols) > 0 {
+		qualify(a.Package.Name, a.EcosystemSpecific.Symbols)
+	}
+	return symbols
+}
+
+// version returns the canonical version of the event. The version "0" of
+// introduced events precedes all others.
+func (e OSVEvent) version() string {
+	switch {
+	case e.Introduced == "0":
+		return "v0.0.0-0"
+	case e.Introduced != "":
+		return canonicalOSVVersion(e.Introduced)
+	case e.Fixed != "":
+		return canonicalOSVVersion(e.Fixed)
+	}
+	return canonicalOSVVersion(e.LastAffected)
+}
+
+// canonicalOSVVersion adds the "v" prefix that the versions of OSV reports
+// lack.
+func canonicalOSVVersion(v string) string {
+	if !strings.HasPrefix(v, "v") {
+		v = "v" + v
+	}
+	return v
+}
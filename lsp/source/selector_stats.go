@@ -0,0 +1,86 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"go/ast"
+	"go/types"
+)
+
+// SelectorStats counts the uses of methods, fields, and package members in
+// selector expressions, such as x.Method and pkg.Func.
+type SelectorStats map[SelectorKey]int
+
+// A SelectorKey identifies a selected method, field, or package member.
+type SelectorKey struct {
+	// Recv is the qualified name of the named type whose method or field
+	// is selected, such as "net/http.Client", or the path of the package
+	// whose member is selected.
+	Recv string
+
+	// Name is the name of the method, field, or package member.
+	Name string
+}
+
+// Merge adds the counts of other to s.
+func (s SelectorStats) Merge(other SelectorStats) {
+	for k, n := range other {
+		s[k] += n
+	}
+}
+
+// CountSelectors counts the selector expressions of the package's files.
+// A method or field is counted under the named type of the expression it
+// is selected from, so that a promoted method counts toward the type
+// that promotes it.
+func CountSelectors(pkg Package) SelectorStats {
+	info := pkg.GetTypesInfo()
+	stats := make(SelectorStats)
+	for _, pgf := range pkg.CompiledGoFiles() {
+		ast.Inspect(pgf.File, func(n ast.Node) bool {
+			sel, ok := n.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			if recv := selectorRecv(info, sel); recv != "" {
+				stats[SelectorKey{Recv: recv, Name: sel.Sel.Name}]++
+			}
+			return true
+		})
+	}
+	return stats
+}
+
+// selectorRecv returns the Recv of the SelectorKey for sel, or "" if sel
+// does not select a method or field of a named type or a member of an
+// imported package.
+func selectorRecv(info *types.Info, sel *ast.SelectorExpr) string {
+	if s, ok := info.Selections[sel]; ok {
+		return NamedTypeKey(s.Recv())
+	}
+	if id, ok := sel.X.(*ast.Ident); ok {
+		if pkgName, ok := info.Uses[id].(*types.PkgName); ok {
+			return pkgName.Imported().Path()
+		}
+	}
+	return ""
+}
+
+// NamedTypeKey returns the qualified name of the named type T, or of the
+// type that *T points to, or "" if there is no such type.
+func NamedTypeKey(T types.Type) string {
+	if p, ok := T.(*types.Pointer); ok {
+		T = p.Elem()
+	}
+	named, ok := T.(*types.Named)
+	if !ok {
+		return ""
+	}
+	obj := named.Obj()
+	if obj.Pkg() == nil {
+		return obj.Name()
+	}
+	return obj.Pkg().Path() + "." + obj.Name()
+}
@@ -0,0 +1,83 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+)
+
+func TestCountSelectors(t *testing.T) {
+	snapshot, _ := newSnapshot(t, map[string]string{
+		"go.mod": "module example.com/m\n\ngo 1.16\n",
+		"a/a.go": `package a
+
+import "strings"
+
+type T struct{ F int }
+
+func (T) M() {}
+
+type U struct{ T }
+
+func f(t T, u *U, s struct{ G int }) {
+	t.M()
+	t.M()
+	_ = t.F
+	u.M()
+	_ = s.G
+	_ = strings.ToUpper("")
+	var x interface{ N() }
+	x.N()
+}
+`,
+	})
+	// SelectorStats does not type-check packages itself.
+	stats, err := snapshot.SelectorStats(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 0 {
+		t.Errorf("SelectorStats before type-checking = %v, want none", stats)
+	}
+	pkgs, err := snapshot.WorkspacePackages(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pkgs) != 1 {
+		t.Fatalf("got %d workspace packages, want 1", len(pkgs))
+	}
+	// Promoted methods count toward the type that promotes them, and the
+	// members of unnamed types are not counted.
+	want := source.SelectorStats{
+		{Recv: "example.com/m/a.T", Name: "M"}: 2,
+		{Recv: "example.com/m/a.T", Name: "F"}: 1,
+		{Recv: "example.com/m/a.U", Name: "M"}: 1,
+		{Recv: "strings", Name: "ToUpper"}:     1,
+	}
+	if got := source.CountSelectors(pkgs[0]); !reflect.DeepEqual(got, want) {
+		t.Errorf("CountSelectors = %v, want %v", got, want)
+	}
+
+	// The stats of the snapshot include the type-checked package.
+	stats, err = snapshot.SelectorStats(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("SelectorStats = %v, want %v", stats, want)
+	}
+	// They are not merged again while the same packages are type-checked.
+	again, err := snapshot.SelectorStats(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reflect.ValueOf(again).Pointer() != reflect.ValueOf(stats).Pointer() {
+		t.Errorf("SelectorStats merged the counts again")
+	}
+}
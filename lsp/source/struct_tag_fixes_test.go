@@ -0,0 +1,73 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source_test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+)
+
+func TestStructTagFixes(t *testing.T) {
+	ctx := context.Background()
+	snapshot, dir := newSnapshotWithOptions(t, testdataFiles(t, "structtags"), func(options *source.Options) {
+		options.StructTags = []string{"json", "yaml"}
+		options.StructTagOptions = map[string]string{"yaml": "omitempty"}
+	})
+	for _, test := range []struct {
+		name, selection string
+		cursor          bool // whether the range is empty, at the selection
+		titles          []string
+	}{
+		{
+			name:      "struct",
+			selection: "User struct",
+			cursor:    true,
+			titles:    []string{"Add json struct tags", "Add yaml struct tags", "Remove json struct tags", "Remove yaml struct tags"},
+		},
+		{
+			name:      "field",
+			selection: "ID        int",
+			titles:    []string{"Add json struct tags", "Add yaml struct tags"},
+		},
+		{
+			// Fields declared together and unexported fields are left alone.
+			name:      "unnamed fields",
+			selection: "A, B      int\n\tinternal  bool",
+		},
+		{
+			// Composite literals use the struct rather than declare it.
+			name:      "literal",
+			selection: "User{ID: 1}",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			uri, rng := rangeOf(t, dir, "a/a.go", test.selection)
+			if test.cursor {
+				rng.End = rng.Start
+			}
+			fh, err := snapshot.GetFile(ctx, uri)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fixes, err := source.StructTagFixes(ctx, snapshot, fh, rng)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var titles []string
+			for _, fix := range fixes {
+				titles = append(titles, fix.Title)
+				golden := "structtags/" + test.name + "_" + strings.ReplaceAll(strings.ToLower(fix.Title), " ", "_") + ".golden"
+				checkEditsGolden(t, dir, fix.Edits, golden)
+			}
+			if !reflect.DeepEqual(titles, test.titles) {
+				t.Errorf("fixes = %q, want %q", titles, test.titles)
+			}
+		})
+	}
+}
@@ -0,0 +1,396 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"github.com/kevinswiber/languageserver-go/event"
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/span"
+	errors "golang.org/x/xerrors"
+)
+
+// StructTagFixes returns suggested fixes that add or remove struct tags on
+// the fields of the struct type enclosing pRng. If pRng selects some of the
+// struct's fields, only those fields are changed; otherwise all of them are.
+//
+// For each of the tag keys configured in the StructTags option, a fix adds a
+// tag with that key to every exported, named field, or updates the name in
+// an existing one. For each of those keys already in use, another fix
+// removes the key from the fields' tags.
+func StructTagFixes(ctx context.Context, snapshot Snapshot, fh FileHandle, pRng protocol.Range) ([]SuggestedFix, error) {
+	ctx, done := event.Start(ctx, "source.StructTagFixes")
+	defer done()
+
+	pgf, err := snapshot.ParseGo(ctx, fh, ParseFull)
+	if err != nil {
+		return nil, errors.Errorf("getting file for StructTagFixes: %w", err)
+	}
+	rng, err := pgf.Mapper.RangeToSpanRange(pRng)
+	if err != nil {
+		return nil, err
+	}
+	path, _ := astutil.PathEnclosingInterval(pgf.File, rng.Start, rng.End)
+	var st *ast.StructType
+	for _, n := range path {
+		switch n := n.(type) {
+		case *ast.StructType:
+			st = n
+		case *ast.TypeSpec:
+			st, _ = n.Type.(*ast.StructType)
+		case *ast.BlockStmt, *ast.FuncType, *ast.CompositeLit:
+			// Don't offer tags from within code that uses the struct.
+			return nil, nil
+		default:
+			continue
+		}
+		break
+	}
+	if st == nil || st.Fields == nil || len(st.Fields.List) == 0 {
+		return nil, nil
+	}
+
+	var fields []*ast.Field
+	for _, f := range st.Fields.List {
+		if rng.Start < rng.End && (f.End() <= rng.Start || rng.End <= f.Pos()) {
+			continue
+		}
+		// Embedded fields and fields declared together share a tag, which
+		// cannot name each of them.
+		if len(f.Names) != 1 || !f.Names[0].IsExported() {
+			continue
+		}
+		fields = append(fields, f)
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	opts := snapshot.View().Options()
+	var fixes []SuggestedFix
+	add := func(title string, edit func(f *ast.Field, tag *structTag) bool) error {
+		edits, err := structTagEdits(ctx, snapshot, pgf, st, fields, edit)
+		if err != nil || len(edits) == 0 {
+			return err
+		}
+		fixes = append(fixes, SuggestedFix{
+			Title: title,
+			Edits: map[span.URI][]protocol.TextEdit{fh.URI(): edits},
+		})
+		return nil
+	}
+	for _, key := range opts.StructTags {
+		key := key
+		err := add(fmt.Sprintf("Add %s struct tags", key), func(f *ast.Field, tag *structTag) bool {
+			name := TransformTagName(f.Names[0].Name, opts.StructTagCase)
+			return tag.set(key, name, opts.StructTagOptions[key])
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, key := range usedTagKeys(fields) {
+		key := key
+		err := add(fmt.Sprintf("Remove %s struct tags", key), func(f *ast.Field, tag *structTag) bool {
+			return tag.remove(key)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return fixes, nil
+}
+
+// structTagEdits returns the edits that apply the given tag modification to
+// each of the fields of st. The resulting struct type is formatted, so that
+// the tags are aligned as gofmt would align them.
+func structTagEdits(ctx context.Context, snapshot Snapshot, pgf *ParsedGoFile, st *ast.StructType, fields []*ast.Field, modify func(*ast.Field, *structTag) bool) ([]protocol.TextEdit, error) {
+	tok := snapshot.FileSet().File(st.Pos())
+	if tok == nil {
+		return nil, errors.Errorf("no file for struct type")
+	}
+	type edit struct {
+		start, end int
+		text       string
+	}
+	var edits []edit
+	for _, f := range fields {
+		var tag structTag
+		if f.Tag != nil {
+			value, err := strconv.Unquote(f.Tag.Value)
+			if err != nil {
+				continue
+			}
+			var ok bool
+			if tag, ok = parseStructTag(value); !ok {
+				// Leave malformed tags alone rather than risk mangling them.
+				continue
+			}
+		}
+		if !modify(f, &tag) {
+			continue
+		}
+		switch {
+		case f.Tag == nil:
+			pos := tok.Offset(f.Type.End())
+			edits = append(edits, edit{pos, pos, " " + tag.literal()})
+		case len(tag) == 0:
+			edits = append(edits, edit{tok.Offset(f.Type.End()), tok.Offset(f.Tag.End()), ""})
+		default:
+			edits = append(edits, edit{tok.Offset(f.Tag.Pos()), tok.Offset(f.Tag.End()), tag.literal()})
+		}
+	}
+	if len(edits) == 0 {
+		return nil, nil
+	}
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+
+	var b strings.Builder
+	last := 0
+	for _, e := range edits {
+		b.Write(pgf.Src[last:e.start])
+		b.WriteString(e.text)
+		last = e.end
+	}
+	b.Write(pgf.Src[last:])
+
+	formatted, err := format.Source([]byte(b.String()))
+	if err != nil {
+		// The file cannot be formatted, perhaps because of syntax errors
+		// elsewhere, so apply the unformatted edits.
+		var result []protocol.TextEdit
+		for _, e := range edits {
+			rng, err := NewMappedRange(snapshot.FileSet(), pgf.Mapper, tok.Pos(e.start), tok.Pos(e.end)).Range()
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, protocol.TextEdit{Range: rng, NewText: e.text})
+		}
+		return result, nil
+	}
+	all, err := computeTextEdits(ctx, snapshot, pgf, string(formatted))
+	if err != nil {
+		return nil, err
+	}
+	// Formatting may also change unrelated parts of the file, so only keep
+	// the edits to the struct type itself.
+	startLine, endLine := uint32(tok.Line(st.Pos())-1), uint32(tok.Line(st.End())-1)
+	var result []protocol.TextEdit
+	for _, e := range all {
+		if line := e.Range.Start.Line; startLine <= line && line <= endLine {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+// usedTagKeys returns the sorted tag keys used by any of the fields.
+func usedTagKeys(fields []*ast.Field) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, f := range fields {
+		if f.Tag == nil {
+			continue
+		}
+		value, err := strconv.Unquote(f.Tag.Value)
+		if err != nil {
+			continue
+		}
+		tag, _ := parseStructTag(value)
+		for _, p := range tag {
+			if !seen[p.key] {
+				seen[p.key] = true
+				keys = append(keys, p.key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// A structTag is a struct tag in the conventional format described by
+// reflect.StructTag, as a list of key:"value" pairs in source order.
+type structTag []structTagPair
+
+type structTagPair struct {
+	key, value string
+}
+
+// parseStructTag parses a struct tag in the conventional format. It reports
+// whether the tag was well-formed.
+func parseStructTag(tag string) (structTag, bool) {
+	var result structTag
+	for {
+		tag = strings.TrimLeft(tag, " ")
+		if tag == "" {
+			return result, true
+		}
+		i := 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			return nil, false
+		}
+		key := tag[:i]
+		tag = tag[i+1:]
+
+		// Scan the quoted string to find the value.
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			return nil, false
+		}
+		value, err := strconv.Unquote(tag[:i+1])
+		if err != nil {
+			return nil, false
+		}
+		tag = tag[i+1:]
+		result = append(result, structTagPair{key, value})
+	}
+}
+
+// set sets the name of the tag's value for key, keeping any options already
+// present and adding the comma-separated options opts. A value of "-", which
+// excludes the field, is kept as is. set reports whether the tag changed.
+func (t *structTag) set(key, name, opts string) bool {
+	for i, p := range *t {
+		if p.key != key {
+			continue
+		}
+		if p.value == "-" {
+			return false
+		}
+		parts := strings.Split(p.value, ",")
+		parts[0] = name
+		for _, opt := range strings.Split(opts, ",") {
+			if opt != "" && !containsString(parts[1:], opt) {
+				parts = append(parts, opt)
+			}
+		}
+		value := strings.Join(parts, ",")
+		(*t)[i].value = value
+		return value != p.value
+	}
+	value := name
+	if opts != "" {
+		value += "," + opts
+	}
+	*t = append(*t, structTagPair{key, value})
+	return true
+}
+
+// remove removes key from the tag, reporting whether it was present.
+func (t *structTag) remove(key string) bool {
+	for i, p := range *t {
+		if p.key == key {
+			*t = append((*t)[:i], (*t)[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// literal returns the tag as a Go string literal, preferring a raw string.
+func (t structTag) literal() string {
+	var parts []string
+	for _, p := range t {
+		parts = append(parts, p.key+":"+strconv.Quote(p.value))
+	}
+	s := strings.Join(parts, " ")
+	if strings.Contains(s, "`") {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
+
+func containsString(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// TransformTagName returns the name used in a struct tag for the field with
+// the given name, according to the case c.
+func TransformTagName(name string, c StructTagCase) string {
+	words := splitWords(name)
+	if len(words) == 0 {
+		return name
+	}
+	switch c {
+	case CamelCase:
+		words[0] = strings.ToLower(words[0])
+		for i := 1; i < len(words); i++ {
+			words[i] = upperFirst(words[i])
+		}
+		return strings.Join(words, "")
+	case PascalCase:
+		for i := range words {
+			words[i] = upperFirst(words[i])
+		}
+		return strings.Join(words, "")
+	case SnakeCase:
+		return strings.ToLower(strings.Join(words, "_"))
+	case KebabCase:
+		return strings.ToLower(strings.Join(words, "-"))
+	default:
+		return name
+	}
+}
+
+// splitWords splits an identifier into its words, at underscores and at the
+// changes of case that begin a word. A run of upper case letters, such as
+// an initialism, forms a single word: "HTTPServerID" is split into "HTTP",
+// "Server", and "ID".
+func splitWords(name string) []string {
+	var words []string
+	runes := []rune(name)
+	start := 0
+	for i, r := range runes {
+		switch {
+		case r == '_':
+			if i > start {
+				words = append(words, string(runes[start:i]))
+			}
+			start = i + 1
+		case i > start && unicode.IsUpper(r):
+			prev := runes[i-1]
+			if !unicode.IsUpper(prev) || (i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+		}
+	}
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
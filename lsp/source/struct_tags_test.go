@@ -0,0 +1,56 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import "testing"
+
+func TestTransformTagName(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		c    StructTagCase
+		want string
+	}{
+		{"FirstName", CamelCase, "firstName"},
+		{"FirstName", PascalCase, "FirstName"},
+		{"FirstName", SnakeCase, "first_name"},
+		{"FirstName", KebabCase, "first-name"},
+		{"FirstName", KeepCase, "FirstName"},
+		{"HTTPServerID", CamelCase, "httpServerID"},
+		{"HTTPServerID", SnakeCase, "http_server_id"},
+		{"Field2Name", SnakeCase, "field2_name"},
+		{"already_snake", CamelCase, "alreadySnake"},
+		{"ID", CamelCase, "id"},
+	} {
+		if got := TransformTagName(tt.name, tt.c); got != tt.want {
+			t.Errorf("TransformTagName(%q, %s) = %q, want %q", tt.name, tt.c, got, tt.want)
+		}
+	}
+}
+
+func TestStructTag(t *testing.T) {
+	tag, ok := parseStructTag(`json:"name,omitempty" yaml:"a\"b"`)
+	if !ok {
+		t.Fatal("parseStructTag failed on a well-formed tag")
+	}
+	if !tag.set("json", "other", "omitempty,string") {
+		t.Error("set reported no change")
+	}
+	if tag.set("json", "other", "omitempty") {
+		t.Error("set reported a change for an identical value")
+	}
+	if !tag.remove("yaml") {
+		t.Error("remove did not find key yaml")
+	}
+	tag.set("db", "other", "")
+	if got, want := tag.literal(), "`json:\"other,omitempty,string\" db:\"other\"`"; got != want {
+		t.Errorf("literal() = %s, want %s", got, want)
+	}
+
+	for _, bad := range []string{`json`, `json:name`, `json:"name`, `:"x"`} {
+		if _, ok := parseStructTag(bad); ok {
+			t.Errorf("parseStructTag(%q) succeeded, want failure", bad)
+		}
+	}
+}
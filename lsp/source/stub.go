@@ -0,0 +1,98 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"context"
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"github.com/kevinswiber/languageserver-go/event"
+	"github.com/kevinswiber/languageserver-go/lsp/analysis/stubmethods"
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/span"
+	errors "golang.org/x/xerrors"
+)
+
+// StubMethodsFixes returns a suggested fix for each interface that the type
+// declared at pRng implements only partially. Each fix adds stubs for the
+// methods that the type is missing.
+func StubMethodsFixes(ctx context.Context, snapshot Snapshot, fh FileHandle, pRng protocol.Range) ([]SuggestedFix, error) {
+	ctx, done := event.Start(ctx, "source.StubMethodsFixes")
+	defer done()
+
+	pkg, pgf, err := GetParsedFile(ctx, snapshot, fh, NarrowestPackage)
+	if err != nil {
+		return nil, errors.Errorf("getting file for StubMethodsFixes: %w", err)
+	}
+	rng, err := pgf.Mapper.RangeToSpanRange(pRng)
+	if err != nil {
+		return nil, err
+	}
+	path, _ := astutil.PathEnclosingInterval(pgf.File, rng.Start, rng.End)
+	var spec *ast.TypeSpec
+	for _, n := range path {
+		switch n := n.(type) {
+		case *ast.TypeSpec:
+			spec = n
+		case *ast.Field, *ast.BlockStmt:
+			// Don't offer stubs from within a field list or function body.
+			return nil, nil
+		default:
+			continue
+		}
+		break
+	}
+	if spec == nil {
+		return nil, nil
+	}
+	obj, ok := pkg.GetTypesInfo().Defs[spec.Name].(*types.TypeName)
+	if !ok {
+		return nil, nil
+	}
+	named, ok := obj.Type().(*types.Named)
+	if !ok {
+		return nil, nil
+	}
+	// Use a pointer receiver if any existing method does.
+	var pointer bool
+	for i := 0; i < named.NumMethods(); i++ {
+		if sig, ok := named.Method(i).Type().(*types.Signature); ok && sig.Recv() != nil {
+			if _, ok := sig.Recv().Type().(*types.Pointer); ok {
+				pointer = true
+				break
+			}
+		}
+	}
+
+	var fixes []SuggestedFix
+	for _, iface := range stubmethods.PartialInterfaces(obj, pkg.GetTypes()) {
+		si := stubmethods.NewStubInfo(obj, iface.Type(), pointer, pkg.GetTypes())
+		if si == nil {
+			continue
+		}
+		fix, err := stubmethods.StubMethods(snapshot.FileSet(), pgf.File, si)
+		if err != nil {
+			return nil, err
+		}
+		var edits []protocol.TextEdit
+		for _, e := range fix.TextEdits {
+			rng, err := NewMappedRange(snapshot.FileSet(), pgf.Mapper, e.Pos, e.End).Range()
+			if err != nil {
+				return nil, err
+			}
+			edits = append(edits, protocol.TextEdit{
+				Range:   rng,
+				NewText: string(e.NewText),
+			})
+		}
+		fixes = append(fixes, SuggestedFix{
+			Title: fix.Message,
+			Edits: map[span.URI][]protocol.TextEdit{fh.URI(): edits},
+		})
+	}
+	return fixes, nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+	"github.com/kevinswiber/languageserver-go/span"
+)
+
+func TestStubMethodsFixes(t *testing.T) {
+	ctx := context.Background()
+	snapshot, dir := newSnapshot(t, testdataFiles(t, "stubmethods"))
+	uri, rng := rangeOf(t, dir, "a/a.go", "T struct")
+	fh, err := snapshot.GetFile(ctx, uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixes, err := source.StubMethodsFixes(ctx, snapshot, fh, rng)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The interfaces of the package come before those of its imports.
+	var titles []string
+	for _, fix := range fixes {
+		titles = append(titles, fix.Title)
+	}
+	if len(fixes) != 2 || titles[0] != "Implement getter" || titles[1] != "Implement iface.Store" {
+		t.Fatalf("StubMethodsFixes = %q, want fixes for getter and iface.Store", titles)
+	}
+	checkEditsGolden(t, dir, fixes[0].Edits, "stubmethods/getter.golden")
+	checkEditsGolden(t, dir, fixes[1].Edits, "stubmethods/store.golden")
+}
+
+func TestStubMethodsFix(t *testing.T) {
+	// The fix of the type error makes the same edits as the code action
+	// for the interface.
+	ctx := context.Background()
+	snapshot, dir := newSnapshot(t, testdataFiles(t, "stubmethods"))
+	uri, rng := rangeOf(t, dir, "a/a.go", "(*T)(nil)")
+	fh, err := snapshot.GetVersionedFile(ctx, uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	docEdits, err := source.ApplyFix(ctx, source.StubMethods, snapshot, fh, rng)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edits := make(map[span.URI][]protocol.TextEdit)
+	for _, e := range docEdits {
+		uri := e.TextDocument.URI.SpanURI()
+		edits[uri] = append(edits[uri], e.Edits...)
+	}
+	checkEditsGolden(t, dir, edits, "stubmethods/store.golden")
+}
@@ -0,0 +1,112 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// A SumFile is the parsed form of a go.sum file.
+type SumFile struct {
+	Lines []*SumLine
+}
+
+// A SumLine is a well-formed line of a go.sum file, which records the hash
+// of a module's file tree or of its go.mod file.
+type SumLine struct {
+	Mod module.Version
+
+	// GoMod reports whether the line is the hash of the module's go.mod
+	// file, whose version has a "/go.mod" suffix.
+	GoMod bool
+	Hash  string
+
+	// Start and End are the positions of the line, excluding its newline.
+	Start, End modfile.Position
+}
+
+// String returns the module and version of the line, as written in the
+// file.
+func (l *SumLine) String() string {
+	if l.GoMod {
+		return l.Mod.Path + " " + l.Mod.Version + "/go.mod"
+	}
+	return l.Mod.Path + " " + l.Mod.Version
+}
+
+// ParseSumFile parses the contents of a go.sum file. Unlike go.mod files,
+// a malformed line does not prevent the use of the others, so the file is
+// always returned, along with a modfile.ErrorList of the malformed lines,
+// if any.
+func ParseSumFile(filename string, data []byte) (*SumFile, error) {
+	f := &SumFile{}
+	var errs modfile.ErrorList
+	pos := modfile.Position{Line: 1, LineRune: 1}
+	for len(data) > 0 {
+		text := data
+		next := len(data)
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			text, next = data[:i], i+1
+		}
+		end := pos
+		end.LineRune += len([]rune(string(text)))
+		end.Byte += len(text)
+
+		if fields := strings.Fields(string(text)); len(fields) > 0 {
+			line, err := parseSumLine(fields)
+			if err != nil {
+				errs = append(errs, modfile.Error{
+					Filename: filename,
+					Pos:      pos,
+					Err:      err,
+				})
+			} else {
+				line.Start, line.End = pos, end
+				f.Lines = append(f.Lines, line)
+			}
+		}
+		data = data[next:]
+		pos = modfile.Position{Line: pos.Line + 1, LineRune: 1, Byte: pos.Byte + next}
+	}
+	if len(errs) > 0 {
+		return f, errs
+	}
+	return f, nil
+}
+
+func parseSumLine(fields []string) (*SumLine, error) {
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("malformed go.sum line: want module, version, and hash, got %d fields", len(fields))
+	}
+	line := &SumLine{Hash: fields[2]}
+	line.Mod.Path = fields[0]
+	line.Mod.Version = fields[1]
+	if strings.HasSuffix(line.Mod.Version, "/go.mod") {
+		line.Mod.Version = strings.TrimSuffix(line.Mod.Version, "/go.mod")
+		line.GoMod = true
+	}
+	if err := module.CheckPathMajor(line.Mod.Version, pathMajor(line.Mod.Path)); err != nil || !semver.IsValid(line.Mod.Version) {
+		return nil, fmt.Errorf("malformed go.sum line: invalid version %q", fields[1])
+	}
+	if i := strings.Index(line.Hash, ":"); i <= 0 || i == len(line.Hash)-1 {
+		return nil, fmt.Errorf("malformed go.sum line: invalid hash %q", line.Hash)
+	}
+	return line, nil
+}
+
+// pathMajor returns the major version suffix of the module path, if any.
+func pathMajor(path string) string {
+	_, major, ok := module.SplitPathVersion(path)
+	if !ok {
+		return ""
+	}
+	return major
+}
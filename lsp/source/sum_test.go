@@ -0,0 +1,56 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/mod/modfile"
+)
+
+func TestParseSumFile(t *testing.T) {
+	content := `golang.org/x/mod v0.4.2 h1:Gz96sIWK3OalVv/I/qNygP42zyoKp3xptRVCWRFEBvo=
+golang.org/x/mod v0.4.2/go.mod h1:s0Qsj1ACt9ePp/hMypM3fl4fZqREWJwdYDEqhRiZZUA=
+
+example.com/bad v1.0.0
+example.com/bad notaversion h1:abc=
+example.com/v2 v1.0.0 h1:abc=
+example.com/nohash v1.0.0 abc
+gopkg.in/yaml.v2 v2.4.0/go.mod h1:RDklbk79AGWmwhnvt/jBztapEOGDOx6ZbXqjP6csGnQ=`
+	f, err := ParseSumFile("go.sum", []byte(content))
+	var got []string
+	for _, line := range f.Lines {
+		got = append(got, line.String())
+	}
+	want := []string{
+		"golang.org/x/mod v0.4.2",
+		"golang.org/x/mod v0.4.2/go.mod",
+		"gopkg.in/yaml.v2 v2.4.0/go.mod",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseSumFile lines = %q, want %q", got, want)
+	}
+	second := strings.Index(content, "\n") + 1
+	if l := f.Lines[1]; l.Start.Line != 2 || l.Start.Byte != second || l.End.Byte != strings.Index(content[second:], "\n")+second {
+		t.Errorf("ParseSumFile line 2 spans %v-%v", l.Start, l.End)
+	}
+
+	errs, ok := err.(modfile.ErrorList)
+	if !ok {
+		t.Fatalf("ParseSumFile error = %v, want a modfile.ErrorList", err)
+	}
+	var gotLines []int
+	for _, e := range errs {
+		if !strings.HasPrefix(e.Err.Error(), "malformed go.sum line") {
+			t.Errorf("unexpected error %v", e.Err)
+		}
+		gotLines = append(gotLines, e.Pos.Line)
+	}
+	if wantLines := []int{4, 5, 6, 7}; !reflect.DeepEqual(gotLines, wantLines) {
+		t.Errorf("ParseSumFile errors on lines %v, want %v", gotLines, wantLines)
+	}
+}
@@ -0,0 +1,432 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+	"github.com/kevinswiber/languageserver-go/analysisinternal"
+	"github.com/kevinswiber/languageserver-go/event"
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/span"
+	errors "golang.org/x/xerrors"
+)
+
+// TestableFunc returns the function or method declaration at pRng, if a test
+// can be generated for it, along with its name as used in test messages,
+// such as "Foo" or "T.Foo".
+func TestableFunc(ctx context.Context, snapshot Snapshot, fh FileHandle, pRng protocol.Range) (*ast.FuncDecl, string, error) {
+	if strings.HasSuffix(fh.URI().Filename(), "_test.go") {
+		return nil, "", nil
+	}
+	pgf, err := snapshot.ParseGo(ctx, fh, ParseFull)
+	if err != nil {
+		return nil, "", errors.Errorf("getting file for TestableFunc: %w", err)
+	}
+	rng, err := pgf.Mapper.RangeToSpanRange(pRng)
+	if err != nil {
+		return nil, "", err
+	}
+	path, _ := astutil.PathEnclosingInterval(pgf.File, rng.Start, rng.End)
+	var decl *ast.FuncDecl
+	for _, n := range path {
+		if d, ok := n.(*ast.FuncDecl); ok {
+			decl = d
+			break
+		}
+	}
+	// Only offer a test from the function's signature, not its body.
+	if decl == nil || (decl.Body != nil && rng.Start >= decl.Body.Lbrace) {
+		return nil, "", nil
+	}
+	switch decl.Name.Name {
+	case "_", "init", "main":
+		if decl.Recv == nil {
+			return nil, "", nil
+		}
+	}
+	name := decl.Name.Name
+	if decl.Recv != nil {
+		recv := receiverTypeName(decl.Recv)
+		if recv == "" {
+			return nil, "", nil
+		}
+		name = recv + "." + name
+	}
+	return decl, name, nil
+}
+
+// receiverTypeName returns the name of the receiver's base type, or "" if
+// it cannot be determined.
+func receiverTypeName(recv *ast.FieldList) string {
+	if len(recv.List) != 1 {
+		return ""
+	}
+	typ := recv.List[0].Type
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ = star.X
+	}
+	if id, ok := typ.(*ast.Ident); ok {
+		return id.Name
+	}
+	return ""
+}
+
+// AddTest returns the change that adds a table-driven test skeleton for the
+// function or method declared at pRng to the _test.go file corresponding to
+// fh. The test has a test case struct with a field for each parameter and
+// result, runs each case as a subtest, and compares the results.
+//
+// The test is written in the package of an existing test file. For a new
+// test file, the package's existing tests determine whether it belongs to
+// the package itself or to the external test package.
+func AddTest(ctx context.Context, snapshot Snapshot, fh FileHandle, pRng protocol.Range) (*FileChange, error) {
+	ctx, done := event.Start(ctx, "source.AddTest")
+	defer done()
+
+	decl, _, err := TestableFunc(ctx, snapshot, fh, pRng)
+	if err != nil {
+		return nil, err
+	}
+	if decl == nil {
+		return nil, fmt.Errorf("no function or method found")
+	}
+	pkg, pgf, err := GetParsedFile(ctx, snapshot, fh, NarrowestPackage)
+	if err != nil {
+		return nil, errors.Errorf("getting file for AddTest: %w", err)
+	}
+	fn, ok := pkg.GetTypesInfo().Defs[decl.Name].(*types.Func)
+	if !ok {
+		return nil, fmt.Errorf("no type information for %s", decl.Name.Name)
+	}
+
+	filename := strings.TrimSuffix(pgf.URI.Filename(), ".go") + "_test.go"
+	testURI := span.URIFromPath(filename)
+	testFH, err := snapshot.GetFile(ctx, testURI)
+	if err != nil {
+		return nil, err
+	}
+	var testPGF *ParsedGoFile
+	if _, err := testFH.Read(); err == nil {
+		if testPGF, err = snapshot.ParseGo(ctx, testFH, ParseFull); err != nil {
+			return nil, err
+		}
+		if testPGF.File.Name == nil || testPGF.File.Name.Name == "" {
+			return nil, fmt.Errorf("%s has no package clause", filepath.Base(filename))
+		}
+	}
+
+	// An unexported function can only be tested from the package itself.
+	sig := fn.Type().(*types.Signature)
+	testable := fn.Exported() && (sig.Recv() == nil || isExportedRecv(fn))
+	var external bool
+	if testPGF != nil {
+		external = testPGF.File.Name.Name != pkg.Name()
+		if external && !testable {
+			return nil, fmt.Errorf("cannot test unexported %s from external test package %s", fn.Name(), testPGF.File.Name.Name)
+		}
+	} else if testable {
+		if external, err = usesExternalTests(ctx, snapshot, pkg); err != nil {
+			return nil, err
+		}
+	}
+
+	testName := testFuncName(fn)
+	if testPGF != nil && testPGF.File.Scope.Lookup(testName) != nil {
+		return nil, fmt.Errorf("%s already exists in %s", testName, filepath.Base(filename))
+	}
+
+	if testPGF == nil {
+		// Build the new file from its package clause, so that the imports
+		// can be added in the same way as to an existing file.
+		name := pkg.Name()
+		if external {
+			name += "_test"
+		}
+		src := []byte(fmt.Sprintf("package %s\n", name))
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, filename, src, parser.ImportsOnly)
+		if err != nil {
+			return nil, err
+		}
+		adder := newTestImportAdder(file, pkg, external)
+		test := writeTableTest(fn, testName, adder)
+		content := applyTextEdits(fset.File(file.Pos()), src, adder.Edits())
+		content = append(content, "\n"+test...)
+		if formatted, err := format.Source(content); err == nil {
+			content = formatted
+		}
+		return &FileChange{URI: testURI, Content: content}, nil
+	}
+
+	adder := newTestImportAdder(testPGF.File, pkg, external)
+	test := writeTableTest(fn, testName, adder)
+	if formatted, err := format.Source([]byte(test)); err == nil {
+		test = string(formatted)
+	}
+	end := testPGF.Tok.Pos(testPGF.Tok.Size())
+	prefix := "\n"
+	if !bytes.HasSuffix(testPGF.Src, []byte("\n")) {
+		prefix = "\n\n"
+	}
+	var edits []protocol.TextEdit
+	for _, e := range append(adder.Edits(), analysis.TextEdit{Pos: end, End: end, NewText: []byte(prefix + test)}) {
+		rng, err := NewMappedRange(snapshot.FileSet(), testPGF.Mapper, e.Pos, e.End).Range()
+		if err != nil {
+			return nil, err
+		}
+		edits = append(edits, protocol.TextEdit{Range: rng, NewText: string(e.NewText)})
+	}
+	return &FileChange{URI: testURI, Edits: edits}, nil
+}
+
+// usesExternalTests reports whether the tests of pkg are written in an
+// external test package, that is, whether the package has external test
+// files and no internal ones.
+func usesExternalTests(ctx context.Context, snapshot Snapshot, pkg Package) (bool, error) {
+	pkgs, err := snapshot.WorkspacePackages(ctx)
+	if err != nil {
+		return false, err
+	}
+	var internal, external bool
+	for _, p := range pkgs {
+		var isExternal bool
+		switch p.PkgPath() {
+		case pkg.PkgPath():
+		case pkg.PkgPath() + "_test":
+			isExternal = true
+		default:
+			continue
+		}
+		for _, pgf := range p.CompiledGoFiles() {
+			if !strings.HasSuffix(pgf.URI.Filename(), "_test.go") {
+				continue
+			}
+			if isExternal {
+				external = true
+			} else {
+				internal = true
+			}
+		}
+	}
+	return external && !internal, nil
+}
+
+func isExportedRecv(fn *types.Func) bool {
+	recv := fn.Type().(*types.Signature).Recv()
+	if named, ok := Deref(recv.Type()).(*types.Named); ok {
+		return named.Obj().Exported()
+	}
+	return false
+}
+
+// newTestImportAdder returns an ImportAdder for the test file, which refers
+// to the package under test through an import if it is an external test.
+func newTestImportAdder(file *ast.File, pkg Package, external bool) *analysisinternal.ImportAdder {
+	if external {
+		return analysisinternal.NewImportAdder(file, nil)
+	}
+	return analysisinternal.NewImportAdder(file, pkg.GetTypes())
+}
+
+// testFuncName returns the name of the test for fn, following the
+// conventions of the go vet tests check: TestFoo, TestT_Foo, or Test_foo.
+func testFuncName(fn *types.Func) string {
+	name := fn.Name()
+	if recv := fn.Type().(*types.Signature).Recv(); recv != nil {
+		if named, ok := Deref(recv.Type()).(*types.Named); ok {
+			name = named.Obj().Name() + "_" + name
+		}
+	}
+	if r, _ := utf8.DecodeRuneInString(name); !unicode.IsUpper(r) {
+		return "Test_" + name
+	}
+	return "Test" + name
+}
+
+// isResultName reports whether name is one of the names of the got and
+// wanted results of a generated test: got, got1, want, want1, and so on.
+func isResultName(name string) bool {
+	for _, prefix := range []string{"got", "want"} {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		suffix := name[len(prefix):]
+		if strings.TrimLeft(suffix, "0123456789") == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// tableTestField is a field of the generated test case struct.
+type tableTestField struct {
+	name string
+	typ  string
+}
+
+// writeTableTest returns the source of a table-driven test named testName
+// for fn, qualifying types with the adder.
+func writeTableTest(fn *types.Func, testName string, adder *analysisinternal.ImportAdder) string {
+	sig := fn.Type().(*types.Signature)
+	qf := adder.Qualifier
+
+	used := map[string]bool{"name": true, "tt": true, "t": true, "tests": true, "err": true, "wantErr": true}
+	unique := func(name string) string {
+		for used[name] || isResultName(name) {
+			name += "Arg"
+		}
+		used[name] = true
+		return name
+	}
+
+	fields := []tableTestField{{"name", "string"}}
+	var callee string
+	if recv := sig.Recv(); recv != nil {
+		name := recv.Name()
+		if name == "" || name == "_" {
+			name = "recv"
+		}
+		name = unique(name)
+		fields = append(fields, tableTestField{name, types.TypeString(recv.Type(), qf)})
+		callee = "tt." + name + "." + fn.Name()
+	} else {
+		callee = fn.Name()
+		if q := qf(fn.Pkg()); q != "" {
+			callee = q + "." + callee
+		}
+	}
+
+	var args []string
+	for i := 0; i < sig.Params().Len(); i++ {
+		p := sig.Params().At(i)
+		name := p.Name()
+		if name == "" || name == "_" {
+			name = fmt.Sprintf("arg%d", i)
+		}
+		name = unique(name)
+		fields = append(fields, tableTestField{name, types.TypeString(p.Type(), qf)})
+		arg := "tt." + name
+		if sig.Variadic() && i == sig.Params().Len()-1 {
+			arg += "..."
+		}
+		args = append(args, arg)
+	}
+
+	// Results are compared with their wanted values, except for a final
+	// error result, whose presence is compared instead.
+	results := sig.Results()
+	n := results.Len()
+	hasErr := n > 0 && isErrorType(results.At(n-1).Type())
+	if hasErr {
+		n--
+	}
+	var gots, wants []string
+	var deepEqual bool
+	for i := 0; i < n; i++ {
+		suffix := ""
+		if i > 0 {
+			suffix = fmt.Sprint(i)
+		}
+		gots = append(gots, "got"+suffix)
+		wants = append(wants, "want"+suffix)
+		fields = append(fields, tableTestField{"want" + suffix, types.TypeString(results.At(i).Type(), qf)})
+		if !isComparableBasic(results.At(i).Type()) {
+			deepEqual = true
+		}
+	}
+	if hasErr {
+		fields = append(fields, tableTestField{"wantErr", "bool"})
+	}
+
+	desc := fn.Name()
+	if recv := sig.Recv(); recv != nil {
+		if named, ok := Deref(recv.Type()).(*types.Named); ok {
+			desc = named.Obj().Name() + "." + desc
+		}
+	}
+	testing := adder.Qualifier(types.NewPackage("testing", "testing"))
+	var reflect string
+	if deepEqual {
+		reflect = adder.Qualifier(types.NewPackage("reflect", "reflect"))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "func %s(t *%s.T) {\n", testName, testing)
+	b.WriteString("tests := []struct {\n")
+	for _, f := range fields {
+		fmt.Fprintf(&b, "%s %s\n", f.name, f.typ)
+	}
+	b.WriteString("}{\n// TODO: Add test cases.\n}\n")
+	fmt.Fprintf(&b, "for _, tt := range tests {\nt.Run(tt.name, func(t *%s.T) {\n", testing)
+
+	lhs := append([]string(nil), gots...)
+	if hasErr {
+		lhs = append(lhs, "err")
+	}
+	call := fmt.Sprintf("%s(%s)", callee, strings.Join(args, ", "))
+	if len(lhs) > 0 {
+		fmt.Fprintf(&b, "%s := %s\n", strings.Join(lhs, ", "), call)
+	} else {
+		b.WriteString(call + "\n")
+	}
+	if hasErr {
+		b.WriteString("if (err != nil) != tt.wantErr {\n")
+		fmt.Fprintf(&b, "t.Fatalf(\"%s() error = %%v, wantErr %%v\", err, tt.wantErr)\n}\n", desc)
+	}
+	for i, got := range gots {
+		if isComparableBasic(results.At(i).Type()) {
+			fmt.Fprintf(&b, "if %s != tt.%s {\n", got, wants[i])
+		} else {
+			fmt.Fprintf(&b, "if !%s.DeepEqual(%s, tt.%s) {\n", reflect, got, wants[i])
+		}
+		label := ""
+		if len(gots) > 1 {
+			label = " " + got
+		}
+		fmt.Fprintf(&b, "t.Errorf(\"%s()%s = %%v, want %%v\", %s, tt.%s)\n}\n", desc, label, got, wants[i])
+	}
+	b.WriteString("})\n}\n}\n")
+	return b.String()
+}
+
+func isErrorType(T types.Type) bool {
+	return types.Identical(T, types.Universe.Lookup("error").Type())
+}
+
+// isComparableBasic reports whether values of type T can be compared with
+// != for the purpose of a test.
+func isComparableBasic(T types.Type) bool {
+	_, ok := T.Underlying().(*types.Basic)
+	return ok
+}
+
+// applyTextEdits applies the edits, whose positions are within tok, to src.
+func applyTextEdits(tok *token.File, src []byte, edits []analysis.TextEdit) []byte {
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].Pos < edits[j].Pos })
+	var result []byte
+	last := 0
+	for _, e := range edits {
+		start, end := tok.Offset(e.Pos), tok.Offset(e.End)
+		result = append(result, src[last:start]...)
+		result = append(result, e.NewText...)
+		last = end
+	}
+	return append(result, src[last:]...)
+}
@@ -0,0 +1,125 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"go/format"
+	"go/types"
+	"testing"
+
+	"github.com/kevinswiber/languageserver-go/analysisinternal"
+)
+
+func TestWriteTableTest(t *testing.T) {
+	const src = `package a
+
+type T struct{}
+
+func (t *T) Read(p []byte) (n int, err error) { return 0, nil }
+
+func Split(s, sep string) ([]string, int) { return nil, 0 }
+
+func lower(name string) {}
+
+func Compare(got, want1 int, wanted string) bool { return false }
+`
+	files, pkg := typeCheck(t, src)
+	T := pkg.Scope().Lookup("T").Type().(*types.Named)
+
+	for _, tt := range []struct {
+		fn   *types.Func
+		want string
+	}{
+		{T.Method(0), `func TestT_Read(t *testing.T) {
+	tests := []struct {
+		name    string
+		tArg    *T
+		p       []byte
+		want    int
+		wantErr bool
+	}{
+		// TODO: Add test cases.
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.tArg.Read(tt.p)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("T.Read() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("T.Read() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+`},
+		{pkg.Scope().Lookup("Split").(*types.Func), `func TestSplit(t *testing.T) {
+	tests := []struct {
+		name  string
+		s     string
+		sep   string
+		want  []string
+		want1 int
+	}{
+		// TODO: Add test cases.
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, got1 := Split(tt.s, tt.sep)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Split() got = %v, want %v", got, tt.want)
+			}
+			if got1 != tt.want1 {
+				t.Errorf("Split() got1 = %v, want %v", got1, tt.want1)
+			}
+		})
+	}
+}
+`},
+		{pkg.Scope().Lookup("lower").(*types.Func), `func Test_lower(t *testing.T) {
+	tests := []struct {
+		name    string
+		nameArg string
+	}{
+		// TODO: Add test cases.
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lower(tt.nameArg)
+		})
+	}
+}
+`},
+		{pkg.Scope().Lookup("Compare").(*types.Func), `func TestCompare(t *testing.T) {
+	tests := []struct {
+		name     string
+		gotArg   int
+		want1Arg int
+		wanted   string
+		want     bool
+	}{
+		// TODO: Add test cases.
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Compare(tt.gotArg, tt.want1Arg, tt.wanted)
+			if got != tt.want {
+				t.Errorf("Compare() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+`},
+	} {
+		adder := analysisinternal.NewImportAdder(files[0], pkg)
+		got, err := format.Source([]byte(writeTableTest(tt.fn, testFuncName(tt.fn), adder)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("test for %s:\n%s\nwant:\n%s", tt.fn.Name(), got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package a
+
+type T struct{}
+
+// Close closes t.
+func (t *T) Close() error { return nil }
+
+func (t *T) Read(p []byte) (int, error) { return 0, nil }
+
+func closeBoth(t, u *T) error {
+	t.Close()
+	return u.Close()
+}
+
+// The parameters of keep and read are used other than by calling Close.
+func keep(t *T) *T { return t }
+
+func read(t *T) { t.Read(nil) }
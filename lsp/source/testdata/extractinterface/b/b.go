@@ -0,0 +1,5 @@
+package b
+
+import "example.com/extract/a"
+
+func Close(t *a.T) error { return t.Close() }
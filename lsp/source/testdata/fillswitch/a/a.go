@@ -0,0 +1,37 @@
+package a
+
+type Color int
+
+const (
+	Red Color = iota
+	Green
+	Blue
+)
+
+func name(c Color) string {
+	switch c {
+	case Red:
+		return "red"
+	}
+	return ""
+}
+
+type Shape interface {
+	Area() float64
+}
+
+type square struct{ side float64 }
+
+func (s square) Area() float64 { return s.side * s.side }
+
+type rect struct{ w, h float64 }
+
+func (r *rect) Area() float64 { return r.w * r.h }
+
+func area(s Shape) float64 {
+	switch s := s.(type) {
+	case square:
+		return s.Area()
+	}
+	return 0
+}
@@ -0,0 +1,10 @@
+package b
+
+import "example.com/fill/a"
+
+// Circle implements a.Shape, but a cannot refer to it, as b imports a.
+type Circle struct{ R float64 }
+
+func (c Circle) Area() float64 { return 3 * c.R * c.R }
+
+var _ a.Shape = Circle{}
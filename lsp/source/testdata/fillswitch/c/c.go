@@ -0,0 +1,9 @@
+package c
+
+type Triangle struct{ Base, Height float64 }
+
+func (t Triangle) Area() float64 { return t.Base * t.Height / 2 }
+
+type unexported struct{}
+
+func (unexported) Area() float64 { return 0 }
@@ -0,0 +1,14 @@
+package a
+
+type User struct {
+	ID        int
+	FirstName string `json:"first"`
+	Email     string `json:"email,omitempty" yaml:"email"`
+	Admin     bool   `json:"-"`
+	A, B      int
+	internal  bool
+}
+
+func f() {
+	_ = User{ID: 1}
+}
@@ -0,0 +1,23 @@
+package a
+
+import (
+	"context"
+
+	"example.com/stub/iface"
+)
+
+// bufio is not the bufio package.
+var bufio = 1
+
+type getter interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Len() int
+}
+
+type T struct{}
+
+func (t *T) Get(ctx context.Context, key string) ([]byte, error) { return nil, nil }
+
+var _ iface.Store = (*T)(nil)
+
+func use(getter) {}
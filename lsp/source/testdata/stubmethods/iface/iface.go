@@ -0,0 +1,16 @@
+package iface
+
+import (
+	"bufio"
+	"context"
+	"io"
+)
+
+// The stubs of Store name the unnamed parameters without colliding with
+// the results, and rename the parameters that shadow the receiver.
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Put(context.Context, []byte, t []byte) (b []byte, err error)
+	Copy(w io.Writer, t, t_ io.Reader) (n int64, err error)
+	Flush(*bufio.Writer) error
+}
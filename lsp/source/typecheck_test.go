@@ -0,0 +1,37 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+)
+
+// typeCheck parses the sources as the files of package a, in order, and
+// type-checks them, resolving imports from the standard library.
+func typeCheck(t *testing.T, srcs ...string) ([]*ast.File, *types.Package) {
+	t.Helper()
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for i, src := range srcs {
+		f, err := parser.ParseFile(fset, fmt.Sprintf("a%d.go", i), src, 0)
+		if err != nil {
+			t.Fatalf("%v\n%s", err, src)
+		}
+		files = append(files, f)
+	}
+	conf := types.Config{Importer: importer.Default()}
+	pkg, err := conf.Check("a", fset, files, nil)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, strings.Join(srcs, "\n"))
+	}
+	return files, pkg
+}
@@ -0,0 +1,379 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/span"
+)
+
+// An UnusedIdentifier is an exported identifier of a workspace package that
+// no workspace package refers to.
+type UnusedIdentifier struct {
+	// Name is the name of the identifier qualified by its package, and by
+	// its type for methods and fields, as in "pkg.T.M".
+	Name string
+
+	// Kind is one of "func", "type", "var", "const", "method", and "field".
+	Kind string
+
+	URI   span.URI
+	Range protocol.Range
+}
+
+// UnusedExported returns the exported functions, types, variables, and
+// constants of the workspace packages in dir, and the exported methods and
+// fields of their exported types, that are not used by the package itself,
+// its tests, or the workspace packages that depend on it. If dir is empty,
+// all of the workspace packages are searched.
+//
+// Members of an unused type are not reported separately. Identifiers that
+// may be used without being referred to are not reported: main packages,
+// methods that satisfy an interface of the searched packages or their
+// dependencies, fields with struct tags, which are usually used through
+// reflection, and functions exported to C by an //export directive.
+func UnusedExported(ctx context.Context, snapshot Snapshot, dir span.URI) ([]*UnusedIdentifier, error) {
+	if dir == "" {
+		dir = snapshot.View().Folder()
+	}
+	wsPkgs, err := snapshot.WorkspacePackages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Exported identifiers may be used by the test variants of their
+	// package, which are type-checked separately.
+	testVariants := make(map[string][]Package)
+	var pkgs []Package
+	for _, pkg := range wsPkgs {
+		if forTest := testedPackage(pkg); forTest != "" {
+			testVariants[forTest] = append(testVariants[forTest], pkg)
+			continue
+		}
+		if pkg.Name() == "main" || len(pkg.CompiledGoFiles()) == 0 {
+			continue
+		}
+		inDir := true
+		for _, pgf := range pkg.CompiledGoFiles() {
+			inDir = inDir && InDir(dir.Filename(), pgf.URI.Filename())
+		}
+		if inDir {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+
+	u := &unusedSearch{
+		snapshot: snapshot,
+		searched: make(map[string]bool),
+		used:     make(map[token.Position]bool),
+		seen:     make(map[*types.Package]bool),
+		ifaces:   make(map[string][]*types.Interface),
+	}
+	for _, pkg := range pkgs {
+		searchPkgs := append([]Package{pkg}, testVariants[pkg.PkgPath()]...)
+		for _, p := range searchPkgs {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			u.search(p)
+			reverseDeps, err := snapshot.GetReverseDependencies(ctx, p.ID())
+			if err != nil {
+				return nil, err
+			}
+			for _, rdep := range reverseDeps {
+				u.search(rdep)
+			}
+		}
+	}
+
+	var unused []*UnusedIdentifier
+	for _, pkg := range pkgs {
+		ids, err := u.unused(pkg)
+		if err != nil {
+			return nil, err
+		}
+		unused = append(unused, ids...)
+	}
+	sort.Slice(unused, func(i, j int) bool {
+		if unused[i].URI != unused[j].URI {
+			return unused[i].URI < unused[j].URI
+		}
+		return protocol.CompareRange(unused[i].Range, unused[j].Range) < 0
+	})
+	return unused, nil
+}
+
+// UnusedExportedDiagnostics returns a diagnostic for each identifier
+// reported by UnusedExported for the whole workspace.
+func UnusedExportedDiagnostics(ctx context.Context, snapshot Snapshot) (map[span.URI][]*Diagnostic, error) {
+	unused, err := UnusedExported(ctx, snapshot, "")
+	if err != nil {
+		return nil, err
+	}
+	reports := make(map[span.URI][]*Diagnostic)
+	for _, id := range unused {
+		reports[id.URI] = append(reports[id.URI], &Diagnostic{
+			URI:      id.URI,
+			Range:    id.Range,
+			Severity: protocol.SeverityHint,
+			Source:   UnusedExportedIdentifier,
+			Message:  fmt.Sprintf("exported %s %s is not used in the workspace", id.Kind, id.Name),
+			Tags:     []protocol.DiagnosticTag{protocol.Unnecessary},
+		})
+	}
+	return reports, nil
+}
+
+// testedPackage returns the path of the package tested by pkg, if pkg is
+// a test variant or an external test package.
+func testedPackage(pkg Package) string {
+	if pkg.ForTest() != "" {
+		return pkg.ForTest()
+	}
+	for _, pgf := range pkg.CompiledGoFiles() {
+		if strings.HasSuffix(pgf.URI.Filename(), "_test.go") {
+			return strings.TrimSuffix(pkg.PkgPath(), "_test")
+		}
+	}
+	return ""
+}
+
+// unusedSearch records the uses of objects, by the position of their
+// declaration, and the interfaces that methods may satisfy.
+type unusedSearch struct {
+	snapshot Snapshot
+	searched map[string]bool
+
+	// used is keyed by position, not object, as the test variants of a
+	// package declare their own objects for the same identifiers.
+	used map[token.Position]bool
+
+	seen   map[*types.Package]bool
+	ifaces map[string][]*types.Interface // by method name
+}
+
+func (u *unusedSearch) search(pkg Package) {
+	if u.searched[pkg.ID()] {
+		return
+	}
+	u.searched[pkg.ID()] = true
+	// The receivers of a type's methods are not uses of the type.
+	receivers := make(map[*ast.Ident]bool)
+	for _, f := range pkg.GetSyntax() {
+		for _, decl := range f.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv != nil && len(fn.Recv.List) > 0 {
+				ast.Inspect(fn.Recv.List[0].Type, func(n ast.Node) bool {
+					if id, ok := n.(*ast.Ident); ok {
+						receivers[id] = true
+					}
+					return true
+				})
+			}
+		}
+	}
+	info := pkg.GetTypesInfo()
+	for id, obj := range info.Uses {
+		if obj.Pkg() != nil && obj.Pos().IsValid() && !receivers[id] {
+			u.used[u.position(obj)] = true
+		}
+	}
+	// The elements of unkeyed composite literals are uses of every field
+	// of their struct type, which are not referred to by name.
+	for _, f := range pkg.GetSyntax() {
+		ast.Inspect(f, func(n ast.Node) bool {
+			lit, ok := n.(*ast.CompositeLit)
+			if !ok || len(lit.Elts) == 0 {
+				return true
+			}
+			if _, ok := lit.Elts[0].(*ast.KeyValueExpr); ok {
+				return true
+			}
+			tv, ok := info.Types[lit]
+			if !ok {
+				return true
+			}
+			if strct, ok := Deref(tv.Type).Underlying().(*types.Struct); ok {
+				for i := 0; i < strct.NumFields(); i++ {
+					if field := strct.Field(i); field.Pos().IsValid() {
+						u.used[u.position(field)] = true
+					}
+				}
+			}
+			return true
+		})
+	}
+	u.addInterfaces(pkg.GetTypes())
+}
+
+// addInterfaces records the package-level interfaces of tpkg and of its
+// dependencies.
+func (u *unusedSearch) addInterfaces(tpkg *types.Package) {
+	if tpkg == nil || u.seen[tpkg] {
+		return
+	}
+	u.seen[tpkg] = true
+	scope := tpkg.Scope()
+	for _, name := range scope.Names() {
+		tname, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok {
+			continue
+		}
+		iface, ok := tname.Type().Underlying().(*types.Interface)
+		if !ok {
+			continue
+		}
+		for i := 0; i < iface.NumMethods(); i++ {
+			if m := iface.Method(i); m.Exported() {
+				u.ifaces[m.Name()] = append(u.ifaces[m.Name()], iface)
+			}
+		}
+	}
+	for _, imp := range tpkg.Imports() {
+		u.addInterfaces(imp)
+	}
+}
+
+func (u *unusedSearch) position(obj types.Object) token.Position {
+	return u.snapshot.FileSet().PositionFor(obj.Pos(), false)
+}
+
+func (u *unusedSearch) isUsed(obj types.Object) bool {
+	return obj == nil || u.used[u.position(obj)]
+}
+
+// satisfiesInterface reports whether the method of the named type is part
+// of an interface that the type, or a pointer to it, implements.
+func (u *unusedSearch) satisfiesInterface(named *types.Named, method *types.Func) bool {
+	for _, iface := range u.ifaces[method.Name()] {
+		if types.Implements(named, iface) || types.Implements(types.NewPointer(named), iface) {
+			return true
+		}
+	}
+	return false
+}
+
+// unused returns the unused exported identifiers of the package.
+func (u *unusedSearch) unused(pkg Package) ([]*UnusedIdentifier, error) {
+	info := pkg.GetTypesInfo()
+	var unused []*UnusedIdentifier
+	for _, pgf := range pkg.CompiledGoFiles() {
+		add := func(id *ast.Ident, kind string, qualifiers ...string) error {
+			rng, err := NewMappedRange(u.snapshot.FileSet(), pgf.Mapper, id.Pos(), id.End()).Range()
+			if err != nil {
+				return err
+			}
+			name := append([]string{pkg.Name()}, qualifiers...)
+			unused = append(unused, &UnusedIdentifier{
+				Name:  strings.Join(append(name, id.Name), "."),
+				Kind:  kind,
+				URI:   pgf.URI,
+				Range: rng,
+			})
+			return nil
+		}
+		for _, decl := range pgf.File.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if !decl.Name.IsExported() || hasExportDirective(decl.Doc) {
+					continue
+				}
+				fn, ok := info.Defs[decl.Name].(*types.Func)
+				if !ok || u.isUsed(fn) {
+					continue
+				}
+				recv := fn.Type().(*types.Signature).Recv()
+				if recv == nil {
+					if err := add(decl.Name, "func"); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				named, ok := Deref(recv.Type()).(*types.Named)
+				// Methods of unused types are not reported separately.
+				if !ok || !named.Obj().Exported() || !u.isUsed(named.Obj()) || u.satisfiesInterface(named, fn) {
+					continue
+				}
+				if err := add(decl.Name, "method", named.Obj().Name()); err != nil {
+					return nil, err
+				}
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						if !spec.Name.IsExported() {
+							continue
+						}
+						if !u.isUsed(info.Defs[spec.Name]) {
+							if err := add(spec.Name, "type"); err != nil {
+								return nil, err
+							}
+							continue
+						}
+						kind, fields := "field", (*ast.FieldList)(nil)
+						switch typ := spec.Type.(type) {
+						case *ast.StructType:
+							fields = typ.Fields
+						case *ast.InterfaceType:
+							kind, fields = "method", typ.Methods
+						}
+						if fields == nil {
+							continue
+						}
+						for _, field := range fields.List {
+							// Fields with struct tags are usually used through
+							// reflection, and embedded fields through promotion.
+							if field.Tag != nil {
+								continue
+							}
+							for _, name := range field.Names {
+								if !name.IsExported() || u.isUsed(info.Defs[name]) {
+									continue
+								}
+								if err := add(name, kind, spec.Name.Name); err != nil {
+									return nil, err
+								}
+							}
+						}
+					case *ast.ValueSpec:
+						kind := "var"
+						if decl.Tok == token.CONST {
+							kind = "const"
+						}
+						for _, name := range spec.Names {
+							if !name.IsExported() || u.isUsed(info.Defs[name]) {
+								continue
+							}
+							if err := add(name, kind); err != nil {
+								return nil, err
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return unused, nil
+}
+
+// hasExportDirective reports whether the doc comment of a function has a
+// cgo //export directive.
+func hasExportDirective(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if strings.HasPrefix(c.Text, "//export ") {
+			return true
+		}
+	}
+	return false
+}
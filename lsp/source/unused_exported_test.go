@@ -0,0 +1,161 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/kevinswiber/languageserver-go/lsp/cache"
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+	"github.com/kevinswiber/languageserver-go/span"
+)
+
+// newSnapshot returns a snapshot of a workspace with the files, which are
+// keyed by their slash-separated paths, and the workspace directory.
+func newSnapshot(t *testing.T, files map[string]string) (source.Snapshot, string) {
+	t.Helper()
+	return newSnapshotWithOptions(t, files, func(*source.Options) {})
+}
+
+// newSnapshotWithOptions is like newSnapshot, but modifies the default
+// options of the view with the function.
+func newSnapshotWithOptions(t *testing.T, files map[string]string, modify func(*source.Options)) (source.Snapshot, string) {
+	t.Helper()
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "gopls-source-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	for name, content := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tmp, err := ioutil.TempDir("", "gopls-source-workspace-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(tmp) })
+	options := source.DefaultOptions().Clone()
+	options.Env = map[string]string{"GOPACKAGESDRIVER": "off"}
+	modify(options)
+	session := cache.New(ctx, nil).NewSession(ctx)
+	view, snapshot, release, err := session.NewView(ctx, "source_test", span.URIFromPath(dir), span.URIFromPath(tmp), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		release()
+		view.Shutdown(ctx)
+	})
+	return snapshot, dir
+}
+
+func TestUnusedExported(t *testing.T) {
+	snapshot, dir := newSnapshot(t, map[string]string{
+		"go.mod": "module example.com/m\n\ngo 1.16\n",
+		"a/a.go": `package a
+
+import "io"
+
+type Used struct {
+	Field  int
+	Extra  int
+	Tagged int ` + "`json:\"tagged\"`" + `
+}
+
+func (u *Used) Method() {}
+
+func (u *Used) Unused() {}
+
+// Read satisfies io.Reader.
+func (Used) Read([]byte) (int, error) { return 0, nil }
+
+var _ io.Reader = Used{}
+
+// Point is only used in an unkeyed composite literal.
+type Point struct{ X, Y int }
+
+// The members of an unused type are not reported, and its receivers are
+// not uses of it.
+type Unused struct{ Field int }
+
+func (Unused) Method() {}
+
+//export Exported
+func Exported() {}
+
+func Tested() {}
+
+func Func() {}
+
+var Var int
+
+const Const = 1
+`,
+		"a/a_test.go": `package a
+
+import "testing"
+
+func TestTested(t *testing.T) { Tested() }
+`,
+		"cmd/main.go": `package main
+
+import "example.com/m/a"
+
+func main() {
+	var u a.Used
+	u.Method()
+	_ = u.Field
+	_ = a.Point{1, 2}
+}
+
+func Main() {}
+`,
+	})
+	ctx := context.Background()
+	unused, err := source.UnusedExported(ctx, snapshot, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	type id struct{ Name, Kind string }
+	var got []id
+	for _, u := range unused {
+		if u.URI != span.URIFromPath(filepath.Join(dir, "a", "a.go")) {
+			t.Errorf("%s is reported in %s", u.Name, u.URI)
+		}
+		got = append(got, id{u.Name, u.Kind})
+	}
+	want := []id{
+		{"a.Used.Extra", "field"},
+		{"a.Used.Unused", "method"},
+		{"a.Unused", "type"},
+		{"a.Func", "func"},
+		{"a.Var", "var"},
+		{"a.Const", "const"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("UnusedExported = %v, want %v", got, want)
+	}
+
+	// Searching another directory reports none of the identifiers of a.
+	unused, err = source.UnusedExported(ctx, snapshot, span.URIFromPath(filepath.Join(dir, "cmd")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unused) != 0 {
+		t.Errorf("UnusedExported(cmd) = %v, want none", unused)
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"strconv"
 	"strings"
 
+	"golang.org/x/mod/modfile"
 	"github.com/kevinswiber/languageserver-go/lsp/protocol"
 	"github.com/kevinswiber/languageserver-go/span"
 	errors "golang.org/x/xerrors"
@@ -72,6 +73,28 @@ func (s MappedRange) URI() span.URI {
 	return s.m.URI
 }
 
+// LineToRange returns the range from start to end in a go.mod, go.work, or
+// go.sum file.
+func LineToRange(m *protocol.ColumnMapper, uri span.URI, start, end modfile.Position) (protocol.Range, error) {
+	return ByteOffsetsToRange(m, uri, start.Byte, end.Byte)
+}
+
+// ByteOffsetsToRange returns the range between the byte offsets start and
+// end of the file.
+func ByteOffsetsToRange(m *protocol.ColumnMapper, uri span.URI, start, end int) (protocol.Range, error) {
+	line, col, err := m.Converter.ToPosition(start)
+	if err != nil {
+		return protocol.Range{}, err
+	}
+	s := span.NewPoint(line, col, start)
+	line, col, err = m.Converter.ToPosition(end)
+	if err != nil {
+		return protocol.Range{}, err
+	}
+	e := span.NewPoint(line, col, end)
+	return m.Range(span.New(uri, s, e))
+}
+
 // GetParsedFile is a convenience function that extracts the Package and
 // ParsedGoFile for a file in a Snapshot. pkgPolicy is one of NarrowestPackage/
 // WidestPackage.
@@ -168,6 +191,11 @@ func DetectLanguage(langID, filename string) FileKind {
 		return Mod
 	case "go.sum":
 		return Sum
+	case "go.work":
+		return Work
+	}
+	if filepath.Base(filename) == AnalysisConfigFile {
+		return Config
 	}
 	// Fallback to detecting the language based on the file extension.
 	switch filepath.Ext(filename) {
@@ -175,6 +203,8 @@ func DetectLanguage(langID, filename string) FileKind {
 		return Mod
 	case ".sum":
 		return Sum
+	case ".work":
+		return Work
 	default: // fallback to Go
 		return Go
 	}
@@ -186,6 +216,10 @@ func (k FileKind) String() string {
 		return "go.mod"
 	case Sum:
 		return "go.sum"
+	case Work:
+		return "go.work"
+	case Config:
+		return AnalysisConfigFile
 	default:
 		return "go"
 	}
@@ -89,7 +89,9 @@ type Snapshot interface {
 	DiagnosePackage(ctx context.Context, pkg Package) (map[span.URI][]*Diagnostic, error)
 
 	// Analyze runs the analyses for the given package at this snapshot.
-	Analyze(ctx context.Context, pkgID string, analyzers []*Analyzer) ([]*Diagnostic, error)
+	// If vetTools is set, it also runs the external analysis tools of the
+	// VetTools option.
+	Analyze(ctx context.Context, pkgID string, analyzers []*Analyzer, vetTools bool) ([]*Diagnostic, error)
 
 	// RunGoCommandPiped runs the given `go` command, writing its output
 	// to stdout and stderr. Verb, Args, and WorkingDir must be specified.
@@ -114,6 +116,29 @@ type Snapshot interface {
 	// ParseMod is used to parse go.mod files.
 	ParseMod(ctx context.Context, fh FileHandle) (*ParsedModule, error)
 
+	// WorkFile returns the URI of the go.work file that defines the
+	// modules of the snapshot's workspace, if any.
+	WorkFile() span.URI
+
+	// ParseWork is used to parse go.work files.
+	ParseWork(ctx context.Context, fh FileHandle) (*ParsedWorkFile, error)
+
+	// ParseSum is used to parse go.sum files.
+	ParseSum(ctx context.Context, fh FileHandle) (*ParsedSumFile, error)
+
+	// ModGraph returns the module graph of the module specified by the
+	// given go.mod file, as reported by `go mod graph`.
+	ModGraph(ctx context.Context, fh FileHandle) (*ModuleGraph, error)
+
+	// VulnerabilityDatabase returns the reports of the vulnerability
+	// database configured by the VulnerabilityDatabase option, if any.
+	VulnerabilityDatabase(ctx context.Context) ([]*OSVEntry, error)
+
+	// DownloadedModules returns the paths of the modules that have been
+	// downloaded to the module cache of the view. The paths may be up to
+	// half a minute old.
+	DownloadedModules(ctx context.Context) ([]string, error)
+
 	// ModWhy returns the results of `go mod why` for the module specified by
 	// the given go.mod file.
 	ModWhy(ctx context.Context, fh FileHandle) (map[string]string, error)
@@ -122,6 +147,11 @@ type Snapshot interface {
 	// the given go.mod file.
 	ModTidy(ctx context.Context, pm *ParsedModule) (*TidiedModule, error)
 
+	// GoFilesInTree returns the .go files in the directory tree of dir,
+	// skipping the directories that the go command ignores, and the open
+	// files in the tree.
+	GoFilesInTree(ctx context.Context, dir span.URI) ([]span.URI, error)
+
 	// GoModForFile returns the URI of the go.mod file for the given URI.
 	GoModForFile(uri span.URI) span.URI
 
@@ -152,6 +182,10 @@ type Snapshot interface {
 	// WorkspacePackages returns the snapshot's top-level packages.
 	WorkspacePackages(ctx context.Context) ([]Package, error)
 
+	// SelectorStats returns the counts of the selector expressions in the
+	// snapshot's top-level packages that have already been type-checked.
+	SelectorStats(ctx context.Context) (SelectorStats, error)
+
 	// GetCriticalError returns any critical errors in the workspace.
 	GetCriticalError(ctx context.Context) *CriticalError
 }
@@ -241,11 +275,31 @@ type View interface {
 	// by the GOPRIVATE environment variable.
 	IsGoPrivatePath(path string) bool
 
+	// GoModCache returns the module cache directory of the view, as
+	// reported by the GOMODCACHE environment variable.
+	GoModCache() string
+
 	// ModuleUpgrades returns known module upgrades.
 	ModuleUpgrades() map[string]string
 
 	// RegisterModuleUpgrades registers that upgrades exist for the given modules.
 	RegisterModuleUpgrades(upgrades map[string]string)
+
+	// BuildMatrixViews returns the views that type-check the view's folder
+	// under the configurations of its BuildMatrix option.
+	BuildMatrixViews() []View
+
+	// BuildConfig returns the configuration of a view returned by
+	// BuildMatrixViews or ExcludedFileView, or nil for other views.
+	BuildConfig() *BuildConfig
+
+	// ExcludedFileView returns the view that type-checks the view's folder
+	// under a configuration that includes the Go file, which the view's
+	// build constraints exclude, creating it if necessary. The session
+	// then uses that view for the requests about the file. It returns nil
+	// if no configuration can be inferred from the file's name and build
+	// constraints.
+	ExcludedFileView(ctx context.Context, fh FileHandle) (View, error)
 }
 
 // A FileSource maps uris to FileHandles. This abstraction exists both for
@@ -274,6 +328,18 @@ type ParsedGoFile struct {
 	ParseErr scanner.ErrorList
 }
 
+// A FileChange describes a change to a file that may not exist yet.
+type FileChange struct {
+	// URI is the changed file.
+	URI span.URI
+
+	// Content is the content of the file if it does not exist yet.
+	Content []byte
+
+	// Edits are the edits to the file if it exists.
+	Edits []protocol.TextEdit
+}
+
 // A ParsedModule contains the results of parsing a go.mod file.
 type ParsedModule struct {
 	URI         span.URI
@@ -282,6 +348,34 @@ type ParsedModule struct {
 	ParseErrors []*Diagnostic
 }
 
+// A ParsedWorkFile contains the results of parsing a go.work file.
+type ParsedWorkFile struct {
+	URI         span.URI
+	File        *WorkFile
+	Mapper      *protocol.ColumnMapper
+	ParseErrors []*Diagnostic
+}
+
+// A ParsedSumFile contains the results of parsing a go.sum file.
+type ParsedSumFile struct {
+	URI         span.URI
+	File        *SumFile
+	Mapper      *protocol.ColumnMapper
+	ParseErrors []*Diagnostic
+}
+
+// A ModuleGraph is the module requirement graph of a main module.
+type ModuleGraph struct {
+	// Requirers maps each module version in the graph to the module
+	// versions whose go.mod files require it. The version of the main
+	// module is empty.
+	Requirers map[module.Version][]module.Version
+
+	// Selected maps the path of each module in the build list to its
+	// selected version.
+	Selected map[string]string
+}
+
 // A TidiedModule contains the results of running `go mod tidy` on a module.
 type TidiedModule struct {
 	// Diagnostics representing changes made by `go mod tidy`.
@@ -498,7 +592,7 @@ func (id FileIdentity) String() string {
 }
 
 // FileKind describes the kind of the file in question.
-// It can be one of Go, mod, or sum.
+// It can be one of Go, mod, sum, work, or config.
 type FileKind int
 
 const (
@@ -511,6 +605,10 @@ const (
 	Mod
 	// Sum is a go.sum file.
 	Sum
+	// Work is a go.work file.
+	Work
+	// Config is a gopls.yaml analysis configuration file.
+	Config
 )
 
 // Analyzer represents a go/analysis analyzer with some boolean properties
@@ -532,6 +630,11 @@ type Analyzer struct {
 	// ActionKind is the kind of code action this analyzer produces. If
 	// unspecified the type defaults to quickfix.
 	ActionKind protocol.CodeActionKind
+
+	// Diagnose, if non-nil, computes the analyzer's diagnostics in place of
+	// running Analyzer, for analyses that need more than the package's own
+	// syntax, such as the doc comments of its dependencies.
+	Diagnose func(ctx context.Context, snapshot Snapshot, pkg Package) ([]*Diagnostic, error)
 }
 
 func (a Analyzer) IsEnabled(view View) bool {
@@ -610,6 +713,11 @@ const (
 	ModTidyError             DiagnosticSource = "go mod tidy"
 	OptimizationDetailsError DiagnosticSource = "optimizer details"
 	UpgradeNotification      DiagnosticSource = "upgrade available"
+	IgnoreDirectiveError     DiagnosticSource = "ignore directive"
+	UnusedExportedIdentifier DiagnosticSource = "unused exported"
+	WorkFileError            DiagnosticSource = "go.work"
+	SumFileError             DiagnosticSource = "go.sum"
+	Vulnerability            DiagnosticSource = "vulnerability"
 )
 
 func AnalyzerErrorKind(name string) DiagnosticSource {
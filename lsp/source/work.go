@@ -0,0 +1,164 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// A WorkFile is the parsed form of a go.work file, which lists the modules
+// of a multi-module workspace with its use directives.
+type WorkFile struct {
+	Go      *modfile.Go
+	Use     []*WorkUse
+	Replace []*modfile.Replace
+
+	Syntax *modfile.FileSyntax
+}
+
+// A WorkUse is a use directive of a go.work file.
+type WorkUse struct {
+	// Path is the directory of the module, as written in the file. It is
+	// relative to the directory of the go.work file, unless it is absolute.
+	Path   string
+	Syntax *modfile.Line
+}
+
+// Format returns the formatted contents of the file.
+func (f *WorkFile) Format() ([]byte, error) {
+	return modfile.Format(f.Syntax), nil
+}
+
+// ParseWorkFile parses the contents of a go.work file. Like modfile.Parse,
+// it reports the errors of the file as a modfile.ErrorList.
+func ParseWorkFile(filename string, data []byte) (*WorkFile, error) {
+	// The syntax of go.work files is that of go.mod files, whose lax parser
+	// accepts the go directive and ignores the others.
+	lax, err := modfile.ParseLax(filename, data, nil)
+	if err != nil {
+		return nil, err
+	}
+	f := &WorkFile{
+		Go:     lax.Go,
+		Syntax: lax.Syntax,
+	}
+	var errs modfile.ErrorList
+	for _, stmt := range lax.Syntax.Stmt {
+		switch stmt := stmt.(type) {
+		case *modfile.Line:
+			f.add(&errs, filename, stmt, stmt.Token[0], stmt.Token[1:])
+		case *modfile.LineBlock:
+			if len(stmt.Token) > 1 {
+				errs = append(errs, modfile.Error{
+					Filename: filename,
+					Pos:      stmt.Start,
+					Err:      fmt.Errorf("unknown block type: %s", strings.Join(stmt.Token, " ")),
+				})
+				continue
+			}
+			for _, line := range stmt.Line {
+				f.add(&errs, filename, line, stmt.Token[0], line.Token)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return f, nil
+}
+
+func (f *WorkFile) add(errs *modfile.ErrorList, filename string, line *modfile.Line, verb string, args []string) {
+	errorf := func(format string, args ...interface{}) {
+		*errs = append(*errs, modfile.Error{
+			Filename: filename,
+			Pos:      line.Start,
+			Verb:     verb,
+			Err:      fmt.Errorf(format, args...),
+		})
+	}
+	switch verb {
+	default:
+		errorf("unknown directive: %s", verb)
+
+	case "go":
+		// The go directive is parsed by modfile.ParseLax.
+
+	case "use":
+		if len(args) != 1 {
+			errorf("usage: use local/dir")
+			return
+		}
+		path, err := parseWorkString(args[0])
+		if err != nil {
+			errorf("invalid quoted string: %v", err)
+			return
+		}
+		f.Use = append(f.Use, &WorkUse{Path: path, Syntax: line})
+
+	case "replace":
+		arrow := 2
+		if len(args) >= 2 && args[1] == "=>" {
+			arrow = 1
+		}
+		if len(args) < arrow+2 || len(args) > arrow+3 || args[arrow] != "=>" {
+			errorf("usage: %s module/path [v1.2.3] => other/module v1.4\n\t or %s module/path [v1.2.3] => ../local/directory", verb, verb)
+			return
+		}
+		var strs []string
+		for i, arg := range args {
+			if i == arrow {
+				continue
+			}
+			s, err := parseWorkString(arg)
+			if err != nil {
+				errorf("invalid quoted string: %v", err)
+				return
+			}
+			strs = append(strs, s)
+		}
+		r := &modfile.Replace{Syntax: line}
+		r.Old.Path = strs[0]
+		if err := module.CheckImportPath(r.Old.Path); err != nil {
+			errorf("invalid module path: %v", err)
+			return
+		}
+		if arrow == 2 {
+			r.Old.Version = strs[1]
+		}
+		r.New.Path = strs[arrow]
+		if len(args) == arrow+3 {
+			r.New.Version = strs[arrow+1]
+		}
+		for _, v := range []string{r.Old.Version, r.New.Version} {
+			if v != "" && !semver.IsValid(v) {
+				errorf("invalid module version %q", v)
+				return
+			}
+		}
+		if r.New.Version == "" && !modfile.IsDirectoryPath(r.New.Path) {
+			errorf("replacement module without version must be directory path (rooted or starting with ./ or ../)")
+			return
+		}
+		if r.New.Version != "" && modfile.IsDirectoryPath(r.New.Path) {
+			errorf("replacement module directory path %q cannot have version", r.New.Path)
+			return
+		}
+		f.Replace = append(f.Replace, r)
+	}
+}
+
+// parseWorkString returns the value of a possibly quoted token.
+func parseWorkString(s string) (string, error) {
+	if strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "`") {
+		return strconv.Unquote(s)
+	}
+	return s, nil
+}
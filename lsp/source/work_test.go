@@ -0,0 +1,79 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseWorkFile(t *testing.T) {
+	tests := []struct {
+		content     string
+		wantGo      string
+		wantUse     []string
+		wantReplace []string
+		wantErr     string
+	}{
+		{
+			content: "go 1.18\n",
+			wantGo:  "1.18",
+		},
+		{
+			content: "go 1.18\n\nuse ./a\nuse (\n\t.\n\t\"./b c\"\n)\n",
+			wantGo:  "1.18",
+			wantUse: []string{"./a", ".", "./b c"},
+		},
+		{
+			content:     "go 1.18\n\nuse ./a\n\nreplace (\n\texample.com/b => ../b\n\texample.com/c v1.0.0 => example.com/d v1.1.0\n)\n",
+			wantGo:      "1.18",
+			wantUse:     []string{"./a"},
+			wantReplace: []string{"example.com/b => ../b", "example.com/c@v1.0.0 => example.com/d@v1.1.0"},
+		},
+		{
+			content: "go 1.18\n\nuse ./a ./b\n",
+			wantErr: "usage: use local/dir",
+		},
+		{
+			content: "go 1.18\n\nrequire example.com/a v1.0.0\n",
+			wantErr: "unknown directive: require",
+		},
+		{
+			content: "go 1.18\n\nreplace example.com/a => example.com/b\n",
+			wantErr: "replacement module without version must be directory path",
+		},
+	}
+	for _, test := range tests {
+		f, err := ParseWorkFile("go.work", []byte(test.content))
+		if test.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("ParseWorkFile(%q) = %v, want error containing %q", test.content, err, test.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseWorkFile(%q) failed: %v", test.content, err)
+			continue
+		}
+		if f.Go == nil || f.Go.Version != test.wantGo {
+			t.Errorf("ParseWorkFile(%q).Go = %v, want %q", test.content, f.Go, test.wantGo)
+		}
+		var gotUse []string
+		for _, use := range f.Use {
+			gotUse = append(gotUse, use.Path)
+		}
+		if !reflect.DeepEqual(gotUse, test.wantUse) {
+			t.Errorf("ParseWorkFile(%q).Use = %q, want %q", test.content, gotUse, test.wantUse)
+		}
+		var gotReplace []string
+		for _, r := range f.Replace {
+			gotReplace = append(gotReplace, r.Old.String()+" => "+r.New.String())
+		}
+		if !reflect.DeepEqual(gotReplace, test.wantReplace) {
+			t.Errorf("ParseWorkFile(%q).Replace = %q, want %q", test.content, gotReplace, test.wantReplace)
+		}
+	}
+}
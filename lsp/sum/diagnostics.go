@@ -0,0 +1,286 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package sum provides core features related to go.sum file
+// handling for use by Go editors and tools.
+package sum
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/sumdb/dirhash"
+	"github.com/kevinswiber/languageserver-go/event"
+	"github.com/kevinswiber/languageserver-go/lsp/debug/tag"
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+	"github.com/kevinswiber/languageserver-go/span"
+)
+
+func Diagnostics(ctx context.Context, snapshot source.Snapshot) (map[source.VersionedFileIdentity][]*source.Diagnostic, error) {
+	ctx, done := event.Start(ctx, "sum.Diagnostics", tag.Snapshot.Of(snapshot.ID()))
+	defer done()
+
+	reports := map[source.VersionedFileIdentity][]*source.Diagnostic{}
+	for _, modURI := range snapshot.ModFiles() {
+		fh, err := snapshot.GetVersionedFile(ctx, sumURI(modURI))
+		if err != nil {
+			return nil, err
+		}
+		// Modules without dependencies have no go.sum file.
+		if _, err := fh.Read(); err != nil {
+			continue
+		}
+		diagnostics, err := DiagnosticsForSum(ctx, snapshot, fh)
+		if err != nil {
+			return nil, err
+		}
+		reports[fh.VersionedFileIdentity()] = diagnostics
+	}
+	return reports, nil
+}
+
+// DiagnosticsForSum reports the malformed and duplicate lines of the go.sum
+// file, the lines whose hashes disagree with the module cache, and the
+// lines for module versions that are not needed by the module graph of its
+// go.mod file.
+func DiagnosticsForSum(ctx context.Context, snapshot source.Snapshot, fh source.FileHandle) ([]*source.Diagnostic, error) {
+	ps, err := snapshot.ParseSum(ctx, fh)
+	if err != nil {
+		return nil, err
+	}
+	diagnostics := append([]*source.Diagnostic{}, ps.ParseErrors...)
+
+	type sumKey struct {
+		mod   module.Version
+		goMod bool
+	}
+	seen := make(map[sumKey]*source.SumLine)
+	var lines []*source.SumLine // the lines that are not duplicates
+	for _, line := range ps.File.Lines {
+		k := sumKey{line.Mod, line.GoMod}
+		first, ok := seen[k]
+		if !ok {
+			seen[k] = line
+			lines = append(lines, line)
+			continue
+		}
+		rng, err := source.LineToRange(ps.Mapper, fh.URI(), line.Start, line.End)
+		if err != nil {
+			return nil, err
+		}
+		if first.Hash != line.Hash {
+			diagnostics = append(diagnostics, &source.Diagnostic{
+				URI:      fh.URI(),
+				Range:    rng,
+				Severity: protocol.SeverityError,
+				Source:   source.SumFileError,
+				Message:  fmt.Sprintf("conflicting hashes for %s: %s on line %d", line, first.Hash, first.Start.Line),
+			})
+			continue
+		}
+		fix, err := removeLinesFix("Remove duplicate go.sum line", ps, line)
+		if err != nil {
+			return nil, err
+		}
+		diagnostics = append(diagnostics, &source.Diagnostic{
+			URI:            fh.URI(),
+			Range:          rng,
+			Severity:       protocol.SeverityWarning,
+			Source:         source.SumFileError,
+			Message:        fmt.Sprintf("duplicate go.sum line for %s", line),
+			Tags:           []protocol.DiagnosticTag{protocol.Unnecessary},
+			SuggestedFixes: []source.SuggestedFix{fix},
+		})
+	}
+
+	modcache := snapshot.View().GoModCache()
+	for _, line := range lines {
+		want, ok := cachedHash(modcache, line)
+		if !ok || want == line.Hash {
+			continue
+		}
+		rng, err := source.LineToRange(ps.Mapper, fh.URI(), line.Start, line.End)
+		if err != nil {
+			return nil, err
+		}
+		diagnostics = append(diagnostics, &source.Diagnostic{
+			URI:      fh.URI(),
+			Range:    rng,
+			Severity: protocol.SeverityError,
+			Source:   source.SumFileError,
+			Message:  fmt.Sprintf("checksum mismatch for %s: go.sum has %s, but the module cache has %s", line, line.Hash, want),
+		})
+	}
+
+	stale, err := staleLines(ctx, snapshot, fh.URI(), lines)
+	if err != nil {
+		// The module graph is unavailable if the go.mod file is broken, or
+		// if the module cache lacks some of its go.mod files.
+		event.Error(ctx, "diagnosing go.sum", err, tag.URI.Of(fh.URI()))
+		return diagnostics, nil
+	}
+	var removeAll *source.SuggestedFix
+	if len(stale) > 1 {
+		fix, err := removeLinesFix("Remove all stale go.sum lines", ps, stale...)
+		if err != nil {
+			return nil, err
+		}
+		removeAll = &fix
+	}
+	for _, line := range stale {
+		rng, err := source.LineToRange(ps.Mapper, fh.URI(), line.Start, line.End)
+		if err != nil {
+			return nil, err
+		}
+		fix, err := removeLinesFix("Remove stale go.sum line", ps, line)
+		if err != nil {
+			return nil, err
+		}
+		fixes := []source.SuggestedFix{fix}
+		if removeAll != nil {
+			fixes = append(fixes, *removeAll)
+		}
+		diagnostics = append(diagnostics, &source.Diagnostic{
+			URI:            fh.URI(),
+			Range:          rng,
+			Severity:       protocol.SeverityWarning,
+			Source:         source.SumFileError,
+			Message:        fmt.Sprintf("%s is not in the build list", line),
+			Tags:           []protocol.DiagnosticTag{protocol.Unnecessary},
+			SuggestedFixes: fixes,
+		})
+	}
+	return diagnostics, nil
+}
+
+// staleLines returns the lines of the go.sum file for module versions that
+// are not needed by the module graph of its go.mod file. The go.mod hashes
+// are needed for all of the module versions of the graph, but the hashes
+// of the file trees only for the selected versions.
+func staleLines(ctx context.Context, snapshot source.Snapshot, uri span.URI, lines []*source.SumLine) ([]*source.SumLine, error) {
+	modFH, err := workspaceModFile(ctx, snapshot, uri)
+	if err != nil || modFH == nil {
+		return nil, err
+	}
+	pm, err := snapshot.ParseMod(ctx, modFH)
+	if err != nil {
+		return nil, err
+	}
+	graph, err := snapshot.ModGraph(ctx, modFH)
+	if err != nil {
+		return nil, err
+	}
+	// Replaced modules are loaded from their replacements, whose hashes
+	// are recorded instead.
+	replacement := func(m module.Version) (module.Version, bool) {
+		for _, r := range pm.File.Replace {
+			if r.Old.Path == m.Path && (r.Old.Version == "" || r.Old.Version == m.Version) {
+				return r.New, r.New.Version != ""
+			}
+		}
+		return m, true
+	}
+	inGraph := make(map[module.Version]bool)
+	selected := make(map[module.Version]bool)
+	for m := range graph.Requirers {
+		isSelected := graph.Selected[m.Path] == m.Version
+		// Keep the hashes of the replaced modules too, as some versions
+		// of the go command record them.
+		inGraph[m] = true
+		selected[m] = selected[m] || isSelected
+		if r, ok := replacement(m); ok {
+			inGraph[r] = true
+			selected[r] = selected[r] || isSelected
+		}
+	}
+	var stale []*source.SumLine
+	for _, line := range lines {
+		if line.GoMod && !inGraph[line.Mod] || !line.GoMod && !selected[line.Mod] {
+			stale = append(stale, line)
+		}
+	}
+	return stale, nil
+}
+
+// sumURI returns the URI of the go.sum file of the go.mod file.
+func sumURI(modURI span.URI) span.URI {
+	return span.URIFromPath(strings.TrimSuffix(modURI.Filename(), ".mod") + ".sum")
+}
+
+// workspaceModFile returns the go.mod file of the go.sum file, or nil if it
+// is not a go.mod file of the snapshot.
+func workspaceModFile(ctx context.Context, snapshot source.Snapshot, uri span.URI) (source.FileHandle, error) {
+	modURI := span.URIFromPath(filepath.Join(filepath.Dir(uri.Filename()), "go.mod"))
+	for _, u := range snapshot.ModFiles() {
+		if u == modURI {
+			return snapshot.GetFile(ctx, modURI)
+		}
+	}
+	return nil, nil
+}
+
+// cachedHash returns the hash of the module version of the line, as
+// recorded by the module cache when it downloaded the module. It reports
+// false if the module cache does not contain the module version.
+func cachedHash(modcache string, line *source.SumLine) (string, bool) {
+	// Only the current hash algorithm is recorded.
+	if modcache == "" || !strings.HasPrefix(line.Hash, "h1:") {
+		return "", false
+	}
+	path, err := module.EscapePath(line.Mod.Path)
+	if err != nil {
+		return "", false
+	}
+	version, err := module.EscapeVersion(line.Mod.Version)
+	if err != nil {
+		return "", false
+	}
+	prefix := filepath.Join(modcache, "cache", "download", path, "@v", version)
+	if line.GoMod {
+		modFile := prefix + ".mod"
+		if _, err := os.Stat(modFile); err != nil {
+			return "", false
+		}
+		// This is how the go command hashes go.mod files.
+		h, err := dirhash.Hash1([]string{"go.mod"}, func(string) (io.ReadCloser, error) {
+			return os.Open(modFile)
+		})
+		if err != nil {
+			return "", false
+		}
+		return h, true
+	}
+	data, err := ioutil.ReadFile(prefix + ".ziphash")
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(string(data)), true
+}
+
+// removeLinesFix returns a fix that removes the lines from the go.sum file.
+func removeLinesFix(title string, ps *source.ParsedSumFile, lines ...*source.SumLine) (source.SuggestedFix, error) {
+	var edits []protocol.TextEdit
+	for _, line := range lines {
+		end := line.End.Byte
+		if end < len(ps.Mapper.Content) {
+			end++ // the newline
+		}
+		rng, err := source.ByteOffsetsToRange(ps.Mapper, ps.URI, line.Start.Byte, end)
+		if err != nil {
+			return source.SuggestedFix{}, err
+		}
+		edits = append(edits, protocol.TextEdit{Range: rng})
+	}
+	return source.SuggestedFix{
+		Title: title,
+		Edits: map[span.URI][]protocol.TextEdit{ps.URI: edits},
+	}, nil
+}
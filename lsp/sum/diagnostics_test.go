@@ -0,0 +1,230 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sum
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kevinswiber/languageserver-go/lsp/cache"
+	"github.com/kevinswiber/languageserver-go/lsp/diff"
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+	"github.com/kevinswiber/languageserver-go/span"
+)
+
+// The hashes of the go.mod files in the module cache of newSumSnapshot.
+const (
+	aModHash = "h1:a776hzolqpIKGwWbmHusj9Q5+6H6wlPk6+u8EZPHptQ="
+	bModHash = "h1:8xdIx8LpQAK6ksT91/F2aGI0Kq4z+yBUFDU6f3g9/PU="
+	cModHash = "h1:qZPdy7koPyVhLfOsQtblw6bFK7FgHzimMb2d5LRQSWc="
+)
+
+// sumContent is the go.sum file of the module of newSumSnapshot.
+const sumContent = `example.com/a v1.0.0 h1:wrong=
+example.com/a v1.0.0/go.mod ` + aModHash + `
+example.com/a v1.0.0/go.mod ` + aModHash + `
+example.com/b v1.0.0 h1:unneeded=
+example.com/b v1.0.0/go.mod ` + cModHash + `
+example.com/b v1.1.0 h1:first=
+example.com/b v1.1.0 h1:second=
+example.com/b v1.1.0/go.mod ` + bModHash + `
+example.com/c v1.0.0 h1:replacement=
+example.com/c v1.0.0/go.mod ` + cModHash + `
+example.com/d v1.0.0/go.mod h1:unneeded=
+`
+
+// newSumSnapshot returns a snapshot of a module whose dependencies are in a
+// module cache, and the directory of the module. The module requires
+// example.com/a, which requires an older example.com/b, and example.com/old,
+// which is replaced by example.com/c.
+func newSumSnapshot(t *testing.T) (source.Snapshot, string) {
+	t.Helper()
+	ctx := context.Background()
+	root, err := ioutil.TempDir("", "sum")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(root) })
+	write := func(name, content string) {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("modcache/cache/download/example.com/a/@v/v1.0.0.mod", "module example.com/a\n\nrequire example.com/b v1.0.0\n")
+	write("modcache/cache/download/example.com/a/@v/v1.0.0.ziphash", "h1:cached=\n")
+	write("modcache/cache/download/example.com/b/@v/v1.0.0.mod", "module example.com/b\n")
+	write("modcache/cache/download/example.com/b/@v/v1.1.0.mod", "module example.com/b\n")
+	write("modcache/cache/download/example.com/c/@v/v1.0.0.mod", "module example.com/c\n")
+	write("m/go.mod", `module example.com/m
+
+go 1.16
+
+require (
+	example.com/a v1.0.0
+	example.com/b v1.1.0
+	example.com/old v1.0.0
+)
+
+replace example.com/old => example.com/c v1.0.0
+`)
+	write("m/go.sum", sumContent)
+
+	options := source.DefaultOptions().Clone()
+	options.Env = map[string]string{
+		"GOPACKAGESDRIVER": "off",
+		"GOMODCACHE":       filepath.Join(root, "modcache"),
+		"GOPROXY":          "off",
+		"GOFLAGS":          "",
+	}
+	session := cache.New(ctx, nil).NewSession(ctx)
+	view, snapshot, release, err := session.NewView(ctx, "sum_test", span.URIFromPath(filepath.Join(root, "m")), "", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		release()
+		view.Shutdown(ctx)
+	})
+	return snapshot, filepath.Join(root, "m")
+}
+
+func TestDiagnosticsForSum(t *testing.T) {
+	ctx := context.Background()
+	snapshot, dir := newSumSnapshot(t)
+	fh, err := snapshot.GetFile(ctx, span.URIFromPath(filepath.Join(dir, "go.sum")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	diagnostics, err := DiagnosticsForSum(ctx, snapshot, fh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	fixes := make(map[string][]source.SuggestedFix)
+	for _, d := range diagnostics {
+		if d.Range.Start.Line != d.Range.End.Line || d.Range.Start.Character != 0 {
+			t.Errorf("%s: range %v does not span a line", d.Message, d.Range)
+		}
+		got = append(got, fmt.Sprintf("%d: %v: %s", d.Range.Start.Line+1, d.Severity, d.Message))
+		fixes[d.Message] = d.SuggestedFixes
+	}
+	// The go.mod hashes of the module versions of the graph are needed, but
+	// the hashes of the file trees only for the selected versions, and for
+	// the replacements instead of the replaced modules.
+	want := []string{
+		"3: Warning: duplicate go.sum line for example.com/a v1.0.0/go.mod",
+		"7: Error: conflicting hashes for example.com/b v1.1.0: h1:first= on line 6",
+		"1: Error: checksum mismatch for example.com/a v1.0.0: go.sum has h1:wrong=, but the module cache has h1:cached=",
+		"5: Error: checksum mismatch for example.com/b v1.0.0/go.mod: go.sum has " + cModHash + ", but the module cache has " + bModHash,
+		"4: Warning: example.com/b v1.0.0 is not in the build list",
+		"11: Warning: example.com/d v1.0.0/go.mod is not in the build list",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("DiagnosticsForSum =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	ps, err := snapshot.ParseSum(ctx, fh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	apply := func(fix source.SuggestedFix) string {
+		t.Helper()
+		edits, err := source.FromProtocolEdits(ps.Mapper, fix.Edits[fh.URI()])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return diff.ApplyEdits(sumContent, edits)
+	}
+	without := func(lines ...int) string {
+		var kept []string
+		for i, line := range strings.SplitAfter(sumContent, "\n") {
+			skip := false
+			for _, l := range lines {
+				skip = skip || i+1 == l
+			}
+			if !skip {
+				kept = append(kept, line)
+			}
+		}
+		return strings.Join(kept, "")
+	}
+	for _, test := range []struct {
+		message string
+		titles  []string
+		want    []string
+	}{
+		{"duplicate go.sum line for example.com/a v1.0.0/go.mod", []string{"Remove duplicate go.sum line"}, []string{without(3)}},
+		{"conflicting hashes for example.com/b v1.1.0: h1:first= on line 6", nil, nil},
+		{"example.com/b v1.0.0 is not in the build list", []string{"Remove stale go.sum line", "Remove all stale go.sum lines"}, []string{without(4), without(4, 11)}},
+		{"example.com/d v1.0.0/go.mod is not in the build list", []string{"Remove stale go.sum line", "Remove all stale go.sum lines"}, []string{without(11), without(4, 11)}},
+	} {
+		var titles, results []string
+		for _, fix := range fixes[test.message] {
+			titles = append(titles, fix.Title)
+			results = append(results, apply(fix))
+		}
+		if !reflect.DeepEqual(titles, test.titles) {
+			t.Errorf("%s: fixes %q, want %q", test.message, titles, test.titles)
+			continue
+		}
+		for i := range results {
+			if results[i] != test.want[i] {
+				t.Errorf("%s: fix %q produced\n%s\nwant\n%s", test.message, titles[i], results[i], test.want[i])
+			}
+		}
+	}
+}
+
+func TestCachedHash(t *testing.T) {
+	modcache, err := ioutil.TempDir("", "modcache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(modcache)
+	dir := filepath.Join(modcache, "cache", "download", "github.com", "!user", "a", "@v")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "v1.0.0.mod"), []byte("module example.com/b\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "v1.0.0.ziphash"), []byte("h1:zip=\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		line string
+		want string // the hash, or "" if there is none
+	}{
+		{"github.com/User/a v1.0.0/go.mod h1:x=", bModHash},
+		{"github.com/User/a v1.0.0 h1:x=", "h1:zip="},
+		{"github.com/User/a v1.1.0 h1:x=", ""},
+		{"github.com/User/a v1.1.0/go.mod h1:x=", ""},
+		// Hashes of other algorithms are not recorded.
+		{"github.com/User/a v1.0.0 h2:x=", ""},
+	} {
+		f, err := source.ParseSumFile("go.sum", []byte(test.line+"\n"))
+		if err != nil || len(f.Lines) != 1 {
+			t.Fatalf("ParseSumFile(%q) = %v, %v", test.line, f, err)
+		}
+		got, ok := cachedHash(modcache, f.Lines[0])
+		if ok != (test.want != "") || got != test.want {
+			t.Errorf("cachedHash(%q) = %q, %t, want %q", test.line, got, ok, test.want)
+		}
+	}
+	f, _ := source.ParseSumFile("go.sum", []byte("github.com/User/a v1.0.0 h1:x=\n"))
+	if got, ok := cachedHash("", f.Lines[0]); ok {
+		t.Errorf("cachedHash without a module cache = %q, want none", got)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sum
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kevinswiber/languageserver-go/event"
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+	errors "golang.org/x/xerrors"
+)
+
+// Hover describes which go.mod files require the module version of the
+// go.sum line at the position.
+func Hover(ctx context.Context, snapshot source.Snapshot, fh source.FileHandle, position protocol.Position) (*protocol.Hover, error) {
+	ctx, done := event.Start(ctx, "sum.Hover")
+	defer done()
+
+	// We only provide hover information for the go.sum files of the view's
+	// go.mod files.
+	modFH, err := workspaceModFile(ctx, snapshot, fh.URI())
+	if err != nil || modFH == nil {
+		return nil, err
+	}
+	ps, err := snapshot.ParseSum(ctx, fh)
+	if err != nil {
+		return nil, errors.Errorf("getting go.sum file handle: %w", err)
+	}
+	spn, err := ps.Mapper.PointSpan(position)
+	if err != nil {
+		return nil, errors.Errorf("computing cursor position: %w", err)
+	}
+	offset := spn.Start().Offset()
+
+	// Confirm that the cursor is on a well-formed line.
+	var line *source.SumLine
+	for _, l := range ps.File.Lines {
+		if l.Start.Byte <= offset && offset <= l.End.Byte {
+			line = l
+			break
+		}
+	}
+	if line == nil {
+		return nil, nil
+	}
+	rng, err := source.LineToRange(ps.Mapper, fh.URI(), line.Start, line.End)
+	if err != nil {
+		return nil, err
+	}
+	graph, err := snapshot.ModGraph(ctx, modFH)
+	if err != nil {
+		// The module graph is unavailable offline if the module cache lacks
+		// some of the go.mod files.
+		return nil, nil
+	}
+
+	options := snapshot.View().Options()
+	markdown := options.PreferredContentFormat == protocol.Markdown
+	code := func(s string) string {
+		if markdown {
+			return "`" + s + "`"
+		}
+		return s
+	}
+	var requirers []string
+	for _, m := range graph.Requirers[line.Mod] {
+		if m.Version == "" {
+			// The main module is identified by its go.mod file.
+			requirers = append(requirers, fmt.Sprintf("%s (%s)", code(m.Path), modFH.URI().Filename()))
+		} else {
+			requirers = append(requirers, code(m.String()))
+		}
+	}
+	sort.Strings(requirers)
+
+	var b strings.Builder
+	if markdown {
+		fmt.Fprintf(&b, "```text\n%s\n```\n\n", line.Mod)
+	} else {
+		fmt.Fprintf(&b, "%s\n\n", line.Mod)
+	}
+	if selected, ok := graph.Selected[line.Mod.Path]; ok && selected != line.Mod.Version {
+		fmt.Fprintf(&b, "Selected version: %s\n\n", code(selected))
+	}
+	if len(requirers) == 0 {
+		b.WriteString("Not required by the module graph.")
+	} else {
+		b.WriteString("Required by:\n")
+		for _, r := range requirers {
+			fmt.Fprintf(&b, "\n- %s", r)
+		}
+	}
+	return &protocol.Hover{
+		Contents: protocol.MarkupContent{
+			Kind:  options.PreferredContentFormat,
+			Value: b.String(),
+		},
+		Range: rng,
+	}, nil
+}
@@ -0,0 +1,53 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sum
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/span"
+)
+
+func TestHover(t *testing.T) {
+	ctx := context.Background()
+	snapshot, dir := newSumSnapshot(t)
+	fh, err := snapshot.GetFile(ctx, span.URIFromPath(filepath.Join(dir, "go.sum")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	modFile := filepath.Join(dir, "go.mod")
+	for _, test := range []struct {
+		line uint32 // zero-based
+		want string
+	}{
+		{0, "```text\nexample.com/a@v1.0.0\n```\n\nRequired by:\n\n- `example.com/m` (" + modFile + ")"},
+		{4, "```text\nexample.com/b@v1.0.0\n```\n\nSelected version: `v1.1.0`\n\nRequired by:\n\n- `example.com/a@v1.0.0`"},
+		{10, "```text\nexample.com/d@v1.0.0\n```\n\nNot required by the module graph."},
+	} {
+		hover, err := Hover(ctx, snapshot, fh, protocol.Position{Line: test.line, Character: 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hover == nil {
+			t.Errorf("line %d: no hover", test.line+1)
+			continue
+		}
+		if hover.Contents.Value != test.want {
+			t.Errorf("line %d: hover =\n%s\nwant\n%s", test.line+1, hover.Contents.Value, test.want)
+		}
+		if want := (protocol.Range{Start: protocol.Position{Line: test.line}, End: protocol.Position{Line: test.line, Character: hover.Range.End.Character}}); hover.Range != want || hover.Range.End.Character == 0 {
+			t.Errorf("line %d: hover range = %v, want the line", test.line+1, hover.Range)
+		}
+	}
+
+	// There is no hover past the last line.
+	hover, err := Hover(ctx, snapshot, fh, protocol.Position{Line: 11})
+	if err != nil || hover != nil {
+		t.Errorf("hover past the last line = %v, %v, want none", hover, err)
+	}
+}
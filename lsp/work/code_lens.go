@@ -0,0 +1,45 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package work
+
+import (
+	"context"
+
+	"github.com/kevinswiber/languageserver-go/lsp/command"
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+)
+
+// LensFuncs returns the supported lensFuncs for go.work files.
+func LensFuncs() map[command.Command]source.LensFunc {
+	return map[command.Command]source.LensFunc{
+		command.GenerateGoWork: generateLens,
+	}
+}
+
+func generateLens(ctx context.Context, snapshot source.Snapshot, fh source.FileHandle) ([]protocol.CodeLens, error) {
+	pw, err := snapshot.ParseWork(ctx, fh)
+	if err != nil || pw.File == nil {
+		return nil, err
+	}
+	cmd, err := command.NewGenerateGoWorkCommand("Use all modules of the workspace", command.URIArg{
+		URI: protocol.URIFromSpanURI(fh.URI()),
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Put the lens above the first use directive, or the go directive.
+	var rng protocol.Range
+	switch {
+	case len(pw.File.Use) > 0:
+		rng, err = source.LineToRange(pw.Mapper, fh.URI(), pw.File.Use[0].Syntax.Start, pw.File.Use[0].Syntax.Start)
+	case pw.File.Go != nil:
+		rng, err = source.LineToRange(pw.Mapper, fh.URI(), pw.File.Go.Syntax.Start, pw.File.Go.Syntax.End)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []protocol.CodeLens{{Range: rng, Command: cmd}}, nil
+}
@@ -0,0 +1,114 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package work provides core features related to go.work file
+// handling for use by Go editors and tools.
+package work
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/kevinswiber/languageserver-go/event"
+	"github.com/kevinswiber/languageserver-go/lsp/debug/tag"
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+	"github.com/kevinswiber/languageserver-go/span"
+)
+
+func Diagnostics(ctx context.Context, snapshot source.Snapshot) (map[source.VersionedFileIdentity][]*source.Diagnostic, error) {
+	ctx, done := event.Start(ctx, "work.Diagnostics", tag.Snapshot.Of(snapshot.ID()))
+	defer done()
+
+	reports := map[source.VersionedFileIdentity][]*source.Diagnostic{}
+	uri := snapshot.WorkFile()
+	if uri == "" {
+		return reports, nil
+	}
+	fh, err := snapshot.GetVersionedFile(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	diagnostics, err := DiagnosticsForWork(ctx, snapshot, fh)
+	if err != nil {
+		return nil, err
+	}
+	reports[fh.VersionedFileIdentity()] = diagnostics
+	return reports, nil
+}
+
+// DiagnosticsForWork reports the parse errors of the go.work file, and its
+// use directives whose directories do not contain a module or contain the
+// same module as another.
+func DiagnosticsForWork(ctx context.Context, snapshot source.Snapshot, fh source.FileHandle) ([]*source.Diagnostic, error) {
+	pw, err := snapshot.ParseWork(ctx, fh)
+	if err != nil {
+		if pw == nil || len(pw.ParseErrors) == 0 {
+			return nil, err
+		}
+		return pw.ParseErrors, nil
+	}
+
+	diagnostics := []*source.Diagnostic{}
+	modules := make(map[string]string) // module path -> directory of its first use
+	for _, use := range pw.File.Use {
+		rng, err := source.LineToRange(pw.Mapper, fh.URI(), use.Syntax.Start, use.Syntax.End)
+		if err != nil {
+			return nil, err
+		}
+		addError := func(format string, args ...interface{}) {
+			diagnostics = append(diagnostics, &source.Diagnostic{
+				URI:      fh.URI(),
+				Range:    rng,
+				Severity: protocol.SeverityError,
+				Source:   source.WorkFileError,
+				Message:  fmt.Sprintf(format, args...),
+			})
+		}
+		pm, err := parseUsedModule(ctx, snapshot, fh.URI(), use)
+		if err != nil {
+			addError("%v", err)
+			continue
+		}
+		if pm.File.Module == nil {
+			addError("no module declaration in %s", pm.URI.Filename())
+			continue
+		}
+		path := pm.File.Module.Mod.Path
+		if first, ok := modules[path]; ok {
+			addError("module %s appears multiple times in workspace: %s and %s", path, first, use.Path)
+			continue
+		}
+		modules[path] = use.Path
+	}
+	return diagnostics, nil
+}
+
+// parseUsedModule parses the go.mod file of the directory of the use
+// directive of the go.work file.
+func parseUsedModule(ctx context.Context, snapshot source.Snapshot, workURI span.URI, use *source.WorkUse) (*source.ParsedModule, error) {
+	modURI := span.URIFromPath(filepath.Join(useDir(workURI, use), "go.mod"))
+	modFH, err := snapshot.GetFile(ctx, modURI)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := modFH.Read(); err != nil {
+		return nil, fmt.Errorf("directory %s does not contain a go.mod file", use.Path)
+	}
+	pm, err := snapshot.ParseMod(ctx, modFH)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", modURI.Filename(), err)
+	}
+	return pm, nil
+}
+
+// useDir returns the absolute path of the directory of the use directive.
+func useDir(workURI span.URI, use *source.WorkUse) string {
+	dir := filepath.FromSlash(use.Path)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(filepath.Dir(workURI.Filename()), dir)
+	}
+	return filepath.Clean(dir)
+}
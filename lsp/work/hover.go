@@ -0,0 +1,79 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package work
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kevinswiber/languageserver-go/event"
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+	errors "golang.org/x/xerrors"
+)
+
+// Hover describes the module used by the use directive at the position.
+func Hover(ctx context.Context, snapshot source.Snapshot, fh source.FileHandle, position protocol.Position) (*protocol.Hover, error) {
+	// We only provide hover information for the view's go.work file.
+	if fh.URI() != snapshot.WorkFile() {
+		return nil, nil
+	}
+
+	ctx, done := event.Start(ctx, "work.Hover")
+	defer done()
+
+	pw, err := snapshot.ParseWork(ctx, fh)
+	if err != nil {
+		return nil, errors.Errorf("getting go.work file handle: %w", err)
+	}
+	spn, err := pw.Mapper.PointSpan(position)
+	if err != nil {
+		return nil, errors.Errorf("computing cursor position: %w", err)
+	}
+	offset := spn.Start().Offset()
+
+	// Confirm that the cursor is on a use directive.
+	var use *source.WorkUse
+	for _, u := range pw.File.Use {
+		if u.Syntax.Start.Byte <= offset && offset <= u.Syntax.End.Byte {
+			use = u
+			break
+		}
+	}
+	if use == nil {
+		return nil, nil
+	}
+	rng, err := source.LineToRange(pw.Mapper, fh.URI(), use.Syntax.Start, use.Syntax.End)
+	if err != nil {
+		return nil, err
+	}
+	pm, err := parseUsedModule(ctx, snapshot, fh.URI(), use)
+	if err != nil || pm.File.Module == nil {
+		// The error is reported as a diagnostic.
+		return nil, nil
+	}
+
+	options := snapshot.View().Options()
+	var b strings.Builder
+	decl := "module " + pm.File.Module.Mod.Path
+	if pm.File.Go != nil {
+		decl += "\n\ngo " + pm.File.Go.Version
+	}
+	if options.PreferredContentFormat == protocol.Markdown {
+		fmt.Fprintf(&b, "```go\n%s\n```\n\n", decl)
+		fmt.Fprintf(&b, "Directory: `%s`", useDir(fh.URI(), use))
+	} else {
+		fmt.Fprintf(&b, "%s\n\n", decl)
+		fmt.Fprintf(&b, "Directory: %s", useDir(fh.URI(), use))
+	}
+	return &protocol.Hover{
+		Contents: protocol.MarkupContent{
+			Kind:  options.PreferredContentFormat,
+			Value: b.String(),
+		},
+		Range: rng,
+	}, nil
+}
@@ -0,0 +1,203 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package work
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/kevinswiber/languageserver-go/lsp/cache"
+	"github.com/kevinswiber/languageserver-go/lsp/command"
+	"github.com/kevinswiber/languageserver-go/lsp/diff"
+	"github.com/kevinswiber/languageserver-go/lsp/protocol"
+	"github.com/kevinswiber/languageserver-go/lsp/source"
+	"github.com/kevinswiber/languageserver-go/span"
+)
+
+// workContent is the go.work file of newWorkSnapshot.
+const workContent = `go 1.18
+
+use (
+	./a
+	./b
+./c
+	./missing
+)
+`
+
+// newWorkSnapshot returns a snapshot of a workspace with a go.work file,
+// and the directory of the workspace. Of the directories that the go.work
+// file uses, a contains the module example.com/a, b contains a go.mod file
+// without a module declaration, c contains example.com/a again, and
+// missing does not exist.
+func newWorkSnapshot(t *testing.T) (source.Snapshot, string) {
+	t.Helper()
+	ctx := context.Background()
+	root, err := ioutil.TempDir("", "work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(root) })
+	for name, content := range map[string]string{
+		"go.work":  workContent,
+		"a/go.mod": "module example.com/a\n\ngo 1.16\n",
+		"b/go.mod": "go 1.16\n",
+		"c/go.mod": "module example.com/a\n",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	options := source.DefaultOptions().Clone()
+	options.Env = map[string]string{
+		"GOPACKAGESDRIVER": "off",
+		"GOPROXY":          "off",
+		"GOFLAGS":          "",
+	}
+	session := cache.New(ctx, nil).NewSession(ctx)
+	view, snapshot, release, err := session.NewView(ctx, "work_test", span.URIFromPath(root), "", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		release()
+		view.Shutdown(ctx)
+	})
+	return snapshot, root
+}
+
+// workFile returns the handle of the go.work file of the snapshot.
+func workFile(t *testing.T, snapshot source.Snapshot) source.FileHandle {
+	t.Helper()
+	uri := snapshot.WorkFile()
+	if uri == "" {
+		t.Fatal("the snapshot has no go.work file")
+	}
+	fh, err := snapshot.GetFile(context.Background(), uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fh
+}
+
+// lineRange returns the range of the line from the start to the end
+// character.
+func lineRange(line, start, end uint32) protocol.Range {
+	return protocol.Range{
+		Start: protocol.Position{Line: line, Character: start},
+		End:   protocol.Position{Line: line, Character: end},
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	snapshot, dir := newWorkSnapshot(t)
+	reports, err := Diagnostics(context.Background(), snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type diagnostic struct {
+		rng     protocol.Range
+		message string
+	}
+	var got []diagnostic
+	for id, diagnostics := range reports {
+		if id.URI != snapshot.WorkFile() {
+			t.Errorf("diagnostics of %s, want only those of the go.work file", id.URI)
+		}
+		for _, d := range diagnostics {
+			if d.Source != source.WorkFileError || d.Severity != protocol.SeverityError {
+				t.Errorf("diagnostic %q has source %q and severity %v, want %q errors", d.Message, d.Source, d.Severity, source.WorkFileError)
+			}
+			got = append(got, diagnostic{d.Range, d.Message})
+		}
+	}
+	want := []diagnostic{
+		{lineRange(4, 1, 4), "no module declaration in " + filepath.Join(dir, "b", "go.mod")},
+		{lineRange(5, 0, 3), "module example.com/a appears multiple times in workspace: ./a and ./c"},
+		{lineRange(6, 1, 10), "directory ./missing does not contain a go.mod file"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diagnostics = %v, want %v", got, want)
+	}
+}
+
+func TestHover(t *testing.T) {
+	snapshot, dir := newWorkSnapshot(t)
+	fh := workFile(t, snapshot)
+	for _, test := range []struct {
+		line uint32 // zero-based
+		want string // the hover, or "" if there is none
+		rng  protocol.Range
+	}{
+		{3, "```go\nmodule example.com/a\n\ngo 1.16\n```\n\nDirectory: `" + filepath.Join(dir, "a") + "`", lineRange(3, 1, 4)},
+		{5, "```go\nmodule example.com/a\n```\n\nDirectory: `" + filepath.Join(dir, "c") + "`", lineRange(5, 0, 3)},
+		// The errors of the other directories are reported as diagnostics.
+		{4, "", protocol.Range{}},
+		{6, "", protocol.Range{}},
+		// There is no hover outside of the use directives.
+		{0, "", protocol.Range{}},
+	} {
+		hover, err := Hover(context.Background(), snapshot, fh, protocol.Position{Line: test.line, Character: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.want == "" {
+			if hover != nil {
+				t.Errorf("line %d: hover = %q, want none", test.line+1, hover.Contents.Value)
+			}
+			continue
+		}
+		if hover == nil {
+			t.Errorf("line %d: no hover", test.line+1)
+			continue
+		}
+		if hover.Contents.Value != test.want || hover.Range != test.rng {
+			t.Errorf("line %d: hover =\n%s\nat %v, want\n%s\nat %v", test.line+1, hover.Contents.Value, hover.Range, test.want, test.rng)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	ctx := context.Background()
+	snapshot, _ := newWorkSnapshot(t)
+	fh := workFile(t, snapshot)
+	edits, err := Format(ctx, snapshot, fh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pw, err := snapshot.ParseWork(ctx, fh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diffEdits, err := source.FromProtocolEdits(pw.Mapper, edits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "go 1.18\n\nuse (\n\t./a\n\t./b\n\t./c\n\t./missing\n)\n"
+	if got := diff.ApplyEdits(workContent, diffEdits); got != want {
+		t.Errorf("formatted go.work =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestGenerateLens(t *testing.T) {
+	snapshot, _ := newWorkSnapshot(t)
+	lenses, err := generateLens(context.Background(), snapshot, workFile(t, snapshot))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The lens is above the first use directive.
+	if len(lenses) != 1 || lenses[0].Range != lineRange(3, 1, 1) || lenses[0].Command.Command != command.GenerateGoWork.ID() {
+		t.Errorf("lenses = %v, want a %s lens at the first use directive", lenses, command.GenerateGoWork)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"sync/atomic"
 
 	"github.com/kevinswiber/languageserver-go/event"
@@ -75,10 +76,18 @@ func (s *Server) didChangeConfiguration(ctx context.Context, _ *protocol.DidChan
 		if err := s.fetchConfig(ctx, view.Name(), view.Folder(), options); err != nil {
 			return err
 		}
+		// The severities and tags of diagnostics are applied as they are
+		// published, so if they change, the unchanged diagnostics must be
+		// published again.
+		overridesChanged := !reflect.DeepEqual(view.Options().DiagnosticSeverity, options.DiagnosticSeverity) ||
+			!reflect.DeepEqual(view.Options().DiagnosticTags, options.DiagnosticTags)
 		view, err := view.SetOptions(ctx, options)
 		if err != nil {
 			return err
 		}
+		if overridesChanged {
+			s.forgetPublishedDiagnostics()
+		}
 		go func() {
 			snapshot, release := view.Snapshot(ctx)
 			defer release()